@@ -0,0 +1,599 @@
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////
+// An encrypted artefact (image, certificate ...) belonging to an Item
+// The payload is AES-GCM encrypted under a symmetric key of the item's owner.
+// The ItemID and OwnerID are bound to the ciphertext as additional data, so an envelope
+// copied to another item or owner will not decrypt.
+//
+// Keys and content travel in the transient data of the transaction (see context.go),
+// never in its arguments, which the block keeps. The endorsing peers still see them
+// while they run the transaction.
+//
+// When the item changes hands, by TransferItem (XFER) or by a sale (SettleSale):
+//   1. The item's OwnerID moves to the new owner, who becomes the PendingOwnerID
+//   2. The previous owner re-seals the payload under a one-time handover key and
+//      passes that key to the new owner off-chain. TransferItem does so at once. After
+//      a sale the seller calls TransferItem to the buyer, unless the settling
+//      transaction carried the seller's keys
+//   3. The new owner re-seals it under their own key with AcceptItemArtefact. From
+//      then on neither the previous owner's key nor the handover key opens it
+///////////////////////////////////////////////////////////////////////////////////////
+type ItemArtefact struct {
+	ItemID         string
+	RecType        string // ARTEFACT
+	OwnerID        string // Owner whose key, or handover key, seals the Payload
+	PendingOwnerID string // New owner who has yet to accept the artefact
+	Handover       string // PENDING until the previous owner releases it, then RELEASED
+	MediaType      string
+	DocHash        string // SHA-256 of the plaintext - matches an ItemDocument if one was registered
+	KeyCheck       string // Fingerprint of the sealing key, used to reject a wrong key before decrypting
+	Nonce          string // base64 AES-GCM nonce
+	Payload        string // base64 AES-GCM ciphertext
+}
+
+const (
+	HandoverPending  = "PENDING"
+	HandoverReleased = "RELEASED"
+)
+
+// Transient data of the artefact functions
+const (
+	TransientArtefactKey     = "artefactKey"     // The caller's own key
+	TransientHandoverKey     = "handoverKey"     // One-time key of a change of hands
+	TransientArtefactContent = "artefactContent" // The plaintext, for PostItemArtefact
+)
+
+//////////////////////////////////////////////////////////
+// Decode a base64 AES key (16, 24 or 32 bytes)
+//////////////////////////////////////////////////////////
+func ParseArtefactKey(encoded string) ([]byte, error) {
+
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, NewError(ErrInvalidArgument, "ParseArtefactKey(): Key should be base64 encoded")
+	}
+	switch len(key) {
+	case 16, 24, 32:
+		return key, nil
+	}
+	return nil, NewError(ErrInvalidArgument, fmt.Sprintf("ParseArtefactKey(): Key should be 16, 24 or 32 bytes, got %d", len(key)))
+}
+
+//////////////////////////////////////////////////////////
+// Fingerprint of a key. The key itself is never stored
+//////////////////////////////////////////////////////////
+func ArtefactKeyCheck(key []byte) string {
+	h := sha256.New()
+	h.Write([]byte("ARTEFACT-KEY-CHECK"))
+	h.Write(key)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+////////////////////////////////////////////////////////////////////////////////////
+// Nonce for an artefact encryption
+// Every peer has to produce the same ciphertext, so the nonce cannot be random.
+// It is derived from the transaction ID, which is unique per invoke, and the owner
+////////////////////////////////////////////////////////////////////////////////////
+func ArtefactNonce(txID string, itemID string, ownerID string) []byte {
+	sum := sha256.Sum256([]byte(txID + "|" + itemID + "|" + ownerID))
+	return sum[:12]
+}
+
+func artefactAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func artefactAD(art ItemArtefact) []byte {
+	return []byte(art.ItemID + "|" + art.OwnerID)
+}
+
+/////////////////////////////////////////////////////////////////////////
+// Encrypt the plaintext into the artefact under the owner's key
+// art.ItemID and art.OwnerID must be set before sealing
+/////////////////////////////////////////////////////////////////////////
+func SealArtefact(art ItemArtefact, key []byte, nonce []byte, plaintext []byte) (ItemArtefact, error) {
+
+	aead, err := artefactAEAD(key)
+	if err != nil {
+		return art, NewError(ErrInvalidArgument, "SealArtefact(): "+err.Error())
+	}
+	if len(nonce) != aead.NonceSize() {
+		return art, NewError(ErrInternal, fmt.Sprintf("SealArtefact(): Nonce should be %d bytes", aead.NonceSize()))
+	}
+
+	art.DocHash = HashDocument(plaintext)
+	art.KeyCheck = ArtefactKeyCheck(key)
+	art.Nonce = base64.StdEncoding.EncodeToString(nonce)
+	art.Payload = base64.StdEncoding.EncodeToString(aead.Seal(nil, nonce, plaintext, artefactAD(art)))
+	return art, nil
+}
+
+/////////////////////////////////////////////////////////////////////////
+// Decrypt the artefact payload with the owner's key
+/////////////////////////////////////////////////////////////////////////
+func OpenArtefact(art ItemArtefact, key []byte) ([]byte, error) {
+
+	if ArtefactKeyCheck(key) != art.KeyCheck {
+		return nil, NewError(ErrUnauthorized, "OpenArtefact(): Key does not belong to the current owner "+art.OwnerID)
+	}
+
+	aead, err := artefactAEAD(key)
+	if err != nil {
+		return nil, NewError(ErrInvalidArgument, "OpenArtefact(): "+err.Error())
+	}
+	nonce, err := base64.StdEncoding.DecodeString(art.Nonce)
+	if err != nil || len(nonce) != aead.NonceSize() {
+		return nil, NewError(ErrCorruptRecord, "OpenArtefact(): Corrupt nonce for item "+art.ItemID, "RecType", "ARTEFACT", "Field", "Nonce")
+	}
+	sealed, err := base64.StdEncoding.DecodeString(art.Payload)
+	if err != nil {
+		return nil, NewError(ErrCorruptRecord, "OpenArtefact(): Corrupt payload for item "+art.ItemID, "RecType", "ARTEFACT", "Field", "Payload")
+	}
+
+	plaintext, err := aead.Open(nil, nonce, sealed, artefactAD(art))
+	if err != nil {
+		return nil, NewError(ErrCorruptRecord, "OpenArtefact(): Payload failed authentication for item "+art.ItemID, "RecType", "ARTEFACT", "Field", "Payload")
+	}
+	return plaintext, nil
+}
+
+/////////////////////////////////////////////////////////////////////////
+// Re-encrypt the artefact for a new owner
+// The current owner's key is needed to recover the plaintext
+/////////////////////////////////////////////////////////////////////////
+func RekeyArtefact(art ItemArtefact, oldKey []byte, newOwnerID string, newKey []byte, nonce []byte) (ItemArtefact, error) {
+
+	plaintext, err := OpenArtefact(art, oldKey)
+	if err != nil {
+		return art, err
+	}
+
+	art.OwnerID = newOwnerID
+	return SealArtefact(art, newKey, nonce, plaintext)
+}
+
+//////////////////////////////////////////////////////////
+// Converts an Item Artefact to a JSON String
+//////////////////////////////////////////////////////////
+func ArtefacttoJSON(art ItemArtefact) ([]byte, error) {
+
+	ajson, err := json.Marshal(art)
+	if err != nil {
+		fmt.Println("ArtefacttoJSON error: ", err)
+		return nil, err
+	}
+	return ajson, nil
+}
+
+//////////////////////////////////////////////////////////
+// Converts JSON String to an Item Artefact
+//////////////////////////////////////////////////////////
+func JSONtoArtefact(data []byte) (ItemArtefact, error) {
+
+	art := ItemArtefact{}
+	err := json.Unmarshal(data, &art)
+	if err != nil {
+		fmt.Println("JSONtoArtefact error: ", err)
+		return art, err
+	}
+	return art, err
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// A key from the transient data, base64 encoded or the raw 16, 24 or 32 bytes.
+// Base64 is tried first: the encoding of a 16 or 24 byte key is itself 24 or
+// 32 bytes long
+//////////////////////////////////////////////////////////////////////////////////
+func ParseTransientKey(value []byte) ([]byte, error) {
+
+	if key, err := ParseArtefactKey(strings.TrimSpace(string(value))); err == nil {
+		return key, nil
+	}
+	switch len(value) {
+	case 16, 24, 32:
+		return value, nil
+	}
+	return nil, NewError(ErrInvalidArgument, "ParseTransientKey(): Key should be 16, 24 or 32 bytes, raw or base64 encoded")
+}
+
+func GetTransientKey(ctx *TxContext, name string) ([]byte, error) {
+
+	value, err := ctx.Tx.CallerTransient(name)
+	if err != nil {
+		return nil, WrapError(err, "GetTransientKey(): Cannot read the transient data")
+	}
+	if len(value) == 0 {
+		return nil, NewError(ErrInvalidArgument, "GetTransientKey(): No "+name+" in the transient data", "Field", name)
+	}
+	key, err := ParseTransientKey(value)
+	if err != nil {
+		return nil, NewError(ErrInvalidArgument, "GetTransientKey(): Invalid "+name+" in the transient data", "Field", name)
+	}
+	return key, nil
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// Store an encrypted artefact for an Item
+// The owner's key and the content are read from the transient data (artefactKey, artefactContent).
+// Only the ciphertext is written. Only the item's owner, or an Auction House on their behalf, may
+// post it. An item registered without an owner gets one here, from an Auction House
+// Structure of args ItemID, RecType, OwnerID, MediaType
+// ./peer chaincode invoke -n mycc -C mychannel -c '{"Args":["PostItemArtefact", "1000", "ARTEFACT", "100", "image/png"]}' --transient '{"artefactKey":"<key>", "artefactContent":"<content>"}'
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+func PostItemArtefact(ctx *TxContext, function string, args []string) ([]byte, error) {
+
+	if len(args) != 4 {
+		fmt.Println("PostItemArtefact(): Incorrect number of arguments. Expecting 4 ")
+		return nil, NewError(ErrInvalidArgument, "PostItemArtefact(): Incorrect number of arguments. Expecting 4 ")
+	}
+
+	key, err := GetTransientKey(ctx, TransientArtefactKey)
+	if err != nil {
+		return nil, err
+	}
+	content, err := ctx.Tx.CallerTransient(TransientArtefactContent)
+	if err != nil || content == nil {
+		return nil, NewError(ErrInvalidArgument, "PostItemArtefact(): No "+TransientArtefactContent+" in the transient data", "Field", TransientArtefactContent)
+	}
+
+	item, err := ValidateItemSubmission(ctx, args[0])
+	if err != nil {
+		fmt.Println("PostItemArtefact() : Failed Could not Validate Item Object in Blockchain ", args[0])
+		return nil, err
+	}
+
+	_, err = ValidateMember(ctx, args[2])
+	if err != nil {
+		fmt.Println("PostItemArtefact() : Failed Owner not registered on the block-chain ", args[2])
+		return nil, err
+	}
+
+	if !CanManageUser(ctx, args[2]) {
+		return nil, NewError(ErrUnauthorized, "PostItemArtefact(): Caller may not act for "+args[2])
+	}
+	switch GetItemOwner(ctx, item.ItemID) {
+	case args[2]:
+	case "":
+		// An Auction House vouches for the owner of an item registered without one
+		if GetCallerAttribute(ctx, "role") != "AH" {
+			return nil, NewError(ErrUnauthorized, "PostItemArtefact(): Item "+args[0]+" has no recorded owner. An Auction House must post its artefact")
+		}
+		owned := item
+		owned.OwnerID = args[2]
+		_, err = ctx.Items.Replace(item, owned)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, NewError(ErrUnauthorized, "PostItemArtefact(): Item "+args[0]+" is not owned by "+args[2])
+	}
+
+	art := ItemArtefact{ItemID: args[0], RecType: "ARTEFACT", OwnerID: args[2], MediaType: args[3]}
+	art, err = SealArtefact(art, key, ArtefactNonce(ctx.Tx.TxID(), art.ItemID, art.OwnerID), content)
+	if err != nil {
+		return nil, err
+	}
+
+	buff, err := ctx.Items.AddArtefact(art)
+	if err != nil {
+		fmt.Println("PostItemArtefact() : write error while inserting record")
+		return nil, err
+	}
+
+	return buff, nil
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// Transfer an Item to a new owner (XFER)
+// The item's ownership moves at once and the change of hands is logged in the ItemHistoryTable.
+// The artefact is re-sealed under the handover key, for the new owner to accept with
+// AcceptItemArtefact. After a sale, ownership has already moved: the seller transfers to the
+// buyer to release the artefact. The current owner's key and the handover key are read from
+// the transient data (artefactKey, handoverKey)
+// Structure of args ItemID, RecType, CurrentOwnerID, NewOwnerID
+// ./peer chaincode invoke -n mycc -C mychannel -c '{"Args":["TransferItem", "1000", "XFER", "100", "400"]}' --transient '{"artefactKey":"<key>", "handoverKey":"<key>"}'
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+func TransferItem(ctx *TxContext, function string, args []string) ([]byte, error) {
+
+	if len(args) != 4 {
+		fmt.Println("TransferItem(): Incorrect number of arguments. Expecting 4 ")
+		return nil, NewError(ErrInvalidArgument, "TransferItem(): Incorrect number of arguments. Expecting 4 ")
+	}
+
+	if !CanManageUser(ctx, args[2]) {
+		return nil, NewError(ErrUnauthorized, "TransferItem(): Caller may not act for "+args[2])
+	}
+
+	art, err := ctx.Items.GetArtefact(args[0])
+	if err != nil {
+		fmt.Println("TransferItem() : No artefact registered for item ", args[0])
+		return nil, err
+	}
+
+	item, err := ValidateItemSubmission(ctx, args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = ValidateMember(ctx, args[3])
+	if err != nil {
+		fmt.Println("TransferItem() : Failed New Owner not registered on the block-chain ", args[3])
+		return nil, err
+	}
+
+	// After a sale the artefact is still sealed by the seller while the item is the buyer's
+	sold := art.Handover == HandoverPending && art.PendingOwnerID == args[3]
+	if art.OwnerID != args[2] || (!sold && GetItemOwner(ctx, args[0]) != args[2]) {
+		return nil, NewError(ErrUnauthorized, "TransferItem(): Item "+args[0]+" is not owned by "+args[2])
+	}
+
+	if art.Handover != "" && !sold {
+		return nil, NewError(ErrConflict, "TransferItem(): Item "+args[0]+" is already being handed over to "+art.PendingOwnerID)
+	}
+
+	art, err = releaseArtefact(ctx, art, args[3])
+	if err != nil {
+		return nil, err
+	}
+
+	buff, err := ctx.Items.ReplaceArtefact(art)
+	if err != nil {
+		fmt.Println("TransferItem() : write error while replacing artefact")
+		return nil, err
+	}
+
+	// A sale moved the item when it settled
+	if sold {
+		return buff, nil
+	}
+
+	err = ChangeItemOwner(ctx, item, args[3], "NA", "NA")
+	if err != nil {
+		return nil, err
+	}
+
+	AddEvent(ctx, AuctionEvent{Type: EventItemTransferred, ItemID: args[0], UserID: args[3], PrevUserID: args[2]})
+	return buff, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Record the item's new owner and log the change of hands in the ItemHistoryTable
+// status and auctionedBy as in ItemLog
+//////////////////////////////////////////////////////////////////////////////////
+func ChangeItemOwner(ctx *TxContext, item ItemObject, ownerID string, status string, auctionedBy string) error {
+
+	owned := item
+	owned.OwnerID = ownerID
+	_, err := ctx.Items.Replace(item, owned)
+	if err != nil {
+		return err
+	}
+
+	txTime, err := GetTxTime(ctx)
+	if err != nil {
+		return err
+	}
+	itemLog := ItemLog{
+		ItemID:       item.ItemID,
+		Status:       status,
+		AuctionedBy:  auctionedBy,
+		RecType:      "ITEMHIS",
+		ItemDesc:     item.ItemDesc,
+		CurrentOwner: ownerID,
+		Date:         txTime.Format("2006-01-02 15:04:05"),
+	}
+
+	_, err = ctx.History.Add(itemLog)
+	if err != nil {
+		fmt.Println("ChangeItemOwner() : write error while inserting record into ItemHistoryTable")
+		return err
+	}
+	return nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Re-seal the artefact under the handover key for newOwnerID, with the current
+// owner's key. Both are read from the transient data
+//////////////////////////////////////////////////////////////////////////////////
+func releaseArtefact(ctx *TxContext, art ItemArtefact, newOwnerID string) (ItemArtefact, error) {
+
+	key, err := GetTransientKey(ctx, TransientArtefactKey)
+	if err != nil {
+		return art, err
+	}
+	handoverKey, err := GetTransientKey(ctx, TransientHandoverKey)
+	if err != nil {
+		return art, err
+	}
+
+	art, err = RekeyArtefact(art, key, art.OwnerID, handoverKey, ArtefactNonce(ctx.Tx.TxID(), art.ItemID, newOwnerID))
+	if err != nil {
+		return art, err
+	}
+	art.PendingOwnerID = newOwnerID
+	art.Handover = HandoverReleased
+	return art, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// The sale of an item settled: ownership moves to the buyer and the artefact,
+// if there is one, is handed over. It is released to the buyer at once when
+// the settling transaction carries the seller's key and a handover key in its
+// transient data, otherwise it waits for the seller's TransferItem
+//////////////////////////////////////////////////////////////////////////////////
+func HandOverSoldItem(ctx *TxContext, bid Bid) error {
+
+	itemID, buyerID := bid.ItemID, bid.BuyerID
+	aucR, err := ctx.Auctions.Get(bid.AuctionID)
+	if err != nil {
+		return err
+	}
+	item, err := GetItemObject(ctx, itemID)
+	if err != nil {
+		return err
+	}
+	err = ChangeItemOwner(ctx, item, buyerID, "OnAuc", aucR.AuctionHouseID)
+	if err != nil {
+		return err
+	}
+
+	art, err := ctx.Items.GetArtefact(itemID)
+	if err != nil {
+		if ErrorCodeOf(err) == ErrNotFound {
+			return nil
+		}
+		return err
+	}
+
+	// Resold before it was released, the seller now releases it to the buyer. Resold
+	// after it was released, the seller passes the handover key on to the buyer
+	if art.Handover == "" {
+		art.Handover = HandoverPending
+	}
+	art.PendingOwnerID = buyerID
+
+	key, _ := ctx.Tx.CallerTransient(TransientArtefactKey)
+	handoverKey, _ := ctx.Tx.CallerTransient(TransientHandoverKey)
+	if art.Handover == HandoverPending && key != nil && handoverKey != nil {
+		art, err = releaseArtefact(ctx, art, buyerID)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = ctx.Items.ReplaceArtefact(art)
+	return err
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// The new owner takes over the artefact released to them: it is re-sealed under their own key,
+// after which the handover key no longer opens it. The handover key and the new owner's key are
+// read from the transient data (handoverKey, artefactKey)
+// Structure of args ItemID, RecType, NewOwnerID
+// ./peer chaincode invoke -n mycc -C mychannel -c '{"Args":["AcceptItemArtefact", "1000", "ARTEFACT", "400"]}' --transient '{"handoverKey":"<key>", "artefactKey":"<key>"}'
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+func AcceptItemArtefact(ctx *TxContext, function string, args []string) ([]byte, error) {
+
+	if len(args) != 3 {
+		fmt.Println("AcceptItemArtefact(): Incorrect number of arguments. Expecting 3 ")
+		return nil, NewError(ErrInvalidArgument, "AcceptItemArtefact(): Incorrect number of arguments. Expecting 3 ")
+	}
+
+	if !CanManageUser(ctx, args[2]) {
+		return nil, NewError(ErrUnauthorized, "AcceptItemArtefact(): Caller may not act for "+args[2])
+	}
+
+	handoverKey, err := GetTransientKey(ctx, TransientHandoverKey)
+	if err != nil {
+		return nil, err
+	}
+	key, err := GetTransientKey(ctx, TransientArtefactKey)
+	if err != nil {
+		return nil, err
+	}
+
+	art, err := ctx.Items.GetArtefact(args[0])
+	if err != nil {
+		return nil, err
+	}
+	if art.PendingOwnerID != args[2] {
+		return nil, NewError(ErrUnauthorized, "AcceptItemArtefact(): Artefact of item "+args[0]+" is not being handed over to "+args[2])
+	}
+	if art.Handover != HandoverReleased {
+		return nil, NewError(ErrConflict, "AcceptItemArtefact(): Artefact of item "+args[0]+" has not been released by "+art.OwnerID)
+	}
+
+	art, err = RekeyArtefact(art, handoverKey, args[2], key, ArtefactNonce(ctx.Tx.TxID(), art.ItemID, args[2]))
+	if err != nil {
+		return nil, err
+	}
+	art.PendingOwnerID = ""
+	art.Handover = ""
+
+	buff, err := ctx.Items.ReplaceArtefact(art)
+	if err != nil {
+		fmt.Println("AcceptItemArtefact() : write error while replacing artefact")
+		return nil, err
+	}
+	return buff, nil
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// Retrieve the (encrypted) artefact envelope for an Item
+// ./peer chaincode query -l golang -n mycc -c '{"Function": "GetItemArtefact", "Args": ["1000"]}'
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+func GetItemArtefact(ctx *TxContext, function string, args []string) ([]byte, error) {
+
+	art, err := ctx.Items.GetArtefact(args[0])
+	if err != nil {
+		fmt.Println("GetItemArtefact() : Failed to Query Object ")
+		return nil, WrapError(err, "GetItemArtefact() : Failed to get Object Data for "+args[0])
+	}
+
+	return ArtefacttoJSON(art)
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// Decrypt an Item artefact with the key sealing it, read from the transient data (artefactKey)
+// Returns the base64 encoded content
+// ./peer chaincode query -n mycc -C mychannel -c '{"Args": ["OpenItemArtefact", "1000"]}' --transient '{"artefactKey":"<key>"}'
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+func OpenItemArtefact(ctx *TxContext, function string, args []string) ([]byte, error) {
+
+	if len(args) != 1 {
+		fmt.Println("OpenItemArtefact(): Incorrect number of arguments. Expecting 1 ")
+		return nil, NewError(ErrInvalidArgument, "OpenItemArtefact(): Incorrect number of arguments. Expecting 1 ")
+	}
+
+	key, err := GetTransientKey(ctx, TransientArtefactKey)
+	if err != nil {
+		return nil, err
+	}
+
+	art, err := ctx.Items.GetArtefact(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := OpenArtefact(art, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(base64.StdEncoding.EncodeToString(plaintext)), nil
+}
@@ -33,7 +33,6 @@ import (
 	"errors"
 	"fmt"
 
-	"github.com/hyperledger/fabric/core/chaincode/shim"
 	//"github.com/op/go-logging"
 
 	"os"
@@ -51,13 +50,13 @@ import (
 // which used a record type field. The array below holds a list of valid record types.
 // This could be stored on a blockchain table or an application
 //////////////////////////////////////////////////////////////////////////////////////////////////
-var recType = []string{"ARTINV", "USER", "BID", "AUCREQ", "POSTTRAN", "OPENAUC", "CLAUC", "XFER", "VERIFY"}
+var recType = []string{"ARTINV", "USER", "BID", "AUCREQ", "POSTTRAN", "OPENAUC", "CLAUC", "XFER", "VERIFY", "ITEMDOC", "ARTEFACT", "REINDEX", "EXTAUC", "BIDSUM"}
 
 //////////////////////////////////////////////////////////////////////////////////////////////////
 // The following array holds the list of tables that should be created
 // The deploy/init deletes the tables and recreates them every time a deploy is invoked
 //////////////////////////////////////////////////////////////////////////////////////////////////
-var aucTables = []string{"UserTable", "UserCatTable", "ItemTable", "ItemCatTable", "ItemHistoryTable", "AuctionTable", "AucInitTable", "AucOpenTable", "BidTable", "TransTable"}
+var aucTables = []string{"UserTable", "UserCatTable", "ItemTable", "ItemCatTable", "ItemTypeTable", "ItemHistoryTable", "ItemArtefactTable", "AuctionTable", "AucInitTable", "AucOpenTable", "BidTable", "BidSummaryTable", "TransTable", "ItemTransTable", "UserTransTable"}
 
 ///////////////////////////////////////////////////////////////////////////////////////
 // This creates a record of the Asset (Inventory)
@@ -68,12 +67,28 @@ var aucTables = []string{"UserTable", "UserCatTable", "ItemTable", "ItemCatTable
 ///////////////////////////////////////////////////////////////////////////////////////
 
 type ItemObject struct {
-	ItemID      string
-	RecType     string
-	ItemDesc    string
-	ItemDetail  string // Could included details such as who created the Art work if item is a Painting
-	ItemType    string
-	ItemSubject string
+	ItemID         string
+	RecType        string
+	ItemDesc       string
+	ItemDetail     string // Could included details such as who created the Art work if item is a Painting
+	ItemType       string
+	ItemSubject    string
+	ItemDocs       []ItemDocument // Images, certificates of authenticity etc. registered against the item
+	RegisteredDate string         // Date on which the item was registered - decides its catalog partition
+	OwnerID        string         `metadata:",optional"` // Current owner. Set by the chaincode, empty until known
+}
+
+///////////////////////////////////////////////////////////////////////////////////////
+// A document attached to an Item (image, certificate of authenticity, appraisal ...)
+// The document itself is stored off-chain at DocURI. Only its SHA-256 hash is kept
+// on the ledger so that a copy presented later can be checked against the original
+// Example:
+// ItemDocument { "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08", "image/png", "https://gallery.example.com/sample_7.png" }
+///////////////////////////////////////////////////////////////////////////////////////
+type ItemDocument struct {
+	DocHash   string // Hex encoded SHA-256 of the document content
+	MediaType string // image/png, application/pdf etc.
+	DocURI    string `metadata:",optional"` // Off-chain location of the document
 }
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -100,16 +115,19 @@ type ItemLog struct {
 // SH (Shipper)
 /////////////////////////////////////////////////////////////
 type UserObject struct {
-	UserID    string
-	RecType   string // Type = USER
-	Name      string
-	UserType  string // Auction House (AH), Bank (BK), Buyer or Seller (TR), Shipper (SH), Appraiser (AP)
-	Address   string
-	Phone     string
-	Email     string
-	Bank      string
-	AccountNo string
-	RoutingNo string
+	UserID         string
+	RecType        string // Type = USER
+	Name           string
+	UserType       string // Auction House (AH), Bank (BK), Buyer or Seller (TR), Shipper (SH), Appraiser (AP)
+	Address        string // Encrypted or hashed - see pii.go
+	Phone          string // Encrypted or hashed
+	Email          string // Encrypted or hashed
+	Bank           string
+	AccountNo      string // Salted hash
+	RoutingNo      string // Salted hash
+	ErasedDate     string // Set when the personal data was erased
+	Status         string // ACTIVE, INACTIVE
+	RegisteredDate string // Date on which the user was registered - decides the UserCatTable partition
 }
 
 /////////////////////////////////////////////////////////////////////////////
@@ -150,6 +168,7 @@ type ItemTransaction struct {
 	HammerTime  string // Time of hammer strike - SOLD
 	HammerPrice string // Total Settlement price
 	Details     string // Details about the Transaction
+	TxID        string `metadata:",optional"` // Fabric transaction that recorded it, the last key. Set by the chaincode
 }
 
 ////////////////////////////////////////////////////////////////
@@ -173,32 +192,43 @@ type Bid struct {
 // In this model all attributes in a table are strings
 // The chain code does both validation
 // A dummy key like 2016 in some cases is used for a query to get all rows
+// On Fabric 1.x and later the keys are the attributes of a composite key (see store_kv.go)
 //
 //              "UserTable":        1, Key: UserID
 //              "ItemTable":        1, Key: ItemID
-//              "UserCatTable":     3, Key: "2016", UserType, UserID
-//              "ItemCatTable":     3, Key: "2016", ItemSubject, ItemID
+//              "UserCatTable":     3, Key: Partition, UserType, UserID
+//              "ItemCatTable":     3, Key: Partition, ItemSubject, ItemID
+//              "ItemTypeTable":    3, Key: Partition, ItemType, ItemID
 //              "AuctionTable":     1, Key: AuctionID
-//              "AucInitTable":     2, Key: Year, AuctionID
-//              "AucOpenTable":     2, Key: Year, AuctionID
-//              "TransTable":       2, Key: AuctionID, ItemID
+//              "AucInitTable":     2, Key: Partition, AuctionID
+//              "AucOpenTable":     2, Key: Partition, AuctionID
+//              "TransTable":       4, Key: AuctionID, ItemID, TransType, TxID
+//              "ItemTransTable":   4, Key: ItemID, AuctionID, TransType, TxID
+//              "UserTransTable":   4, Key: UserId, AuctionID, TransType, TxID
 //              "BidTable":         2, Key: AuctionID, BidNo
+//              "BidSummaryTable":  1, Key: AuctionID (see bid_summary.go)
 //              "ItemHistoryTable": 4, Key: ItemID, Status, AuctionHouseID(if applicable),date-time
+//              "ItemArtefactTable":1, Key: ItemID
 //
 /////////////////////////////////////////////////////////////////////////////////////////////////////
 
 func GetNumberOfKeys(tname string) int {
 	TableMap := map[string]int{
-		"UserTable":        1,
-		"ItemTable":        1,
-		"UserCatTable":     3,
-		"ItemCatTable":     3,
-		"AuctionTable":     1,
-		"AucInitTable":     2,
-		"AucOpenTable":     2,
-		"TransTable":       2,
-		"BidTable":         2,
-		"ItemHistoryTable": 4,
+		"UserTable":         1,
+		"ItemTable":         1,
+		"UserCatTable":      3,
+		"ItemCatTable":      3,
+		"ItemTypeTable":     3,
+		"AuctionTable":      1,
+		"AucInitTable":      2,
+		"AucOpenTable":      2,
+		"TransTable":        4,
+		"ItemTransTable":    4,
+		"UserTransTable":    4,
+		"BidTable":          2,
+		"BidSummaryTable":   1,
+		"ItemHistoryTable":  4,
+		"ItemArtefactTable": 1,
 	}
 	return TableMap[tname]
 }
@@ -209,17 +239,30 @@ func GetNumberOfKeys(tname string) int {
 // during an invoke
 //
 //////////////////////////////////////////////////////////////
-func InvokeFunction(fname string) func(stub shim.ChaincodeStubInterface, function string, args []string) ([]byte, error) {
-	InvokeFunc := map[string]func(stub shim.ChaincodeStubInterface, function string, args []string) ([]byte, error){
-		"PostItem":           PostItem,
-		"PostUser":           PostUser,
-		"PostAuctionRequest": PostAuctionRequest,
-		//"PostBid":            PostBid,
-		"OpenAuctionForBids": OpenAuctionForBids,
-		"BuyItNow":           BuyItNow,
-		//"CloseAuction":       CloseAuction,
-		//"CloseOpenAuctions":  CloseOpenAuctions,
-	}
+var InvokeFunc = map[string]func(ctx *TxContext, function string, args []string) ([]byte, error){
+	"PostItem":            PostItem,
+	"PostUser":            PostUser,
+	"PostAuctionRequest":  PostAuctionRequest,
+	"PostItemDocument":    PostItemDocument,
+	"PostItemArtefact":    PostItemArtefact,
+	"TransferItem":        TransferItem,
+	"AcceptItemArtefact":  AcceptItemArtefact,
+	"EraseUser":           EraseUser,
+	"UpdateUser":          UpdateUser,
+	"DeactivateUser":      DeactivateUser,
+	"UpdateItem":          UpdateItem,
+	"ReindexPartitions":   ReindexPartitions,
+	"RebuildBidSummaries": RebuildBidSummaries,
+	"PostBid":             PostBid,
+	"ExtendAuction":       ExtendAuction,
+	"OpenAuctionForBids":  OpenAuctionForBids,
+	"BuyItNow":            BuyItNow,
+	"PostTransaction":     PostTransaction,
+	"CloseAuction":        CloseAuction,
+	"CloseOpenAuctions":   CloseOpenAuctions,
+}
+
+func InvokeFunction(fname string) func(ctx *TxContext, function string, args []string) ([]byte, error) {
 	return InvokeFunc[fname]
 }
 
@@ -227,23 +270,33 @@ func InvokeFunction(fname string) func(stub shim.ChaincodeStubInterface, functio
 // Query Functions based on Function name
 //
 //////////////////////////////////////////////////////////////
-func QueryFunction(fname string) func(stub shim.ChaincodeStubInterface, function string, args []string) ([]byte, error) {
-	QueryFunc := map[string]func(stub shim.ChaincodeStubInterface, function string, args []string) ([]byte, error){
-		"GetItem":             GetItem,
-		"GetUser":             GetUser,
-		"GetAuctionRequest":   GetAuctionRequest,
-		"GetBid":              GetBid,
-		"GetLastBid":          GetLastBid,
-		"GetHighestBid":       GetHighestBid,
-		"GetNoOfBidsReceived": GetNoOfBidsReceived,
-		// "GetListOfBids":         GetListOfBids,
-		"GetUserListByCat": GetUserListByCat,
-		// "GetListOfInitAucs":     GetListOfInitAucs,
-		// "GetListOfOpenAucs":     GetListOfOpenAucs,
-		// "ValidateItemOwnership": ValidateItemOwnership,
-		// "IsItemOnAuction": IsItemOnAuction,
-		"GetVersion": GetVersion,
-	}
+var QueryFunc = map[string]func(ctx *TxContext, function string, args []string) ([]byte, error){
+	"GetItem":             GetItem,
+	"GetUser":             GetUser,
+	"GetAuctionRequest":   GetAuctionRequest,
+	"GetBid":              GetBid,
+	"GetLastBid":          GetLastBid,
+	"GetHighestBid":       GetHighestBid,
+	"GetNoOfBidsReceived": GetNoOfBidsReceived,
+	"GetListOfBids":       GetListOfBids,
+	"GetUserListByCat":    GetUserListByCat,
+	"GetListOfInitAucs":   GetListOfInitAucs,
+	"GetListOfOpenAucs":   GetListOfOpenAucs,
+	// "ValidateItemOwnership": ValidateItemOwnership,
+	// "IsItemOnAuction": IsItemOnAuction,
+	"GetVersion":               GetVersion,
+	"VerifyItemDocument":       VerifyItemDocument,
+	"GetItemArtefact":          GetItemArtefact,
+	"OpenItemArtefact":         OpenItemArtefact,
+	"VerifyUserPII":            VerifyUserPII,
+	"GetItemListByCat":         GetItemListByCat,
+	"GetItemListBySubject":     GetItemListBySubject,
+	"GetTransactionsByAuction": GetTransactionsByAuction,
+	"GetTransactionsByItem":    GetTransactionsByItem,
+	"GetTransactionsByUser":    GetTransactionsByUser,
+}
+
+func QueryFunction(fname string) func(ctx *TxContext, function string, args []string) ([]byte, error) {
 	return QueryFunc[fname]
 }
 
@@ -275,19 +328,19 @@ func main() {
 		ccPath = fmt.Sprintf("%s/src/github.com/ITPeople-Blockchain/auction/art/artchaincode/", gopath)
 	}
 
-	// Start the shim -- running the fabric
-	/*err := shim.Start(new(SimpleChaincode))
-	if err != nil {
-		fmt.Println("Error starting Item Fun Application chaincode: %s", err)
-	}*/
+	// Start the shim -- running the fabric (see peer_v06.go, peer_v1.go, peer_v2.go)
+	startShim()
 
 }
 
 ////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 // SimpleChaincode - Init Chaincode implementation - The following sequence of transactions can be used to test the Chaincode
+// The peer calls Init, Invoke and Query, whose form depends on the Fabric release the chaincode is built for
+// (see peer_v06.go, peer_v1.go and peer_v2.go). InitContext, InvokeContext and QueryContext do the work and
+// run against any TxContext, e.g. one over a MemoryStore (see context.go)
 ////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 
-func (t *SimpleChaincode) Init(stub shim.ChaincodeStubInterface, function string, args []string) ([]byte, error) {
+func (t *SimpleChaincode) InitContext(ctx *TxContext, function string, args []string) ([]byte, error) {
 
 	// TODO - Include all initialization to be complete before Invoke and Query
 	// Uses aucTables to delete tables if they exist and re-create them
@@ -297,17 +350,27 @@ func (t *SimpleChaincode) Init(stub shim.ChaincodeStubInterface, function string
 	var err error
 
 	for _, val := range aucTables {
-		err = stub.DeleteTable(val)
+		err = ctx.Store.DeleteTable(val)
 		if err != nil {
 			return nil, fmt.Errorf("Init(): DeleteTable of %s  Failed ", val)
 		}
-		err = InitLedger(stub, val)
+		err = InitLedger(ctx.Store, val)
 		if err != nil {
 			return nil, fmt.Errorf("Init(): InitLedger of %s  Failed ", val)
 		}
 	}
 	// Update the ledger with the Application version
-	err = stub.PutState("version", []byte(strconv.Itoa(23)))
+	err = ctx.Store.PutState("version", []byte(strconv.Itoa(23)))
+	if err != nil {
+		return nil, err
+	}
+
+	// Partition bucket for the category tables - YEAR unless MONTH is asked for (see partition.go)
+	bucket := PartitionYear
+	if len(args) > 0 {
+		bucket = args[0]
+	}
+	err = SetPartitionBucket(ctx.Store, bucket)
 	if err != nil {
 		return nil, err
 	}
@@ -329,7 +392,7 @@ func (t *SimpleChaincode) Init(stub shim.ChaincodeStubInterface, function string
 // - The CloseAuction creates a transaction and invokes PostTransaction
 ////////////////////////////////////////////////////////////////
 
-func (t *SimpleChaincode) Invoke(stub shim.ChaincodeStubInterface, function string, args []string) ([]byte, error) {
+func (t *SimpleChaincode) InvokeContext(ctx *TxContext, function string, args []string) ([]byte, error) {
 	var err error
 	var buff []byte
 
@@ -340,19 +403,37 @@ func (t *SimpleChaincode) Invoke(stub shim.ChaincodeStubInterface, function stri
 	// Newer structs - the recType can be positioned anywhere and ChkReqType will check for recType
 	// example:
 	// ./peer chaincode invoke -l golang -n mycc -c '{"Function": "PostBid", "Args":["1111", "BID", "1", "1000", "300", "1200"]}'
+	// Create and update functions also take their fields as a JSON object (see namedargs.go)
 	//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 
+	args, err = ParseNamedArgs(function, args)
+	if err != nil {
+		return nil, err
+	}
+
 	if ChkReqType(args) == true {
 
 		InvokeRequest := InvokeFunction(function)
-		if InvokeRequest != nil {
-			buff, err = InvokeRequest(stub, function, args)
+		if InvokeRequest == nil {
+			fmt.Println("Invoke() Invalid function call : ", function)
+			return nil, NewError(ErrInvalidArgument, "Invoke() : Invalid function call : "+function)
+		}
+		buff, err = InvokeRequest(ctx, function, args)
+
+		// Publish the events raised by the request, or drop them if it failed
+		if err == nil {
+			err = FlushEvents(ctx)
+		} else {
+			DiscardEvents(ctx)
 		}
 	} else {
-		fmt.Println("Invoke() Invalid recType : ", args, "\n")
-		return nil, errors.New("Invoke() : Invalid recType : " + args[0])
+		fmt.Println("Invoke() Invalid recType : ", args)
+		return nil, NewError(ErrInvalidArgument, "Invoke() : Invalid recType : "+strings.Join(args, ", "))
 	}
 
+	if err != nil {
+		return nil, AsChaincodeError(err)
+	}
 	return buff, err
 }
 
@@ -364,32 +445,76 @@ func (t *SimpleChaincode) Invoke(stub shim.ChaincodeStubInterface, function stri
 // ./peer chaincode query -l golang -n mycc -c '{"Function": "GetItem", "Args": ["2000"]}'
 //////////////////////////////////////////////////////////////////////////////////////////
 
-func (t *SimpleChaincode) Query(stub shim.ChaincodeStubInterface, function string, args []string) ([]byte, error) {
+func (t *SimpleChaincode) QueryContext(ctx *TxContext, function string, args []string) ([]byte, error) {
 	var err error
 	var buff []byte
-	fmt.Println("ID Extracted and Type = ", args[0])
 	fmt.Println("Args supplied : ", args)
 
 	if len(args) < 1 {
 		fmt.Println("Query() : Include at least 1 arguments Key ")
-		return nil, errors.New("Query() : Expecting Transation type and Key value for query")
+		return nil, NewError(ErrInvalidArgument, "Query() : Expecting Transation type and Key value for query")
+	}
+
+	// A query's writes are not kept, and the Fabric 0.6 peer refuses them
+	if bids, ok := ctx.Bids.(bidTableRepository); ok {
+		bids.readOnly = true
+		ctx.Bids = bids
 	}
 
 	QueryRequest := QueryFunction(function)
 	if QueryRequest != nil {
-		buff, err = QueryRequest(stub, function, args)
+		buff, err = QueryRequest(ctx, function, args)
 	} else {
 		fmt.Println("Query() Invalid function call : ", function)
-		return nil, errors.New("Query() : Invalid function call : " + function)
+		return nil, NewError(ErrInvalidArgument, "Query() : Invalid function call : "+function)
 	}
 
+	// Pass the handler's error on, so that the client can tell what went wrong
 	if err != nil {
-		fmt.Println("Query() Object not found : ", args[0])
-		return nil, errors.New("Query() : Object not found : " + args[0])
+		fmt.Println("Query() : ", function, " failed : ", err)
+		return nil, AsChaincodeError(err)
 	}
 	return buff, err
 }
 
+//////////////////////////////////////////////////////////////////////////////////////////
+// SimpleChaincode - the single Invoke of Fabric 1.x and later
+// Those releases have no Query: queries arrive through Invoke as well and are told apart
+// by their function name. The client reads a query's result and does not submit it
+// Init runs on every upgrade as well as on instantiate. If the ledger has a version the
+// tables are kept, since InitContext would delete them
+//////////////////////////////////////////////////////////////////////////////////////////
+
+func (t *SimpleChaincode) CallContext(ctx *TxContext, function string, args []string) ([]byte, error) {
+
+	if QueryFunction(function) != nil {
+		return t.QueryContext(ctx, function, args)
+	}
+	return t.InvokeContext(ctx, function, args)
+}
+
+func (t *SimpleChaincode) InstantiateContext(ctx *TxContext, function string, args []string) ([]byte, error) {
+
+	version, err := ctx.Store.GetState("version")
+	if err != nil {
+		return nil, err
+	}
+	if version == nil {
+		return t.InitContext(ctx, function, args)
+	}
+
+	fmt.Println("Init() : Ledger version ", string(version), " found - keeping the tables")
+
+	// Tables added since, e.g. BidSummaryTable. Existing ones are left as they are
+	for _, val := range aucTables {
+		err = InitLedger(ctx.Store, val)
+		if err != nil {
+			return nil, fmt.Errorf("Init(): InitLedger of %s  Failed ", val)
+		}
+	}
+	return []byte("Init(): Upgrade Complete"), nil
+}
+
 //////////////////////////////////////////////////////////////////////////////////////////
 // Retrieve Auction applications version Information
 // This API is to check whether application has been deployed successfully or not
@@ -397,21 +522,19 @@ func (t *SimpleChaincode) Query(stub shim.ChaincodeStubInterface, function strin
 // ./peer chaincode query -l golang -n mycc -c '{"Function": "GetVersion", "Args": ["version"]}'
 //
 //////////////////////////////////////////////////////////////////////////////////////////
-func GetVersion(stub shim.ChaincodeStubInterface, function string, args []string) ([]byte, error) {
+func GetVersion(ctx *TxContext, function string, args []string) ([]byte, error) {
 	if len(args) < 1 {
 		fmt.Println("GetVersion() : Requires 1 argument 'version'")
-		return nil, errors.New("GetVersion() : Requires 1 argument 'version'")
+		return nil, NewError(ErrInvalidArgument, "GetVersion() : Requires 1 argument 'version'")
 	}
 	// Get version from the ledger
-	version, err := stub.GetState(args[0])
+	version, err := ctx.Store.GetState(args[0])
 	if err != nil {
-		jsonResp := "{\"Error\":\"Failed to get state for version\"}"
-		return nil, errors.New(jsonResp)
+		return nil, WrapError(err, "GetVersion() : Failed to get state for version")
 	}
 
 	if version == nil {
-		jsonResp := "{\"Error\":\" auction application version is invalid\"}"
-		return nil, errors.New(jsonResp)
+		return nil, NewError(ErrNotFound, "GetVersion() : Auction application version is not set", "Key", args[0])
 	}
 
 	jsonResp := "{\"version\":\"" + string(version) + "\"}"
@@ -425,26 +548,25 @@ func GetVersion(stub shim.ChaincodeStubInterface, function string, args []string
 // ./peer chaincode query -l golang -n mycc -c '{"Function": "GetUser", "Args": ["100"]}'
 //
 //////////////////////////////////////////////////////////////////////////////////////////
-func GetUser(stub shim.ChaincodeStubInterface, function string, args []string) ([]byte, error) {
+func GetUser(ctx *TxContext, function string, args []string) ([]byte, error) {
 
 	var err error
 
 	// Get the Object and Display it
-	Avalbytes, err := QueryLedger(stub, "UserTable", args)
+	user, err := ctx.Users.Get(args[0])
 	if err != nil {
 		fmt.Println("GetUser() : Failed to Query Object ")
-		jsonResp := "{\"Error\":\"Failed to get  Object Data for " + args[0] + "\"}"
-		return nil, errors.New(jsonResp)
+		return nil, WrapError(err, "Failed to get Object Data for "+args[0])
 	}
 
-	if Avalbytes == nil {
-		fmt.Println("GetUser() : Incomplete Query Object ")
-		jsonResp := "{\"Error\":\"Incomplete information about the key for " + args[0] + "\"}"
-		return nil, errors.New(jsonResp)
+	// Personal data is redacted according to the caller's role
+	user, err = RedactUser(ctx, user)
+	if err != nil {
+		return nil, err
 	}
 
-	fmt.Println("GetUser() : Response : Successfull -")
-	return Avalbytes, nil
+	fmt.Println("GetUser() : Response : Successfull")
+	return UsertoJSON(user)
 }
 
 /////////////////////////////////////////////////////////////////////////////////////////
@@ -452,27 +574,20 @@ func GetUser(stub shim.ChaincodeStubInterface, function string, args []string) (
 // Retrieve a Item by Item ID
 // ./peer chaincode query -l golang -n mycc -c '{"Function": "GetItem", "Args": ["1000"]}'
 /////////////////////////////////////////////////////////////////////////////////////////
-func GetItem(stub shim.ChaincodeStubInterface, function string, args []string) ([]byte, error) {
+func GetItem(ctx *TxContext, function string, args []string) ([]byte, error) {
 
 	var err error
 
 	// Get the Objects and Display it
-	Avalbytes, err := QueryLedger(stub, "ItemTable", args)
+	itemObject, err := ctx.Items.Get(args[0])
 	if err != nil {
 		fmt.Println("GetItem() : Failed to Query Object ")
-		jsonResp := "{\"Error\":\"Failed to get  Object Data for " + args[0] + "\"}"
-		return nil, errors.New(jsonResp)
-	}
-
-	if Avalbytes == nil {
-		fmt.Println("GetItem() : Incomplete Query Object ")
-		jsonResp := "{\"Error\":\"Incomplete information about the key for " + args[0] + "\"}"
-		return nil, errors.New(jsonResp)
+		return nil, WrapError(err, "Failed to get Object Data for "+args[0])
 	}
 
 	fmt.Println("GetItem() : Response : Successfull ")
 
-	return Avalbytes, nil
+	return ARtoJSON(itemObject)
 }
 
 /////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -482,26 +597,19 @@ func GetItem(stub shim.ChaincodeStubInterface, function string, args []string) (
 // There are two other tables just for query purposes - AucInitTable, AucOpenTable
 //
 /////////////////////////////////////////////////////////////////////////////////////////////////////
-func GetAuctionRequest(stub shim.ChaincodeStubInterface, function string, args []string) ([]byte, error) {
+func GetAuctionRequest(ctx *TxContext, function string, args []string) ([]byte, error) {
 
 	var err error
 
 	// Get the Objects and Display it
-	Avalbytes, err := QueryLedger(stub, "AuctionTable", args)
+	aucR, err := ctx.Auctions.Get(args[0])
 	if err != nil {
 		fmt.Println("GetAuctionRequest() : Failed to Query Object ")
-		jsonResp := "{\"Error\":\"Failed to get  Object Data for " + args[0] + "\"}"
-		return nil, errors.New(jsonResp)
+		return nil, WrapError(err, "Failed to get Object Data for "+args[0])
 	}
 
-	if Avalbytes == nil {
-		fmt.Println("GetAuctionRequest() : Incomplete Query Object ")
-		jsonResp := "{\"Error\":\"Incomplete information about the key for " + args[0] + "\"}"
-		return nil, errors.New(jsonResp)
-	}
-
-	fmt.Println("GetAuctionRequest() : Response : Successfull - \n")
-	return Avalbytes, nil
+	fmt.Println("GetAuctionRequest() : Response : Successfull")
+	return AucReqtoJSON(aucR)
 }
 
 ///////////////////////////////////////////////////////////////////////////////////////////////////
@@ -510,7 +618,7 @@ func GetAuctionRequest(stub shim.ChaincodeStubInterface, function string, args [
 // ./peer chaincode query -l golang -n mycc -c '{"Function": "GetLastBid", "Args": ["1111"], "1"}'
 //
 ///////////////////////////////////////////////////////////////////////////////////////////////////
-func GetBid(stub shim.ChaincodeStubInterface, function string, args []string) ([]byte, error) {
+func GetBid(ctx *TxContext, function string, args []string) ([]byte, error) {
 
 	var err error
 
@@ -519,25 +627,18 @@ func GetBid(stub shim.ChaincodeStubInterface, function string, args []string) ([
 	if len(args) < 2 {
 		fmt.Println("GetBid(): Incorrect number of arguments. Expecting 2 ")
 		fmt.Println("GetBid(): ./peer chaincode query -l golang -n mycc -c '{\"Function\": \"GetBid\", \"Args\": [\"1111\",\"6\"]}'")
-		return nil, errors.New("GetBid(): Incorrect number of arguments. Expecting 2 ")
+		return nil, NewError(ErrInvalidArgument, "GetBid(): Incorrect number of arguments. Expecting 2 ")
 	}
 
 	// Get the Objects and Display it
-	Avalbytes, err := QueryLedger(stub, "BidTable", args)
+	bid, err := ctx.Bids.Get(args[0], args[1])
 	if err != nil {
 		fmt.Println("GetBid() : Failed to Query Object ")
-		jsonResp := "{\"Error\":\"Failed to get  Object Data for " + args[0] + "\"}"
-		return nil, errors.New(jsonResp)
+		return nil, WrapError(err, "Failed to get Object Data for "+args[0])
 	}
 
-	if Avalbytes == nil {
-		fmt.Println("GetBid() : Incomplete Query Object ")
-		jsonResp := "{\"Error\":\"Incomplete information about the key for " + args[0] + "\"}"
-		return nil, errors.New(jsonResp)
-	}
-
-	fmt.Println("GetBid() : Response : Successfull -")
-	return Avalbytes, nil
+	fmt.Println("GetBid() : Response : Successfull")
+	return BidtoJSON(bid)
 }
 
 ///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -548,37 +649,36 @@ func GetBid(stub shim.ChaincodeStubInterface, function string, args []string) ([
 // While this version of the chain code does not enforce strict validation
 // the business process recomends validating each persona for the service
 // they provide or their participation on the auction blockchain, future enhancements will do that
+// Address, Phone, Email and the bank account numbers are hashed or encrypted before they are
+// written - pass the PII key in the transaction metadata to have contact fields encrypted (see pii.go)
 // ./peer chaincode invoke -l golang -n mycc -c '{"Function": "PostUser", "Args":["100", "USER", "Ashley Hart", "TRD",  "Morrisville Parkway, #216, Morrisville, NC 27560", "9198063535", "ashley@itpeople.com", "SUNTRUST", "00017102345", "0234678"]}'
+// ./peer chaincode invoke -l golang -n mycc -c '{"Function": "PostUser", "Args":["{\"UserID\":\"100\",\"Name\":\"Ashley Hart\",\"UserType\":\"TRD\",\"Email\":\"ashley@itpeople.com\"}"]}'
 ///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 
-func PostUser(stub shim.ChaincodeStubInterface, function string, args []string) ([]byte, error) {
+func PostUser(ctx *TxContext, function string, args []string) ([]byte, error) {
 
 	record, err := CreateUserObject(args[0:]) //
 	if err != nil {
 		return nil, err
 	}
-	buff, err := UsertoJSON(record) //
 
+	txTime, err := GetTxTime(ctx)
 	if err != nil {
-		fmt.Println("PostuserObject() : Failed Cannot create object buffer for write : ", args[1])
-		return nil, errors.New("PostUser(): Failed Cannot create object buffer for write : " + args[1])
-	} else {
-		// Update the ledger with the Buffer Data
-		// err = stub.PutState(args[0], buff)
-		keys := []string{args[0]}
-		err = UpdateLedger(stub, "UserTable", keys, buff)
-		if err != nil {
-			fmt.Println("PostUser() : write error while inserting record")
-			return nil, err
-		}
+		return nil, err
+	}
+	record.RegisteredDate = txTime.Format("2006-01-02 15:04:05")
 
-		// Post Entry into UserCatTable - i.e. User Category Table
-		keys = []string{"2016", args[3], args[0]}
-		err = UpdateLedger(stub, "UserCatTable", keys, buff)
-		if err != nil {
-			fmt.Println("PostUser() : write error while inserting recordinto UserCatTable \n")
-			return nil, err
-		}
+	// Hash or encrypt personal data before it reaches the ledger
+	record, err = ProtectUser(ctx, record)
+	if err != nil {
+		return nil, err
+	}
+
+	// Update the ledger - UserTable and UserCatTable
+	buff, err := ctx.Users.Add(record)
+	if err != nil {
+		fmt.Println("PostUser() : write error while inserting record")
+		return nil, err
 	}
 
 	return buff, err
@@ -592,75 +692,294 @@ func CreateUserObject(args []string) (UserObject, error) {
 	// Check there are 10 Arguments
 	if len(args) != 10 {
 		fmt.Println("CreateUserObject(): Incorrect number of arguments. Expecting 10 ")
-		return aUser, errors.New("CreateUserObject() : Incorrect number of arguments. Expecting 10 ")
+		return aUser, NewError(ErrInvalidArgument, "CreateUserObject() : Incorrect number of arguments. Expecting 10 ")
 	}
 
 	// Validate UserID is an integer
 
 	_, err = strconv.Atoi(args[0])
 	if err != nil {
-		return aUser, errors.New("CreateUserObject() : User ID should be an integer")
+		return aUser, NewError(ErrInvalidArgument, "CreateUserObject() : User ID should be an integer")
 	}
 
-	aUser = UserObject{args[0], args[1], args[2], args[3], args[4], args[5], args[6], args[7], args[8], args[9]}
-	fmt.Println("CreateUserObject() : User Object : ", aUser)
+	// RecType is set here, whatever the caller passed, so the row decodes as a user
+	aUser = UserObject{
+		UserID:    args[0],
+		RecType:   "USER",
+		Name:      args[2],
+		UserType:  args[3],
+		Address:   args[4],
+		Phone:     args[5],
+		Email:     args[6],
+		Bank:      args[7],
+		AccountNo: args[8],
+		RoutingNo: args[9],
+		Status:    "ACTIVE",
+	}
+	fmt.Println("CreateUserObject() : User Object : ", aUser.UserID, aUser.UserType)
 
 	return aUser, nil
 }
 
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Update a registered User
+// Takes the same arguments as PostUser. Personal data is sent in plain and protected again; an empty field keeps
+// its stored value. Stored (hashed/encrypted) or REDACTED values are refused. If the UserType changes the user is
+// moved to the new category in UserCatTable
+// ./peer chaincode invoke -l golang -n mycc -c '{"Function": "UpdateUser", "Args":["100", "USER", "Ashley Hart", "AH",  "Morrisville Parkway, #216, Morrisville, NC 27560", "9198063535", "ashley@itpeople.com", "SUNTRUST", "00017102345", "0234678"]}'
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func UpdateUser(ctx *TxContext, function string, args []string) ([]byte, error) {
+
+	record, err := CreateUserObject(args[0:])
+	if err != nil {
+		return nil, err
+	}
+
+	if !CanManageUser(ctx, record.UserID) {
+		return nil, NewError(ErrUnauthorized, "UpdateUser(): Caller is not permitted to update user "+record.UserID)
+	}
+
+	current, err := GetUserObject(ctx, record.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if current.Status == "INACTIVE" {
+		return nil, NewError(ErrConflict, "UpdateUser(): User "+record.UserID+" has been deactivated")
+	}
+
+	// Carry over what the client does not own
+	record.ErasedDate = current.ErasedDate
+	record.Status = current.Status
+	record.RegisteredDate = current.RegisteredDate
+
+	record, err = ProtectUser(ctx, record)
+	if err != nil {
+		return nil, err
+	}
+
+	// Personal data the client left empty is kept
+	for _, name := range append(piiContactFields, piiHashedFields...) {
+		if f := userPIIField(&record, name); *f == "" {
+			*f = *userPIIField(&current, name)
+		}
+	}
+
+	return ctx.Users.Replace(current, record)
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Deactivate a User
+// The record is kept so that past bids and transactions still resolve, but ValidateMember rejects the user from now on
+// ./peer chaincode invoke -l golang -n mycc -c '{"Function": "DeactivateUser", "Args":["100", "USER"]}'
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func DeactivateUser(ctx *TxContext, function string, args []string) ([]byte, error) {
+
+	if len(args) != 2 {
+		fmt.Println("DeactivateUser(): Incorrect number of arguments. Expecting 2 ")
+		return nil, NewError(ErrInvalidArgument, "DeactivateUser(): Incorrect number of arguments. Expecting 2 ")
+	}
+
+	if !CanManageUser(ctx, args[0]) {
+		return nil, NewError(ErrUnauthorized, "DeactivateUser(): Caller is not permitted to deactivate user "+args[0])
+	}
+
+	current, err := GetUserObject(ctx, args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	if current.Status == "INACTIVE" {
+		return nil, NewError(ErrConflict, "DeactivateUser(): User "+args[0]+" is already inactive")
+	}
+
+	record := current
+	record.Status = "INACTIVE"
+	return ctx.Users.Replace(current, record)
+}
+
+////////////////////////////////////////////////////////////////////////////
+// Fetch a User Object from the UserTable
+////////////////////////////////////////////////////////////////////////////
+func GetUserObject(ctx *TxContext, userID string) (UserObject, error) {
+
+	user, err := ctx.Users.Get(userID)
+	if err != nil {
+		fmt.Println("GetUserObject() : Failed to Query Object ", userID)
+		return UserObject{}, NewError(ErrNotFound, "GetUserObject(): User not found : "+userID, "UserID", userID)
+	}
+	return user, nil
+}
+
 /////////////////////////////////////////////////////////////////////////////////////////////////////////////
 // Create a master Object of the Item
 // Since the Owner Changes hands, a record has to be written for each
 // Transaction with the updated Encryption Key of the new owner
 // Example
-//./peer chaincode invoke -l golang -n mycc -c '{"Function": "PostItem", "Args":["1000", "ARTINV", "Shadows by Asppen", "Asppen Messer", "20140202", "Original", "Landscape" , "Canvas", "15 x 15 in", "sample_7.png","$600", "100"]}'
+//./peer chaincode invoke -l golang -n mycc -c '{"Function": "PostItem", "Args":["1000", "ARTINV", "Shadows by Asppen", "Asppen Messer", "Original", "Landscape"]}'
+// Documents can be attached at registration by appending (DocHash, MediaType, DocURI) triples
+//./peer chaincode invoke -l golang -n mycc -c '{"Function": "PostItem", "Args":["1000", "ARTINV", "Shadows by Asppen", "Asppen Messer", "Original", "Landscape", "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08", "image/png", "https://gallery.example.com/sample_7.png"]}'
+//./peer chaincode invoke -l golang -n mycc -c '{"Function": "PostItem", "Args":["{\"ItemID\":\"1000\",\"ItemDesc\":\"Shadows by Asppen\",\"ItemDetail\":\"Asppen Messer\",\"ItemType\":\"Original\",\"ItemSubject\":\"Landscape\"}"]}'
 /////////////////////////////////////////////////////////////////////////////////////////////////////////////
 
-func PostItem(stub shim.ChaincodeStubInterface, function string, args []string) ([]byte, error) {
+func PostItem(ctx *TxContext, function string, args []string) ([]byte, error) {
 
 	itemObject, err := CreateItemObject(args[0:])
 	if err != nil {
-		fmt.Println("PostItem(): Cannot create item object \n")
+		fmt.Println("PostItem(): Cannot create item object")
 		return nil, err
 	}
 
-	// Convert Item Object to JSON
-	buff, err := ARtoJSON(itemObject) //
+	txTime, err := GetTxTime(ctx)
 	if err != nil {
-		fmt.Println("PostItem() : Failed Cannot create object buffer for write : ", args[0])
-		return nil, errors.New("PostItem(): Failed Cannot create object buffer for write : " + args[0])
-	} else {
-		// Update the ledger with the Buffer Data
-		// err = stub.PutState(args[0], buff)
-		keys := []string{args[0]}
-		err = UpdateLedger(stub, "ItemTable", keys, buff)
+		return nil, err
+	}
+	itemObject.RegisteredDate = txTime.Format("2006-01-02 15:04:05")
+
+	// A member registering their own item owns it. An Auction House cataloguing an item
+	// leaves the owner to be set when the item's artefact is posted
+	itemObject.OwnerID = ""
+	if GetCallerAttribute(ctx, "role") != "AH" {
+		itemObject.OwnerID = GetCallerAttribute(ctx, "userid")
+	}
+
+	// Update the ledger - the item is indexed by subject and by type so that the UI can browse the catalog
+	buff, err := ctx.Items.Add(itemObject)
+	if err != nil {
+		fmt.Println("PostItem() : write error while inserting record")
+		return nil, err
+	}
+
+	return buff, nil
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Update the description, detail, type or subject of a registered Item
+// Documents registered against the item are kept. ItemCatTable and ItemTypeTable are re-indexed
+// Only the owner of the item or an Auction House may update it
+//./peer chaincode invoke -l golang -n mycc -c '{"Function": "UpdateItem", "Args":["1000", "ARTINV", "Shadows by Asppen", "Asppen Messer", "Original", "Portrait"]}'
+/////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func UpdateItem(ctx *TxContext, function string, args []string) ([]byte, error) {
+
+	if len(args) != 6 {
+		fmt.Println("UpdateItem(): Incorrect number of arguments. Expecting 6 ")
+		return nil, NewError(ErrInvalidArgument, "UpdateItem(): Incorrect number of arguments. Expecting 6 ")
+	}
+
+	record, err := CreateItemObject(args[0:])
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := GetItemObject(ctx, record.ItemID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !CanManageItem(ctx, current.ItemID) {
+		return nil, NewError(ErrUnauthorized, "UpdateItem(): Caller is not permitted to update item "+current.ItemID)
+	}
+
+	record.ItemDocs = current.ItemDocs
+	record.RegisteredDate = current.RegisteredDate
+	record.OwnerID = current.OwnerID
+	return ctx.Items.Replace(current, record)
+}
+
+////////////////////////////////////////////////////////////////////////////
+// Fetch an Item Object from the ItemTable
+////////////////////////////////////////////////////////////////////////////
+func GetItemObject(ctx *TxContext, itemID string) (ItemObject, error) {
+
+	return ValidateItemSubmission(ctx, itemID)
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Current owner of an item. Items registered before owners were recorded
+// fall back to the owner on their artefact. Empty if there is none
+//////////////////////////////////////////////////////////////////////////////////
+func GetItemOwner(ctx *TxContext, itemID string) string {
+
+	item, err := GetItemObject(ctx, itemID)
+	if err == nil && item.OwnerID != "" {
+		return item.OwnerID
+	}
+	art, err := ctx.Items.GetArtefact(itemID)
+	if err != nil {
+		return ""
+	}
+	return art.OwnerID
+}
+
+// The item's owner or an Auction House. Only an Auction House may manage an
+// item with no owner on record
+func CanManageItem(ctx *TxContext, itemID string) bool {
+	return CanManageUser(ctx, GetItemOwner(ctx, itemID))
+}
+
+////////////////////////////////////////////////////////////////////////////
+// Replace a row of an index table, moving it if its keys changed
+////////////////////////////////////////////////////////////////////////////
+func ReplaceIndexEntry(store TableStore, tableName string, oldKeys []string, newKeys []string, buff []byte) error {
+
+	if strings.Join(oldKeys, "|") == strings.Join(newKeys, "|") {
+		err := ReplaceLedgerEntry(store, tableName, newKeys, buff)
 		if err != nil {
-			fmt.Println("PostItem() : write error while inserting record\n")
-			return buff, err
+			fmt.Println("ReplaceIndexEntry() : write error while replacing record in ", tableName)
 		}
+		return err
 	}
 
-	return buff, nil
+	err := DeleteFromLedger(store, tableName, oldKeys)
+	if err != nil {
+		fmt.Println("ReplaceIndexEntry() : Failed to remove ", oldKeys, " from ", tableName)
+		return err
+	}
+
+	err = UpdateLedger(store, tableName, newKeys, buff)
+	if err != nil {
+		fmt.Println("ReplaceIndexEntry() : Failed to add ", newKeys, " to ", tableName)
+		return err
+	}
+	return nil
 }
 
 func CreateItemObject(args []string) (ItemObject, error) {
 
 	var myItem ItemObject
 
-	// Check there are 12 Arguments provided as per the the struct - two are computed
-	if len(args) != 6 {
-		fmt.Println("CreateItemObject(): Incorrect number of arguments. Expecting 6 ")
-		return myItem, errors.New("CreateItemObject(): Incorrect number of arguments. Expecting 6 ")
+	// Check there are 6 Arguments provided as per the the struct
+	// optionally followed by (DocHash, MediaType, DocURI) triples for each attached document
+	if len(args) < 6 || (len(args)-6)%3 != 0 {
+		fmt.Println("CreateItemObject(): Incorrect number of arguments. Expecting 6 plus 3 per document ")
+		return myItem, NewError(ErrInvalidArgument, "CreateItemObject(): Incorrect number of arguments. Expecting 6 plus 3 per document ")
 	}
 
-	// Append the AES Key, The Encrypted Image Byte Array and the file type
-	myItem = ItemObject{args[0], args[1], args[2], args[3], args[4], args[5]}
+	// RecType is set here, whatever the caller passed, so the row decodes as an item
+	myItem = ItemObject{
+		ItemID:      args[0],
+		RecType:     "ARTINV",
+		ItemDesc:    args[2],
+		ItemDetail:  args[3],
+		ItemType:    args[4],
+		ItemSubject: args[5],
+	}
 
-	fmt.Println("CreateItemObject(): Item Object created: ID# ", myItem.ItemID)
+	for i := 6; i < len(args); i += 3 {
+		doc, err := CreateItemDocument(args[i : i+3])
+		if err != nil {
+			return myItem, err
+		}
+		err = AddItemDocument(&myItem, doc)
+		if err != nil {
+			return myItem, err
+		}
+	}
 
-	// Code to Validate the Item Object)
-	// If User presents Crypto Key then key is used to validate the picture that is stored as part of the title
-	// TODO
+	fmt.Println("CreateItemObject(): Item Object created: ID# ", myItem.ItemID)
 
 	return myItem, nil
 }
@@ -670,84 +989,74 @@ func CreateItemObject(args []string) (ItemObject, error) {
 // The owner of an Item, when ready to put the item on an auction
 // will create an auction request  and specify a  auction house.
 //
-// ./peer chaincode invoke -l golang -n mycc -c '{"Function": "PostAuctionRequest", "Args":["1111", "AUCREQ", "1700", "200", "400", "04012016", "1200", "INIT", "2016-05-20 11:00:00.3 +0000 UTC","2016-05-23 11:00:00.3 +0000 UTC"]}'
+// Structure of args AuctionID, RecType, ItemID, AuctionHouseID, RequestDate, Status, OpenDate, CloseDate
+// ./peer chaincode invoke -l golang -n mycc -c '{"Function": "PostAuctionRequest", "Args":["1111", "AUCREQ", "1000", "200", "2016-04-01", "INIT", "", ""]}'
+// ./peer chaincode invoke -l golang -n mycc -c '{"Function": "PostAuctionRequest", "Args":["{\"AuctionID\":\"1111\",\"ItemID\":\"1000\",\"AuctionHouseID\":\"200\",\"RequestDate\":\"2016-04-01\"}"]}'
 //
 // The start and end time of the auction are actually assigned when the auction is opened  by OpenAuctionForBids()
 ///////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 
-func PostAuctionRequest(stub shim.ChaincodeStubInterface, function string, args []string) ([]byte, error) {
+func PostAuctionRequest(ctx *TxContext, function string, args []string) ([]byte, error) {
 
 	ar, err := CreateAuctionRequest(args[0:])
 	if err != nil {
 		return nil, err
 	}
 
+	// The request date decides the AucInitTable partition
+	_, err = ParseRecordDate(ar.RequestDate)
+	if err != nil {
+		return nil, NewError(ErrInvalidArgument, "PostAuctionRequest(): Request Date is not a valid date : "+ar.RequestDate)
+	}
+
 	// Validate Auction House to check it is a registered User
-	aucHouse, err := ValidateMember(stub, ar.AuctionHouseID)
-	fmt.Println("Auction House information  ", aucHouse, " ID: ", ar.AuctionHouseID)
+	aucHouse, err := ValidateMember(ctx, ar.AuctionHouseID)
+	fmt.Println("Auction House information  ", aucHouse.UserID, " ID: ", ar.AuctionHouseID)
 	if err != nil {
 		fmt.Println("PostAuctionRequest() : Failed Auction House not Registered in Blockchain ", ar.AuctionHouseID)
 		return nil, err
 	}
 
 	// Validate Item record
-	itemObject, err := ValidateItemSubmission(stub, ar.ItemID)
+	_, err = ValidateItemSubmission(ctx, ar.ItemID)
 	if err != nil {
 		fmt.Println("PostAuctionRequest() : Failed Could not Validate Item Object in Blockchain ", ar.ItemID)
-		return itemObject, err
+		return nil, err
 	}
 
-	// Convert AuctionRequest to JSON
-	buff, err := AucReqtoJSON(ar) // Converting the auction request struct to []byte array
+	// Update the ledger - AuctionTable, and an entry in the AucInitTable that this Item has been placed for Auction
+	// The UI can pull all items available for auction and the item can be Opened for accepting bids
+	// The first key of AucInitTable is the partition of the request date (see partition.go)
+	buff, err := ctx.Auctions.Add(ar)
 	if err != nil {
-		fmt.Println("PostAuctionRequest() : Failed Cannot create object buffer for write : ", args[1])
-		return nil, errors.New("PostAuctionRequest(): Failed Cannot create object buffer for write : " + args[1])
-	} else {
-		// Update the ledger with the Buffer Data
-		//err = stub.PutState(args[0], buff)
-		keys := []string{args[0]}
-		err = UpdateLedger(stub, "AuctionTable", keys, buff)
-		if err != nil {
-			fmt.Println("PostAuctionRequest() : write error while inserting record\n")
-			return buff, err
-		}
-
-		//An entry is made in the AuctionInitTable that this Item has been placed for Auction
-		// The UI can pull all items available for auction and the item can be Opened for accepting bids
-		// The 2016 is a dummy key and has notr value other than to get all rows
-
-		keys = []string{"2016", args[0]}
-		err = UpdateLedger(stub, "AucInitTable", keys, buff)
-		if err != nil {
-			fmt.Println("PostAuctionRequest() : write error while inserting record into AucInitTable \n")
-			return buff, err
-		}
-
+		fmt.Println("PostAuctionRequest() : write error while inserting record")
+		return nil, err
 	}
 
+	AddEvent(ctx, AuctionEvent{Type: EventAuctionRequested, AuctionID: ar.AuctionID, ItemID: ar.ItemID, UserID: ar.AuctionHouseID})
 	return buff, err
 }
 
 func CreateAuctionRequest(args []string) (AuctionRequest, error) {
 	var aucReg AuctionRequest
 
-	// Check there are 11 Arguments
+	// Check there are 8 Arguments
 	// See example -- The Open and Close Dates are Dummy, and will be set by open auction
-	// '{"Function": "PostAuctionRequest", "Args":["1111", "AUCREQ", "1000", "200", "100", "04012016", "1200", "1800",
-	//   "INIT", "2016-05-20 11:00:00.3 +0000 UTC","2016-05-23 11:00:00.3 +0000 UTC"]}'
+	// '{"Function": "PostAuctionRequest", "Args":["1111", "AUCREQ", "1000", "200", "2016-04-01", "INIT", "", ""]}'
 	if len(args) != 8 {
-		fmt.Println("CreateAuctionRegistrationObject(): Incorrect number of arguments. Expecting 8 ")
-		return aucReg, errors.New("CreateAuctionRegistrationObject() : Incorrect number of arguments. Expecting 11 ")
+		fmt.Println("CreateAuctionRequest(): Incorrect number of arguments. Expecting 8 ")
+		return aucReg, NewError(ErrInvalidArgument, "CreateAuctionRequest() : Incorrect number of arguments. Expecting 8 ")
 	}
 
 	// Validate UserID is an integer . I think this redundant and can be avoided
 
 	/*err = validateID(args[0])
 	if err != nil {
-		return aucReg, errors.New("CreateAuctionRequest() : User ID should be an integer")
+		return aucReg, NewError(ErrInvalidArgument, "CreateAuctionRequest() : User ID should be an integer")
 	}*/
 
-	aucReg = AuctionRequest{args[0], args[1], args[2], args[3], args[4], args[5], args[6], args[7]}
+	// RecType is set here, whatever the caller passed, so the row decodes as an auction
+	aucReg = AuctionRequest{args[0], "AUCREQ", args[2], args[3], args[4], args[5], args[6], args[7]}
 	fmt.Println("CreateAuctionObject() : Auction Registration : ", aucReg)
 
 	return aucReg, nil
@@ -758,19 +1067,27 @@ func CreateAuctionRequest(args []string) (AuctionRequest, error) {
 // Once an Item has been opened for auction, bids can be submitted as long as the auction is "OPEN"
 //./peer chaincode invoke -l golang -n mycc -c '{"Function": "PostBid", "Args":["1111", "BID", "1", "1000", "300", "1200"]}'
 //./peer chaincode invoke -l golang -n mycc -c '{"Function": "PostBid", "Args":["1111", "BID", "2", "1000", "400", "3000"]}'
+//./peer chaincode invoke -l golang -n mycc -c '{"Function": "PostBid", "Args":["{\"AuctionID\":\"1111\",\"BidNo\":\"2\",\"ItemID\":\"1000\",\"BuyerID\":\"400\",\"BidPrice\":\"3000\"}"]}'
 //
 /////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 
-/*func PostBid(stub shim.ChaincodeStubInterface, function string, args []string) ([]byte, error) {
+func PostBid(ctx *TxContext, function string, args []string) ([]byte, error) {
 
 	bid, err := CreateBidObject(args[0:]) //
 	if err != nil {
 		return nil, err
 	}
 
+	// The bid is timed by the transaction so that every peer agrees on it
+	txTime, err := GetTxTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	bid.BidTime = txTime.Format("2006-01-02 15:04:05")
+
 	// Reject the Bid if the Buyer Information Is not Valid or not registered on the Block Chain
-	buyerInfo, err := ValidateMember(stub, args[4])
-	fmt.Println("Buyer information  ", buyerInfo, "  ", args[4])
+	buyerInfo, err := ValidateMember(ctx, args[4])
+	fmt.Println("Buyer information  ", buyerInfo.UserID, "  ", args[4])
 	if err != nil {
 		fmt.Println("PostBid() : Failed Buyer not registered on the block-chain ", args[4])
 		return nil, err
@@ -779,21 +1096,15 @@ func CreateAuctionRequest(args []string) (AuctionRequest, error) {
 	///////////////////////////////////////
 	// Reject Bid if Auction is not "OPEN"
 	///////////////////////////////////////
-	RBytes, err := GetAuctionRequest(stub, "GetAuctionRequest", []string{args[0]})
+	aucR, err := ctx.Auctions.Get(args[0])
 	if err != nil {
 		fmt.Println("PostBid() : Cannot find Auction record ", args[0])
-		return nil, errors.New("PostBid(): Cannot find Auction record : " + args[0])
-	}
-
-	aucR, err := JSONtoAucReq(RBytes)
-	if err != nil {
-		fmt.Println("PostBid() : Cannot UnMarshall Auction record")
-		return nil, errors.New("PostBid(): Cannot UnMarshall Auction record: " + args[0])
+		return nil, NewError(ErrNotFound, "PostBid(): Cannot find Auction record : "+args[0], "AuctionID", args[0])
 	}
 
 	if aucR.Status != "OPEN" {
 		fmt.Println("PostBid() : Cannot accept Bid as Auction is not OPEN ", args[0])
-		return nil, errors.New("PostBid(): Cannot accept Bid as Auction is not OPEN : " + args[0])
+		return nil, NewError(ErrAuctionNotOpen, "PostBid(): Cannot accept Bid as Auction is not OPEN : "+args[0], "AuctionID", args[0], "Status", aucR.Status)
 	}
 
 	///////////////////////////////////////////////////////////////////
@@ -801,7 +1112,7 @@ func CreateAuctionRequest(args []string) (AuctionRequest, error) {
 	///////////////////////////////////////////////////////////////////
 	if tCompare(bid.BidTime, aucR.CloseDate) == false {
 		fmt.Println("PostBid() Failed : BidTime past the Auction Close Time")
-		return nil, fmt.Errorf("PostBid() Failed : BidTime past the Auction Close Time %s, %s", bid.BidTime, aucR.CloseDate)
+		return nil, NewError(ErrAuctionNotOpen, fmt.Sprintf("PostBid() Failed : BidTime past the Auction Close Time %s, %s", bid.BidTime, aucR.CloseDate), "AuctionID", aucR.AuctionID, "CloseDate", aucR.CloseDate)
 	}
 
 	//////////////////////////////////////////////////////////////////
@@ -809,46 +1120,53 @@ func CreateAuctionRequest(args []string) (AuctionRequest, error) {
 	//////////////////////////////////////////////////////////////////
 	if aucR.ItemID != bid.ItemID {
 		fmt.Println("PostBid() Failed : Item ID mismatch on bid. Bid Rejected")
-		return nil, errors.New("PostBid() : Item ID mismatch on Bid. Bid Rejected")
+		return nil, NewError(ErrInvalidArgument, "PostBid() : Item ID mismatch on Bid. Bid Rejected")
 	}
 
 	//////////////////////////////////////////////////////////////////////
-	// Reject Bid if Bid Price is less than Reserve Price
-	// Convert Bid Price and Reserve Price to Integer (TODO - Float)
+	// Reject Bid if Bid Price does not beat the highest bid so far
+	// Convert Bid Prices to Integer (TODO - Float)
 	//////////////////////////////////////////////////////////////////////
 	bp, err := strconv.Atoi(bid.BidPrice)
 	if err != nil {
 		fmt.Println("PostBid() Failed : Bid price should be an integer")
-		return nil, errors.New("PostBid() : Bid price should be an integer")
+		return nil, NewError(ErrInvalidArgument, "PostBid() : Bid price should be an integer")
 	}
 
-	hp, err := strconv.Atoi(aucR.ReservePrice)
+	HBytes, err := GetHighestBid(ctx, "GetHighestBid", []string{args[0]})
 	if err != nil {
-		return nil, errors.New("PostItem() : Reserve Price should be an integer")
+		return nil, err
 	}
 
-	// Check if Bid Price is > Auction Request Reserve Price
-	if bp < hp {
-		return nil, errors.New("PostItem() : Bid Price must be greater than Reserve Price")
+	var prevBid Bid
+	if HBytes != nil {
+		prevBid, err = JSONtoBid(HBytes)
+		if err != nil {
+			return nil, NewError(ErrCorruptRecord, "PostBid() : Cannot UnMarshall the highest bid : "+err.Error(), "RecType", "BID")
+		}
+
+		hp, err := strconv.Atoi(prevBid.BidPrice)
+		if err != nil {
+			return nil, NewError(ErrCorruptRecord, "PostBid() : Highest Bid Price should be an integer", "RecType", "BID", "Field", "BidPrice")
+		}
+
+		if bp <= hp {
+			return nil, NewError(ErrBidTooLow, "PostBid() : Bid Price must be greater than the highest bid of "+prevBid.BidPrice, "BidPrice", bid.BidPrice, "HighestBid", prevBid.BidPrice)
+		}
 	}
 
 	////////////////////////////
 	// Post or Accept the Bid
 	////////////////////////////
-	buff, err := BidtoJSON(bid) //
-
+	buff, err := ctx.Bids.Add(bid)
 	if err != nil {
-		fmt.Println("PostBid() : Failed Cannot create object buffer for write : ", args[1])
-		return nil, errors.New("PostBid(): Failed Cannot create object buffer for write : " + args[1])
-	} else {
-		// Update the ledger with the Buffer Data
-		// err = stub.PutState(args[0], buff)
-		keys := []string{args[0], args[2]}
-		err = UpdateLedger(stub, "BidTable", keys, buff)
-		if err != nil {
-			fmt.Println("PostBidTable() : write error while inserting record\n")
-			return buff, err
-		}
+		fmt.Println("PostBidTable() : write error while inserting record")
+		return nil, err
+	}
+
+	AddEvent(ctx, AuctionEvent{Type: EventNewHighBid, AuctionID: bid.AuctionID, ItemID: bid.ItemID, UserID: bid.BuyerID, PrevUserID: prevBid.BuyerID, Price: bid.BidPrice})
+	if prevBid.BuyerID != "" && prevBid.BuyerID != bid.BuyerID {
+		AddEvent(ctx, AuctionEvent{Type: EventOutbid, AuctionID: bid.AuctionID, ItemID: bid.ItemID, UserID: prevBid.BuyerID, Price: bid.BidPrice})
 	}
 
 	return buff, err
@@ -858,32 +1176,32 @@ func CreateBidObject(args []string) (Bid, error) {
 	var err error
 	var aBid Bid
 
-	// Check there are 11 Arguments
+	// Check there are 6 Arguments
 	// See example
 	if len(args) != 6 {
 		fmt.Println("CreateBidObject(): Incorrect number of arguments. Expecting 6 ")
-		return aBid, errors.New("CreateBidObject() : Incorrect number of arguments. Expecting 6 ")
+		return aBid, NewError(ErrInvalidArgument, "CreateBidObject() : Incorrect number of arguments. Expecting 6 ")
 	}
 
 	// Validate Bid is an integer
 
 	_, err = strconv.Atoi(args[0])
 	if err != nil {
-		return aBid, errors.New("CreateBidObject() : Bid ID should be an integer")
+		return aBid, NewError(ErrInvalidArgument, "CreateBidObject() : Bid ID should be an integer")
 	}
 
 	_, err = strconv.Atoi(args[2])
 	if err != nil {
-		return aBid, errors.New("CreateBidObject() : Bid ID should be an integer")
+		return aBid, NewError(ErrInvalidArgument, "CreateBidObject() : Bid No should be an integer")
 	}
 
-	bidTime := time.Now().Format("2006-01-02 15:04:05")
-
-	aBid = Bid{args[0], args[1], args[2], args[3], args[4], args[5], bidTime}
+	// RecType is set here, whatever the caller passed, so the row decodes as a bid
+	// BidTime is stamped by the caller from the transaction time
+	aBid = Bid{args[0], "BID", args[2], args[3], args[4], args[5], ""}
 	fmt.Println("CreateBidObject() : Bid Object : ", aBid)
 
 	return aBid, nil
-}*/
+}
 
 //////////////////////////////////////////////////////////
 // JSON To args[] - return a map of the JSON string
@@ -903,14 +1221,17 @@ func JSONtoArgs(Avalbytes []byte) (map[string]interface{}, error) {
 // Variation of the above - return value from a JSON string
 //////////////////////////////////////////////////////////
 
-func GetKeyValue(Avalbytes []byte, key string) string {
+func GetKeyValue(Avalbytes []byte, key string) (string, error) {
 	var dat map[string]interface{}
 	if err := json.Unmarshal(Avalbytes, &dat); err != nil {
-		panic(err)
+		return "", NewError(ErrCorruptRecord, "GetKeyValue() : Record is not JSON : "+err.Error(), "Field", key)
 	}
 
-	val := dat[key].(string)
-	return val
+	val, ok := dat[key].(string)
+	if !ok {
+		return "", NewError(ErrCorruptRecord, "GetKeyValue() : Record has no string "+key, "Field", key)
+	}
+	return val, nil
 }
 
 //////////////////////////////////////////////////////////
@@ -939,6 +1260,15 @@ func tCompare(t1 string, t2 string) bool {
 	return false
 }
 
+//////////////////////////////////////////////////////////
+// Time of the current transaction as stamped by the submitter
+// Unlike time.Now() this is the same on every peer
+//////////////////////////////////////////////////////////
+func GetTxTime(ctx *TxContext) (time.Time, error) {
+
+	return ctx.Tx.TxTime()
+}
+
 //////////////////////////////////////////////////////////
 // Converts JSON String to an ART Object
 //////////////////////////////////////////////////////////
@@ -1057,7 +1387,6 @@ func UsertoJSON(user UserObject) ([]byte, error) {
 		fmt.Println("UsertoJSON error: ", err)
 		return nil, err
 	}
-	fmt.Println("UsertoJSON created: ", ajson)
 	return ajson, nil
 }
 
@@ -1072,7 +1401,6 @@ func JSONtoUser(user []byte) (UserObject, error) {
 		fmt.Println("JSONtoUser error: ", err)
 		return ur, err
 	}
-	fmt.Println("JSONtoUser created: ", ur)
 	return ur, err
 }
 
@@ -1085,7 +1413,7 @@ func validateID(id string) error {
 
 	_, err := strconv.Atoi(id)
 	if err != nil {
-		return errors.New("validateID(): User ID should be an integer")
+		return NewError(ErrInvalidArgument, "validateID(): User ID should be an integer")
 	}
 	return nil
 }
@@ -1094,52 +1422,38 @@ func validateID(id string) error {
 // Validate if the User Information Exists
 // in the block-chain
 ////////////////////////////////////////////////////////////////////////////
-func ValidateMember(stub shim.ChaincodeStubInterface, owner string) ([]byte, error) {
-
-	// Get the Item Objects and Display it
-	// Avalbytes, err := stub.GetState(owner)
-	args := []string{owner, "USER"}
-	Avalbytes, err := QueryLedger(stub, "UserTable", args)
+func ValidateMember(ctx *TxContext, owner string) (UserObject, error) {
 
+	// Get the User Object
+	member, err := ctx.Users.Get(owner)
 	if err != nil {
 		fmt.Println("ValidateMember() : Failed - Cannot find valid owner record for ART  ", owner)
-		jsonResp := "{\"Error\":\"Failed to get Owner Object Data for " + owner + "\"}"
-		return nil, errors.New(jsonResp)
+		return UserObject{}, NewError(ErrNotFound, "ValidateMember() : User not found : "+owner, "UserID", owner)
 	}
 
-	if Avalbytes == nil {
-		fmt.Println("ValidateMember() : Failed - Incomplete owner record for ART  ", owner)
-		jsonResp := "{\"Error\":\"Failed - Incomplete information about the owner for " + owner + "\"}"
-		return nil, errors.New(jsonResp)
+	if member.Status == "INACTIVE" {
+		fmt.Println("ValidateMember() : Failed - User has been deactivated ", owner)
+		return UserObject{}, NewError(ErrUnauthorized, "ValidateMember() : User "+owner+" has been deactivated", "UserID", owner)
 	}
 
 	fmt.Println("ValidateMember() : Validated Item Owner:\n", owner)
-	return Avalbytes, nil
+	return member, nil
 }
 
 ////////////////////////////////////////////////////////////////////////////
 // Validate if the User Information Exists
 // in the block-chain
 ////////////////////////////////////////////////////////////////////////////
-func ValidateItemSubmission(stub shim.ChaincodeStubInterface, artId string) ([]byte, error) {
+func ValidateItemSubmission(ctx *TxContext, artId string) (ItemObject, error) {
 
-	// Get the Item Objects and Display it
-	args := []string{artId, "ARTINV"}
-	Avalbytes, err := QueryLedger(stub, "ItemTable", args)
+	// Get the Item Object
+	item, err := ctx.Items.Get(artId)
 	if err != nil {
 		fmt.Println("ValidateItemSubmission() : Failed - Cannot find valid owner record for ART  ", artId)
-		jsonResp := "{\"Error\":\"Failed to get Owner Object Data for " + artId + "\"}"
-		return nil, errors.New(jsonResp)
-	}
-
-	if Avalbytes == nil {
-		fmt.Println("ValidateItemSubmission() : Failed - Incomplete owner record for ART  ", artId)
-		jsonResp := "{\"Error\":\"Failed - Incomplete information about the owner for " + artId + "\"}"
-		return nil, errors.New(jsonResp)
+		return ItemObject{}, NewError(ErrNotFound, "ValidateItemSubmission() : Item not found : "+artId, "ItemID", artId)
 	}
 
-	//fmt.Println("ValidateItemSubmission() : Validated Item Owner:", Avalbytes)
-	return Avalbytes, nil
+	return item, nil
 }
 
 ////////////////////////////////////////////////////////////////////////////
@@ -1156,7 +1470,7 @@ func ValidateItemSubmission(stub shim.ChaincodeStubInterface, artId string) ([]b
 //  - InitAuctionTriggerReg()
 //  - etc. etc.
 ////////////////////////////////////////////////////////////////////////////
-func InitLedger(stub shim.ChaincodeStubInterface, tableName string) error {
+func InitLedger(store TableStore, tableName string) error {
 
 	// Generic Table Creation Function - requires Table Name and Table Key Entry
 	// Create Table - Get number of Keys the tables supports
@@ -1165,23 +1479,13 @@ func InitLedger(stub shim.ChaincodeStubInterface, tableName string) error {
 
 	nKeys := GetNumberOfKeys(tableName)
 	if nKeys < 1 {
-		fmt.Println("Atleast 1 Key must be provided \n")
+		fmt.Println("Atleast 1 Key must be provided")
 		fmt.Println("Auction_Application: Failed creating Table ", tableName)
 		return errors.New("Auction_Application: Failed creating Table " + tableName)
 	}
 
-	var columnDefsForTbl []*shim.ColumnDefinition
-
-	for i := 0; i < nKeys; i++ {
-		columnDef := shim.ColumnDefinition{Name: "keyName" + strconv.Itoa(i), Type: shim.ColumnDefinition_STRING, Key: true}
-		columnDefsForTbl = append(columnDefsForTbl, &columnDef)
-	}
-
-	columnLastTblDef := shim.ColumnDefinition{Name: "Details", Type: shim.ColumnDefinition_BYTES, Key: false}
-	columnDefsForTbl = append(columnDefsForTbl, &columnLastTblDef)
-
 	// Create the Table (Nil is returned if the Table exists or if the table is created successfully
-	err := stub.CreateTable(tableName, columnDefsForTbl)
+	err := store.CreateTable(tableName, nKeys)
 
 	if err != nil {
 		fmt.Println("Auction_Application: Failed creating Table ", tableName)
@@ -1195,30 +1499,19 @@ func InitLedger(stub shim.ChaincodeStubInterface, tableName string) error {
 // Open a User Registration Table if one does not exist
 // Register users into this table
 ////////////////////////////////////////////////////////////////////////////
-func UpdateLedger(stub shim.ChaincodeStubInterface, tableName string, keys []string, args []byte) error {
+func UpdateLedger(store TableStore, tableName string, keys []string, args []byte) error {
 
 	nKeys := GetNumberOfKeys(tableName)
 	if nKeys < 1 {
-		fmt.Println("Atleast 1 Key must be provided \n")
+		fmt.Println("Atleast 1 Key must be provided")
 	}
 
-	var columns []*shim.Column
-
-	for i := 0; i < nKeys; i++ {
-		col := shim.Column{Value: &shim.Column_String_{String_: keys[i]}}
-		columns = append(columns, &col)
-	}
-
-	lastCol := shim.Column{Value: &shim.Column_Bytes{Bytes: []byte(args)}}
-	columns = append(columns, &lastCol)
-
-	row := shim.Row{columns}
-	ok, err := stub.InsertRow(tableName, row)
+	ok, err := store.InsertRow(tableName, LedgerRow{Keys: keys[:nKeys], Value: args})
 	if err != nil {
-		return fmt.Errorf("UpdateLedger: InsertRow into "+tableName+" Table operation failed. %s", err)
+		return WrapError(err, "UpdateLedger: InsertRow into "+tableName+" Table operation failed")
 	}
 	if !ok {
-		return errors.New("UpdateLedger: InsertRow into " + tableName + " Table failed. Row with given key " + keys[0] + " already exists")
+		return NewError(ErrConflict, "UpdateLedger: InsertRow into "+tableName+" Table failed. Row with given key "+keys[0]+" already exists", "Table", tableName, "Key", keys[0])
 	}
 
 	fmt.Println("UpdateLedger: InsertRow into ", tableName, " Table operation Successful. ")
@@ -1229,24 +1522,16 @@ func UpdateLedger(stub shim.ChaincodeStubInterface, tableName string, keys []str
 // Open a User Registration Table if one does not exist
 // Register users into this table
 ////////////////////////////////////////////////////////////////////////////
-func DeleteFromLedger(stub shim.ChaincodeStubInterface, tableName string, keys []string) error {
-	var columns []shim.Column
+func DeleteFromLedger(store TableStore, tableName string, keys []string) error {
 
-	//nKeys := GetNumberOfKeys(tableName)
-	nCol := len(keys)
-	if nCol < 1 {
-		fmt.Println("Atleast 1 Key must be provided \n")
+	if len(keys) < 1 {
+		fmt.Println("Atleast 1 Key must be provided")
 		return errors.New("DeleteFromLedger failed. Must include at least key values")
 	}
 
-	for i := 0; i < nCol; i++ {
-		colNext := shim.Column{Value: &shim.Column_String_{String_: keys[i]}}
-		columns = append(columns, colNext)
-	}
-
-	err := stub.DeleteRow(tableName, columns)
+	err := store.DeleteRow(tableName, keys)
 	if err != nil {
-		return fmt.Errorf("DeleteFromLedger operation failed. %s", err)
+		return WrapError(err, "DeleteFromLedger operation failed")
 	}
 
 	fmt.Println("DeleteFromLedger: DeleteRow from ", tableName, " Table operation Successful. ")
@@ -1257,30 +1542,19 @@ func DeleteFromLedger(stub shim.ChaincodeStubInterface, tableName string, keys [
 // Replaces the Entry in the Ledger
 //
 ////////////////////////////////////////////////////////////////////////////
-func ReplaceLedgerEntry(stub shim.ChaincodeStubInterface, tableName string, keys []string, args []byte) error {
+func ReplaceLedgerEntry(store TableStore, tableName string, keys []string, args []byte) error {
 
 	nKeys := GetNumberOfKeys(tableName)
 	if nKeys < 1 {
-		fmt.Println("Atleast 1 Key must be provided \n")
+		fmt.Println("Atleast 1 Key must be provided")
 	}
 
-	var columns []*shim.Column
-
-	for i := 0; i < nKeys; i++ {
-		col := shim.Column{Value: &shim.Column_String_{String_: keys[i]}}
-		columns = append(columns, &col)
-	}
-
-	lastCol := shim.Column{Value: &shim.Column_Bytes{Bytes: []byte(args)}}
-	columns = append(columns, &lastCol)
-
-	row := shim.Row{columns}
-	ok, err := stub.ReplaceRow(tableName, row)
+	ok, err := store.ReplaceRow(tableName, LedgerRow{Keys: keys[:nKeys], Value: args})
 	if err != nil {
-		return fmt.Errorf("ReplaceLedgerEntry: Replace Row into "+tableName+" Table operation failed. %s", err)
+		return WrapError(err, "ReplaceLedgerEntry: Replace Row into "+tableName+" Table operation failed")
 	}
 	if !ok {
-		return errors.New("ReplaceLedgerEntry: Replace Row into " + tableName + " Table failed. Row with given key " + keys[0] + " already exists")
+		return NewError(ErrNotFound, "ReplaceLedgerEntry: Replace Row into "+tableName+" Table failed. No row with given key "+keys[0], "Table", tableName, "Key", keys[0])
 	}
 
 	fmt.Println("ReplaceLedgerEntry: Replace Row in ", tableName, " Table operation Successful. ")
@@ -1290,36 +1564,25 @@ func ReplaceLedgerEntry(stub shim.ChaincodeStubInterface, tableName string, keys
 ////////////////////////////////////////////////////////////////////////////
 // Query a User Object by Table Name and Key
 ////////////////////////////////////////////////////////////////////////////
-func QueryLedger(stub shim.ChaincodeStubInterface, tableName string, args []string) ([]byte, error) {
+func QueryLedger(store TableStore, tableName string, args []string) ([]byte, error) {
 
-	var columns []shim.Column
 	nCol := GetNumberOfKeys(tableName)
-	for i := 0; i < nCol; i++ {
-		colNext := shim.Column{Value: &shim.Column_String_{String_: args[i]}}
-		columns = append(columns, colNext)
+	Avalbytes, found, err := store.GetRow(tableName, args[:nCol])
+	if err != nil {
+		return nil, WrapError(err, "QueryLedger() : GetRow from "+tableName+" operation failed")
 	}
 
-	row, err := stub.GetRow(tableName, columns)
-	fmt.Println("Length or number of rows retrieved ", len(row.Columns))
-
-	if len(row.Columns) == 0 {
-		jsonResp := "{\"Error\":\"Failed retrieving data " + args[0] + ". \"}"
-		fmt.Println("Error retrieving data record for Key = ", args[0], "Error : ", jsonResp)
-		return nil, errors.New(jsonResp)
+	if !found {
+		fmt.Println("Error retrieving data record for Key = ", args[0])
+		return nil, NewError(ErrNotFound, "QueryLedger() : No row in "+tableName+" for "+args[0], "Table", tableName, "Key", args[0])
 	}
 
-	//fmt.Println("User Query Response:", row)
-	//jsonResp := "{\"Owner\":\"" + string(row.Columns[nCol].GetBytes()) + "\"}"
-	//fmt.Println("User Query Response:%s\n", jsonResp)
-	Avalbytes := row.Columns[nCol].GetBytes()
-
 	// Perform Any additional processing of data
 	fmt.Println("QueryLedger() : Successful - Proceeding to ProcessRequestType ")
-	err = ProcessQueryResult(stub, Avalbytes, args)
+	err = ProcessQueryResult(Avalbytes, args)
 	if err != nil {
-		fmt.Println("QueryLedger() : Cannot create object  : ", args[1])
-		jsonResp := "{\"QueryLedger() Error\":\" Cannot create Object for key " + args[0] + "\"}"
-		return nil, errors.New(jsonResp)
+		fmt.Println("QueryLedger() : Cannot create object  : ", args[0])
+		return nil, WrapError(err, "QueryLedger() : Cannot create Object for key "+args[0])
 	}
 	return Avalbytes, nil
 }
@@ -1327,180 +1590,181 @@ func QueryLedger(stub shim.ChaincodeStubInterface, tableName string, args []stri
 /////////////////////////////////////////////////////////////////////////////////////////////////////
 // Get List of Bids for an Auction
 // in the block-chain --
+// Bids are returned in the order they were numbered (BidNo)
 // ./peer chaincode query -l golang -n mycc -c '{"Function": "GetListOfBids", "Args": ["1111"]}'
 // ./peer chaincode query -l golang -n mycc -c '{"Function": "GetLastBid", "Args": ["1111"]}'
 // ./peer chaincode query -l golang -n mycc -c '{"Function": "GetHighestBid", "Args": ["1111"]}'
 /////////////////////////////////////////////////////////////////////////////////////////////////////
-/*func GetListOfBids(stub shim.ChaincodeStubInterface, function string, args []string) ([]byte, error) {
+func GetListOfBids(ctx *TxContext, function string, args []string) ([]byte, error) {
 
-	rows, err := GetList(stub, "BidTable", args)
-	if err != nil {
-		return nil, fmt.Errorf("GetListOfBids operation failed. Error marshaling JSON: %s", err)
+	if len(args) < 1 {
+		fmt.Println("GetListOfBids(): Incorrect number of arguments. Expecting 1 ")
+		return nil, NewError(ErrInvalidArgument, "GetListOfBids(): Incorrect number of arguments. Expecting 1 ")
 	}
 
-	nCol := GetNumberOfKeys("BidTable")
+	spec := ListSpec{TableName: "BidTable", Record: Bid{}, Decode: decodeBid, DefaultSort: []SortField{{Field: "BidNo"}}, PriceField: "BidPrice", NumericFields: []string{"BidNo"}, DateField: "BidTime"}
 
-	tlist := make([]Bid, len(rows))
-	for i := 0; i < len(rows); i++ {
-		ts := rows[i].Columns[nCol].GetBytes()
-		bid, err := JSONtoBid(ts)
-		if err != nil {
-			fmt.Println("GetListOfBids() Failed : Ummarshall error")
-			return nil, fmt.Errorf("GetListOfBids() operation failed. %s", err)
-		}
-		tlist[i] = bid
+	buff, err := QueryList(ctx.Store, spec, args)
+	if err != nil {
+		return nil, WrapError(err, "GetListOfBids() operation failed")
 	}
 
-	jsonRows, _ := json.Marshal(tlist)
-
-	fmt.Println("List of Bids Requested : ", jsonRows)
-	return jsonRows, nil
-
+	return buff, nil
 }
-*/
+
 ////////////////////////////////////////////////////////////////////////////////////////////////////////
 // Get List of Auctions that have been initiated
 // in the block-chain
+// Auctions are returned by close date
 // This is a fixed Query to be issued as below
 // ./peer chaincode query -l golang -n mycc -c '{"Function": "GetListOfInitAucs", "Args": ["2016"]}'
 ////////////////////////////////////////////////////////////////////////////////////////////////////////
-/*func GetListOfInitAucs(stub shim.ChaincodeStubInterface, function string, args []string) ([]byte, error) {
+func GetListOfInitAucs(ctx *TxContext, function string, args []string) ([]byte, error) {
 
-	rows, err := GetList(stub, "AucInitTable", args)
-	if err != nil {
-		return nil, fmt.Errorf("GetListOfInitAucs operation failed. Error marshaling JSON: %s", err)
+	if len(args) < 1 {
+		fmt.Println("GetListOfInitAucs(): Incorrect number of arguments. Expecting 1 ")
+		return nil, NewError(ErrInvalidArgument, "GetListOfInitAucs(): Incorrect number of arguments. Expecting 1 ")
 	}
 
-	nCol := GetNumberOfKeys("AucInitTable")
+	spec := ListSpec{TableName: "AucInitTable", Partitioned: true, Record: AuctionRequest{}, Decode: decodeAucReq, DefaultSort: aucCloseOrder, DateField: "CloseDate"}
 
-	tlist := make([]AuctionRequest, len(rows))
-	for i := 0; i < len(rows); i++ {
-		ts := rows[i].Columns[nCol].GetBytes()
-		ar, err := JSONtoAucReq(ts)
-		if err != nil {
-			fmt.Println("GetListOfInitAucs() Failed : Ummarshall error")
-			return nil, fmt.Errorf("getBillForMonth() operation failed. %s", err)
-		}
-		tlist[i] = ar
+	buff, err := QueryList(ctx.Store, spec, args)
+	if err != nil {
+		return nil, WrapError(err, "GetListOfInitAucs() operation failed")
 	}
 
-	jsonRows, _ := json.Marshal(tlist)
-
-	//fmt.Println("List of Auctions Requested : ", jsonRows)
-	return jsonRows, nil
-
-}*/
+	return buff, nil
+}
 
 ////////////////////////////////////////////////////////////////////////////
 // Get List of Open Auctions  for which bids can be supplied
 // in the block-chain
+// Auctions are returned by close date, the one closing first comes first
 // This is a fixed Query to be issued as below
 // ./peer chaincode query -l golang -n mycc -c '{"Function": "GetListOfOpenAucs", "Args": ["2016"]}'
+// ./peer chaincode query -l golang -n mycc -c '{"Function": "GetListOfOpenAucs", "Args": ["2016..2017"]}'
 ////////////////////////////////////////////////////////////////////////////
-/*func GetListOfOpenAucs(stub shim.ChaincodeStubInterface, function string, args []string) ([]byte, error) {
+func GetListOfOpenAucs(ctx *TxContext, function string, args []string) ([]byte, error) {
 
-	rows, err := GetList(stub, "AucOpenTable", args)
-	if err != nil {
-		return nil, fmt.Errorf("GetListOfOpenAucs operation failed. Error marshaling JSON: %s", err)
+	if len(args) < 1 {
+		fmt.Println("GetListOfOpenAucs(): Incorrect number of arguments. Expecting 1 ")
+		return nil, NewError(ErrInvalidArgument, "GetListOfOpenAucs(): Incorrect number of arguments. Expecting 1 ")
 	}
 
-	nCol := GetNumberOfKeys("AucOpenTable")
+	spec := ListSpec{TableName: "AucOpenTable", Partitioned: true, Record: AuctionRequest{}, Decode: decodeAucReq, DefaultSort: aucCloseOrder, DateField: "CloseDate"}
 
-	tlist := make([]AuctionRequest, len(rows))
-	for i := 0; i < len(rows); i++ {
-		ts := rows[i].Columns[nCol].GetBytes()
-		ar, err := JSONtoAucReq(ts)
-		if err != nil {
-			fmt.Println("GetListOfOpenAucs() Failed : Ummarshall error")
-			return nil, fmt.Errorf("GetListOfOpenAucs() operation failed. %s", err)
-		}
-		tlist[i] = ar
+	buff, err := QueryList(ctx.Store, spec, args)
+	if err != nil {
+		return nil, WrapError(err, "GetListOfOpenAucs() operation failed")
 	}
 
-	jsonRows, _ := json.Marshal(tlist)
-
-	//fmt.Println("List of Open Auctions : ", jsonRows)
-	return jsonRows, nil
-
-}*/
+	return buff, nil
+}
 
 ////////////////////////////////////////////////////////////////////////////
 // Get a List of Users by Category
 // in the block-chain
+// ./peer chaincode query -l golang -n mycc -c '{"Function": "GetUserListByCat", "Args": ["2016", "AH"]}'
+// ./peer chaincode query -l golang -n mycc -c '{"Function": "GetUserListByCat", "Args": ["2016..2017", "AH"]}'
 ////////////////////////////////////////////////////////////////////////////
-func GetUserListByCat(stub shim.ChaincodeStubInterface, function string, args []string) ([]byte, error) {
+func GetUserListByCat(ctx *TxContext, function string, args []string) ([]byte, error) {
 
 	// Check there are 1 Arguments provided as per the the struct - two are computed
 	// See example
 	if len(args) < 1 {
 		fmt.Println("GetUserListByCat(): Incorrect number of arguments. Expecting 1 ")
-		fmt.Println("GetUserListByCat(): ./peer chaincode query -l golang -n mycc -c '{\"Function\": \"GetUserListByCat\", \"Args\": [\"AH\"]}'")
-		return nil, errors.New("CreateUserObject(): Incorrect number of arguments. Expecting 1 ")
+		fmt.Println("GetUserListByCat(): ./peer chaincode query -l golang -n mycc -c '{\"Function\": \"GetUserListByCat\", \"Args\": [\"2016\", \"AH\"]}'")
+		return nil, NewError(ErrInvalidArgument, "GetUserListByCat(): Incorrect number of arguments. Expecting 1 ")
+	}
+
+	// Personal data is redacted according to the caller's role
+	decodeUser := func(data []byte) (interface{}, error) {
+		uo, err := JSONtoUser(data)
+		if err != nil {
+			return nil, err
+		}
+		return RedactUser(ctx, uo)
 	}
 
-	rows, err := GetList(stub, "UserCatTable", args)
+	spec := ListSpec{TableName: "UserCatTable", Partitioned: true, Record: UserObject{}, Decode: decodeUser}
+
+	buff, err := QueryList(ctx.Store, spec, args)
 	if err != nil {
-		return nil, fmt.Errorf("GetUserListByCat() operation failed. Error marshaling JSON: %s", err)
+		return nil, WrapError(err, "GetUserListByCat() operation failed")
 	}
 
-	nCol := GetNumberOfKeys("UserCatTable")
+	return buff, nil
+}
 
-	tlist := make([]UserObject, len(rows))
-	for i := 0; i < len(rows); i++ {
-		ts := rows[i].Columns[nCol].GetBytes()
-		uo, err := JSONtoUser(ts)
-		if err != nil {
-			fmt.Println("GetUserListByCat() Failed : Ummarshall error")
-			return nil, fmt.Errorf("GetUserListByCat() operation failed. %s", err)
-		}
-		tlist[i] = uo
+////////////////////////////////////////////////////////////////////////////
+// Get a List of Items by Category (ItemType)
+// ./peer chaincode query -l golang -n mycc -c '{"Function": "GetItemListByCat", "Args": ["2016", "Original"]}'
+////////////////////////////////////////////////////////////////////////////
+func GetItemListByCat(ctx *TxContext, function string, args []string) ([]byte, error) {
+
+	if len(args) < 1 {
+		fmt.Println("GetItemListByCat(): Incorrect number of arguments. Expecting 1 ")
+		return nil, NewError(ErrInvalidArgument, "GetItemListByCat(): Incorrect number of arguments. Expecting 1 ")
 	}
 
-	jsonRows, _ := json.Marshal(tlist)
+	spec := ListSpec{TableName: "ItemTypeTable", Partitioned: true, Record: ItemObject{}, Decode: decodeItem}
 
-	//fmt.Println("All Users : ", jsonRows)
-	return jsonRows, nil
+	buff, err := QueryList(ctx.Store, spec, args)
+	if err != nil {
+		return nil, WrapError(err, "GetItemListByCat() operation failed")
+	}
 
+	return buff, nil
 }
 
 ////////////////////////////////////////////////////////////////////////////
-// Get a List of Rows based on query criteria from the OBC
-//
+// Get a List of Items by Subject
+// ./peer chaincode query -l golang -n mycc -c '{"Function": "GetItemListBySubject", "Args": ["2016", "Landscape"]}'
 ////////////////////////////////////////////////////////////////////////////
-func GetList(stub shim.ChaincodeStubInterface, tableName string, args []string) ([]shim.Row, error) {
-	var columns []shim.Column
+func GetItemListBySubject(ctx *TxContext, function string, args []string) ([]byte, error) {
 
-	nKeys := GetNumberOfKeys(tableName)
-	nCol := len(args)
-	if nCol < 1 {
-		fmt.Println("Atleast 1 Key must be provided \n")
-		return nil, errors.New("GetList failed. Must include at least key values")
+	if len(args) < 1 {
+		fmt.Println("GetItemListBySubject(): Incorrect number of arguments. Expecting 1 ")
+		return nil, NewError(ErrInvalidArgument, "GetItemListBySubject(): Incorrect number of arguments. Expecting 1 ")
 	}
 
-	for i := 0; i < nCol; i++ {
-		colNext := shim.Column{Value: &shim.Column_String_{String_: args[i]}}
-		columns = append(columns, colNext)
-	}
+	spec := ListSpec{TableName: "ItemCatTable", Partitioned: true, Record: ItemObject{}, Decode: decodeItem}
 
-	rowChannel, err := stub.GetRows(tableName, columns)
+	buff, err := QueryList(ctx.Store, spec, args)
 	if err != nil {
-		return nil, fmt.Errorf("GetList operation failed. %s", err)
+		return nil, WrapError(err, "GetItemListBySubject() operation failed")
 	}
-	var rows []shim.Row
-	for {
-		select {
-		case row, ok := <-rowChannel:
-			if !ok {
-				rowChannel = nil
-			} else {
-				rows = append(rows, row)
-				//If required enable for debugging
-				//fmt.Println(row)
-			}
-		}
-		if rowChannel == nil {
-			break
-		}
+
+	return buff, nil
+}
+
+////////////////////////////////////////////////////////////////////////////
+// Decoders and default orderings used by the list queries - see paging.go
+////////////////////////////////////////////////////////////////////////////
+func decodeBid(data []byte) (interface{}, error)    { return JSONtoBid(data) }
+func decodeAucReq(data []byte) (interface{}, error) { return JSONtoAucReq(data) }
+func decodeItem(data []byte) (interface{}, error)   { return JSONtoAR(data) }
+
+// Close date order, ties broken on AuctionID
+var aucCloseOrder = []SortField{{Field: "CloseDate"}, {Field: "AuctionID"}}
+
+////////////////////////////////////////////////////////////////////////////
+// Get a List of Rows based on query criteria from the OBC
+//
+////////////////////////////////////////////////////////////////////////////
+func GetList(store TableStore, tableName string, args []string) ([]LedgerRow, error) {
+
+	nKeys := GetNumberOfKeys(tableName)
+
+	var rows []LedgerRow
+	err := ScanRows(store, tableName, args, func(row LedgerRow) error {
+		rows = append(rows, row)
+		//If required enable for debugging
+		//fmt.Println(row)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	fmt.Println("Number of Keys retrieved : ", nKeys)
@@ -1509,95 +1773,92 @@ func GetList(stub shim.ChaincodeStubInterface, tableName string, args []string)
 }
 
 ////////////////////////////////////////////////////////////////////////////
-// Get The Highest Bid Received so far for an Auction
-// in the block-chain
+// Hand the rows matching a partial key to fn one at a time
+// Nothing is accumulated, so callers keep only the rows they need
+// If fn fails the scan stops and the error is returned
 ////////////////////////////////////////////////////////////////////////////
-func GetLastBid(stub shim.ChaincodeStubInterface, function string, args []string) ([]byte, error) {
+func ScanRows(store TableStore, tableName string, args []string, fn func(row LedgerRow) error) error {
 
-	tn := "BidTable"
-	rows, err := GetList(stub, tn, args)
+	if len(args) < 1 {
+		fmt.Println("Atleast 1 Key must be provided")
+		return errors.New("GetList failed. Must include at least key values")
+	}
+
+	err := store.ScanRows(tableName, args, fn)
 	if err != nil {
-		return nil, fmt.Errorf("GetLastBid operation failed. %s", err)
+		return WrapError(err, "GetList operation failed")
 	}
-	nCol := GetNumberOfKeys(tn)
-	var Avalbytes []byte
-	var dat map[string]interface{}
-	layout := "2006-01-02 15:04:05"
-	highestTime, err := time.Parse(layout, layout)
+	return nil
+}
 
-	for i := 0; i < len(rows); i++ {
-		currentBid := rows[i].Columns[nCol].GetBytes()
-		if err := json.Unmarshal(currentBid, &dat); err != nil {
-			fmt.Println("GetHighestBid() Failed : Ummarshall error")
-			return nil, fmt.Errorf("GetHighestBid(0 operation failed. %s", err)
-		}
-		bidTime, err := time.Parse(layout, dat["BidTime"].(string))
-		if err != nil {
-			fmt.Println("GetLastBid() Failed : time Conversion error on BidTime")
-			return nil, fmt.Errorf("GetHighestBid() Int Conversion error on BidPrice! failed. %s", err)
-		}
+////////////////////////////////////////////////////////////////////////////
+// Get the Last Bid Received so far for an Auction
+// in the block-chain. Read through the auction's BidSummary (bid_summary.go)
+////////////////////////////////////////////////////////////////////////////
+func GetLastBid(ctx *TxContext, function string, args []string) ([]byte, error) {
 
-		if bidTime.Sub(highestTime) > 0 {
-			highestTime = bidTime
-			Avalbytes = currentBid
-		}
+	if len(args) < 1 {
+		return nil, NewError(ErrInvalidArgument, "GetLastBid(): Incorrect number of arguments. Expecting 1 ")
 	}
 
-	return Avalbytes, nil
-
+	summary, found, err := ctx.Bids.Summary(args[0])
+	if err != nil {
+		return nil, WrapError(err, "GetLastBid() operation failed")
+	}
+	if !found {
+		return nil, nil
+	}
+	return getSummaryBid(ctx, "GetLastBid", summary, summary.LastBidNo)
 }
 
 ////////////////////////////////////////////////////////////////////////////
-// Get The Highest Bid Received so far for an Auction
+// Get the Number of Bids Received so far for an Auction
 // in the block-chain
 ////////////////////////////////////////////////////////////////////////////
-func GetNoOfBidsReceived(stub shim.ChaincodeStubInterface, function string, args []string) ([]byte, error) {
+func GetNoOfBidsReceived(ctx *TxContext, function string, args []string) ([]byte, error) {
+
+	if len(args) < 1 {
+		return nil, NewError(ErrInvalidArgument, "GetNoOfBidsReceived(): Incorrect number of arguments. Expecting 1 ")
+	}
 
-	tn := "BidTable"
-	rows, err := GetList(stub, tn, args)
+	summary, _, err := ctx.Bids.Summary(args[0])
 	if err != nil {
-		return nil, fmt.Errorf("GetLastBid operation failed. %s", err)
+		return nil, WrapError(err, "GetNoOfBidsReceived() operation failed")
 	}
-	nBids := len(rows)
-	return []byte(strconv.Itoa(nBids)), nil
+	return []byte(strconv.Itoa(summary.BidCount)), nil
 }
 
 ////////////////////////////////////////////////////////////////////////////
 // Get the Highest Bid in the List
-//
+// Read through the auction's BidSummary (bid_summary.go)
 ////////////////////////////////////////////////////////////////////////////
-func GetHighestBid(stub shim.ChaincodeStubInterface, function string, args []string) ([]byte, error) {
+func GetHighestBid(ctx *TxContext, function string, args []string) ([]byte, error) {
 
-	tn := "BidTable"
-	rows, err := GetList(stub, tn, args)
+	if len(args) < 1 {
+		return nil, NewError(ErrInvalidArgument, "GetHighestBid(): Incorrect number of arguments. Expecting 1 ")
+	}
+
+	summary, found, err := ctx.Bids.Summary(args[0])
 	if err != nil {
-		return nil, fmt.Errorf("GetLastBid operation failed. %s", err)
+		return nil, WrapError(err, "GetHighestBid() operation failed")
 	}
-	nCol := GetNumberOfKeys(tn)
-	var Avalbytes []byte
-	var dat map[string]interface{}
-	var bidPrice, highestBid int
-	highestBid = 0
-
-	for i := 0; i < len(rows); i++ {
-		currentBid := rows[i].Columns[nCol].GetBytes()
-		if err := json.Unmarshal(currentBid, &dat); err != nil {
-			fmt.Println("GetHighestBid() Failed : Ummarshall error")
-			return nil, fmt.Errorf("GetHighestBid(0 operation failed. %s", err)
-		}
-		bidPrice, err = strconv.Atoi(dat["BidPrice"].(string))
-		if err != nil {
-			fmt.Println("GetHighestBid() Failed : Int Conversion error on BidPrice")
-			return nil, fmt.Errorf("GetHighestBid() Int Conversion error on BidPrice! failed. %s", err)
-		}
+	if !found {
+		return nil, nil
+	}
+	return getSummaryBid(ctx, "GetHighestBid", summary, summary.HighBidNo)
+}
 
-		if bidPrice >= highestBid {
-			highestBid = bidPrice
-			Avalbytes = currentBid
+// A bid the summary points to. It must be there
+func getSummaryBid(ctx *TxContext, function string, summary BidSummary, bidNo string) ([]byte, error) {
+
+	bid, err := ctx.Bids.Get(summary.AuctionID, bidNo)
+	if err != nil {
+		if ErrorCodeOf(err) == ErrNotFound {
+			return nil, NewError(ErrCorruptRecord, function+"() : Bid summary points to a missing bid : "+bidNo, "RecType", "BIDSUM", "Field", "BidNo")
 		}
+		return nil, WrapError(err, function+"() operation failed")
 	}
-
-	return Avalbytes, nil
+	return BidtoJSON(bid)
 }
 
 /////////////////////////////////////////////////////////////////
@@ -1644,11 +1905,11 @@ func ChkReqType(args []string) bool {
 func CheckRequestType(rt string) bool {
 	for _, val := range recType {
 		if val == rt {
-			fmt.Println("CheckRequestType() : Valid Request Type , val : ", val, rt, "\n")
+			fmt.Println("CheckRequestType() : Valid Request Type , val : ", val, rt)
 			return true
 		}
 	}
-	fmt.Println("CheckRequestType() : Invalid Request Type , val : ", rt, "\n")
+	fmt.Println("CheckRequestType() : Invalid Request Type , val : ", rt)
 	return false
 }
 
@@ -1657,68 +1918,20 @@ func CheckRequestType(rt string) bool {
 // var recType = []string{"ARTINV", "USER", "BID", "AUCREQ", "POSTTRAN", "OPENAUC", "CLAUC"}
 /////////////////////////////////////////////////////////////////////////////////////////////
 
-func ProcessQueryResult(stub shim.ChaincodeStubInterface, Avalbytes []byte, args []string) error {
+func ProcessQueryResult(Avalbytes []byte, args []string) error {
 
-	// Identify Record Type by scanning the args for one of the recTypes
-	// This is kind of a post-processor once the query fetches the results
+	// Identify Record Type by its RecType and decode it with the registered
+	// struct and validator - see records.go
 	// RecType is the style of programming in the punch card days ..
 	// ... well
 
-	var dat map[string]interface{}
-
-	if err := json.Unmarshal(Avalbytes, &dat); err != nil {
-		panic(err)
-	}
-
-	var recType string
-	recType = dat["RecType"].(string)
-	switch recType {
-
-	case "ARTINV":
-
-		ar, err := JSONtoAR(Avalbytes) //
-		if err != nil {
-			fmt.Println("ProcessRequestType(): Cannot create itemObject \n", ar)
-			return err
-		}
-		return err
-
-	case "USER":
-		ur, err := JSONtoUser(Avalbytes) //
-		if err != nil {
-			return err
-		}
-		fmt.Println("ProcessRequestType() : ", ur)
-		return err
-
-	case "AUCREQ":
-	case "OPENAUC":
-	case "CLAUC":
-		ar, err := JSONtoAucReq(Avalbytes) //
-		if err != nil {
-			return err
-		}
-		fmt.Println("ProcessRequestType() : ", ar)
-		return err
-	case "BID":
-		bid, err := JSONtoBid(Avalbytes) //
-		if err != nil {
-			return err
-		}
-		fmt.Println("ProcessRequestType() : ", bid)
+	recType, _, err := DecodeRecord(Avalbytes)
+	if err != nil {
+		fmt.Println("ProcessQueryResult() : Cannot decode record : ", err)
 		return err
-	case "DEFAULT":
-		return nil
-	case "XFER":
-		return nil
-	case "VERIFY":
-		return nil
-	default:
-
-		return errors.New("Unknown")
 	}
+	fmt.Println("ProcessQueryResult() : Record of type ", recType)
 	return nil
-
 }
 
 ///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -1727,24 +1940,23 @@ func ProcessQueryResult(stub shim.ChaincodeStubInterface, Avalbytes []byte, args
 // ./peer chaincode invoke -l golang -n mycc -c '{"Function": "OpenAuctionForBids", "Args":["1111", "OPENAUC", "3"]}'
 ///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 
-func OpenAuctionForBids(stub shim.ChaincodeStubInterface, function string, args []string) ([]byte, error) {
+func OpenAuctionForBids(ctx *TxContext, function string, args []string) ([]byte, error) {
 
-	// Fetch Auction Object and check its Status
-	Avalbytes, err := QueryLedger(stub, "AuctionTable", args)
-	if err != nil {
-		fmt.Println("OpenAuctionForBids(): Auction Object Retrieval Failed ")
-		return nil, errors.New("OpenAuctionForBids(): Auction Object Retrieval Failed ")
+	if len(args) != 3 {
+		fmt.Println("OpenAuctionForBids(): Incorrect number of arguments. Expecting 3 ")
+		return nil, NewError(ErrInvalidArgument, "OpenAuctionForBids(): Incorrect number of arguments. Expecting 3 ")
 	}
 
-	aucR, err := JSONtoAucReq(Avalbytes)
+	// Fetch Auction Object and check its Status
+	aucR, err := ctx.Auctions.Get(args[0])
 	if err != nil {
-		fmt.Println("OpenAuctionForBids(): Auction Object Unmarshalling Failed ")
-		return nil, errors.New("OpenAuctionForBids(): Auction Object UnMarshalling Failed ")
+		fmt.Println("OpenAuctionForBids(): Auction Object Retrieval Failed ")
+		return nil, NewError(ErrNotFound, "OpenAuctionForBids(): Auction Object Retrieval Failed ")
 	}
 
 	if aucR.Status == "CLOSED" {
 		fmt.Println("OpenAuctionForBids(): Auction is Closed - Cannot Open for new bids ")
-		return nil, errors.New("OpenAuctionForBids(): is Closed - Cannot Open for new bids Failed ")
+		return nil, NewError(ErrConflict, "OpenAuctionForBids(): is Closed - Cannot Open for new bids Failed ")
 	}
 
 	// Calculate Time Now and Duration of Auction
@@ -1753,10 +1965,14 @@ func OpenAuctionForBids(stub shim.ChaincodeStubInterface, function string, args
 	aucDuration, err := strconv.Atoi(args[2])
 	if err != nil {
 		fmt.Println("OpenAuctionForBids(): Auction Duration is an integer that represents minute! OpenAuctionForBids() Failed ")
-		return nil, errors.New("OpenAuctionForBids(): Auction Duration is an integer that represents minute! OpenAuctionForBids() Failed ")
+		return nil, NewError(ErrInvalidArgument, "OpenAuctionForBids(): Auction Duration is an integer that represents minute! OpenAuctionForBids() Failed ")
 	}
 
-	aucStartDate := time.Now()
+	// Use the transaction time so that every peer computes the same dates
+	aucStartDate, err := GetTxTime(ctx)
+	if err != nil {
+		return nil, err
+	}
 	aucEndDate := aucStartDate.Add(time.Duration(aucDuration) * time.Minute)
 	//sleepTime := time.Duration(aucDuration * 60 * 1000 * 1000 * 1000)
 
@@ -1765,28 +1981,15 @@ func OpenAuctionForBids(stub shim.ChaincodeStubInterface, function string, args
 	aucR.CloseDate = aucEndDate.Format("2006-01-02 15:04:05")
 	aucR.Status = "OPEN"
 
-	buff, err := UpdateAuctionStatus(stub, "AuctionTable", aucR)
-	if err != nil {
-		fmt.Println("OpenAuctionForBids(): UpdateAuctionStatus() Failed ")
-		return nil, errors.New("OpenAuctionForBids(): UpdateAuctionStatus() Failed ")
-	}
-
 	// Remove the Auction from INIT Bucket and move to OPEN bucket
 	// This was designed primarily to help the UI
-
-	keys := []string{"2016", aucR.AuctionID}
-	err = DeleteFromLedger(stub, "AucInitTable", keys)
+	buff, err := ctx.Auctions.Open(aucR)
 	if err != nil {
-		fmt.Println("OpenAuctionForBids(): DeleteFromLedger() Failed ")
-		return nil, errors.New("OpenAuctionForBids(): DeleteFromLedger() Failed ")
+		fmt.Println("OpenAuctionForBids(): Auctions.Open() Failed ")
+		return nil, WrapError(err, "OpenAuctionForBids(): Auctions.Open() Failed")
 	}
 
-	// Add the Auction to Open Bucket
-	err = UpdateLedger(stub, "AucOpenTable", keys, buff)
-	if err != nil {
-		fmt.Println("OpenAuctionForBids() : write error while inserting record into AucInitTable \n")
-		return buff, err
-	}
+	AddEvent(ctx, AuctionEvent{Type: EventAuctionOpened, AuctionID: aucR.AuctionID, ItemID: aucR.ItemID, UserID: aucR.AuctionHouseID, CloseDate: aucR.CloseDate})
 
 	// Initiate Timer for the duration of the Auction
 	// Bids are accepted as long as the timer is alive
@@ -1801,6 +2004,77 @@ func OpenAuctionForBids(stub shim.ChaincodeStubInterface, function string, args
 	return buff, err
 }
 
+//////////////////////////////////////////////////////////////////////////////////
+// Check whether the caller is the Auction House running an auction
+//////////////////////////////////////////////////////////////////////////////////
+func CanManageAuction(ctx *TxContext, aucR AuctionRequest) bool {
+	if GetCallerAttribute(ctx, "role") != "AH" {
+		return false
+	}
+	caller := GetCallerAttribute(ctx, "userid")
+	return caller != "" && caller == aucR.AuctionHouseID
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Extend an OPEN Auction
+// Pushes the close date out by the given number of minutes. The auction must not have closed yet
+// Only the Auction House running the auction may extend it
+// Structure of args auctionReqID, RecType, Extension in Minutes
+// ./peer chaincode invoke -l golang -n mycc -c '{"Function": "ExtendAuction", "Args":["1111", "EXTAUC", "5"]}'
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func ExtendAuction(ctx *TxContext, function string, args []string) ([]byte, error) {
+
+	if len(args) != 3 {
+		fmt.Println("ExtendAuction(): Incorrect number of arguments. Expecting 3 ")
+		return nil, NewError(ErrInvalidArgument, "ExtendAuction(): Incorrect number of arguments. Expecting 3 ")
+	}
+
+	extension, err := strconv.Atoi(args[2])
+	if err != nil || extension < 1 {
+		fmt.Println("ExtendAuction(): Extension is a positive integer that represents minutes ")
+		return nil, NewError(ErrInvalidArgument, "ExtendAuction(): Extension is a positive integer that represents minutes ")
+	}
+
+	aucR, err := ctx.Auctions.Get(args[0])
+	if err != nil {
+		fmt.Println("ExtendAuction(): Auction Object Retrieval Failed ")
+		return nil, NewError(ErrNotFound, "ExtendAuction(): Auction Object Retrieval Failed ")
+	}
+
+	if !CanManageAuction(ctx, aucR) {
+		return nil, NewError(ErrUnauthorized, "ExtendAuction(): Only the Auction House running auction "+aucR.AuctionID+" may extend it")
+	}
+
+	if aucR.Status != "OPEN" {
+		return nil, NewError(ErrAuctionNotOpen, "ExtendAuction(): Auction is not OPEN : "+aucR.AuctionID)
+	}
+
+	txTime, err := GetTxTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if tCompare(txTime.Format("2006-01-02 15:04:05"), aucR.CloseDate) == false {
+		return nil, NewError(ErrAuctionNotOpen, "ExtendAuction(): Auction has already passed its Close Time "+aucR.CloseDate)
+	}
+
+	closeDate, err := time.Parse("2006-01-02 15:04:05", aucR.CloseDate)
+	if err != nil {
+		return nil, NewError(ErrCorruptRecord, "ExtendAuction(): Invalid Close Date on auction : "+aucR.CloseDate, "RecType", "AUCREQ", "Field", "CloseDate")
+	}
+	aucR.CloseDate = closeDate.Add(time.Duration(extension) * time.Minute).Format("2006-01-02 15:04:05")
+
+	// The open bucket holds a copy of the auction
+	buff, err := ctx.Auctions.Update(aucR)
+	if err != nil {
+		fmt.Println("ExtendAuction(): Auctions.Update() Failed ")
+		return nil, err
+	}
+
+	AddEvent(ctx, AuctionEvent{Type: EventAuctionExtended, AuctionID: aucR.AuctionID, ItemID: aucR.ItemID, UserID: aucR.AuctionHouseID, CloseDate: aucR.CloseDate})
+	return buff, nil
+}
+
 //////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 // Create a Command to execute Close Auction From the Command line
 // cloaseauction.sh is created and then executed as seen below
@@ -1825,12 +2099,12 @@ func ShellCmdToCloseAuction(aucID string) error {
 	x := "sh /opt/gopath/src/github.com/hyperledger/fabric/peer/closeauction.sh"
 	err := exe_cmd(x)
 	if err != nil {
-		fmt.Println("%s", err)
+		fmt.Println(err)
 	}
 
 	err = exe_cmd("rm /opt/gopath/src/github.com/hyperledger/fabric/peer/closeauction.sh")
 	if err != nil {
-		fmt.Println("%s", err)
+		fmt.Println(err)
 	}
 
 	fmt.Println("Kicking off CloseAuction", argStr)
@@ -1846,7 +2120,7 @@ func exe_cmd(cmd string) error {
 
 	_, err := exec.Command(head, parts...).CombinedOutput()
 	if err != nil {
-		fmt.Println("%s", err)
+		fmt.Println(err)
 	}
 	return err
 }
@@ -1856,44 +2130,56 @@ func exe_cmd(cmd string) error {
 // 1. Read OpenAucTable
 // 2. Compare now with expiry time with now
 // 3. If now is > expiry time call CloseAuction
+// Open auctions are looked up in the partitions given (see partition.go),
+// by default in every partition
+// ./peer chaincode invoke -l golang -n mycc -c '{"Function": "CloseOpenAuctions", "Args": ["CLAUC"]}'
+// ./peer chaincode invoke -l golang -n mycc -c '{"Function": "CloseOpenAuctions", "Args": ["CLAUC", "2016..2017"]}'
 //////////////////////////////////////////////////////////////////////////
 
-/*func CloseOpenAuctions(stub shim.ChaincodeStubInterface, function string, args []string) ([]byte, error) {
+func CloseOpenAuctions(ctx *TxContext, function string, args []string) ([]byte, error) {
 
-	rows, err := GetListOfOpenAucs(stub, "AucOpenTable", []string{"2016"})
+	txTime, err := GetTxTime(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("GetListOfOpenAucs operation failed. Error marshaling JSON: %s", err)
+		return nil, err
 	}
+	now := txTime.Format("2006-01-02 15:04:05")
 
-	tlist := make([]AuctionRequest, len(rows))
-	err = json.Unmarshal([]byte(rows), &tlist)
-	if err != nil {
-		fmt.Println("Unmarshal failed : ", err)
+	partitions := []string{""}
+	if len(args) > 1 {
+		partitions, err = ExpandPartitions(ctx.Store, args[1])
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	for i := 0; i < len(tlist); i++ {
-		ar := tlist[i]
+	// Collect first, closing an auction removes it from AucOpenTable
+	var expired []AuctionRequest
+	for _, p := range partitions {
+		err = ctx.Auctions.ScanOpen(p, func(ar AuctionRequest) error {
+			// Compare Auction Times
+			if tCompare(now, ar.CloseDate) == false {
+				expired = append(expired, ar)
+			}
+			return nil
+		})
 		if err != nil {
-			fmt.Println("CloseOpenAuctions() Failed : Ummarshall error")
-			return nil, fmt.Errorf("GetListOfOpenAucs() operation failed. %s", err)
+			return nil, WrapError(err, "CloseOpenAuctions() operation failed")
 		}
+	}
 
-		fmt.Println("CloseOpenAuctions() ", ar)
-
-		// Compare Auction Times
-		if tCompare(time.Now().Format("2006-01-02 15:04:05"), ar.CloseDate) == false {
+	for _, ar := range expired {
+		fmt.Println("CloseOpenAuctions() ", ar.AuctionID)
 
-			// Request Closing Auction
-			_, err := CloseAuction(stub, "CloseAuction", []string{ar.AuctionID})
-			if err != nil {
-				fmt.Println("CloseOpenAuctions() Failed : Ummarshall error")
-				return nil, fmt.Errorf("GetListOfOpenAucs() operation failed. %s", err)
-			}
+		// Request Closing Auction
+		_, err := CloseAuction(ctx, "CloseAuction", []string{ar.AuctionID, "CLAUC"})
+		if err != nil {
+			fmt.Println("CloseOpenAuctions() Failed : CloseAuction error ", ar.AuctionID)
+			return nil, WrapError(err, "CloseOpenAuctions() operation failed")
 		}
 	}
 
-	return rows, nil
-}*/
+	return json.Marshal(expired)
+}
 
 //////////////////////////////////////////////////////////////////////////
 // Close the Auction
@@ -1909,74 +2195,87 @@ func exe_cmd(cmd string) error {
 //
 // To invoke from Command Line via CLI or REST API
 // ./peer chaincode invoke -l golang -n mycc -c '{"Function": "CloseAuction", "Args": ["1111", "AUCREQ"]}'
-// ./peer chaincode invoke -l golang -n mycc -c '{"Function": "CloseAuction", "Args": ["1111", "AUCREQ"]}'
 //
 //////////////////////////////////////////////////////////////////////////
 
-/*func CloseAuction(stub shim.ChaincodeStubInterface, function string, args []string) ([]byte, error) {
+func CloseAuction(ctx *TxContext, function string, args []string) ([]byte, error) {
+
+	if len(args) != 2 {
+		fmt.Println("CloseAuction(): Incorrect number of arguments. Expecting 2 ")
+		return nil, NewError(ErrInvalidArgument, "CloseAuction(): Incorrect number of arguments. Expecting 2 ")
+	}
 
 	// Close The Auction -  Fetch Auction Object
-	Avalbytes, err := QueryLedger(stub, "AuctionTable", []string{args[0], "AUCREQ"})
+	aucR, err := ctx.Auctions.Get(args[0])
 	if err != nil {
 		fmt.Println("CloseAuction(): Auction Object Retrieval Failed ")
-		return nil, errors.New("CloseAuction(): Auction Object Retrieval Failed ")
+		return nil, NewError(ErrNotFound, "CloseAuction(): Auction Object Retrieval Failed ")
 	}
 
-	aucR, err := JSONtoAucReq(Avalbytes)
+	_, err = CloseAuctionRecord(ctx, aucR)
 	if err != nil {
-		fmt.Println("CloseAuction(): Auction Object Unmarshalling Failed ")
-		return nil, errors.New("CloseAuction(): Auction Object UnMarshalling Failed ")
+		return nil, err
 	}
 
-	//  Update Auction Status
-	aucR.Status = "CLOSED"
-	fmt.Println("CloseAuction(): UpdateAuctionStatus() successful ", aucR)
-
-	Avalbytes, err = UpdateAuctionStatus(stub, "AuctionTable", aucR)
-	if err != nil {
-		fmt.Println("CloseAuction(): UpdateAuctionStatus() Failed ")
-		return nil, errors.New("CloseAuction(): UpdateAuctionStatus() Failed ")
-	}
+	fmt.Println("CloseAuction(): Proceeding to process the highest bid ")
 
-	// Remove the Auction from Open Bucket
-	keys := []string{"2016", aucR.AuctionID}
-	err = DeleteFromLedger(stub, "AucOpenTable", keys)
+	// Process Final Bid - Turn it into a Transaction
+	Avalbytes, err := GetHighestBid(ctx, "GetHighestBid", []string{args[0]})
 	if err != nil {
-		fmt.Println("CloseAuction(): DeleteFromLedger(AucOpenTable) Failed ")
-		return nil, errors.New("CloseAuction(): DeleteFromLedger(AucOpenTable) Failed ")
+		fmt.Println("CloseAuction(): No bids available, error encountered - PostTransaction() failed ")
+		return nil, err
 	}
 
-	fmt.Println("CloseAuction(): Proceeding to process the highest bid ")
-
-	// Process Final Bid - Turn it into a Transaction
-	Avalbytes, err = GetHighestBid(stub, "GetHighestBid", []string{args[0]})
 	if Avalbytes == nil {
 		fmt.Println("CloseAuction(): No bids available, no change in Item Status - PostTransaction() Completed Successfully ")
+		AddEvent(ctx, AuctionEvent{Type: EventNoSale, AuctionID: aucR.AuctionID, ItemID: aucR.ItemID, UserID: aucR.AuctionHouseID})
 		return Avalbytes, nil
 	}
 
+	bid, err := JSONtoBid(Avalbytes)
 	if err != nil {
-		fmt.Println("CloseAuction(): No bids available, error encountered - PostTransaction() failed ")
-		return nil, err
+		return nil, NewError(ErrCorruptRecord, "CloseAuction(): JSONtoBid Error : "+err.Error(), "RecType", "BID")
 	}
-
-	bid, _ := JSONtoBid(Avalbytes)
 	fmt.Println("CloseAuction(): Proceeding to process the highest bid ", bid)
-	tran := BidtoTransaction(bid)
-	fmt.Println("CloseAuction(): Converting Bid to tran ", tran)
-
-	// Process the last bid once Time Expires
-	tranArgs := []string{tran.AuctionID, tran.RecType, tran.ItemID, tran.TransType, tran.UserId, tran.TransDate, tran.HammerTime, tran.HammerPrice, tran.Details}
-	fmt.Println("CloseAuction(): Proceeding to process the  Transaction ", tranArgs)
 
-	Avalbytes, err = PostTransaction(stub, "PostTransaction", tranArgs)
+	Avalbytes, err = SettleSale(ctx, bid, "")
 	if err != nil {
 		fmt.Println("CloseAuction(): PostTransaction() Failed ")
-		return nil, errors.New("CloseAuction(): PostTransaction() Failed ")
+		return nil, WrapError(err, "CloseAuction(): PostTransaction() Failed")
 	}
 	fmt.Println("CloseAuction(): PostTransaction() Completed Successfully ")
 	return Avalbytes, nil
-}*/
+}
+
+//////////////////////////////////////////////////////////////////////////
+// Mark an OPEN auction CLOSED and remove it from AucOpenTable
+//////////////////////////////////////////////////////////////////////////
+func CloseAuctionRecord(ctx *TxContext, aucR AuctionRequest) ([]byte, error) {
+
+	if aucR.Status == "CLOSED" {
+		fmt.Println("CloseAuctionRecord(): Auction is already CLOSED ", aucR.AuctionID)
+		return nil, NewError(ErrConflict, "CloseAuctionRecord(): Auction is already CLOSED : "+aucR.AuctionID, "AuctionID", aucR.AuctionID, "Status", aucR.Status)
+	}
+	if aucR.Status != "OPEN" {
+		fmt.Println("CloseAuctionRecord(): Auction is not OPEN ", aucR.AuctionID)
+		return nil, NewError(ErrAuctionNotOpen, "CloseAuctionRecord(): Auction is not OPEN : "+aucR.AuctionID)
+	}
+
+	//  Update Auction Status
+	aucR.Status = "CLOSED"
+
+	// Update Auction Status and remove the Auction from Open Bucket
+	buff, err := ctx.Auctions.Close(aucR)
+	if err != nil {
+		fmt.Println("CloseAuctionRecord(): Auctions.Close() Failed ")
+		return nil, WrapError(err, "CloseAuctionRecord(): Auctions.Close() Failed")
+	}
+	fmt.Println("CloseAuctionRecord(): Auctions.Close() successful ", aucR.AuctionID)
+
+	AddEvent(ctx, AuctionEvent{Type: EventAuctionClosed, AuctionID: aucR.AuctionID, ItemID: aucR.ItemID, UserID: aucR.AuctionHouseID, CloseDate: aucR.CloseDate})
+
+	return buff, nil
+}
 
 ////////////////////////////////////////////////////////////////////////////////////////////
 // Buy It Now
@@ -1984,12 +2283,31 @@ func exe_cmd(cmd string) error {
 // If Buy IT Now Option is available then a Buyer has the option to buy the ITEM
 // before the bids exceed BuyITNow Price . Normally, The application should take of this
 // at the UI level and this chain-code assumes application has validated that
+// Structure of args AuctionID, RecType, BidNo, ItemID, BuyerID, BuyItNow Price
+// ./peer chaincode invoke -l golang -n mycc -c '{"Function": "BuyItNow", "Args":["1111", "BID", "1", "1000", "300", "1800"]}'
 ////////////////////////////////////////////////////////////////////////////////////////////
 
-func BuyItNow(stub shim.ChaincodeStubInterface, function string, args []string) ([]byte, error) {
+func BuyItNow(ctx *TxContext, function string, args []string) ([]byte, error) {
+
+	if len(args) != 6 {
+		fmt.Println("BuyItNow(): Incorrect number of arguments. Expecting 6 ")
+		return nil, NewError(ErrInvalidArgument, "BuyItNow(): Incorrect number of arguments. Expecting 6 ")
+	}
+
+	// Convert the BuyITNow to a Bid type struct
+	buyItNowBid, err := CreateBidObject(args[0:])
+	if err != nil {
+		return nil, err
+	}
+
+	// Check if BuyItNow Price > Highest Bid so far
+	binP, err := strconv.Atoi(args[5])
+	if err != nil {
+		return nil, NewError(ErrInvalidArgument, "BuyItNow() : Invalid BuyItNow Price")
+	}
 
 	// Process Final Bid - Turn it into a Transaction
-	Avalbytes, err := GetHighestBid(stub, "GetHighestBid", []string{args[0]})
+	Avalbytes, err := GetHighestBid(ctx, "GetHighestBid", []string{args[0]})
 	hBidFlag := true
 	if Avalbytes == nil {
 		fmt.Println("BuyItNow(): No bids available, no change in Item Status - PostTransaction() Completed Successfully ")
@@ -2005,108 +2323,63 @@ func BuyItNow(stub shim.ChaincodeStubInterface, function string, args []string)
 	if hBidFlag == true {
 		bid, err := JSONtoBid(Avalbytes)
 		if err != nil {
-			return nil, errors.New("BuyItNow() : JSONtoBid Error")
-		}
-
-		// Check if BuyItNow Price > Highest Bid so far
-		binP, err := strconv.Atoi(args[5])
-		if err != nil {
-			return nil, errors.New("BuyItNow() : Invalid BuyItNow Price")
+			return nil, NewError(ErrCorruptRecord, "BuyItNow() : JSONtoBid Error : "+err.Error(), "RecType", "BID")
 		}
 
 		hbP, err := strconv.Atoi(bid.BidPrice)
 		if err != nil {
-			return nil, errors.New("BuyItNow() : Invalid Highest Bid Price")
+			return nil, NewError(ErrCorruptRecord, "BuyItNow() : Invalid Highest Bid Price", "RecType", "BID", "Field", "BidPrice")
 		}
 
 		if hbP > binP {
-			return nil, errors.New("BuyItNow() : Highest Bid Price > BuyItNow Price - BuyItNow Rejected")
+			return nil, NewError(ErrBidTooLow, "BuyItNow() : Highest Bid Price > BuyItNow Price - BuyItNow Rejected")
 		}
 	}
 
-	// Close The Auction -  Fetch Auction Object
-	Avalbytes, err = QueryLedger(stub, "AuctionTable", []string{args[0], "AUCREQ"})
+	// Reject the offer if the Buyer Information Is not Valid or not registered on the Block Chain
+	buyerInfo, err := ValidateMember(ctx, args[4])
+	fmt.Println("Buyer information  ", buyerInfo.UserID, args[4])
 	if err != nil {
-		fmt.Println("BuyItNow(): Auction Object Retrieval Failed ")
-		return nil, errors.New("BuyItNow(): Auction Object Retrieval Failed ")
+		fmt.Println("BuyItNow() : Failed Buyer not registered on the block-chain ", args[4])
+		return nil, err
 	}
 
-	aucR, err := JSONtoAucReq(Avalbytes)
+	// Close The Auction -  Fetch Auction Object
+	aucR, err := ctx.Auctions.Get(args[0])
 	if err != nil {
-		fmt.Println("BuyItNow(): Auction Object Unmarshalling Failed ")
-		return nil, errors.New("BuyItNow(): Auction Object UnMarshalling Failed ")
+		fmt.Println("BuyItNow(): Auction Object Retrieval Failed ")
+		return nil, NewError(ErrNotFound, "BuyItNow(): Auction Object Retrieval Failed ")
 	}
 
-	//  Update Auction Status
-	aucR.Status = "CLOSED"
-	fmt.Println("BuyItNow(): UpdateAuctionStatus() successful ", aucR)
-
-	Avalbytes, err = UpdateAuctionStatus(stub, "AuctionTable", aucR)
-	if err != nil {
-		fmt.Println("BuyItNow(): UpdateAuctionStatus() Failed ")
-		return nil, errors.New("BuyItNow(): UpdateAuctionStatus() Failed ")
+	if aucR.ItemID != args[3] {
+		fmt.Println("BuyItNow() Failed : Item ID mismatch on offer. Offer Rejected")
+		return nil, NewError(ErrInvalidArgument, "BuyItNow() : Item ID mismatch on offer. Offer Rejected")
 	}
 
-	// Remove the Auction from Open Bucket
-	keys := []string{"2016", aucR.AuctionID}
-	err = DeleteFromLedger(stub, "AucOpenTable", keys)
-	if err != nil {
-		fmt.Println("BuyItNow(): DeleteFromLedger(AucOpenTable) Failed ")
-		return nil, errors.New("BuyItNow(): DeleteFromLedger(AucOpenTable) Failed ")
+	// A Buy It Now offer is a bid: a closed auction does not take it
+	if aucR.Status != "OPEN" {
+		return nil, NewError(ErrAuctionNotOpen, "BuyItNow(): Auction is not OPEN : "+aucR.AuctionID, "AuctionID", aucR.AuctionID, "Status", aucR.Status)
 	}
 
-	fmt.Println("BuyItNow(): Proceeding to process the highest bid ")
-
-	// Convert the BuyITNow to a Bid type struct
-	/*buyItNowBid, err := CreateBidObject(args[0:])
+	_, err = CloseAuctionRecord(ctx, aucR)
 	if err != nil {
 		return nil, err
-	}*/
+	}
 
-	// Reject the offer if the Buyer Information Is not Valid or not registered on the Block Chain
-	buyerInfo, err := ValidateMember(stub, args[4])
-	fmt.Println("Buyer information  ", buyerInfo, args[4])
+	fmt.Println("BuyItNow(): Proceeding to process the buy-it-now offer ")
+
+	txTime, err := GetTxTime(ctx)
 	if err != nil {
-		fmt.Println("BuyItNow() : Failed Buyer not registered on the block-chain ", args[4])
 		return nil, err
 	}
-
-	// tran := BidtoTransaction(buyItNowBid)
-	// fmt.Println("BuyItNow(): Converting Bid to tran ", tran)
+	buyItNowBid.BidTime = txTime.Format("2006-01-02 15:04:05")
 
 	// Process the buy-it-now offer
-	// tranArgs := []string{tran.AuctionID, tran.RecType, tran.ItemID, tran.TransType, tran.UserId, tran.TransDate, tran.HammerTime, tran.HammerPrice, tran.Details}
-	// fmt.Println("BuyItNow(): Proceeding to process the  Transaction ", tranArgs)
-
-	// Avalbytes, err = PostTransaction(stub, "PostTransaction", tranArgs)
+	Avalbytes, err = SettleSale(ctx, buyItNowBid, "Buy It Now")
 	if err != nil {
 		fmt.Println("BuyItNow(): PostTransaction() Failed ")
-		return nil, errors.New("CloseAuction(): PostTransaction() Failed ")
+		return nil, WrapError(err, "BuyItNow(): PostTransaction() Failed")
 	}
 	fmt.Println("BuyItNow(): PostTransaction() Completed Successfully ")
 	return Avalbytes, nil
 }
-
-//////////////////////////////////////////////////////////////////////////
-// Update the Auction Object
-// This function updates the status of the auction
-// from INIT to OPEN to CLOSED
-//////////////////////////////////////////////////////////////////////////
-
-func UpdateAuctionStatus(stub shim.ChaincodeStubInterface, tableName string, ar AuctionRequest) ([]byte, error) {
-
-	buff, err := AucReqtoJSON(ar)
-	if err != nil {
-		fmt.Println("UpdateAuctionStatus() : Failed Cannot create object buffer for write : ", ar.AuctionID)
-		return nil, errors.New("UpdateAuctionStatus(): Failed Cannot create object buffer for write : " + ar.AuctionID)
-	}
-
-	// Update the ledger with the Buffer Data
-	keys := []string{ar.AuctionID, ar.ItemID}
-	err = ReplaceLedgerEntry(stub, "AuctionTable", keys, buff)
-	if err != nil {
-		fmt.Println("UpdateAuctionStatus() : write error while inserting record\n")
-		return buff, err
-	}
-	return buff, err
-}
@@ -0,0 +1,180 @@
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+//////////////////////////////////////////////////////////////////////////////////
+// Bid summaries
+// One BidSummary per auction that has bids, in BidSummaryTable keyed by
+// AuctionID. Bids.Add updates it in the same transaction as the bid, so that
+// GetHighestBid, GetLastBid and GetNoOfBidsReceived read one row instead of
+// scanning the auction's bids in BidTable.
+//
+// Auctions bid on before the summaries existed have none. Bids.Summary then
+// builds one from BidTable, and keeps it when the transaction is an invoke, so
+// that bids and closes are checked against the real high bid from the first
+// call after the upgrade. RebuildBidSummaries builds them all at once, and
+// repairs a summary that no longer matches the bids
+//////////////////////////////////////////////////////////////////////////////////
+
+type BidSummary struct {
+	AuctionID   string
+	RecType     string // BIDSUM
+	BidCount    int
+	HighBidNo   string
+	HighBidder  string // BuyerID of the high bid
+	HighPrice   string
+	LastBidNo   string
+	LastBidTime string
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Count bid in the summary. It becomes the high bid unless its price is lower,
+// and the last bid unless it was received earlier
+//////////////////////////////////////////////////////////////////////////////////
+func (s *BidSummary) Add(bid Bid) {
+
+	s.AuctionID = bid.AuctionID
+	s.RecType = "BIDSUM"
+	s.BidCount++
+
+	// Bid prices are validated as numbers (see records.go)
+	price, _ := strconv.Atoi(bid.BidPrice)
+	high, _ := strconv.Atoi(s.HighPrice)
+	if s.HighBidNo == "" || price >= high {
+		s.HighBidNo, s.HighBidder, s.HighPrice = bid.BidNo, bid.BuyerID, bid.BidPrice
+	}
+
+	// BidTime is "2006-01-02 15:04:05", which sorts as it reads
+	if s.LastBidNo == "" || bid.BidTime >= s.LastBidTime {
+		s.LastBidNo, s.LastBidTime = bid.BidNo, bid.BidTime
+	}
+}
+
+func BidSummarytoJSON(summary BidSummary) ([]byte, error) {
+
+	ajson, err := json.Marshal(summary)
+	if err != nil {
+		fmt.Println("BidSummarytoJSON error: ", err)
+		return nil, err
+	}
+	return ajson, nil
+}
+
+func JSONtoBidSummary(data []byte) (BidSummary, error) {
+
+	summary := BidSummary{}
+	err := json.Unmarshal([]byte(data), &summary)
+	if err != nil {
+		fmt.Println("JSONtoBidSummary error: ", err)
+		return summary, err
+	}
+	return summary, err
+}
+
+func decodeBidSummary(data []byte) (interface{}, error) { return JSONtoBidSummary(data) }
+
+func validateBidSummary(record interface{}) error {
+	summary := record.(BidSummary)
+	return requireRecordFields("AuctionID", summary.AuctionID, "HighBidNo", summary.HighBidNo, "LastBidNo", summary.LastBidNo)
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Decode a row that must be a BidSummary
+//////////////////////////////////////////////////////////////////////////////////
+func DecodeBidSummaryRecord(data []byte) (BidSummary, error) {
+
+	recType, record, err := DecodeRecord(data)
+	if err != nil {
+		return BidSummary{}, err
+	}
+	summary, ok := record.(BidSummary)
+	if !ok {
+		return BidSummary{}, NewError(ErrCorruptRecord, "DecodeBidSummaryRecord() : Expected a bid summary, found RecType "+recType, "RecType", recType)
+	}
+	return summary, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Result of RebuildBidSummaries
+//////////////////////////////////////////////////////////////////////////////////
+type BidSummaryResult struct {
+	Auctions int // Summaries written
+	Bids     int // Bids counted in them
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Recompute the bid summaries of the given auctions from BidTable, or of every
+// auction that has bids when none is given. Only an Auction House may do this
+// Works through the whole of BidTable when no auction is given
+// ./peer chaincode invoke -l golang -n mycc -c '{"Function": "RebuildBidSummaries", "Args": ["BIDSUM"]}'
+// ./peer chaincode invoke -l golang -n mycc -c '{"Function": "RebuildBidSummaries", "Args": ["BIDSUM", "1111", "1112"]}'
+//////////////////////////////////////////////////////////////////////////////////
+func RebuildBidSummaries(ctx *TxContext, function string, args []string) ([]byte, error) {
+
+	if len(args) < 1 {
+		fmt.Println("RebuildBidSummaries(): Incorrect number of arguments. Expecting at least 1 ")
+		return nil, NewError(ErrInvalidArgument, "RebuildBidSummaries(): Incorrect number of arguments. Expecting at least 1 ")
+	}
+
+	if GetCallerAttribute(ctx, "role") != "AH" {
+		return nil, NewError(ErrUnauthorized, "RebuildBidSummaries(): Only an Auction House may rebuild bid summaries")
+	}
+
+	auctionIDs := args[1:]
+	if len(auctionIDs) == 0 {
+		// BidTable is in AuctionID order, the bids of an auction come together
+		err := ctx.Store.ScanRows("BidTable", []string{}, func(row LedgerRow) error {
+			if n := len(auctionIDs); n == 0 || auctionIDs[n-1] != row.Keys[0] {
+				auctionIDs = append(auctionIDs, row.Keys[0])
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, WrapError(err, "RebuildBidSummaries() operation failed")
+		}
+	}
+
+	var result BidSummaryResult
+	for _, auctionID := range auctionIDs {
+		summary, found, err := ctx.Bids.RebuildSummary(auctionID)
+		if err != nil {
+			fmt.Println("RebuildBidSummaries() : Failed on auction ", auctionID)
+			return nil, err
+		}
+		if found {
+			result.Auctions++
+			result.Bids += summary.BidCount
+		}
+	}
+
+	fmt.Println("RebuildBidSummaries() : ", result)
+	buff, err := json.Marshal(result)
+	if err != nil {
+		return nil, errors.New("RebuildBidSummaries() : Cannot marshal the result")
+	}
+	return buff, nil
+}
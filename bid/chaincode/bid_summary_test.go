@@ -0,0 +1,171 @@
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package main
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+//////////////////////////////////////////////////////////////////////////////////
+// Bid summaries kept by Bids.Add, and rebuilt from BidTable
+//////////////////////////////////////////////////////////////////////////////////
+
+// The summary of an auction, computed from its bids
+func (l *testLedger) scannedSummary(auctionID string) BidSummary {
+
+	l.t.Helper()
+	var summary BidSummary
+	err := l.context().Bids.Scan(auctionID, func(bid Bid) error {
+		summary.Add(bid)
+		return nil
+	})
+	if err != nil {
+		l.t.Fatal(err)
+	}
+	return summary
+}
+
+func TestBidSummaryFollowsBids(t *testing.T) {
+
+	l := newSeededLedger(t)
+	l.openAuction("1111", 60)
+
+	// Bids 1 to 12, some within the same second, so that BidNo key order
+	// ("10" < "2") differs from the order they came in
+	buyers := []string{"200", "300", "400"}
+	for n := 1; n <= 12; n++ {
+		if n%3 != 0 {
+			l.advance(time.Minute)
+		}
+		l.mustBid("1111", strconv.Itoa(n), buyers[n%3], strconv.Itoa(100*n))
+
+		summary, found, err := l.context().Bids.Summary("1111")
+		if err != nil || !found {
+			t.Fatalf("bid %d : no summary : %v", n, err)
+		}
+		if expected := l.scannedSummary("1111"); summary != expected {
+			t.Fatalf("bid %d : summary %+v, from the bids %+v", n, summary, expected)
+		}
+		if summary.BidCount != n || summary.HighBidNo != strconv.Itoa(n) || summary.LastBidNo != strconv.Itoa(n) {
+			t.Fatalf("bid %d : summary %+v", n, summary)
+		}
+	}
+
+	_, err := l.bid("1111", "13", "200", "1200")
+	assertErrorCode(t, err, ErrBidTooLow)
+	if summary, _, _ := l.context().Bids.Summary("1111"); summary.BidCount != 12 {
+		t.Fatalf("rejected bid counted : %+v", summary)
+	}
+
+	var bid Bid
+	decodeJSON(t, l.mustQuery("GetLastBid", "1111"), &bid)
+	if bid.BidNo != "12" || bid.BuyerID != "200" {
+		t.Fatalf("last bid %+v", bid)
+	}
+	if n := string(l.mustQuery("GetNoOfBidsReceived", "1111")); n != "12" {
+		t.Fatalf("count %s", n)
+	}
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// A ledger from before the summaries: Init on upgrade adds BidSummaryTable and
+// keeps the bids, RebuildBidSummaries fills it in
+//////////////////////////////////////////////////////////////////////////////////
+func TestUpgradeRebuildsBidSummaries(t *testing.T) {
+
+	l := newSeededLedger(t)
+	l.addItem("1001")
+	l.mustInvoke("PostAuctionRequest", "2222", "AUCREQ", "1001", "100", "2017-03-05", "INIT", "", "")
+	l.openAuction("1111", 10)
+	l.openAuction("2222", 10)
+	l.mustBid("1111", "1", "200", "300")
+	l.mustBid("1111", "2", "300", "400")
+	l.mustInvoke("PostBid", "2222", "BID", "1", "1001", "400", "900")
+	if err := l.store.DeleteTable("BidSummaryTable"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := l.cc.InstantiateContext(l.context(), "init", nil); err != nil {
+		t.Fatalf("upgrade failed : %v", err)
+	}
+	sizes := l.store.TableSizes()
+	if sizes["BidTable"] != 3 || sizes["BidSummaryTable"] != 0 {
+		t.Fatalf("tables after upgrade %v", sizes)
+	}
+	// Queries build the summary from the bids, and do not keep it
+	var high Bid
+	decodeJSON(t, l.mustQuery("GetHighestBid", "1111"), &high)
+	if high.BidNo != "2" || l.store.TableSizes()["BidSummaryTable"] != 0 {
+		t.Fatalf("highest bid before the rebuild %+v, tables %v", high, l.store.TableSizes())
+	}
+
+	var result BidSummaryResult
+	decodeJSON(t, l.mustInvoke("RebuildBidSummaries", "BIDSUM"), &result)
+	if result != (BidSummaryResult{Auctions: 2, Bids: 3}) {
+		t.Fatalf("result %+v", result)
+	}
+	for _, auctionID := range []string{"1111", "2222"} {
+		summary, _, _ := l.context().Bids.Summary(auctionID)
+		if expected := l.scannedSummary(auctionID); summary != expected {
+			t.Fatalf("auction %s : summary %+v, from the bids %+v", auctionID, summary, expected)
+		}
+	}
+
+	// Bidding carries on from the rebuilt summary
+	_, err := l.bid("1111", "3", "200", "350")
+	assertErrorCode(t, err, ErrBidTooLow)
+	l.mustBid("1111", "3", "200", "450")
+	if events := l.events(); len(events) != 2 || events[1].Type != EventOutbid || events[1].UserID != "300" {
+		t.Fatalf("events %+v", events)
+	}
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Bids with no summary, as left by an upgrade that was not followed by
+// RebuildBidSummaries: the first invoke builds it from BidTable and keeps it
+//////////////////////////////////////////////////////////////////////////////////
+func TestBidsWithoutSummary(t *testing.T) {
+
+	l := newSeededLedger(t)
+	l.openAuction("1111", 10)
+	l.mustBid("1111", "1", "200", "300")
+	l.mustBid("1111", "2", "300", "900")
+	if err := l.store.DeleteRow("BidSummaryTable", []string{"1111"}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := l.bid("1111", "3", "400", "500")
+	assertErrorCode(t, err, ErrBidTooLow)
+	// A failed invoke keeps nothing, the summary it built included
+	if sizes := l.store.TableSizes(); sizes["BidTable"] != 2 || sizes["BidSummaryTable"] != 0 {
+		t.Fatalf("tables after the rejected bid %v", sizes)
+	}
+
+	l.mustBid("1111", "3", "400", "1000")
+	if sizes := l.store.TableSizes(); sizes["BidTable"] != 3 || sizes["BidSummaryTable"] != 1 {
+		t.Fatalf("tables after the bid %v", sizes)
+	}
+	summary, found, err := l.context().Bids.Summary("1111")
+	if err != nil || !found || summary != l.scannedSummary("1111") || summary.BidCount != 3 || summary.HighBidNo != "3" {
+		t.Fatalf("summary %+v, from the bids %+v : %v", summary, l.scannedSummary("1111"), err)
+	}
+}
@@ -0,0 +1,360 @@
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package main
+
+import (
+	"math/rand"
+	"reflect"
+	"strconv"
+	"testing"
+	"testing/quick"
+	"time"
+)
+
+//////////////////////////////////////////////////////////////////////////////////
+// Property tests of argument parsing, record codecs and tCompare, on values
+// generated by testing/quick. fuzz_test.go runs the same functions on inputs
+// found by go test -fuzz
+//////////////////////////////////////////////////////////////////////////////////
+
+// The record types with their JSON converters, as registered in records.go
+var recordCodecs = []struct {
+	record interface{}
+	encode func(record interface{}) ([]byte, error)
+	decode func(data []byte) (interface{}, error)
+}{
+	{UserObject{}, func(r interface{}) ([]byte, error) { return UsertoJSON(r.(UserObject)) }, decodeUserRecord},
+	{ItemObject{}, func(r interface{}) ([]byte, error) { return ARtoJSON(r.(ItemObject)) }, decodeItem},
+	{AuctionRequest{}, func(r interface{}) ([]byte, error) { return AucReqtoJSON(r.(AuctionRequest)) }, decodeAucReq},
+	{Bid{}, func(r interface{}) ([]byte, error) { return BidtoJSON(r.(Bid)) }, decodeBid},
+	{BidSummary{}, func(r interface{}) ([]byte, error) { return BidSummarytoJSON(r.(BidSummary)) }, decodeBidSummary},
+	{ItemTransaction{}, func(r interface{}) ([]byte, error) { return TranstoJSON(r.(ItemTransaction)) }, decodeTrans},
+	{ItemLog{}, func(r interface{}) ([]byte, error) { return ItemLogtoJSON(r.(ItemLog)) }, decodeItemLog},
+	{ItemArtefact{}, func(r interface{}) ([]byte, error) { return ArtefacttoJSON(r.(ItemArtefact)) }, decodeArtefact},
+}
+
+func TestRecordCodecsRoundTrip(t *testing.T) {
+
+	rnd := rand.New(rand.NewSource(1))
+	for _, c := range recordCodecs {
+		typ := reflect.TypeOf(c.record)
+		for i := 0; i < 200; i++ {
+			v, ok := quick.Value(typ, rnd)
+			if !ok {
+				t.Fatalf("cannot generate a %s", typ.Name())
+			}
+			record := v.Interface()
+			data, err := c.encode(record)
+			if err != nil {
+				t.Fatalf("%s : cannot encode %+v : %v", typ.Name(), record, err)
+			}
+			decoded, err := c.decode(data)
+			if err != nil {
+				t.Fatalf("%s : cannot decode %s : %v", typ.Name(), data, err)
+			}
+			if !reflect.DeepEqual(decoded, record) {
+				t.Fatalf("%s : %+v came back as %+v", typ.Name(), record, decoded)
+			}
+		}
+	}
+}
+
+func TestRecordCodecsRejectMalformedJSON(t *testing.T) {
+
+	for _, data := range []string{"", "{", "[]", `"BID"`, "12", "\xff"} {
+		for _, c := range recordCodecs {
+			if _, err := c.decode([]byte(data)); err == nil {
+				t.Errorf("%s decoded %q", reflect.TypeOf(c.record).Name(), data)
+			}
+		}
+		if _, _, err := DecodeRecord([]byte(data)); ErrorCodeOf(err) != ErrCorruptRecord {
+			t.Errorf("DecodeRecord(%q) : %v", data, err)
+		}
+	}
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// The Create functions keep their arguments, in order, except RecType, which
+// they set themselves
+//////////////////////////////////////////////////////////////////////////////////
+func TestCreateObjectsKeepArguments(t *testing.T) {
+
+	user := func(id uint32, f [9]string) bool {
+		args := append([]string{strconv.FormatUint(uint64(id), 10)}, f[:]...)
+		u, err := CreateUserObject(args)
+		return err == nil && reflect.DeepEqual([]string{u.UserID, u.Name, u.UserType, u.Address, u.Phone, u.Email, u.Bank, u.AccountNo, u.RoutingNo}, append(args[:1:1], args[2:]...)) &&
+			u.RecType == "USER" && u.Status == "ACTIVE"
+	}
+	item := func(f [6]string) bool {
+		i, err := CreateItemObject(f[:])
+		return err == nil && reflect.DeepEqual([]string{i.ItemID, i.ItemDesc, i.ItemDetail, i.ItemType, i.ItemSubject}, append(f[:1:1], f[2:]...)) &&
+			i.RecType == "ARTINV" && len(i.ItemDocs) == 0
+	}
+	auction := func(f [8]string) bool {
+		a, err := CreateAuctionRequest(f[:])
+		return err == nil && reflect.DeepEqual([]string{a.AuctionID, a.ItemID, a.AuctionHouseID, a.RequestDate, a.Status, a.OpenDate, a.CloseDate}, append(f[:1:1], f[2:]...)) &&
+			a.RecType == "AUCREQ"
+	}
+	bid := func(auctionID uint32, bidNo uint32, f [4]string) bool {
+		args := []string{strconv.FormatUint(uint64(auctionID), 10), f[0], strconv.FormatUint(uint64(bidNo), 10), f[1], f[2], f[3]}
+		b, err := CreateBidObject(args)
+		return err == nil && reflect.DeepEqual([]string{b.AuctionID, b.BidNo, b.ItemID, b.BuyerID, b.BidPrice}, append(args[:1:1], args[2:]...)) &&
+			b.RecType == "BID" && b.BidTime == ""
+	}
+
+	for name, property := range map[string]interface{}{"CreateUserObject": user, "CreateItemObject": item, "CreateAuctionRequest": auction, "CreateBidObject": bid} {
+		if err := quick.Check(property, nil); err != nil {
+			t.Errorf("%s : %v", name, err)
+		}
+	}
+}
+
+func TestCreateObjectsRejectArgumentCounts(t *testing.T) {
+
+	create := map[string]func(args []string) error{
+		"CreateUserObject":     func(args []string) error { _, err := CreateUserObject(args); return err },
+		"CreateItemObject":     func(args []string) error { _, err := CreateItemObject(args); return err },
+		"CreateAuctionRequest": func(args []string) error { _, err := CreateAuctionRequest(args); return err },
+		"CreateBidObject":      func(args []string) error { _, err := CreateBidObject(args); return err },
+	}
+	accepted := map[string]func(n int) bool{
+		"CreateUserObject":     func(n int) bool { return n == 10 },
+		"CreateItemObject":     func(n int) bool { return n >= 6 && (n-6)%3 == 0 },
+		"CreateAuctionRequest": func(n int) bool { return n == 8 },
+		"CreateBidObject":      func(n int) bool { return n == 6 },
+	}
+
+	for name, fn := range create {
+		for n := 0; n <= 16; n++ {
+			args := make([]string, n)
+			for i := range args {
+				args[i] = "1"
+			}
+			err := fn(args)
+			if accepted[name](n) {
+				// Item documents of "1" have no valid hash
+				if err != nil && !(name == "CreateItemObject" && n > 6) {
+					t.Errorf("%s with %d arguments : %v", name, n, err)
+				}
+				continue
+			}
+			if err == nil || ErrorCodeOf(err) != ErrInvalidArgument {
+				t.Errorf("%s with %d arguments : expected %s, got %v", name, n, ErrInvalidArgument, err)
+			}
+		}
+	}
+
+	// IDs that must be integers
+	for _, args := range [][]string{
+		{"X100", "USER", "", "", "", "", "", "", "", ""},
+		{"1 00", "USER", "", "", "", "", "", "", "", ""},
+	} {
+		if _, err := CreateUserObject(args); ErrorCodeOf(err) != ErrInvalidArgument {
+			t.Errorf("CreateUserObject%v : %v", args, err)
+		}
+	}
+	for _, args := range [][]string{
+		{"1111", "BID", "one", "1000", "300", "1200"},
+		{"", "BID", "1", "1000", "300", "1200"},
+	} {
+		if _, err := CreateBidObject(args); ErrorCodeOf(err) != ErrInvalidArgument {
+			t.Errorf("CreateBidObject%v : %v", args, err)
+		}
+	}
+}
+
+// A named-argument object is the whole argument, nothing may follow it
+func TestParseNamedArgsRejectTrailingData(t *testing.T) {
+
+	const doc = `{"ItemID":"1000","DocHash":"ab","MediaType":"image/png"}`
+	if _, err := ParseNamedArgs("PostItemDocument", []string{doc + " \n"}); err != nil {
+		t.Fatalf("%s : %v", doc, err)
+	}
+	for _, arg := range []string{
+		doc + "}",
+		doc + "]",
+		doc + " }}",
+		doc + doc,
+		doc + " 1",
+		`{"ItemID":"1"}}`,
+	} {
+		if _, err := ParseNamedArgs("PostItemDocument", []string{arg}); ErrorCodeOf(err) != ErrInvalidArgument {
+			t.Errorf("%s : expected %s, got %v", arg, ErrInvalidArgument, err)
+		}
+	}
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// tCompare(t1, t2) is "t1 is strictly before t2", false for anything that is
+// not a "2006-01-02 15:04:05" time
+//////////////////////////////////////////////////////////////////////////////////
+
+// A time of year 1 to 9999 to the second, the range the layout can express
+func quickTime(s int64) string {
+
+	const first, last = -62135596800, 253402300799 // 0001-01-01 00:00:00, 9999-12-31 23:59:59
+	if s < 0 {
+		s = -(s + 1)
+	}
+	return time.Unix(first+s%(last-first+1), 0).UTC().Format("2006-01-02 15:04:05")
+}
+
+func TestTCompareProperties(t *testing.T) {
+
+	properties := map[string]interface{}{
+		// Agrees with time.Before, and so with the order of the strings
+		"ordering": func(a, b int64) bool {
+			t1, t2 := quickTime(a), quickTime(b)
+			return tCompare(t1, t2) == (t1 < t2)
+		},
+		"irreflexive": func(a int64) bool {
+			return !tCompare(quickTime(a), quickTime(a))
+		},
+		"asymmetric": func(a, b int64) bool {
+			t1, t2 := quickTime(a), quickTime(b)
+			return !(tCompare(t1, t2) && tCompare(t2, t1))
+		},
+		// A bid before a close time is before any later close time, and an
+		// earlier bid is before it too
+		"monotone": func(a, b, c int64) bool {
+			bid, close1, close2 := quickTime(a), quickTime(b), quickTime(c)
+			if tCompare(close2, close1) {
+				close1, close2 = close2, close1
+			}
+			earlier := quickTime(a / 2)
+			if tCompare(bid, earlier) {
+				bid, earlier = earlier, bid
+			}
+			return (!tCompare(bid, close1) || tCompare(bid, close2)) && (!tCompare(bid, close1) || tCompare(earlier, close1))
+		},
+		// Whatever the other argument, a malformed time is never before or after it
+		"malformed": func(a int64, s string) bool {
+			if _, err := time.Parse("2006-01-02 15:04:05", s); err == nil {
+				return true
+			}
+			return !tCompare(s, quickTime(a)) && !tCompare(quickTime(a), s)
+		},
+	}
+
+	for name, property := range properties {
+		if err := quick.Check(property, &quick.Config{MaxCount: 500}); err != nil {
+			t.Errorf("%s : %v", name, err)
+		}
+	}
+
+	for _, tc := range []struct {
+		t1, t2 string
+		before bool
+	}{
+		{"2016-06-27 18:45:39", "2016-06-28 18:40:57", true},
+		{"2016-06-28 18:40:57", "2016-06-27 18:45:39", false},
+		{"2017-03-05 10:02:59", "2017-03-05 10:03:00", true},
+		{"2017-03-05 10:03:00", "2017-03-05 10:03:00", false},
+		{"2017-03-05", "2017-03-06 00:00:00", false},
+		{"2017-03-05 24:00:00", "2017-03-06 00:00:01", false},
+		{"", "2017-03-05 10:03:00", false},
+		{"2017-03-05 10:03:00", "", false},
+	} {
+		if got := tCompare(tc.t1, tc.t2); got != tc.before {
+			t.Errorf("tCompare(%q, %q) = %v", tc.t1, tc.t2, got)
+		}
+	}
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// compareValues orders the values of a list column (see paging.go) whatever
+// they hold, numbers or not
+//////////////////////////////////////////////////////////////////////////////////
+
+var listColumnValues = []string{"", "9", "10", "10.5", "-1", "1e3", "abc", "2017-03-05 10:00:00", "N/A"}
+
+func TestCompareValuesIsAnOrder(t *testing.T) {
+
+	pick := func(i uint8) string { return listColumnValues[int(i)%len(listColumnValues)] }
+	sign := func(c int) int {
+		switch {
+		case c < 0:
+			return -1
+		case c > 0:
+			return 1
+		}
+		return 0
+	}
+
+	for _, numeric := range []bool{false, true} {
+		numeric := numeric
+		properties := map[string]interface{}{
+			"antisymmetric": func(a, b uint8) bool {
+				return sign(compareValues(pick(a), pick(b), numeric)) == -sign(compareValues(pick(b), pick(a), numeric))
+			},
+			"transitive": func(a, b, c uint8) bool {
+				x, y, z := pick(a), pick(b), pick(c)
+				return !(compareValues(x, y, numeric) <= 0 && compareValues(y, z, numeric) <= 0) || compareValues(x, z, numeric) <= 0
+			},
+		}
+		for name, property := range properties {
+			if err := quick.Check(property, &quick.Config{MaxCount: 500}); err != nil {
+				t.Errorf("%s (numeric %v) : %v", name, numeric, err)
+			}
+		}
+	}
+
+	for _, tc := range []struct {
+		a, b    string
+		numeric bool
+		want    int
+	}{
+		{"9", "10", true, -1},
+		{"9", "10", false, 1},
+		{"10", "10.0", true, 0},
+		{"abc", "9", true, -1},
+		{"abc", "9", false, 1},
+		{"2017-03-05 10:00:00", "2017-03-05 9:00:00", false, -1},
+	} {
+		if got := sign(compareValues(tc.a, tc.b, tc.numeric)); got != tc.want {
+			t.Errorf("compareValues(%q, %q, %v) = %d", tc.a, tc.b, tc.numeric, got)
+		}
+	}
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Transient data in the caller metadata of Fabric 0.6
+//////////////////////////////////////////////////////////////////////////////////
+func TestTransientFromMetadata(t *testing.T) {
+
+	key := []byte("0123456789abcdef")
+	for _, tc := range []struct {
+		md       string
+		name     string
+		expected []byte
+	}{
+		{`{"metadata": "MDEyMzQ1Njc4OWFiY2RlZg==", "artefactKey": "YWJj"}`, TransientMetadataKey, key},
+		{`{"metadata": "MDEyMzQ1Njc4OWFiY2RlZg==", "artefactKey": "YWJj"}`, "artefactKey", []byte("abc")},
+		{`{"metadata": "MDEyMzQ1Njc4OWFiY2RlZg=="}`, "artefactKey", nil},
+		// The PII key alone, as sent before there was other transient data
+		{string(key), TransientMetadataKey, key},
+		{string(key), "artefactKey", nil},
+		{"", TransientMetadataKey, nil},
+	} {
+		if got := TransientFromMetadata([]byte(tc.md), tc.name); !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("TransientFromMetadata(%q, %s) = %q", tc.md, tc.name, got)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+//////////////////////////////////////////////////////////////////////////////////
+// Transaction context
+// What a handler works with for the length of one transaction: the entity
+// repositories, the TableStore under them, and the transaction itself.
+// The peer entry points build one over the stub (see peer_v06.go, peer_v1.go
+// and peer_v2.go). Tests and tools build one over a MemoryStore and a MemoryTx
+// and call InvokeContext, QueryContext or the handlers directly
+//////////////////////////////////////////////////////////////////////////////////
+type TxContext struct {
+	Store        TableStore
+	Tx           TxInfo
+	Users        UserRepository
+	Items        ItemRepository
+	Auctions     AuctionRepository
+	Bids         BidRepository
+	Transactions TransactionRepository
+	History      HistoryRepository
+
+	events []AuctionEvent // Queued by AddEvent - see events.go
+}
+
+func NewTxContext(store TableStore, tx TxInfo) *TxContext {
+
+	return &TxContext{
+		Store:        store,
+		Tx:           tx,
+		Users:        userTableRepository{store},
+		Items:        itemTableRepository{store},
+		Auctions:     auctionTableRepository{store},
+		Bids:         bidTableRepository{store: store},
+		Transactions: transactionTableRepository{store},
+		History:      historyTableRepository{store},
+	}
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// The transaction being run and who submitted it
+//////////////////////////////////////////////////////////////////////////////////
+
+// Transient data the chaincode reads. Keys and secrets go there rather than
+// in the arguments, which are kept in the block with the transaction
+const TransientMetadataKey = "metadata" // The PII key (see pii.go)
+
+type TxInfo interface {
+	TxID() string
+	// Time stamped by the submitter. Unlike time.Now() the same on every peer
+	TxTime() (time.Time, error)
+	// Attribute of the caller's enrollment certificate, e.g. "role"
+	CallerAttribute(name string) ([]byte, error)
+	// Transient data the client passed with the transaction under name, nil if
+	// there is none. On Fabric 1.x and later GetTransient, on 0.6 the caller
+	// metadata (see TransientFromMetadata)
+	CallerTransient(name string) ([]byte, error)
+	SetEvent(name string, payload []byte) error
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// A transaction for the in-memory backend
+// The event the transaction sets is kept in Events
+//////////////////////////////////////////////////////////////////////////////////
+type MemoryTx struct {
+	ID         string
+	Time       time.Time
+	Attributes map[string]string // Caller certificate attributes, e.g. "role": "AH"
+	Transient  map[string][]byte
+	Events     []MemoryEvent
+}
+
+type MemoryEvent struct {
+	Name    string
+	Payload []byte
+}
+
+func (t *MemoryTx) TxID() string {
+	return t.ID
+}
+
+func (t *MemoryTx) TxTime() (time.Time, error) {
+	return t.Time.UTC(), nil
+}
+
+func (t *MemoryTx) CallerAttribute(name string) ([]byte, error) {
+
+	value, ok := t.Attributes[name]
+	if !ok {
+		return nil, fmt.Errorf("MemoryTx: No attribute %s", name)
+	}
+	return []byte(value), nil
+}
+
+func (t *MemoryTx) CallerTransient(name string) ([]byte, error) {
+	return t.Transient[name], nil
+}
+
+func (t *MemoryTx) SetEvent(name string, payload []byte) error {
+	t.Events = append(t.Events, MemoryEvent{Name: name, Payload: payload})
+	return nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Fabric 0.6 has no transient data, only the caller metadata, which is not
+// kept on the ledger either. It carries the transient data as a JSON object of
+// base64 values, e.g. {"metadata": "<PII key>", "artefactKey": "<key>"}.
+// Metadata that is not such an object is the PII key alone, as clients sent
+// it before there was other transient data
+//////////////////////////////////////////////////////////////////////////////////
+func TransientFromMetadata(md []byte, name string) []byte {
+
+	var values map[string][]byte
+	if err := json.Unmarshal(md, &values); err != nil {
+		if name == TransientMetadataKey && len(md) > 0 {
+			return md
+		}
+		return nil
+	}
+	return values[name]
+}
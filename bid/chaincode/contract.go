@@ -0,0 +1,180 @@
+//go:build fabric2 && contract
+// +build fabric2,contract
+
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-contract-api-go/metadata"
+)
+
+//////////////////////////////////////////////////////////////////////////////////
+// Fabric contract API - build with -tags "fabric2 contract"
+// The functions are split over four contracts instead of the InvokeFunction and
+// QueryFunction maps: UserContract, ItemContract, AuctionContract and
+// SettlementContract (contract_*.go). The contract API dispatches on
+// "Contract:Function", takes typed parameters and generates the contract
+// metadata from the method signatures (org.hyperledger.fabric:GetMetadata)
+// ./peer chaincode invoke -n mycc -C mychannel -c '{"Args": ["AuctionContract:PostBid", "{\"AuctionID\":\"1111\",\"BidNo\":\"1\",\"ItemID\":\"1000\",\"BuyerID\":\"300\",\"BidPrice\":\"1200\"}"]}'
+//
+// The methods call the same handlers as the other builds, so the business rules
+// are not repeated here. Their results are decoded into the record types, and
+// errors are returned in the JSON form of errors.go
+//////////////////////////////////////////////////////////////////////////////////
+
+const ContractVersion = "0.25"
+
+func init() {
+
+	cc, err := NewAuctionChaincode()
+	if err != nil {
+		fmt.Println("Error creating Item Fun Application contracts: ", err)
+		os.Exit(1)
+	}
+	peerChaincode = cc
+}
+
+func NewAuctionChaincode() (*contractapi.ContractChaincode, error) {
+
+	cc, err := contractapi.NewChaincode(NewUserContract(), NewItemContract(), NewAuctionContract(), NewSettlementContract())
+	if err != nil {
+		return nil, err
+	}
+	cc.Info = metadata.InfoMetadata{Title: "Item Fun Application", Version: ContractVersion}
+	return cc, nil
+}
+
+func newContract(name string, description string) contractapi.Contract {
+	return contractapi.Contract{
+		Name:                      name,
+		Info:                      metadata.InfoMetadata{Title: name, Description: description, Version: ContractVersion},
+		TransactionContextHandler: new(AuctionContext),
+		AfterTransaction:          afterTransaction,
+	}
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Transaction context
+// The contract API creates one per transaction and sets the stub on it. The
+// ledger context over that stub is built there, so that the repositories and
+// the events raised by a handler belong to the transaction
+//////////////////////////////////////////////////////////////////////////////////
+type AuctionContextInterface interface {
+	contractapi.TransactionContextInterface
+	Ledger() *TxContext
+}
+
+type AuctionContext struct {
+	contractapi.TransactionContext
+	ledger *TxContext
+}
+
+func (c *AuctionContext) SetStub(stub shim.ChaincodeStubInterface) {
+	c.TransactionContext.SetStub(stub)
+	c.ledger = NewPeerContext(stub)
+}
+
+func (c *AuctionContext) Ledger() *TxContext {
+	return c.ledger
+}
+
+// Publish the events raised by the transaction. Only called when it succeeded
+func afterTransaction(ctx AuctionContextInterface) error {
+	return FlushEvents(ctx.Ledger())
+}
+
+// A handler of bid_app_1.go and the other files, as listed in InvokeFunction and QueryFunction
+type contractHandler func(ctx *TxContext, function string, args []string) ([]byte, error)
+
+func runHandler(ctx AuctionContextInterface, handler contractHandler, function string, args ...string) ([]byte, error) {
+
+	buff, err := handler(ctx.Ledger(), function, args)
+	if err != nil {
+		fmt.Println(function+"() : Failed ", err)
+		return nil, AsChaincodeError(err)
+	}
+	return buff, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Run a handler and decode its JSON result into out
+// Returns false when the handler found nothing to return
+//////////////////////////////////////////////////////////////////////////////////
+func callHandler(ctx AuctionContextInterface, handler contractHandler, function string, out interface{}, args ...string) (bool, error) {
+
+	buff, err := runHandler(ctx, handler, function, args...)
+	if err != nil || buff == nil {
+		return false, err
+	}
+	err = json.Unmarshal(buff, out)
+	if err != nil {
+		return false, NewError(ErrInternal, function+"(): Cannot decode the result : "+err.Error())
+	}
+	return true, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Paging, sorting and filtering of the list functions (see paging.go)
+// A list function always returns one page; NextToken is empty on the last one
+//////////////////////////////////////////////////////////////////////////////////
+type ListQuery struct {
+	PageSize int    `metadata:",optional"` // DefaultPageSize when 0
+	Token    string `metadata:",optional"` // NextToken of the previous page
+	Sort     string `metadata:",optional"` // F1[:desc],F2
+	Status   string `metadata:",optional"`
+	MinPrice string `metadata:",optional"`
+	MaxPrice string `metadata:",optional"`
+	From     string `metadata:",optional"`
+	To       string `metadata:",optional"`
+}
+
+// The partial key followed by the name=value options
+func (q ListQuery) Args(keys ...string) []string {
+
+	pageSize := q.PageSize
+	if pageSize == 0 {
+		pageSize = DefaultPageSize
+	}
+	args := append(keys, "pageSize="+strconv.Itoa(pageSize))
+
+	for _, opt := range []struct{ name, value string }{{"token", q.Token}, {"sort", q.Sort}, {"status", q.Status}, {"minPrice", q.MinPrice}, {"maxPrice", q.MaxPrice}, {"from", q.From}, {"to", q.To}} {
+		if opt.value != "" {
+			args = append(args, opt.name+"="+opt.value)
+		}
+	}
+	return args
+}
+
+// Keys that were given; a trailing empty key is left out so that the whole range is read
+func partialKey(keys ...string) []string {
+
+	for len(keys) > 0 && keys[len(keys)-1] == "" {
+		keys = keys[:len(keys)-1]
+	}
+	return keys
+}
@@ -0,0 +1,216 @@
+//go:build fabric2 && contract
+// +build fabric2,contract
+
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package main
+
+import (
+	"strconv"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+//////////////////////////////////////////////////////////////////////////////////
+// Auctions and their bids, and the set-up of the ledger
+// ./peer chaincode invoke -n mycc -C mychannel --isInit -c '{"Args": ["AuctionContract:Instantiate", "MONTH"]}'
+// ./peer chaincode invoke -n mycc -C mychannel -c '{"Args": ["AuctionContract:OpenAuctionForBids", "{\"AuctionID\":\"1111\",\"Duration\":3}"]}'
+// ./peer chaincode query -n mycc -C mychannel -c '{"Args": ["AuctionContract:GetListOfBids", "1111", "{\"Sort\":\"BidPrice:desc\"}"]}'
+//////////////////////////////////////////////////////////////////////////////////
+type AuctionContract struct {
+	contractapi.Contract
+}
+
+type AuctionPage struct {
+	Items     []AuctionRequest
+	Count     int
+	NextToken string
+}
+
+type BidPage struct {
+	Items     []Bid
+	Count     int
+	NextToken string
+}
+
+func NewAuctionContract() *AuctionContract {
+	return &AuctionContract{Contract: newContract("AuctionContract", "Auctions, bids and the set-up of the ledger")}
+}
+
+func (c *AuctionContract) GetEvaluateTransactions() []string {
+	return []string{"GetVersion", "GetAuctionRequest", "GetListOfInitAucs", "GetListOfOpenAucs", "GetBid", "GetLastBid", "GetHighestBid", "GetNoOfBidsReceived", "GetListOfBids"}
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Create the tables, or keep them if the ledger already has a version (upgrade)
+// bucket is the partition bucket, YEAR when empty (see partition.go)
+//////////////////////////////////////////////////////////////////////////////////
+func (c *AuctionContract) Instantiate(ctx AuctionContextInterface, bucket string) (string, error) {
+
+	buff, err := new(SimpleChaincode).InstantiateContext(ctx.Ledger(), "init", partialKey(bucket))
+	if err != nil {
+		return "", AsChaincodeError(err)
+	}
+	return string(buff), nil
+}
+
+func (c *AuctionContract) GetVersion(ctx AuctionContextInterface) (string, error) {
+
+	buff, err := runHandler(ctx, GetVersion, "GetVersion", "version")
+	if err != nil {
+		return "", err
+	}
+	return string(buff), nil
+}
+
+// Only an Auction House may re-index. partitions defaults to 2016 when empty
+func (c *AuctionContract) ReindexPartitions(ctx AuctionContextInterface, partitions []string) ([]ReindexResult, error) {
+
+	results := []ReindexResult{}
+	_, err := callHandler(ctx, ReindexPartitions, "ReindexPartitions", &results, append([]string{"REINDEX"}, partitions...)...)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// Only an Auction House may rebuild. auctionIDs defaults to every auction with bids when empty
+func (c *AuctionContract) RebuildBidSummaries(ctx AuctionContextInterface, auctionIDs []string) (*BidSummaryResult, error) {
+
+	var result BidSummaryResult
+	_, err := callHandler(ctx, RebuildBidSummaries, "RebuildBidSummaries", &result, append([]string{"BIDSUM"}, auctionIDs...)...)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *AuctionContract) PostAuctionRequest(ctx AuctionContextInterface, request AuctionRequestArgs) (*AuctionRequest, error) {
+
+	err := request.Validate("PostAuctionRequest")
+	if err != nil {
+		return nil, err
+	}
+	return c.callAuction(ctx, PostAuctionRequest, "PostAuctionRequest", request.Positional()...)
+}
+
+func (c *AuctionContract) OpenAuctionForBids(ctx AuctionContextInterface, open OpenAuctionArgs) (*AuctionRequest, error) {
+
+	err := open.Validate("OpenAuctionForBids")
+	if err != nil {
+		return nil, err
+	}
+	return c.callAuction(ctx, OpenAuctionForBids, "OpenAuctionForBids", open.Positional()...)
+}
+
+func (c *AuctionContract) ExtendAuction(ctx AuctionContextInterface, auctionID string, minutes int) (*AuctionRequest, error) {
+	return c.callAuction(ctx, ExtendAuction, "ExtendAuction", auctionID, "EXTAUC", strconv.Itoa(minutes))
+}
+
+func (c *AuctionContract) GetAuctionRequest(ctx AuctionContextInterface, auctionID string) (*AuctionRequest, error) {
+	return c.callAuction(ctx, GetAuctionRequest, "GetAuctionRequest", auctionID)
+}
+
+func (c *AuctionContract) GetListOfInitAucs(ctx AuctionContextInterface, partition string, query ListQuery) (*AuctionPage, error) {
+	return c.listAuctions(ctx, GetListOfInitAucs, "GetListOfInitAucs", query.Args(partition))
+}
+
+func (c *AuctionContract) GetListOfOpenAucs(ctx AuctionContextInterface, partition string, query ListQuery) (*AuctionPage, error) {
+	return c.listAuctions(ctx, GetListOfOpenAucs, "GetListOfOpenAucs", query.Args(partition))
+}
+
+func (c *AuctionContract) PostBid(ctx AuctionContextInterface, bid BidArgs) (*Bid, error) {
+
+	err := bid.Validate("PostBid")
+	if err != nil {
+		return nil, err
+	}
+	return c.callBid(ctx, PostBid, "PostBid", bid.Positional()...)
+}
+
+func (c *AuctionContract) GetBid(ctx AuctionContextInterface, auctionID string, bidNo string) (*Bid, error) {
+	return c.callBid(ctx, GetBid, "GetBid", auctionID, bidNo)
+}
+
+// NOT_FOUND when the auction has no bids
+func (c *AuctionContract) GetLastBid(ctx AuctionContextInterface, auctionID string) (*Bid, error) {
+	return c.callBid(ctx, GetLastBid, "GetLastBid", auctionID)
+}
+
+// NOT_FOUND when the auction has no bids
+func (c *AuctionContract) GetHighestBid(ctx AuctionContextInterface, auctionID string) (*Bid, error) {
+	return c.callBid(ctx, GetHighestBid, "GetHighestBid", auctionID)
+}
+
+func (c *AuctionContract) GetNoOfBidsReceived(ctx AuctionContextInterface, auctionID string) (int, error) {
+
+	var nBids int
+	_, err := callHandler(ctx, GetNoOfBidsReceived, "GetNoOfBidsReceived", &nBids, auctionID)
+	return nBids, err
+}
+
+func (c *AuctionContract) GetListOfBids(ctx AuctionContextInterface, auctionID string, query ListQuery) (*BidPage, error) {
+
+	page := &BidPage{}
+	_, err := callHandler(ctx, GetListOfBids, "GetListOfBids", page, query.Args(auctionID)...)
+	if err != nil {
+		return nil, err
+	}
+	if page.Items == nil {
+		page.Items = []Bid{}
+	}
+	return page, nil
+}
+
+func (c *AuctionContract) callAuction(ctx AuctionContextInterface, handler contractHandler, function string, args ...string) (*AuctionRequest, error) {
+
+	aucR := &AuctionRequest{}
+	_, err := callHandler(ctx, handler, function, aucR, args...)
+	if err != nil {
+		return nil, err
+	}
+	return aucR, nil
+}
+
+func (c *AuctionContract) listAuctions(ctx AuctionContextInterface, handler contractHandler, function string, args []string) (*AuctionPage, error) {
+
+	page := &AuctionPage{}
+	_, err := callHandler(ctx, handler, function, page, args...)
+	if err != nil {
+		return nil, err
+	}
+	if page.Items == nil {
+		page.Items = []AuctionRequest{}
+	}
+	return page, nil
+}
+
+func (c *AuctionContract) callBid(ctx AuctionContextInterface, handler contractHandler, function string, args ...string) (*Bid, error) {
+
+	bid := &Bid{}
+	found, err := callHandler(ctx, handler, function, bid, args...)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, NewError(ErrNotFound, function+"(): No bids for auction "+args[0], "AuctionID", args[0])
+	}
+	return bid, nil
+}
@@ -0,0 +1,173 @@
+//go:build fabric2 && contract
+// +build fabric2,contract
+
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package main
+
+import (
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+//////////////////////////////////////////////////////////////////////////////////
+// Items - the catalog, item documents (item_docs.go) and encrypted artefacts
+// (artefact.go)
+// ./peer chaincode invoke -n mycc -C mychannel -c '{"Args": ["ItemContract:PostItem", "{\"ItemID\":\"1000\",\"ItemDesc\":\"Flowers\",\"ItemType\":\"Original\",\"ItemSubject\":\"Nature\"}"]}'
+// ./peer chaincode query -n mycc -C mychannel -c '{"Args": ["ItemContract:GetItemListByCat", "2016", "Original", "{}"]}'
+//////////////////////////////////////////////////////////////////////////////////
+type ItemContract struct {
+	contractapi.Contract
+}
+
+type ItemPage struct {
+	Items     []ItemObject
+	Count     int
+	NextToken string
+}
+
+func NewItemContract() *ItemContract {
+	return &ItemContract{Contract: newContract("ItemContract", "Items put up for auction")}
+}
+
+func (c *ItemContract) GetEvaluateTransactions() []string {
+	return []string{"GetItem", "VerifyItemDocument", "GetItemArtefact", "OpenItemArtefact", "GetItemListByCat", "GetItemListBySubject"}
+}
+
+func (c *ItemContract) PostItem(ctx AuctionContextInterface, item ItemArgs) (*ItemObject, error) {
+	return c.writeItem(ctx, PostItem, "PostItem", item)
+}
+
+func (c *ItemContract) UpdateItem(ctx AuctionContextInterface, item ItemArgs) (*ItemObject, error) {
+	return c.writeItem(ctx, UpdateItem, "UpdateItem", item)
+}
+
+func (c *ItemContract) PostItemDocument(ctx AuctionContextInterface, doc ItemDocumentArgs) (*ItemObject, error) {
+
+	err := doc.Validate("PostItemDocument")
+	if err != nil {
+		return nil, err
+	}
+	return c.callItem(ctx, PostItemDocument, "PostItemDocument", doc.Positional()...)
+}
+
+// mode is SHA256 or BASE64, see item_docs.go
+func (c *ItemContract) VerifyItemDocument(ctx AuctionContextInterface, itemID string, mode string, value string) (*ItemDocVerification, error) {
+
+	result := &ItemDocVerification{}
+	_, err := callHandler(ctx, VerifyItemDocument, "VerifyItemDocument", result, itemID, mode, value)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// The owner's key and the content travel in the transient data, see artefact.go
+func (c *ItemContract) PostItemArtefact(ctx AuctionContextInterface, itemID string, ownerID string, mediaType string) (*ItemArtefact, error) {
+	return c.callArtefact(ctx, PostItemArtefact, "PostItemArtefact", itemID, "ARTEFACT", ownerID, mediaType)
+}
+
+func (c *ItemContract) GetItemArtefact(ctx AuctionContextInterface, itemID string) (*ItemArtefact, error) {
+	return c.callArtefact(ctx, GetItemArtefact, "GetItemArtefact", itemID)
+}
+
+// Returns the base64 encoded content. The key travels in the transient data
+func (c *ItemContract) OpenItemArtefact(ctx AuctionContextInterface, itemID string) (string, error) {
+
+	buff, err := runHandler(ctx, OpenItemArtefact, "OpenItemArtefact", itemID)
+	if err != nil {
+		return "", err
+	}
+	return string(buff), nil
+}
+
+// The current owner's key and the handover key travel in the transient data
+func (c *ItemContract) TransferItem(ctx AuctionContextInterface, itemID string, currentOwnerID string, newOwnerID string) (*ItemArtefact, error) {
+	return c.callArtefact(ctx, TransferItem, "TransferItem", itemID, "XFER", currentOwnerID, newOwnerID)
+}
+
+// The handover key and the new owner's key travel in the transient data
+func (c *ItemContract) AcceptItemArtefact(ctx AuctionContextInterface, itemID string, newOwnerID string) (*ItemArtefact, error) {
+	return c.callArtefact(ctx, AcceptItemArtefact, "AcceptItemArtefact", itemID, "ARTEFACT", newOwnerID)
+}
+
+func (c *ItemContract) callArtefact(ctx AuctionContextInterface, handler contractHandler, function string, args ...string) (*ItemArtefact, error) {
+
+	art := &ItemArtefact{}
+	_, err := callHandler(ctx, handler, function, art, args...)
+	if err != nil {
+		return nil, err
+	}
+	return art, nil
+}
+
+func (c *ItemContract) GetItem(ctx AuctionContextInterface, itemID string) (*ItemObject, error) {
+	return c.callItem(ctx, GetItem, "GetItem", itemID)
+}
+
+// itemType may be empty for all the items of the partition
+func (c *ItemContract) GetItemListByCat(ctx AuctionContextInterface, partition string, itemType string, query ListQuery) (*ItemPage, error) {
+	return c.listItems(ctx, GetItemListByCat, "GetItemListByCat", query.Args(partialKey(partition, itemType)...))
+}
+
+// subject may be empty for all the items of the partition
+func (c *ItemContract) GetItemListBySubject(ctx AuctionContextInterface, partition string, subject string, query ListQuery) (*ItemPage, error) {
+	return c.listItems(ctx, GetItemListBySubject, "GetItemListBySubject", query.Args(partialKey(partition, subject)...))
+}
+
+func (c *ItemContract) writeItem(ctx AuctionContextInterface, handler contractHandler, function string, args ItemArgs) (*ItemObject, error) {
+
+	err := args.Validate(function)
+	if err != nil {
+		return nil, err
+	}
+	return c.callItem(ctx, handler, function, args.Positional()...)
+}
+
+func (c *ItemContract) callItem(ctx AuctionContextInterface, handler contractHandler, function string, args ...string) (*ItemObject, error) {
+
+	item := &ItemObject{}
+	_, err := callHandler(ctx, handler, function, item, args...)
+	if err != nil {
+		return nil, err
+	}
+	if item.ItemDocs == nil {
+		item.ItemDocs = []ItemDocument{}
+	}
+	return item, nil
+}
+
+func (c *ItemContract) listItems(ctx AuctionContextInterface, handler contractHandler, function string, args []string) (*ItemPage, error) {
+
+	page := &ItemPage{}
+	_, err := callHandler(ctx, handler, function, page, args...)
+	if err != nil {
+		return nil, err
+	}
+	if page.Items == nil {
+		page.Items = []ItemObject{}
+	}
+	// The schema of the result has ItemDocs as an array, never null
+	for i := range page.Items {
+		if page.Items[i].ItemDocs == nil {
+			page.Items[i].ItemDocs = []ItemDocument{}
+		}
+	}
+	return page, nil
+}
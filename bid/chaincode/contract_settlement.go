@@ -0,0 +1,142 @@
+//go:build fabric2 && contract
+// +build fabric2,contract
+
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package main
+
+import (
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+//////////////////////////////////////////////////////////////////////////////////
+// Settlement - closing auctions, buy-it-now offers and the transactions they
+// post (see transaction.go)
+// ./peer chaincode invoke -n mycc -C mychannel -c '{"Args": ["SettlementContract:CloseOpenAuctions", ""]}'
+// ./peer chaincode query -n mycc -C mychannel -c '{"Args": ["SettlementContract:GetTransactionsByUser", "200", "{\"From\":\"2016-05-01 00:00:00\"}"]}'
+//////////////////////////////////////////////////////////////////////////////////
+type SettlementContract struct {
+	contractapi.Contract
+}
+
+// Result of CloseAuction. Sold is false, and Transaction empty, when the auction
+// closed without bids
+type AuctionOutcome struct {
+	AuctionID   string
+	Sold        bool
+	Transaction ItemTransaction // The buyer's transaction for the winning bid
+}
+
+type TransactionPage struct {
+	Items     []ItemTransaction
+	Count     int
+	NextToken string
+}
+
+func NewSettlementContract() *SettlementContract {
+	return &SettlementContract{Contract: newContract("SettlementContract", "Closing auctions and settling sales")}
+}
+
+func (c *SettlementContract) GetEvaluateTransactions() []string {
+	return []string{"GetTransactionsByAuction", "GetTransactionsByItem", "GetTransactionsByUser"}
+}
+
+// Returns the buyer's transaction
+func (c *SettlementContract) BuyItNow(ctx AuctionContextInterface, offer BidArgs) (*ItemTransaction, error) {
+
+	err := offer.Validate("BuyItNow")
+	if err != nil {
+		return nil, err
+	}
+
+	tran := &ItemTransaction{}
+	_, err = callHandler(ctx, BuyItNow, "BuyItNow", tran, offer.Positional()...)
+	if err != nil {
+		return nil, err
+	}
+	return tran, nil
+}
+
+func (c *SettlementContract) CloseAuction(ctx AuctionContextInterface, auctionID string) (*AuctionOutcome, error) {
+
+	outcome := &AuctionOutcome{AuctionID: auctionID}
+	found, err := callHandler(ctx, CloseAuction, "CloseAuction", &outcome.Transaction, auctionID, "AUCREQ")
+	if err != nil {
+		return nil, err
+	}
+	outcome.Sold = found
+	return outcome, nil
+}
+
+// span is a partition or a range such as "2016..2017"; every partition when
+// empty. Returns the auctions that were closed
+func (c *SettlementContract) CloseOpenAuctions(ctx AuctionContextInterface, span string) ([]AuctionRequest, error) {
+
+	closed := []AuctionRequest{}
+	_, err := callHandler(ctx, CloseOpenAuctions, "CloseOpenAuctions", &closed, partialKey("CLAUC", span)...)
+	if err != nil {
+		return nil, err
+	}
+	if closed == nil {
+		closed = []AuctionRequest{}
+	}
+	return closed, nil
+}
+
+// Only an Auction House may post transactions
+func (c *SettlementContract) PostTransaction(ctx AuctionContextInterface, tran TransactionArgs) (*ItemTransaction, error) {
+
+	err := tran.Validate("PostTransaction")
+	if err != nil {
+		return nil, err
+	}
+
+	record := &ItemTransaction{}
+	_, err = callHandler(ctx, PostTransaction, "PostTransaction", record, tran.Positional()...)
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+func (c *SettlementContract) GetTransactionsByAuction(ctx AuctionContextInterface, auctionID string, query ListQuery) (*TransactionPage, error) {
+	return c.listTransactions(ctx, GetTransactionsByAuction, "GetTransactionsByAuction", query.Args(auctionID))
+}
+
+func (c *SettlementContract) GetTransactionsByItem(ctx AuctionContextInterface, itemID string, query ListQuery) (*TransactionPage, error) {
+	return c.listTransactions(ctx, GetTransactionsByItem, "GetTransactionsByItem", query.Args(itemID))
+}
+
+func (c *SettlementContract) GetTransactionsByUser(ctx AuctionContextInterface, userID string, query ListQuery) (*TransactionPage, error) {
+	return c.listTransactions(ctx, GetTransactionsByUser, "GetTransactionsByUser", query.Args(userID))
+}
+
+func (c *SettlementContract) listTransactions(ctx AuctionContextInterface, handler contractHandler, function string, args []string) (*TransactionPage, error) {
+
+	page := &TransactionPage{}
+	_, err := callHandler(ctx, handler, function, page, args...)
+	if err != nil {
+		return nil, err
+	}
+	if page.Items == nil {
+		page.Items = []ItemTransaction{}
+	}
+	return page, nil
+}
@@ -0,0 +1,127 @@
+//go:build fabric2 && contract
+// +build fabric2,contract
+
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package main
+
+import (
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+//////////////////////////////////////////////////////////////////////////////////
+// Users - registration, updates and personal data (see pii.go)
+// ./peer chaincode invoke -n mycc -C mychannel -c '{"Args": ["UserContract:PostUser", "{\"UserID\":\"100\",\"Name\":\"Ashley Hart\",\"UserType\":\"AH\"}"]}'
+// ./peer chaincode query -n mycc -C mychannel -c '{"Args": ["UserContract:GetUser", "100"]}'
+//////////////////////////////////////////////////////////////////////////////////
+type UserContract struct {
+	contractapi.Contract
+}
+
+type UserPage struct {
+	Items     []UserObject
+	Count     int
+	NextToken string
+}
+
+func NewUserContract() *UserContract {
+	return &UserContract{Contract: newContract("UserContract", "Registered users of the auction")}
+}
+
+func (c *UserContract) GetEvaluateTransactions() []string {
+	return []string{"GetUser", "VerifyUserPII", "GetUserListByCat"}
+}
+
+func (c *UserContract) PostUser(ctx AuctionContextInterface, user UserArgs) (*UserObject, error) {
+	return c.writeUser(ctx, PostUser, "PostUser", user)
+}
+
+func (c *UserContract) UpdateUser(ctx AuctionContextInterface, user UserArgs) (*UserObject, error) {
+	return c.writeUser(ctx, UpdateUser, "UpdateUser", user)
+}
+
+func (c *UserContract) DeactivateUser(ctx AuctionContextInterface, userID string) (*UserObject, error) {
+
+	user := &UserObject{}
+	_, err := callHandler(ctx, DeactivateUser, "DeactivateUser", user, userID, "USER")
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (c *UserContract) EraseUser(ctx AuctionContextInterface, userID string) (*UserObject, error) {
+
+	user := &UserObject{}
+	_, err := callHandler(ctx, EraseUser, "EraseUser", user, userID, "USER")
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (c *UserContract) GetUser(ctx AuctionContextInterface, userID string) (*UserObject, error) {
+
+	user := &UserObject{}
+	_, err := callHandler(ctx, GetUser, "GetUser", user, userID)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (c *UserContract) VerifyUserPII(ctx AuctionContextInterface, userID string, field string, value string) (*PIIVerification, error) {
+
+	result := &PIIVerification{}
+	_, err := callHandler(ctx, VerifyUserPII, "VerifyUserPII", result, userID, field, value)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// userType may be empty for all the users of the partition
+func (c *UserContract) GetUserListByCat(ctx AuctionContextInterface, partition string, userType string, query ListQuery) (*UserPage, error) {
+
+	page := &UserPage{}
+	_, err := callHandler(ctx, GetUserListByCat, "GetUserListByCat", page, query.Args(partialKey(partition, userType)...)...)
+	if err != nil {
+		return nil, err
+	}
+	if page.Items == nil {
+		page.Items = []UserObject{}
+	}
+	return page, nil
+}
+
+func (c *UserContract) writeUser(ctx AuctionContextInterface, handler contractHandler, function string, args UserArgs) (*UserObject, error) {
+
+	err := args.Validate(function)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &UserObject{}
+	_, err = callHandler(ctx, handler, function, user, args.Positional()...)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
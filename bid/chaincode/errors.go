@@ -0,0 +1,100 @@
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package main
+
+import (
+	"encoding/json"
+)
+
+//////////////////////////////////////////////////////////////////////////////////
+// Errors
+// Every error that leaves Invoke or Query is a ChaincodeError, serialized as
+//   {"Code":"NOT_FOUND","Message":"GetUser(): User not found : 100","Details":{"UserID":"100"}}
+// so that clients can branch on Code. Code and the Details keys are stable;
+// Message is meant for people and may change
+//////////////////////////////////////////////////////////////////////////////////
+type ErrorCode string
+
+const (
+	ErrNotFound        ErrorCode = "NOT_FOUND"        // The record does not exist
+	ErrInvalidArgument ErrorCode = "INVALID_ARGUMENT" // The arguments are wrong, whatever the state of the ledger
+	ErrAuctionNotOpen  ErrorCode = "AUCTION_NOT_OPEN" // The auction does not take bids (any more)
+	ErrBidTooLow       ErrorCode = "BID_TOO_LOW"      // The bid does not beat the highest bid
+	ErrUnauthorized    ErrorCode = "UNAUTHORIZED"     // The caller may not do this
+	ErrConflict        ErrorCode = "CONFLICT"         // The record exists, or is not in a state that allows this
+	ErrCorruptRecord   ErrorCode = "CORRUPT_RECORD"   // A row on the ledger cannot be decoded - see records.go
+	ErrUnknownRecType  ErrorCode = "UNKNOWN_RECTYPE"  // A row on the ledger has a RecType that is not registered
+	ErrInternal        ErrorCode = "INTERNAL"         // Anything else, e.g. a ledger failure
+)
+
+type ChaincodeError struct {
+	Code    ErrorCode
+	Message string
+	Details map[string]string `json:",omitempty"`
+}
+
+func (e *ChaincodeError) Error() string {
+
+	buff, err := json.Marshal(e)
+	if err != nil {
+		return string(e.Code) + " : " + e.Message
+	}
+	return string(buff)
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Create an error. details are name, value pairs, e.g. "AuctionID", "1111"
+//////////////////////////////////////////////////////////////////////////////////
+func NewError(code ErrorCode, message string, details ...string) error {
+
+	e := &ChaincodeError{Code: code, Message: message}
+	for i := 0; i+1 < len(details); i += 2 {
+		if e.Details == nil {
+			e.Details = map[string]string{}
+		}
+		e.Details[details[i]] = details[i+1]
+	}
+	return e
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// err as a ChaincodeError. Errors that were not raised through NewError, such
+// as those of the shim, are INTERNAL
+//////////////////////////////////////////////////////////////////////////////////
+func AsChaincodeError(err error) *ChaincodeError {
+
+	if e, ok := err.(*ChaincodeError); ok {
+		return e
+	}
+	return &ChaincodeError{Code: ErrInternal, Message: err.Error()}
+}
+
+func ErrorCodeOf(err error) ErrorCode {
+	return AsChaincodeError(err).Code
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Put message in front of err's, keeping its code and details
+//////////////////////////////////////////////////////////////////////////////////
+func WrapError(err error, message string) error {
+
+	e := AsChaincodeError(err)
+	return &ChaincodeError{Code: e.Code, Message: message + " : " + e.Message, Details: e.Details}
+}
@@ -0,0 +1,108 @@
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+//////////////////////////////////////////////////////////////////////////////////
+// Chaincode events
+// The schema is documented in docs/events.md - bump EventSchemaVersion on any
+// change to the names or payloads that is not a pure addition
+//
+// The fabric keeps one event per transaction, but one transaction can raise
+// several (a bid that outbids someone, CloseOpenAuctions closing many auctions).
+// Handlers queue events on the TxContext with AddEvent; Invoke publishes them
+// all at the end in one EventEnvelope, named after the first event queued
+//////////////////////////////////////////////////////////////////////////////////
+
+const EventSchemaVersion = 1
+
+const (
+	EventAuctionRequested = "AuctionRequested"
+	EventAuctionOpened    = "AuctionOpened"
+	EventNewHighBid       = "NewHighBid"
+	EventOutbid           = "Outbid"
+	EventAuctionExtended  = "AuctionExtended"
+	EventAuctionClosed    = "AuctionClosed"
+	EventItemSold         = "ItemSold"
+	EventNoSale           = "NoSale"
+	EventItemTransferred  = "ItemTransferred"
+)
+
+//////////////////////////////////////////////////////////////////////////////////
+// A single event. Fields that do not apply to an event type are left empty
+//////////////////////////////////////////////////////////////////////////////////
+type AuctionEvent struct {
+	Type       string
+	AuctionID  string
+	ItemID     string
+	UserID     string // The user the event concerns - see docs/events.md for each type
+	PrevUserID string // The other party, where there is one
+	Price      string
+	CloseDate  string
+	Time       string // Transaction time
+}
+
+type EventEnvelope struct {
+	SchemaVersion int
+	TxID          string
+	Events        []AuctionEvent
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Queue an event for the current transaction. Time is filled in here
+//////////////////////////////////////////////////////////////////////////////////
+func AddEvent(ctx *TxContext, ev AuctionEvent) {
+
+	if txTime, err := GetTxTime(ctx); err == nil {
+		ev.Time = txTime.Format("2006-01-02 15:04:05")
+	}
+	ctx.events = append(ctx.events, ev)
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Publish the events queued by the current transaction, if any
+//////////////////////////////////////////////////////////////////////////////////
+func FlushEvents(ctx *TxContext) error {
+
+	events := ctx.events
+	ctx.events = nil
+	if len(events) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(EventEnvelope{SchemaVersion: EventSchemaVersion, TxID: ctx.Tx.TxID(), Events: events})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("FlushEvents() : ", events[0].Type, " with ", len(events), " event(s)")
+	return ctx.Tx.SetEvent(events[0].Type, payload)
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Drop the events of a transaction that failed
+//////////////////////////////////////////////////////////////////////////////////
+func DiscardEvents(ctx *TxContext) {
+	ctx.events = nil
+}
@@ -0,0 +1,204 @@
+//go:build go1.18
+// +build go1.18
+
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+//////////////////////////////////////////////////////////////////////////////////
+// Fuzz targets for argument parsing, record codecs and tCompare. go test runs
+// them on their seed corpus; to search for new inputs, one target at a time :
+//   go test -run XXX -fuzz FuzzCreateBidObject -fuzztime 30s .
+// The arguments of the Create functions are the fuzzed string split on "\n"
+//////////////////////////////////////////////////////////////////////////////////
+
+// The Create functions may only refuse their arguments, and then as invalid
+func fuzzCreate(f *testing.F, seeds [][]string, create func(args []string) ([]string, error), allowed ...ErrorCode) {
+
+	for _, args := range seeds {
+		f.Add(strings.Join(args, "\n"))
+	}
+	allowed = append(allowed, ErrInvalidArgument)
+	f.Fuzz(func(t *testing.T, in string) {
+		restore := quietStdout()
+		args := strings.Split(in, "\n")
+		fields, err := create(args)
+		restore()
+
+		if err != nil {
+			code := ErrorCodeOf(err)
+			for _, c := range allowed {
+				if code == c {
+					return
+				}
+			}
+			t.Fatalf("%q : unexpected error : %v", args, err)
+		}
+		if !reflect.DeepEqual(fields, args[:len(fields)]) {
+			t.Fatalf("%q : record holds %q", args, fields)
+		}
+	})
+}
+
+func FuzzCreateUserObject(f *testing.F) {
+
+	fuzzCreate(f, [][]string{
+		{"100", "USER", "Ashley Hart", "TR", "One Copley Parkway, #216, Morrisville, NC 27560", "9198063535", "ashley@itpeople.com", "SUNTRUST", "00017102345", "0234678"},
+		{"X100", "USER", "", "", "", "", "", "", "", ""},
+		{"100", "USER"},
+	}, func(args []string) ([]string, error) {
+		u, err := CreateUserObject(args)
+		if err == nil && u.Status != "ACTIVE" {
+			return nil, NewError(ErrInternal, "status "+u.Status)
+		}
+		return []string{u.UserID, u.RecType, u.Name, u.UserType, u.Address, u.Phone, u.Email, u.Bank, u.AccountNo, u.RoutingNo}, err
+	})
+}
+
+func FuzzCreateItemObject(f *testing.F) {
+
+	fuzzCreate(f, [][]string{
+		{"1000", "ARTINV", "Shadows by Asppen", "Asppen Messer", "20140202", "Original"},
+		{"1000", "ARTINV", "Shadows by Asppen", "Asppen Messer", "20140202", "Original", strings.Repeat("ab", 32), "image/jpeg", "https://example.com/1000.jpg"},
+		{"1000", "ARTINV", "", "", "", "", "", "", ""},
+	}, func(args []string) ([]string, error) {
+		i, err := CreateItemObject(args)
+		if err == nil && len(i.ItemDocs) != (len(args)-6)/3 {
+			return nil, NewError(ErrInternal, "documents lost")
+		}
+		return []string{i.ItemID, i.RecType, i.ItemDesc, i.ItemDetail, i.ItemType, i.ItemSubject}, err
+	}, ErrConflict) // The same document twice
+}
+
+func FuzzCreateAuctionRequest(f *testing.F) {
+
+	fuzzCreate(f, [][]string{
+		{"1111", "AUCREQ", "1000", "200", "2016-04-01", "INIT", "", ""},
+		{"1111", "AUCREQ", "1000"},
+	}, func(args []string) ([]string, error) {
+		a, err := CreateAuctionRequest(args)
+		return []string{a.AuctionID, a.RecType, a.ItemID, a.AuctionHouseID, a.RequestDate, a.Status, a.OpenDate, a.CloseDate}, err
+	})
+}
+
+func FuzzCreateBidObject(f *testing.F) {
+
+	fuzzCreate(f, [][]string{
+		{"1111", "BID", "1", "1000", "300", "1200"},
+		{"1111", "BID", "one", "1000", "300", "1200"},
+		{"", "BID", "1", "1000", "300", "1200"},
+	}, func(args []string) ([]string, error) {
+		b, err := CreateBidObject(args)
+		return []string{b.AuctionID, b.RecType, b.BidNo, b.ItemID, b.BuyerID, b.BidPrice}, err
+	})
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Whatever a row holds, decoding it does not panic, and a record that decodes
+// encodes back to itself
+//////////////////////////////////////////////////////////////////////////////////
+func FuzzRecordCodecs(f *testing.F) {
+
+	for _, seed := range []string{
+		`{"UserID":"100","RecType":"USER","Name":"Ashley Hart","UserType":"TR","Status":"ACTIVE"}`,
+		`{"ItemID":"1000","RecType":"ARTINV","ItemDocs":[{"DocHash":"ab","MediaType":"image/jpeg"}]}`,
+		`{"AuctionID":"1111","RecType":"AUCREQ","ItemID":"1000","Status":"OPEN"}`,
+		`{"AuctionID":"1111","RecType":"BID","BidNo":"1","BidPrice":"1200"}`,
+		`{"AuctionID":"1111","RecType":"BIDSUM","BidCount":2,"HighBidNo":"2"}`,
+		`{"AuctionID":"1111","RecType":"POSTTRAN","ItemID":"1000"}`,
+		`{"RecType":"NOSUCH"}`,
+		`{"RecType":""}`,
+		`[]`,
+	} {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		restore := quietStdout()
+		defer restore()
+
+		for _, c := range recordCodecs {
+			record, err := c.decode(data)
+			if err != nil {
+				continue
+			}
+			encoded, err := c.encode(record)
+			if err != nil {
+				t.Fatalf("%s : cannot encode %+v : %v", reflect.TypeOf(c.record).Name(), record, err)
+			}
+			again, err := c.decode(encoded)
+			if err != nil {
+				t.Fatalf("%s : cannot decode %s : %v", reflect.TypeOf(c.record).Name(), encoded, err)
+			}
+			// encoding/json replaces invalid UTF-8, so only valid strings survive as they are
+			if utf8.Valid(data) && !reflect.DeepEqual(again, record) {
+				t.Fatalf("%s : %+v came back as %+v", reflect.TypeOf(c.record).Name(), record, again)
+			}
+		}
+
+		_, _, err := DecodeRecord(data)
+		if err == nil {
+			return
+		}
+		switch ErrorCodeOf(err) {
+		case ErrCorruptRecord, ErrUnknownRecType, ErrInvalidArgument:
+		default:
+			t.Fatalf("DecodeRecord(%q) : unexpected error : %v", data, err)
+		}
+	})
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// tCompare is true only for two times it can read, the first one earlier
+//////////////////////////////////////////////////////////////////////////////////
+func FuzzTCompare(f *testing.F) {
+
+	f.Add("2016-06-27 18:45:39", "2016-06-28 18:40:57")
+	f.Add("2017-03-05 10:03:00", "2017-03-05 10:03:00")
+	f.Add("2017-03-05", "2017-03-06 00:00:00")
+	f.Add("2017-03-05 24:00:00", "")
+
+	f.Fuzz(func(t *testing.T, t1, t2 string) {
+		restore := quietStdout()
+		before, after := tCompare(t1, t2), tCompare(t2, t1)
+		restore()
+
+		const layout = "2006-01-02 15:04:05"
+		p1, err1 := time.Parse(layout, t1)
+		p2, err2 := time.Parse(layout, t2)
+		if err1 != nil || err2 != nil {
+			if before || after {
+				t.Fatalf("tCompare(%q, %q) = %v, tCompare(%q, %q) = %v with a malformed time", t1, t2, before, t2, t1, after)
+			}
+			return
+		}
+		if before != p1.Before(p2) || after != p2.Before(p1) {
+			t.Fatalf("tCompare(%q, %q) = %v, tCompare(%q, %q) = %v", t1, t2, before, t2, t1, after)
+		}
+	})
+}
@@ -0,0 +1,801 @@
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+//////////////////////////////////////////////////////////////////////////////////
+// Every function of InvokeFunction, run through InvokeContext on a seeded
+// ledger (see ledger_test.go). Each case starts from a fresh ledger
+//////////////////////////////////////////////////////////////////////////////////
+
+// Auction 1111 open for 3 minutes with bids of 300 (200) and 400 (300)
+func openWithBids(l *testLedger) {
+	l.openAuction("1111", 3)
+	l.mustBid("1111", "1", "200", "300")
+	l.mustBid("1111", "2", "300", "400")
+}
+
+// Auction 1111 closed and settled, sold to 300 for 400
+func closedAuction(l *testLedger) {
+	openWithBids(l)
+	l.advance(10 * time.Minute)
+	l.mustInvoke("CloseAuction", "1111", "AUCREQ")
+}
+
+// Auction 1111 closed, the caller is the Auction House running it
+func closedAsHouse(l *testLedger) {
+	closedAuction(l)
+	asHouse(l)
+}
+
+// User 500 registered with a PII key: contact fields encrypted
+func withEncryptedUser(l *testLedger) {
+	_, err := l.invokeTransient(transient(TransientMetadataKey, testArtefactKey3), "PostUser",
+		"500", "USER", "Ashley Hart", "TR", "Morrisville", "9198063535", "ashley@example.com", "SUNTRUST", "00017102345", "0234678")
+	if err != nil {
+		l.t.Fatalf("PostUser failed : %v", err)
+	}
+}
+
+// Artefact of item 1000, posted by the Auction House for its owner 200
+func withArtefact(l *testLedger) {
+	_, err := l.invokeTransient(artefactTransient(testArtefactKey), "PostItemArtefact", "1000", "ARTEFACT", "200", "image/png")
+	if err != nil {
+		l.t.Fatalf("PostItemArtefact failed : %v", err)
+	}
+}
+
+// Item 1000 transferred from 200 to 300, its artefact released under testArtefactKey2
+func withHandover(l *testLedger) {
+	withArtefact(l)
+	_, err := l.invokeTransient(handoverTransient(testArtefactKey, testArtefactKey2), "TransferItem", "1000", "XFER", "200", "300")
+	if err != nil {
+		l.t.Fatalf("TransferItem failed : %v", err)
+	}
+}
+
+// Item 1000 with its artefact, sold to 300 and not released yet
+func soldWithArtefact(l *testLedger) {
+	withArtefact(l)
+	closedAuction(l)
+}
+
+// Item 2000 registered by its owner 200, who stays the caller
+func ownItem(l *testLedger) {
+	l.role, l.userID = "TR", "200"
+	l.mustInvoke("PostItem", "2000", "ARTINV", "Dusk", "Pastel", "Original", "Landscape")
+	l.role = "AH"
+}
+
+func asTrader(userID string) func(l *testLedger) {
+	return func(l *testLedger) { l.userID = userID }
+}
+
+// The caller is the seeded Auction House 100, which runs auction 1111
+func asHouse(l *testLedger) {
+	l.userID = "100"
+}
+
+func withArtefactAs(userID string) func(l *testLedger) {
+	return func(l *testLedger) {
+		withArtefact(l)
+		l.userID = userID
+	}
+}
+
+func artefactTransient(key string) map[string][]byte {
+	return transient(TransientArtefactKey, key, TransientArtefactContent, testArtefactContent)
+}
+
+func handoverTransient(key string, handoverKey string) map[string][]byte {
+	return transient(TransientArtefactKey, key, TransientHandoverKey, handoverKey)
+}
+
+// The artefact of item 1000 opens with key, and with none of the others
+func assertArtefactKey(t *testing.T, l *testLedger, key string) {
+
+	t.Helper()
+	for _, k := range []string{testArtefactKey, testArtefactKey2, testArtefactKey3} {
+		buff, err := l.queryTransient(transient(TransientArtefactKey, k), "OpenItemArtefact", "1000")
+		if k == key && (err != nil || string(buff) != "iVBORw0KGgo=") {
+			t.Fatalf("artefact does not open with its key : %s %v", buff, err)
+		}
+		if k != key && err == nil {
+			t.Fatalf("artefact opens with key %s", k)
+		}
+	}
+}
+
+// Users 200 and 300 filed under the legacy partition as before the upgrade,
+// 200 with no RegisteredDate and 300 with its date of 2017
+func withLegacyUsers(l *testLedger) {
+
+	for _, id := range []string{"200", "300"} {
+		user, err := l.context().Users.Get(id)
+		if err != nil {
+			l.t.Fatalf("Get(%s) failed : %v", id, err)
+		}
+		if id == "200" {
+			user.RegisteredDate = ""
+		}
+		buff, _ := UsertoJSON(user)
+		err = ReplaceLedgerEntry(l.store, "UserTable", []string{id}, buff)
+		if err == nil {
+			err = ReplaceIndexEntry(l.store, "UserCatTable", []string{"2017", "TR", id}, []string{LegacyPartition, "TR", id}, buff)
+		}
+		if err != nil {
+			l.t.Fatalf("filing %s under %s failed : %v", id, LegacyPartition, err)
+		}
+	}
+}
+
+// The UserCatTable partition user is filed under
+func userPartitionOf(t *testing.T, l *testLedger, userID string) string {
+
+	t.Helper()
+	partition := ""
+	err := l.store.ScanRows("UserCatTable", nil, func(row LedgerRow) error {
+		if row.Keys[2] == userID {
+			partition = row.Keys[0]
+		}
+		return nil
+	})
+	if err != nil || partition == "" {
+		t.Fatalf("user %s not in UserCatTable : %v", userID, err)
+	}
+	return partition
+}
+
+var invokeCases = []functionCase{
+	// Users
+	{function: "PostUser", name: "registers a user",
+		args: []string{"500", "USER", "Ashley Hart", "TR", "Morrisville", "9198063535", "ashley@example.com", "SUNTRUST", "00017102345", "0234678"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			var user UserObject
+			decodeJSON(t, l.mustQuery("GetUser", "500"), &user)
+			if user.Name != "Ashley Hart" || user.Status != "ACTIVE" || user.RegisteredDate != "2017-03-05 10:00:00" {
+				t.Fatalf("unexpected user %+v", user)
+			}
+			if user.AccountNo == "00017102345" {
+				t.Fatal("account number stored in the clear")
+			}
+		}},
+	{function: "PostUser", name: "hashed value refused", code: ErrInvalidArgument,
+		args: []string{"500", "USER", "Ashley Hart", "TR", "", "", "", "SUNTRUST", "HASH:00", ""}},
+	{function: "PostUser", name: "encrypted value refused", code: ErrInvalidArgument,
+		args: []string{"500", "USER", "Ashley Hart", "TR", "ENC:00", "", "", "", "", ""}},
+	{function: "PostUser", name: "bad PII key", code: ErrInvalidArgument, transient: transient(TransientMetadataKey, "c2hvcnQ="),
+		args: []string{"500", "USER", "Ashley Hart", "TR", "Morrisville", "", "", "", "", ""}},
+	{function: "PostUser", name: "named arguments",
+		args: []string{`{"UserID":"500","Name":"Ashley Hart","UserType":"TR"}`}},
+	{function: "PostUser", name: "another record type passed",
+		args: []string{"500", "BID", "Ashley Hart", "TR", "", "", "", "", "", ""},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			var user UserObject
+			decodeJSON(t, l.mustQuery("GetUser", "500"), &user)
+			if user.RecType != "USER" {
+				t.Fatalf("unexpected user %+v", user)
+			}
+		}},
+	{function: "PostUser", name: "duplicate user", code: ErrConflict,
+		args: []string{"100", "USER", "Again", "AH", "", "", "", "", "", ""}},
+	{function: "PostUser", name: "too few arguments", code: ErrInvalidArgument,
+		args: []string{"500", "USER", "Ashley Hart", "TR"}},
+	{function: "PostUser", name: "user ID not an integer", code: ErrInvalidArgument,
+		args: []string{"X500", "USER", "Ashley Hart", "TR", "", "", "", "", "", ""}},
+	{function: "PostUser", name: "named arguments missing a field", code: ErrInvalidArgument,
+		args: []string{`{"UserID":"500","UserType":"TR"}`}},
+
+	{function: "UpdateUser", name: "updates a user",
+		args: []string{"200", "USER", "New Name", "TR", "", "", "", "", "", ""},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			var user UserObject
+			decodeJSON(t, l.mustQuery("GetUser", "200"), &user)
+			if user.Name != "New Name" || user.RegisteredDate != "2017-03-05 10:00:00" {
+				t.Fatalf("unexpected user %+v", user)
+			}
+		}},
+	{function: "UpdateUser", name: "redacted value refused", code: ErrInvalidArgument,
+		args: []string{"200", "USER", "New Name", "TR", "", "", RedactedValue, "", "", ""}},
+	{function: "UpdateUser", name: "stored value refused", code: ErrInvalidArgument,
+		args: []string{"200", "USER", "New Name", "TR", "", "", "", "", "HASH:00", ""}},
+	{function: "UpdateUser", name: "unknown user", code: ErrNotFound,
+		args: []string{"999", "USER", "New Name", "TR", "", "", "", "", "", ""}},
+	{function: "UpdateUser", name: "caller not permitted", role: "TR", code: ErrUnauthorized,
+		args: []string{"200", "USER", "New Name", "TR", "", "", "", "", "", ""}},
+	{function: "UpdateUser", name: "too few arguments", code: ErrInvalidArgument,
+		args: []string{"200", "USER"}},
+
+	{function: "DeactivateUser", name: "deactivates a user",
+		args: []string{"200", "USER"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			var user UserObject
+			decodeJSON(t, l.mustQuery("GetUser", "200"), &user)
+			if user.Status != "INACTIVE" {
+				t.Fatalf("status %s", user.Status)
+			}
+		}},
+	{function: "DeactivateUser", name: "already inactive", code: ErrConflict,
+		setup: func(l *testLedger) { l.mustInvoke("DeactivateUser", "200", "USER") },
+		args:  []string{"200", "USER"}},
+	{function: "DeactivateUser", name: "caller not permitted", role: "TR", code: ErrUnauthorized,
+		args: []string{"200", "USER"}},
+	{function: "DeactivateUser", name: "too few arguments", code: ErrInvalidArgument,
+		args: []string{"USER"}},
+
+	{function: "EraseUser", name: "erases personal data",
+		args: []string{"200", "USER"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			var user UserObject
+			decodeJSON(t, l.mustQuery("GetUser", "200"), &user)
+			if user.ErasedDate == "" || user.AccountNo != "" || user.Email != "" {
+				t.Fatalf("not erased %+v", user)
+			}
+		}},
+	{function: "EraseUser", name: "unknown user", code: ErrNotFound,
+		args: []string{"999", "USER"}},
+	{function: "EraseUser", name: "caller not permitted", role: "TR", code: ErrUnauthorized,
+		args: []string{"200", "USER"}},
+	{function: "EraseUser", name: "too few arguments", code: ErrInvalidArgument,
+		args: []string{"USER"}},
+
+	// Items
+	{function: "PostItem", name: "registers an item",
+		args: []string{"1001", "ARTINV", "Flowers", "Oil", "Original", "Nature"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			var item ItemObject
+			decodeJSON(t, l.mustQuery("GetItem", "1001"), &item)
+			if item.ItemDesc != "Flowers" || item.RegisteredDate != "2017-03-05 10:00:00" {
+				t.Fatalf("unexpected item %+v", item)
+			}
+		}},
+	{function: "PostItem", name: "with a document",
+		args: []string{"1001", "ARTINV", "Flowers", "Oil", "Original", "Nature", testDocHash, "image/png", "https://example.com/1001.png"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			var item ItemObject
+			decodeJSON(t, l.mustQuery("GetItem", "1001"), &item)
+			if len(item.ItemDocs) != 1 || item.ItemDocs[0].DocHash != testDocHash {
+				t.Fatalf("documents %+v", item.ItemDocs)
+			}
+		}},
+	{function: "PostItem", name: "duplicate item", code: ErrConflict,
+		args: []string{"1000", "ARTINV", "Again", "Oil", "Original", "Nature"}},
+	{function: "PostItem", name: "another record type passed",
+		args: []string{"1001", "ITEMDOC", "Flowers", "Oil", "Original", "Nature"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			if item := l.item("1001"); item.RecType != "ARTINV" || item.ItemDesc != "Flowers" {
+				t.Fatalf("unexpected item %+v", item)
+			}
+		}},
+	{function: "PostItem", name: "too few arguments", code: ErrInvalidArgument,
+		args: []string{"1001", "ARTINV", "Flowers"}},
+	{function: "PostItem", name: "incomplete document", code: ErrInvalidArgument,
+		args: []string{"1001", "ARTINV", "Flowers", "Oil", "Original", "Nature", testDocHash}},
+
+	{function: "UpdateItem", name: "updates an item",
+		args: []string{"1000", "ARTINV", "Renamed", "Oil", "Original", "Landscape"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			var item ItemObject
+			decodeJSON(t, l.mustQuery("GetItem", "1000"), &item)
+			if item.ItemDesc != "Renamed" {
+				t.Fatalf("unexpected item %+v", item)
+			}
+		}},
+	{function: "UpdateItem", name: "owner updates the item", setup: ownItem, role: "TR",
+		args: []string{"2000", "ARTINV", "Renamed", "Pastel", "Original", "Landscape"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			if item := l.item("2000"); item.ItemDesc != "Renamed" || item.OwnerID != "200" {
+				t.Fatalf("item %+v", item)
+			}
+		}},
+	{function: "UpdateItem", name: "another record type passed",
+		args: []string{"1000", "ARTEFACT", "Renamed", "Oil", "Original", "Landscape"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			if item := l.item("1000"); item.RecType != "ARTINV" || item.ItemDesc != "Renamed" {
+				t.Fatalf("unexpected item %+v", item)
+			}
+		}},
+	{function: "UpdateItem", name: "item with no owner", setup: asTrader("300"), role: "TR", code: ErrUnauthorized,
+		args: []string{"1000", "ARTINV", "Renamed", "Oil", "Original", "Landscape"}},
+	{function: "UpdateItem", name: "caller not the owner", role: "TR", code: ErrUnauthorized,
+		setup: func(l *testLedger) {
+			ownItem(l)
+			l.userID = "300"
+		},
+		args: []string{"2000", "ARTINV", "Renamed", "Pastel", "Original", "Landscape"}},
+	{function: "UpdateItem", name: "unknown item", code: ErrNotFound,
+		args: []string{"9999", "ARTINV", "Renamed", "Oil", "Original", "Landscape"}},
+	{function: "UpdateItem", name: "too few arguments", code: ErrInvalidArgument,
+		args: []string{"1000", "ARTINV", "Renamed"}},
+
+	{function: "PostItemDocument", name: "attaches a document",
+		args: []string{"1000", "ITEMDOC", testDocHash, "image/png", "https://example.com/1000.png"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			var result ItemDocVerification
+			decodeJSON(t, l.mustQuery("VerifyItemDocument", "1000", "SHA256", testDocHash), &result)
+			if !result.Verified {
+				t.Fatal("document not registered")
+			}
+		}},
+	{function: "PostItemDocument", name: "same document twice", code: ErrConflict,
+		setup: func(l *testLedger) {
+			l.mustInvoke("PostItemDocument", "1000", "ITEMDOC", testDocHash, "image/png", "https://example.com/1000.png")
+		},
+		args: []string{"1000", "ITEMDOC", testDocHash, "image/png", "https://example.com/1000.png"}},
+	{function: "PostItemDocument", name: "owner attaches a document", setup: ownItem, role: "TR",
+		args: []string{"2000", "ITEMDOC", testDocHash, "image/png", "https://example.com/2000.png"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			if item := l.item("2000"); len(item.ItemDocs) != 1 {
+				t.Fatalf("documents %+v", item.ItemDocs)
+			}
+		}},
+	{function: "PostItemDocument", name: "caller not the owner", role: "TR", code: ErrUnauthorized,
+		setup: func(l *testLedger) {
+			ownItem(l)
+			l.userID = "300"
+		},
+		args: []string{"2000", "ITEMDOC", testDocHash, "image/png", "https://example.com/2000.png"}},
+	{function: "PostItemDocument", name: "hash not hex", code: ErrInvalidArgument,
+		args: []string{"1000", "ITEMDOC", "not-a-hash", "image/png", ""}},
+	{function: "PostItemDocument", name: "unknown item", code: ErrNotFound,
+		args: []string{"9999", "ITEMDOC", testDocHash, "image/png", ""}},
+	{function: "PostItemDocument", name: "too few arguments", code: ErrInvalidArgument,
+		args: []string{"1000", "ITEMDOC", testDocHash}},
+	{function: "PostItemDocument", name: "named arguments",
+		args: []string{`{"ItemID":"1000","DocHash":"` + testDocHash + `","MediaType":"image/png"}`}},
+	{function: "PostItemDocument", name: "named arguments missing the media type", code: ErrInvalidArgument,
+		args: []string{`{"ItemID":"1000","DocHash":"` + testDocHash + `"}`}},
+
+	{function: "PostItemArtefact", name: "stores an artefact and records the owner",
+		transient: artefactTransient(testArtefactKey),
+		args:      []string{"1000", "ARTEFACT", "200", "image/png"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			assertArtefactKey(t, l, testArtefactKey)
+			if item := l.item("1000"); item.OwnerID != "200" {
+				t.Fatalf("item %+v", item)
+			}
+		}},
+	{function: "PostItemArtefact", name: "raw key", transient: artefactTransient("0123456789abcdef"),
+		args: []string{"1000", "ARTEFACT", "200", "image/png"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			if _, err := l.queryTransient(transient(TransientArtefactKey, "0123456789abcdef"), "OpenItemArtefact", "1000"); err != nil {
+				t.Fatal(err)
+			}
+		}},
+	{function: "PostItemArtefact", name: "owner's own item", setup: ownItem,
+		transient: artefactTransient(testArtefactKey), role: "TR",
+		args: []string{"2000", "ARTEFACT", "200", "image/png"}},
+	{function: "PostItemArtefact", name: "someone else's item", setup: ownItem, code: ErrUnauthorized,
+		transient: artefactTransient(testArtefactKey),
+		args:      []string{"2000", "ARTEFACT", "300", "image/png"}},
+	{function: "PostItemArtefact", name: "member claims an item without owner", setup: asTrader("200"), code: ErrUnauthorized,
+		transient: artefactTransient(testArtefactKey), role: "TR",
+		args: []string{"1000", "ARTEFACT", "200", "image/png"}},
+	{function: "PostItemArtefact", name: "already posted", setup: withArtefact, code: ErrConflict,
+		transient: artefactTransient(testArtefactKey),
+		args:      []string{"1000", "ARTEFACT", "200", "image/png"}},
+	{function: "PostItemArtefact", name: "bad key", code: ErrInvalidArgument,
+		transient: artefactTransient("c2hvcnQ="),
+		args:      []string{"1000", "ARTEFACT", "200", "image/png"}},
+	{function: "PostItemArtefact", name: "no key", code: ErrInvalidArgument,
+		transient: transient(TransientArtefactContent, testArtefactContent),
+		args:      []string{"1000", "ARTEFACT", "200", "image/png"}},
+	{function: "PostItemArtefact", name: "owner not registered", code: ErrNotFound,
+		transient: artefactTransient(testArtefactKey),
+		args:      []string{"1000", "ARTEFACT", "999", "image/png"}},
+	{function: "PostItemArtefact", name: "too few arguments", code: ErrInvalidArgument,
+		transient: artefactTransient(testArtefactKey),
+		args:      []string{"1000", "ARTEFACT", "200"}},
+
+	{function: "TransferItem", name: "moves the item and releases the artefact", setup: withArtefact,
+		transient: handoverTransient(testArtefactKey, testArtefactKey2),
+		args:      []string{"1000", "XFER", "200", "300"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			assertArtefactKey(t, l, testArtefactKey2)
+			if item := l.item("1000"); item.OwnerID != "300" {
+				t.Fatalf("item %+v", item)
+			}
+			art := l.artefact("1000")
+			if art.OwnerID != "200" || art.PendingOwnerID != "300" || art.Handover != HandoverReleased {
+				t.Fatalf("artefact %+v", art)
+			}
+		}},
+	{function: "TransferItem", name: "releases a sold item to the buyer", setup: soldWithArtefact,
+		transient: handoverTransient(testArtefactKey, testArtefactKey2),
+		args:      []string{"1000", "XFER", "200", "300"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			assertArtefactKey(t, l, testArtefactKey2)
+			if art := l.artefact("1000"); art.PendingOwnerID != "300" || art.Handover != HandoverReleased {
+				t.Fatalf("artefact %+v", art)
+			}
+		}},
+	{function: "TransferItem", name: "sold item to someone else", setup: soldWithArtefact, code: ErrUnauthorized,
+		transient: handoverTransient(testArtefactKey, testArtefactKey2),
+		args:      []string{"1000", "XFER", "200", "400"}},
+	{function: "TransferItem", name: "already transferred", setup: withHandover, code: ErrUnauthorized,
+		transient: handoverTransient(testArtefactKey, testArtefactKey3),
+		args:      []string{"1000", "XFER", "200", "400"}},
+	{function: "TransferItem", name: "not the owner", setup: withArtefact, code: ErrUnauthorized,
+		transient: handoverTransient(testArtefactKey, testArtefactKey2),
+		args:      []string{"1000", "XFER", "300", "400"}},
+	{function: "TransferItem", name: "caller may not act for the owner", setup: withArtefactAs("300"), code: ErrUnauthorized,
+		transient: handoverTransient(testArtefactKey, testArtefactKey2), role: "TR",
+		args: []string{"1000", "XFER", "200", "300"}},
+	{function: "TransferItem", name: "wrong key", setup: withArtefact, code: ErrUnauthorized,
+		transient: handoverTransient(testArtefactKey3, testArtefactKey2),
+		args:      []string{"1000", "XFER", "200", "300"}},
+	{function: "TransferItem", name: "no handover key", setup: withArtefact, code: ErrInvalidArgument,
+		transient: transient(TransientArtefactKey, testArtefactKey),
+		args:      []string{"1000", "XFER", "200", "300"}},
+	{function: "TransferItem", name: "no artefact", code: ErrNotFound,
+		transient: handoverTransient(testArtefactKey, testArtefactKey2),
+		args:      []string{"1000", "XFER", "200", "300"}},
+	{function: "TransferItem", name: "too few arguments", setup: withArtefact, code: ErrInvalidArgument,
+		args: []string{"1000", "XFER", "200"}},
+
+	{function: "AcceptItemArtefact", name: "re-keys the artefact for the new owner", setup: withHandover,
+		transient: handoverTransient(testArtefactKey3, testArtefactKey2),
+		args:      []string{"1000", "ARTEFACT", "300"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			assertArtefactKey(t, l, testArtefactKey3)
+			if art := l.artefact("1000"); art.OwnerID != "300" || art.PendingOwnerID != "" || art.Handover != "" {
+				t.Fatalf("artefact %+v", art)
+			}
+		}},
+	{function: "AcceptItemArtefact", name: "not released yet", setup: soldWithArtefact, code: ErrConflict,
+		transient: handoverTransient(testArtefactKey3, testArtefactKey2),
+		args:      []string{"1000", "ARTEFACT", "300"}},
+	{function: "AcceptItemArtefact", name: "not the new owner", setup: withHandover, code: ErrUnauthorized,
+		transient: handoverTransient(testArtefactKey3, testArtefactKey2),
+		args:      []string{"1000", "ARTEFACT", "400"}},
+	{function: "AcceptItemArtefact", name: "wrong handover key", setup: withHandover, code: ErrUnauthorized,
+		transient: handoverTransient(testArtefactKey3, testArtefactKey),
+		args:      []string{"1000", "ARTEFACT", "300"}},
+	{function: "AcceptItemArtefact", name: "too few arguments", setup: withHandover, code: ErrInvalidArgument,
+		transient: handoverTransient(testArtefactKey3, testArtefactKey2),
+		args:      []string{"1000", "ARTEFACT"}},
+
+	{function: "ReindexPartitions", name: "re-indexes the legacy partition", setup: withLegacyUsers,
+		args: []string{"REINDEX"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			var results []ReindexResult
+			decodeJSON(t, buff, &results)
+			if len(results) == 0 || results[0].TableName != "UserCatTable" || results[0].Moved != 1 {
+				t.Fatalf("results %+v", results)
+			}
+			if p := userPartitionOf(t, l, "300"); p != "2017" {
+				t.Fatalf("dated user moved to %s", p)
+			}
+		}},
+	{function: "ReindexPartitions", name: "keeps undated users in the legacy partition", setup: withLegacyUsers,
+		args: []string{"REINDEX"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			if p := userPartitionOf(t, l, "200"); p != LegacyPartition {
+				t.Fatalf("undated user moved to %s", p)
+			}
+			if user, _ := l.context().Users.Get("200"); user.RegisteredDate != "" {
+				t.Fatalf("undated user stamped %s", user.RegisteredDate)
+			}
+		}},
+	{function: "ReindexPartitions", name: "caller not an Auction House", role: "TR", code: ErrUnauthorized,
+		args: []string{"REINDEX"}},
+
+	{function: "RebuildBidSummaries", name: "rebuilds a lost summary",
+		setup: func(l *testLedger) {
+			openWithBids(l)
+			l.store.DeleteRow("BidSummaryTable", []string{"1111"})
+		},
+		args: []string{"BIDSUM"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			var result BidSummaryResult
+			decodeJSON(t, buff, &result)
+			if result != (BidSummaryResult{Auctions: 1, Bids: 2}) {
+				t.Fatalf("result %+v", result)
+			}
+			var bid Bid
+			decodeJSON(t, l.mustQuery("GetHighestBid", "1111"), &bid)
+			if bid.BidNo != "2" || bid.BuyerID != "300" {
+				t.Fatalf("highest bid %+v", bid)
+			}
+		}},
+	{function: "RebuildBidSummaries", name: "named auctions", setup: openWithBids,
+		args: []string{"BIDSUM", "1111", "2222"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			var result BidSummaryResult
+			decodeJSON(t, buff, &result)
+			if result != (BidSummaryResult{Auctions: 1, Bids: 2}) {
+				t.Fatalf("result %+v", result)
+			}
+		}},
+	{function: "RebuildBidSummaries", name: "caller not an Auction House", role: "TR", code: ErrUnauthorized,
+		args: []string{"BIDSUM"}},
+
+	// Auctions and bids
+	{function: "PostAuctionRequest", name: "requests an auction",
+		setup: func(l *testLedger) { l.addItem("1001") },
+		args:  []string{"2222", "AUCREQ", "1001", "100", "2017-03-05", "INIT", "", ""},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			if events := l.events(); len(events) != 1 || events[0].Type != EventAuctionRequested {
+				t.Fatalf("events %+v", events)
+			}
+			if aucR := l.auction("2222"); aucR.Status != "INIT" {
+				t.Fatalf("status %s", aucR.Status)
+			}
+		}},
+	{function: "PostAuctionRequest", name: "duplicate auction", code: ErrConflict,
+		args: []string{"1111", "AUCREQ", "1000", "100", "2017-03-05", "INIT", "", ""}},
+	{function: "PostAuctionRequest", name: "another record type passed",
+		setup: func(l *testLedger) { l.addItem("1001") },
+		args:  []string{"2222", "BID", "1001", "100", "2017-03-05", "INIT", "", ""},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			if aucR := l.auction("2222"); aucR.RecType != "AUCREQ" || aucR.Status != "INIT" {
+				t.Fatalf("unexpected auction %+v", aucR)
+			}
+		}},
+	{function: "PostAuctionRequest", name: "unknown item", code: ErrNotFound,
+		args: []string{"2222", "AUCREQ", "9999", "100", "2017-03-05", "INIT", "", ""}},
+	{function: "PostAuctionRequest", name: "unknown auction house", code: ErrNotFound,
+		args: []string{"2222", "AUCREQ", "1000", "999", "2017-03-05", "INIT", "", ""}},
+	{function: "PostAuctionRequest", name: "bad request date", code: ErrInvalidArgument,
+		args: []string{"2222", "AUCREQ", "1000", "100", "yesterday", "INIT", "", ""}},
+	{function: "PostAuctionRequest", name: "too few arguments", code: ErrInvalidArgument,
+		args: []string{"2222", "AUCREQ", "1000"}},
+
+	{function: "OpenAuctionForBids", name: "opens an auction",
+		args: []string{"1111", "OPENAUC", "3"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			aucR := l.auction("1111")
+			if aucR.Status != "OPEN" || aucR.OpenDate != "2017-03-05 10:00:00" || aucR.CloseDate != "2017-03-05 10:03:00" {
+				t.Fatalf("unexpected auction %+v", aucR)
+			}
+		}},
+	{function: "OpenAuctionForBids", name: "closed auction", setup: closedAuction, code: ErrConflict,
+		args: []string{"1111", "OPENAUC", "3"}},
+	{function: "OpenAuctionForBids", name: "unknown auction", code: ErrNotFound,
+		args: []string{"2222", "OPENAUC", "3"}},
+	{function: "OpenAuctionForBids", name: "duration not a number", code: ErrInvalidArgument,
+		args: []string{"1111", "OPENAUC", "soon"}},
+	{function: "OpenAuctionForBids", name: "too few arguments", code: ErrInvalidArgument,
+		args: []string{"1111", "OPENAUC"}},
+
+	{function: "PostBid", name: "accepts a bid",
+		setup: func(l *testLedger) { l.openAuction("1111", 3) },
+		args:  []string{"1111", "BID", "1", "1000", "200", "300"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			var bid Bid
+			decodeJSON(t, l.mustQuery("GetBid", "1111", "1"), &bid)
+			if bid.BidPrice != "300" || bid.BidTime != "2017-03-05 10:00:00" {
+				t.Fatalf("unexpected bid %+v", bid)
+			}
+		}},
+	{function: "PostBid", name: "does not beat the highest bid", setup: openWithBids, code: ErrBidTooLow,
+		args: []string{"1111", "BID", "3", "1000", "400", "400"}},
+	{function: "PostBid", name: "another record type passed",
+		setup: func(l *testLedger) { l.openAuction("1111", 3) },
+		args:  []string{"1111", "AUCREQ", "1", "1000", "200", "300"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			var bid Bid
+			decodeJSON(t, l.mustQuery("GetHighestBid", "1111"), &bid)
+			if bid.RecType != "BID" || bid.BidPrice != "300" {
+				t.Fatalf("unexpected bid %+v", bid)
+			}
+		}},
+	{function: "PostBid", name: "auction not open", code: ErrAuctionNotOpen,
+		args: []string{"1111", "BID", "1", "1000", "200", "300"}},
+	{function: "PostBid", name: "after the close time", code: ErrAuctionNotOpen,
+		setup: func(l *testLedger) {
+			l.openAuction("1111", 3)
+			l.advance(5 * time.Minute)
+		},
+		args: []string{"1111", "BID", "1", "1000", "200", "300"}},
+	{function: "PostBid", name: "duplicate bid number", setup: openWithBids, code: ErrConflict,
+		args: []string{"1111", "BID", "2", "1000", "400", "900"}},
+	{function: "PostBid", name: "item not on the auction", code: ErrInvalidArgument,
+		setup: func(l *testLedger) { l.openAuction("1111", 3) },
+		args:  []string{"1111", "BID", "1", "1001", "200", "300"}},
+	{function: "PostBid", name: "buyer not registered", code: ErrNotFound,
+		setup: func(l *testLedger) { l.openAuction("1111", 3) },
+		args:  []string{"1111", "BID", "1", "1000", "999", "300"}},
+	{function: "PostBid", name: "too few arguments", code: ErrInvalidArgument,
+		setup: func(l *testLedger) { l.openAuction("1111", 3) },
+		args:  []string{"1111", "BID", "1", "1000"}},
+
+	{function: "ExtendAuction", name: "extends an open auction",
+		setup: func(l *testLedger) { l.openAuction("1111", 3); asHouse(l) },
+		args:  []string{"1111", "EXTAUC", "5"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			if aucR := l.auction("1111"); aucR.CloseDate != "2017-03-05 10:08:00" {
+				t.Fatalf("close date %s", aucR.CloseDate)
+			}
+		}},
+	{function: "ExtendAuction", name: "auction not open", code: ErrAuctionNotOpen, setup: asHouse,
+		args: []string{"1111", "EXTAUC", "5"}},
+	{function: "ExtendAuction", name: "caller not an Auction House", role: "TR", code: ErrUnauthorized,
+		setup: func(l *testLedger) { l.openAuction("1111", 3); asHouse(l) },
+		args:  []string{"1111", "EXTAUC", "5"}},
+	{function: "ExtendAuction", name: "another Auction House", code: ErrUnauthorized,
+		setup: func(l *testLedger) { l.openAuction("1111", 3); l.userID = "200" },
+		args:  []string{"1111", "EXTAUC", "5"}},
+	{function: "ExtendAuction", name: "extension not positive", code: ErrInvalidArgument,
+		setup: func(l *testLedger) { l.openAuction("1111", 3) },
+		args:  []string{"1111", "EXTAUC", "-5"}},
+	{function: "ExtendAuction", name: "too few arguments", code: ErrInvalidArgument,
+		setup: func(l *testLedger) { l.openAuction("1111", 3) },
+		args:  []string{"1111", "EXTAUC"}},
+
+	// Settlement
+	{function: "BuyItNow", name: "buys the item and closes the auction", setup: openWithBids,
+		args: []string{"1111", "BID", "3", "1000", "400", "1000"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			if aucR := l.auction("1111"); aucR.Status != "CLOSED" {
+				t.Fatalf("status %s", aucR.Status)
+			}
+			var tran ItemTransaction
+			decodeJSON(t, buff, &tran)
+			if tran.UserId != "400" || tran.HammerPrice != "1000" {
+				t.Fatalf("unexpected transaction %+v", tran)
+			}
+		}},
+	{function: "BuyItNow", name: "below the highest bid", setup: openWithBids, code: ErrBidTooLow,
+		args: []string{"1111", "BID", "3", "1000", "400", "350"}},
+	{function: "BuyItNow", name: "too few arguments", setup: openWithBids, code: ErrInvalidArgument,
+		args: []string{"1111", "BID", "3", "1000"}},
+
+	{function: "CloseAuction", name: "sells to the highest bidder", setup: openWithBids,
+		args: []string{"1111", "AUCREQ"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			var tran ItemTransaction
+			decodeJSON(t, buff, &tran)
+			if tran.TransType != "BUY" || tran.UserId != "300" || tran.HammerPrice != "400" {
+				t.Fatalf("buyer's transaction %+v", tran)
+			}
+			if aucR := l.auction("1111"); aucR.Status != "CLOSED" {
+				t.Fatalf("status %s", aucR.Status)
+			}
+		}},
+	{function: "CloseAuction", name: "without bids",
+		setup: func(l *testLedger) { l.openAuction("1111", 3) },
+		args:  []string{"1111", "AUCREQ"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			if buff != nil {
+				t.Fatalf("result %s", buff)
+			}
+		}},
+	{function: "CloseAuction", name: "auction not open", code: ErrAuctionNotOpen,
+		args: []string{"1111", "AUCREQ"}},
+	{function: "CloseAuction", name: "closed twice", setup: closedAuction, code: ErrConflict,
+		args: []string{"1111", "AUCREQ"}},
+	{function: "CloseAuction", name: "unknown auction", code: ErrNotFound,
+		args: []string{"2222", "AUCREQ"}},
+	{function: "CloseAuction", name: "too many arguments", code: ErrInvalidArgument,
+		args: []string{"1111", "AUCREQ", "now"}},
+
+	{function: "CloseOpenAuctions", name: "closes the expired auctions",
+		setup: func(l *testLedger) {
+			openWithBids(l)
+			l.advance(10 * time.Minute)
+		},
+		args: []string{"CLAUC"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			var closed []AuctionRequest
+			decodeJSON(t, buff, &closed)
+			if len(closed) != 1 || closed[0].AuctionID != "1111" {
+				t.Fatalf("closed %+v", closed)
+			}
+			if aucR := l.auction("1111"); aucR.Status != "CLOSED" {
+				t.Fatalf("status %s", aucR.Status)
+			}
+		}},
+	{function: "CloseOpenAuctions", name: "looks in every partition",
+		setup: func(l *testLedger) {
+			openWithBids(l)
+			l.now = l.now.AddDate(2, 0, 0)
+		},
+		args: []string{"CLAUC"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			if aucR := l.auction("1111"); aucR.Status != "CLOSED" {
+				t.Fatalf("status %s", aucR.Status)
+			}
+		}},
+	{function: "CloseOpenAuctions", name: "looks only in the partitions given",
+		setup: func(l *testLedger) {
+			openWithBids(l)
+			l.now = l.now.AddDate(2, 0, 0)
+		},
+		args: []string{"CLAUC", "2019"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			if aucR := l.auction("1111"); aucR.Status != "OPEN" {
+				t.Fatalf("status %s", aucR.Status)
+			}
+		}},
+	{function: "CloseOpenAuctions", name: "leaves running auctions open", setup: openWithBids,
+		args: []string{"CLAUC"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			if aucR := l.auction("1111"); aucR.Status != "OPEN" {
+				t.Fatalf("status %s", aucR.Status)
+			}
+		}},
+	{function: "CloseOpenAuctions", name: "bad partition range", code: ErrInvalidArgument,
+		args: []string{"CLAUC", "2017..2016"}},
+
+	{function: "PostTransaction", name: "posts a transaction", setup: closedAsHouse,
+		args: []string{"1111", "POSTTRAN", "1000", "COMMISSION", "100", "2017-03-05 10:10:00", "2017-03-05 10:00:00", "40", "Commission"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			var page ListPage
+			decodeJSON(t, l.mustQuery("GetTransactionsByUser", "100", "pageSize=10"), &page)
+			if page.Count != 1 {
+				t.Fatalf("transactions of 100 %+v", page)
+			}
+		}},
+	{function: "PostTransaction", name: "a second commission on the auction",
+		setup: func(l *testLedger) {
+			closedAsHouse(l)
+			l.mustInvoke("PostTransaction", "1111", "POSTTRAN", "1000", "COMMISSION", "100", "2017-03-05 10:10:00", "2017-03-05 10:00:00", "40", "Buyer's premium")
+		},
+		args: []string{"1111", "POSTTRAN", "1000", "COMMISSION", "100", "2017-03-05 10:11:00", "2017-03-05 10:00:00", "25", "Seller's commission"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			var trans []ItemTransaction
+			decodeJSON(t, l.mustQuery("GetTransactionsByUser", "100"), &trans)
+			if len(trans) != 2 || trans[0].HammerPrice != "40" || trans[1].HammerPrice != "25" || trans[0].TxID == trans[1].TxID {
+				t.Fatalf("transactions of 100 %+v", trans)
+			}
+		}},
+	{function: "PostTransaction", name: "auction not closed", code: ErrConflict,
+		setup: func(l *testLedger) { openWithBids(l); asHouse(l) },
+		args:  []string{"1111", "POSTTRAN", "1000", "COMMISSION", "100", "2017-03-05 10:10:00", "2017-03-05 10:00:00", "40", "Commission"}},
+	{function: "PostTransaction", name: "another record type passed", setup: closedAsHouse,
+		args: []string{"1111", "USER", "1000", "COMMISSION", "100", "2017-03-05 10:10:00", "2017-03-05 10:00:00", "40", "Commission"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			var trans []ItemTransaction
+			decodeJSON(t, l.mustQuery("GetTransactionsByUser", "100"), &trans)
+			if len(trans) != 1 || trans[0].RecType != "POSTTRAN" {
+				t.Fatalf("transactions of 100 %+v", trans)
+			}
+		}},
+	{function: "PostTransaction", name: "caller not an Auction House", setup: closedAsHouse, role: "TR", code: ErrUnauthorized,
+		args: []string{"1111", "POSTTRAN", "1000", "COMMISSION", "100", "2017-03-05 10:10:00", "2017-03-05 10:00:00", "40", "Commission"}},
+	{function: "PostTransaction", name: "another Auction House", code: ErrUnauthorized,
+		setup: func(l *testLedger) { closedAuction(l); l.userID = "200" },
+		args:  []string{"1111", "POSTTRAN", "1000", "COMMISSION", "100", "2017-03-05 10:10:00", "2017-03-05 10:00:00", "40", "Commission"}},
+	{function: "PostTransaction", name: "too few arguments", setup: closedAsHouse, code: ErrInvalidArgument,
+		args: []string{"1111", "POSTTRAN", "1000"}},
+}
+
+func TestInvokeFunctions(t *testing.T) {
+	runFunctionCases(t, invokeCases, (*testLedger).invoke)
+}
+
+func TestInvokeUnknownFunction(t *testing.T) {
+
+	l := newSeededLedger(t)
+	_, err := l.invoke("PostNothing", "1", "USER")
+	assertErrorCode(t, err, ErrInvalidArgument)
+}
+
+func TestInvokeInvalidRecType(t *testing.T) {
+
+	l := newSeededLedger(t)
+	_, err := l.invoke("PostUser", "500", "NOTATYPE")
+	assertErrorCode(t, err, ErrInvalidArgument)
+}
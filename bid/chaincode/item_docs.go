@@ -0,0 +1,200 @@
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+//////////////////////////////////////////////////////////////////////////////////
+// Response returned by VerifyItemDocument
+// Verified is false (and the document fields empty) when the presented content
+// does not match any document registered against the item
+//////////////////////////////////////////////////////////////////////////////////
+type ItemDocVerification struct {
+	ItemID    string
+	DocHash   string // Hash of the content that was presented
+	Verified  bool
+	MediaType string
+	DocURI    string
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Create an Item Document from (DocHash, MediaType, DocURI)
+// The hash is normalised to lower case hex so that lookups are case insensitive
+//////////////////////////////////////////////////////////////////////////////////
+func CreateItemDocument(args []string) (ItemDocument, error) {
+
+	var doc ItemDocument
+
+	if len(args) != 3 {
+		fmt.Println("CreateItemDocument(): Incorrect number of arguments. Expecting 3 ")
+		return doc, NewError(ErrInvalidArgument, "CreateItemDocument(): Incorrect number of arguments. Expecting 3 ")
+	}
+
+	hash, err := validateDocHash(args[0])
+	if err != nil {
+		return doc, err
+	}
+
+	if args[1] == "" {
+		return doc, NewError(ErrInvalidArgument, "CreateItemDocument(): Media Type is required for document "+hash)
+	}
+
+	doc = ItemDocument{DocHash: hash, MediaType: args[1], DocURI: args[2]}
+	return doc, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Attach a document to an item. The same content cannot be registered twice
+//////////////////////////////////////////////////////////////////////////////////
+func AddItemDocument(item *ItemObject, doc ItemDocument) error {
+
+	if _, found := FindItemDocument(*item, doc.DocHash); found {
+		return NewError(ErrConflict, "AddItemDocument(): Document "+doc.DocHash+" already registered for item "+item.ItemID)
+	}
+	item.ItemDocs = append(item.ItemDocs, doc)
+	return nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Look up a registered document by its hash
+//////////////////////////////////////////////////////////////////////////////////
+func FindItemDocument(item ItemObject, docHash string) (ItemDocument, bool) {
+
+	docHash = strings.ToLower(docHash)
+	for _, doc := range item.ItemDocs {
+		if doc.DocHash == docHash {
+			return doc, true
+		}
+	}
+	return ItemDocument{}, false
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Hex encoded SHA-256 of a document's content
+//////////////////////////////////////////////////////////////////////////////////
+func HashDocument(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+func validateDocHash(hash string) (string, error) {
+
+	hash = strings.ToLower(strings.TrimSpace(hash))
+	b, err := hex.DecodeString(hash)
+	if err != nil || len(b) != sha256.Size {
+		return "", NewError(ErrInvalidArgument, "validateDocHash(): Document hash should be a hex encoded SHA-256 : "+hash)
+	}
+	return hash, nil
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// Register a document (image, certificate of authenticity, appraisal ...) against an existing Item
+// Structure of args ItemID, RecType, DocHash, MediaType, DocURI
+// Only the owner of the item or an Auction House may register documents
+// ./peer chaincode invoke -l golang -n mycc -c '{"Function": "PostItemDocument", "Args":["1000", "ITEMDOC", "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08", "application/pdf", "https://gallery.example.com/1000/coa.pdf"]}'
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+func PostItemDocument(ctx *TxContext, function string, args []string) ([]byte, error) {
+
+	if len(args) != 5 {
+		fmt.Println("PostItemDocument(): Incorrect number of arguments. Expecting 5 ")
+		return nil, NewError(ErrInvalidArgument, "PostItemDocument(): Incorrect number of arguments. Expecting 5 ")
+	}
+
+	doc, err := CreateItemDocument(args[2:])
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := GetItemObject(ctx, args[0])
+	if err != nil {
+		fmt.Println("PostItemDocument() : Failed Could not Validate Item Object in Blockchain ", args[0])
+		return nil, err
+	}
+
+	if !CanManageItem(ctx, current.ItemID) {
+		return nil, NewError(ErrUnauthorized, "PostItemDocument(): Caller is not permitted to add documents to item "+current.ItemID)
+	}
+
+	itemObject := current
+	itemObject.ItemDocs = append([]ItemDocument(nil), current.ItemDocs...)
+	err = AddItemDocument(&itemObject, doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return ctx.Items.Replace(current, itemObject)
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// Verify that a document presented by a client matches one registered against the Item
+// Structure of args ItemID, Mode, Value where Mode is
+//   SHA256 - Value is the hex encoded SHA-256 of the document
+//   BASE64 - Value is the raw document content, base64 encoded; the chaincode computes the hash
+// ./peer chaincode query -l golang -n mycc -c '{"Function": "VerifyItemDocument", "Args": ["1000", "SHA256", "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"]}'
+// ./peer chaincode query -l golang -n mycc -c '{"Function": "VerifyItemDocument", "Args": ["1000", "BASE64", "dGVzdA=="]}'
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+func VerifyItemDocument(ctx *TxContext, function string, args []string) ([]byte, error) {
+
+	if len(args) != 3 {
+		fmt.Println("VerifyItemDocument(): Incorrect number of arguments. Expecting 3 ")
+		return nil, NewError(ErrInvalidArgument, "VerifyItemDocument(): Incorrect number of arguments. Expecting 3 ")
+	}
+
+	var docHash string
+	var err error
+
+	switch strings.ToUpper(args[1]) {
+	case "SHA256":
+		docHash, err = validateDocHash(args[2])
+		if err != nil {
+			return nil, err
+		}
+	case "BASE64":
+		content, err := base64.StdEncoding.DecodeString(args[2])
+		if err != nil {
+			return nil, NewError(ErrInvalidArgument, "VerifyItemDocument(): Document content should be base64 encoded")
+		}
+		docHash = HashDocument(content)
+	default:
+		return nil, NewError(ErrInvalidArgument, "VerifyItemDocument(): Mode should be SHA256 or BASE64 : "+args[1])
+	}
+
+	itemObject, err := ValidateItemSubmission(ctx, args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	result := ItemDocVerification{ItemID: itemObject.ItemID, DocHash: docHash}
+	if doc, found := FindItemDocument(itemObject, docHash); found {
+		result.Verified = true
+		result.MediaType = doc.MediaType
+		result.DocURI = doc.DocURI
+	}
+	fmt.Println("VerifyItemDocument() : ", result)
+
+	return json.Marshal(result)
+}
@@ -0,0 +1,312 @@
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package main
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+)
+
+//////////////////////////////////////////////////////////////////////////////////
+// Test ledger
+// Runs the chaincode over a MemoryStore, one TxContext per call, the way the
+// peer entry points do. The clock only moves when a test advances it.
+// The store holds back each call's writes until the call succeeds, as the
+// peer does, so a handler that reads its own writes fails here too. Writes a
+// test makes to the store directly are committed before the next call
+//////////////////////////////////////////////////////////////////////////////////
+
+const (
+	testArtefactKey     = "q83vEjRWeJCrze8SNFZ4kKvN7xI0VniQq83vEjRWeJA="
+	testArtefactKey2    = "ESIzRFVmd4iZqrvM3e7/ABEiM0RVZneImaq7zN3u/wA="
+	testArtefactKey3    = "AAECAwQFBgcICQoLDA0ODw=="
+	testArtefactContent = "\x89PNG\r\n\x1a\n"                                                // Opens as iVBORw0KGgo=
+	testDocHash         = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08" // SHA-256 of "test"
+	testPIIHMACKey      = "c2VjcmV0IG9mIHRoZSBlbmRvcnNpbmcgcGVlcnM="
+)
+
+// The tests run as a peer configured with AUCTION_PII_HMAC_KEY
+func init() {
+	piiHMACKey = loadPIIHMACKey(testPIIHMACKey)
+}
+
+var testStartTime = time.Date(2017, 3, 5, 10, 0, 0, 0, time.UTC)
+
+type testLedger struct {
+	t         *testing.T
+	cc        *SimpleChaincode
+	store     *MemoryStore
+	now       time.Time
+	role      string // Caller's role attribute, AH unless a test changes it
+	userID    string // Caller's userid attribute
+	transient map[string][]byte
+	nTx       int
+	lastTx    *MemoryTx
+}
+
+func newTestLedger(t *testing.T) *testLedger {
+
+	l := &testLedger{t: t, cc: new(SimpleChaincode), store: NewSnapshotMemoryStore(), now: testStartTime, role: "AH"}
+	_, err := l.cc.InitContext(l.context(), "init", []string{PartitionYear})
+	if err != nil {
+		t.Fatalf("Init() failed : %v", err)
+	}
+	l.store.Commit()
+	return l
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Users 100 (Auction House), 200, 300 and 400 (traders), item 1000 and the
+// auction 1111 of item 1000, not opened yet
+//////////////////////////////////////////////////////////////////////////////////
+func newSeededLedger(t *testing.T) *testLedger {
+
+	l := newTestLedger(t)
+	l.addUser("100", "AH")
+	for _, id := range []string{"200", "300", "400"} {
+		l.addUser(id, "TR")
+	}
+	l.addItem("1000")
+	l.addAuction("1111", "1000")
+	return l
+}
+
+func (l *testLedger) context() *TxContext {
+
+	l.nTx++
+	attrs := map[string]string{}
+	if l.role != "" {
+		attrs["role"] = l.role
+	}
+	if l.userID != "" {
+		attrs["userid"] = l.userID
+	}
+	l.lastTx = &MemoryTx{ID: "tx" + strconv.Itoa(l.nTx), Time: l.now, Attributes: attrs, Transient: l.transient}
+	return NewTxContext(l.store, l.lastTx)
+}
+
+func (l *testLedger) invoke(function string, args ...string) ([]byte, error) {
+
+	l.store.Commit()
+	buff, err := l.cc.InvokeContext(l.context(), function, args)
+	if err != nil {
+		l.store.Rollback()
+	} else {
+		l.store.Commit()
+	}
+	return buff, err
+}
+
+// Whatever a query writes is dropped
+func (l *testLedger) query(function string, args ...string) ([]byte, error) {
+
+	l.store.Commit()
+	defer l.store.Rollback()
+	return l.cc.QueryContext(l.context(), function, args)
+}
+
+// Transient data from name, value pairs
+func transient(fields ...string) map[string][]byte {
+
+	data := map[string][]byte{}
+	for i := 0; i+1 < len(fields); i += 2 {
+		data[fields[i]] = []byte(fields[i+1])
+	}
+	return data
+}
+
+// A call with transient data
+func (l *testLedger) invokeTransient(data map[string][]byte, function string, args ...string) ([]byte, error) {
+
+	l.transient = data
+	defer func() { l.transient = nil }()
+	return l.invoke(function, args...)
+}
+
+func (l *testLedger) queryTransient(data map[string][]byte, function string, args ...string) ([]byte, error) {
+
+	l.transient = data
+	defer func() { l.transient = nil }()
+	return l.query(function, args...)
+}
+
+func (l *testLedger) mustInvoke(function string, args ...string) []byte {
+
+	l.t.Helper()
+	buff, err := l.invoke(function, args...)
+	if err != nil {
+		l.t.Fatalf("%s%v failed : %v", function, args, err)
+	}
+	return buff
+}
+
+func (l *testLedger) mustQuery(function string, args ...string) []byte {
+
+	l.t.Helper()
+	buff, err := l.query(function, args...)
+	if err != nil {
+		l.t.Fatalf("%s%v failed : %v", function, args, err)
+	}
+	return buff
+}
+
+func (l *testLedger) advance(d time.Duration) {
+	l.now = l.now.Add(d)
+}
+
+func (l *testLedger) addUser(id string, userType string) {
+	l.t.Helper()
+	l.mustInvoke("PostUser", id, "USER", "User "+id, userType, "1 Main Street", "9198063535", id+"@example.com", "SUNTRUST", "00017102345", "0234678")
+}
+
+func (l *testLedger) addItem(id string) {
+	l.t.Helper()
+	l.mustInvoke("PostItem", id, "ARTINV", "Item "+id, "Oil on canvas", "Original", "Landscape")
+}
+
+func (l *testLedger) addAuction(auctionID string, itemID string) {
+	l.t.Helper()
+	l.mustInvoke("PostAuctionRequest", auctionID, "AUCREQ", itemID, "100", l.now.Format("2006-01-02"), "INIT", "", "")
+}
+
+func (l *testLedger) openAuction(auctionID string, minutes int) {
+	l.t.Helper()
+	l.mustInvoke("OpenAuctionForBids", auctionID, "OPENAUC", strconv.Itoa(minutes))
+}
+
+func (l *testLedger) bid(auctionID string, bidNo string, buyerID string, price string) ([]byte, error) {
+	return l.invoke("PostBid", auctionID, "BID", bidNo, "1000", buyerID, price)
+}
+
+func (l *testLedger) mustBid(auctionID string, bidNo string, buyerID string, price string) {
+	l.t.Helper()
+	if _, err := l.bid(auctionID, bidNo, buyerID, price); err != nil {
+		l.t.Fatalf("PostBid %s/%s failed : %v", auctionID, bidNo, err)
+	}
+}
+
+func (l *testLedger) auction(auctionID string) AuctionRequest {
+
+	l.t.Helper()
+	aucR, err := JSONtoAucReq(l.mustQuery("GetAuctionRequest", auctionID))
+	if err != nil {
+		l.t.Fatal(err)
+	}
+	return aucR
+}
+
+func (l *testLedger) item(itemID string) ItemObject {
+
+	l.t.Helper()
+	item, err := JSONtoAR(l.mustQuery("GetItem", itemID))
+	if err != nil {
+		l.t.Fatal(err)
+	}
+	return item
+}
+
+func (l *testLedger) artefact(itemID string) ItemArtefact {
+
+	l.t.Helper()
+	art, err := JSONtoArtefact(l.mustQuery("GetItemArtefact", itemID))
+	if err != nil {
+		l.t.Fatal(err)
+	}
+	return art
+}
+
+// The event the last transaction set, decoded
+func (l *testLedger) events() []AuctionEvent {
+
+	l.t.Helper()
+	if len(l.lastTx.Events) == 0 {
+		return nil
+	}
+	var env EventEnvelope
+	if err := json.Unmarshal(l.lastTx.Events[len(l.lastTx.Events)-1].Payload, &env); err != nil {
+		l.t.Fatal(err)
+	}
+	return env.Events
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// One call of a function in a table test
+// Each case runs on a fresh seeded ledger, after its setup
+//////////////////////////////////////////////////////////////////////////////////
+type functionCase struct {
+	function  string
+	name      string
+	setup     func(l *testLedger)
+	role      string // Caller's role for the call, AH when empty
+	transient map[string][]byte
+	args      []string
+	code      ErrorCode // Expected error code, empty for success
+	check     func(t *testing.T, l *testLedger, buff []byte)
+}
+
+func runFunctionCases(t *testing.T, cases []functionCase, call func(l *testLedger, function string, args ...string) ([]byte, error)) {
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.function+"/"+tc.name, func(t *testing.T) {
+			l := newSeededLedger(t)
+			if tc.setup != nil {
+				tc.setup(l)
+			}
+			if tc.role != "" {
+				l.role = tc.role
+			}
+			l.transient = tc.transient
+			buff, err := call(l, tc.function, tc.args...)
+			l.transient = nil
+			assertErrorCode(t, err, tc.code)
+			if err == nil && tc.check != nil {
+				tc.check(t, l, buff)
+			}
+		})
+	}
+}
+
+func assertErrorCode(t *testing.T, err error, code ErrorCode) {
+
+	t.Helper()
+	if code == "" {
+		if err != nil {
+			t.Fatalf("unexpected error : %v", err)
+		}
+		return
+	}
+	if err == nil {
+		t.Fatalf("expected %s, got no error", code)
+	}
+	if got := ErrorCodeOf(err); got != code {
+		t.Fatalf("expected %s, got %s : %v", code, got, err)
+	}
+}
+
+func decodeJSON(t *testing.T, buff []byte, v interface{}) {
+
+	t.Helper()
+	if err := json.Unmarshal(buff, v); err != nil {
+		t.Fatalf("cannot decode %s : %v", buff, err)
+	}
+}
@@ -0,0 +1,277 @@
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package main
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+//////////////////////////////////////////////////////////////////////////////////
+// Auction status transitions (INIT -> OPEN -> CLOSED) and bid ordering
+//////////////////////////////////////////////////////////////////////////////////
+
+// Auction IDs listed in a table partition
+func (l *testLedger) listedAuctions(function string) []string {
+
+	l.t.Helper()
+	var aucs []AuctionRequest
+	decodeJSON(l.t, l.mustQuery(function, "2017"), &aucs)
+	var ids []string
+	for _, aucR := range aucs {
+		ids = append(ids, aucR.AuctionID)
+	}
+	return ids
+}
+
+func (l *testLedger) assertStatus(auctionID string, status string, initList []string, openList []string) {
+
+	l.t.Helper()
+	if aucR := l.auction(auctionID); aucR.Status != status {
+		l.t.Fatalf("auction %s is %s, expected %s", auctionID, aucR.Status, status)
+	}
+	if got := l.listedAuctions("GetListOfInitAucs"); !equalStrings(got, initList) {
+		l.t.Fatalf("AucInitTable lists %v, expected %v", got, initList)
+	}
+	if got := l.listedAuctions("GetListOfOpenAucs"); !equalStrings(got, openList) {
+		l.t.Fatalf("AucOpenTable lists %v, expected %v", got, openList)
+	}
+}
+
+func equalStrings(a []string, b []string) bool {
+
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestAuctionStatusTransitions(t *testing.T) {
+
+	l := newSeededLedger(t)
+	l.assertStatus("1111", "INIT", []string{"1111"}, nil)
+
+	_, err := l.bid("1111", "1", "200", "300")
+	assertErrorCode(t, err, ErrAuctionNotOpen)
+	_, err = l.invoke("CloseAuction", "1111", "AUCREQ")
+	assertErrorCode(t, err, ErrAuctionNotOpen)
+
+	l.openAuction("1111", 3)
+	if events := l.events(); len(events) != 1 || events[0].Type != EventAuctionOpened || events[0].CloseDate != "2017-03-05 10:03:00" {
+		t.Fatalf("events %+v", events)
+	}
+	l.assertStatus("1111", "OPEN", nil, []string{"1111"})
+
+	l.mustBid("1111", "1", "200", "300")
+	l.advance(5 * time.Minute)
+	_, err = l.bid("1111", "2", "300", "400")
+	assertErrorCode(t, err, ErrAuctionNotOpen)
+
+	l.mustInvoke("CloseAuction", "1111", "AUCREQ")
+	l.assertStatus("1111", "CLOSED", nil, nil)
+
+	// A closed auction stays closed
+	_, err = l.invoke("OpenAuctionForBids", "1111", "OPENAUC", "3")
+	assertErrorCode(t, err, ErrConflict)
+	_, err = l.invoke("CloseAuction", "1111", "AUCREQ")
+	assertErrorCode(t, err, ErrConflict)
+	l.userID = "100"
+	_, err = l.invoke("ExtendAuction", "1111", "EXTAUC", "5")
+	assertErrorCode(t, err, ErrAuctionNotOpen)
+	l.userID = ""
+	_, err = l.invoke("BuyItNow", "1111", "BID", "3", "1000", "400", "1000")
+	assertErrorCode(t, err, ErrAuctionNotOpen)
+	l.assertStatus("1111", "CLOSED", nil, nil)
+}
+
+func TestExtendedAuctionTakesLateBids(t *testing.T) {
+
+	l := newSeededLedger(t)
+	l.openAuction("1111", 3)
+	l.advance(2 * time.Minute)
+	l.userID = "100"
+	l.mustInvoke("ExtendAuction", "1111", "EXTAUC", "5")
+	l.userID = ""
+	if events := l.events(); len(events) != 1 || events[0].Type != EventAuctionExtended {
+		t.Fatalf("events %+v", events)
+	}
+
+	l.advance(4 * time.Minute)
+	l.mustBid("1111", "1", "200", "300")
+
+	// Not expired yet at 10:06, expired at 10:09
+	l.mustInvoke("CloseOpenAuctions", "CLAUC")
+	l.assertStatus("1111", "OPEN", nil, []string{"1111"})
+	l.advance(3 * time.Minute)
+	l.mustInvoke("CloseOpenAuctions", "CLAUC")
+	l.assertStatus("1111", "CLOSED", nil, nil)
+}
+
+func TestClosingWithoutBids(t *testing.T) {
+
+	l := newSeededLedger(t)
+	l.openAuction("1111", 3)
+	l.advance(5 * time.Minute)
+	l.mustInvoke("CloseAuction", "1111", "AUCREQ")
+
+	if events := l.events(); len(events) != 2 || events[0].Type != EventAuctionClosed || events[1].Type != EventNoSale {
+		t.Fatalf("events %+v", events)
+	}
+	if sizes := l.store.TableSizes(); sizes["TransTable"] != 0 {
+		t.Fatalf("%d transactions posted", sizes["TransTable"])
+	}
+}
+
+func TestBidsMustRise(t *testing.T) {
+
+	l := newSeededLedger(t)
+	l.openAuction("1111", 3)
+
+	steps := []struct {
+		bidNo string
+		buyer string
+		price string
+		code  ErrorCode
+	}{
+		{"1", "200", "300", ""},
+		{"2", "300", "300", ErrBidTooLow}, // Equal is not enough
+		{"3", "300", "299", ErrBidTooLow},
+		{"4", "300", "301", ""},
+		{"5", "200", "1000", ""},
+		{"6", "400", "999", ErrBidTooLow},
+	}
+	for _, step := range steps {
+		_, err := l.bid("1111", step.bidNo, step.buyer, step.price)
+		assertErrorCode(t, err, step.code)
+	}
+
+	var bid Bid
+	decodeJSON(t, l.mustQuery("GetHighestBid", "1111"), &bid)
+	if bid.BidNo != "5" || bid.BuyerID != "200" {
+		t.Fatalf("highest bid %+v", bid)
+	}
+	if n := string(l.mustQuery("GetNoOfBidsReceived", "1111")); n != "3" {
+		t.Fatalf("%s bids accepted", n)
+	}
+}
+
+func TestOutbidEvents(t *testing.T) {
+
+	l := newSeededLedger(t)
+	l.openAuction("1111", 3)
+	l.mustBid("1111", "1", "200", "300")
+	if events := l.events(); len(events) != 1 || events[0].Type != EventNewHighBid {
+		t.Fatalf("events %+v", events)
+	}
+
+	l.mustBid("1111", "2", "300", "400")
+	events := l.events()
+	if len(events) != 2 || events[0].Type != EventNewHighBid || events[1].Type != EventOutbid || events[1].UserID != "200" {
+		t.Fatalf("events %+v", events)
+	}
+
+	// Raising one's own bid outbids nobody
+	l.mustBid("1111", "3", "300", "500")
+	if events := l.events(); len(events) != 1 {
+		t.Fatalf("events %+v", events)
+	}
+}
+
+func TestFailedBidRaisesNoEvents(t *testing.T) {
+
+	l := newSeededLedger(t)
+	l.openAuction("1111", 3)
+	l.mustBid("1111", "1", "200", "300")
+	_, err := l.bid("1111", "2", "300", "100")
+	assertErrorCode(t, err, ErrBidTooLow)
+	if len(l.lastTx.Events) != 0 {
+		t.Fatalf("events %+v", l.lastTx.Events)
+	}
+}
+
+// Bid numbers compare as numbers, so bid 10 comes after bid 9
+func TestBidsListedInBidNumberOrder(t *testing.T) {
+
+	l := newSeededLedger(t)
+	l.openAuction("1111", 30)
+	for n := 1; n <= 12; n++ {
+		buyer := []string{"200", "300", "400"}[n%3]
+		l.mustBid("1111", strconv.Itoa(n), buyer, strconv.Itoa(100+n*10))
+	}
+
+	var bids []Bid
+	decodeJSON(t, l.mustQuery("GetListOfBids", "1111"), &bids)
+	if len(bids) != 12 {
+		t.Fatalf("%d bids", len(bids))
+	}
+	for i, bid := range bids {
+		if bid.BidNo != strconv.Itoa(i+1) {
+			t.Fatalf("bid %d is %s", i+1, bid.BidNo)
+		}
+	}
+
+	var bid Bid
+	decodeJSON(t, l.mustQuery("GetHighestBid", "1111"), &bid)
+	if bid.BidNo != "12" {
+		t.Fatalf("highest bid %+v", bid)
+	}
+}
+
+func TestBidPagesCoverEveryBid(t *testing.T) {
+
+	l := newSeededLedger(t)
+	l.openAuction("1111", 30)
+	for n := 1; n <= 7; n++ {
+		l.mustBid("1111", strconv.Itoa(n), "200", strconv.Itoa(100+n))
+	}
+
+	var seen []string
+	token := ""
+	for pages := 0; ; pages++ {
+		if pages > 7 {
+			t.Fatal("paging does not end")
+		}
+		args := []string{"1111", "pageSize=3", "sort=BidPrice:desc"}
+		if token != "" {
+			args = append(args, "token="+token)
+		}
+		var page struct {
+			Items     []Bid
+			NextToken string
+		}
+		decodeJSON(t, l.mustQuery("GetListOfBids", args...), &page)
+		for _, bid := range page.Items {
+			seen = append(seen, bid.BidNo)
+		}
+		if page.NextToken == "" {
+			break
+		}
+		token = page.NextToken
+	}
+	if !equalStrings(seen, []string{"7", "6", "5", "4", "3", "2", "1"}) {
+		t.Fatalf("bids %v", seen)
+	}
+}
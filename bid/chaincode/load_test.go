@@ -0,0 +1,385 @@
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+//////////////////////////////////////////////////////////////////////////////////
+// Load generator
+// Opens synthetic auctions on a MemoryStore and posts rising bids on them from
+// several goroutines, querying the auction after every bid. Each call goes
+// through a countingStore, so the report gives for every function the latency
+// percentiles and the rows the call had to read. Every auction is bid on by one
+// goroutine only: on a peer, concurrent bids on the same auction would fail
+// validation rather than interleave
+//   go test -run TestLoad -v -load.auctions=16 -load.bids=2000 -load.workers=8
+//////////////////////////////////////////////////////////////////////////////////
+
+var (
+	loadAuctions = flag.Int("load.auctions", 8, "auctions opened by the load generator")
+	loadBids     = flag.Int("load.bids", 100, "bids posted on each auction")
+	loadWorkers  = flag.Int("load.workers", 4, "goroutines posting bids")
+)
+
+// Queries run after every bid
+var loadQueries = []string{"GetHighestBid", "GetLastBid", "GetNoOfBidsReceived"}
+
+const loadBuyers = 20
+
+//////////////////////////////////////////////////////////////////////////////////
+// A TableStore counting what one call reads
+//////////////////////////////////////////////////////////////////////////////////
+type countingStore struct {
+	TableStore
+	gets    int // GetRow calls
+	scanned int // Rows passed to ScanRows and ScanRowsAfter callbacks
+}
+
+func (s *countingStore) GetRow(tableName string, keys []string) ([]byte, bool, error) {
+	s.gets++
+	return s.TableStore.GetRow(tableName, keys)
+}
+
+func (s *countingStore) ScanRows(tableName string, keys []string, fn func(row LedgerRow) error) error {
+	return s.TableStore.ScanRows(tableName, keys, func(row LedgerRow) error {
+		s.scanned++
+		return fn(row)
+	})
+}
+
+func (s *countingStore) ScanRowsAfter(tableName string, keys []string, after []string, fn func(row LedgerRow) error) error {
+	return s.TableStore.ScanRowsAfter(tableName, keys, after, func(row LedgerRow) error {
+		s.scanned++
+		return fn(row)
+	})
+}
+
+// Calls of one function
+type callStats struct {
+	latencies []time.Duration
+	gets      int
+	scanned   int
+	maxScan   int
+	errors    int
+}
+
+type loadGenerator struct {
+	cc    *SimpleChaincode
+	store *MemoryStore
+	now   time.Time
+	nTx   int64
+
+	mu    sync.Mutex
+	stats map[string]*callStats
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// A ledger with an Auction House (100), buyers 200.. and the auctions 5000..,
+// open for a day, of items 1000..
+//////////////////////////////////////////////////////////////////////////////////
+func newLoadGenerator(tb testing.TB, auctions int) *loadGenerator {
+
+	tb.Helper()
+	restore := quietStdout()
+	defer restore()
+
+	g := &loadGenerator{cc: new(SimpleChaincode), store: NewMemoryStore(), now: testStartTime, stats: map[string]*callStats{}}
+	_, err := g.cc.InitContext(g.context(g.store), "init", []string{PartitionYear})
+	if err != nil {
+		tb.Fatalf("Init() failed : %v", err)
+	}
+
+	must := func(function string, args ...string) {
+		if _, err := g.cc.InvokeContext(g.context(g.store), function, args); err != nil {
+			tb.Fatalf("%s%v failed : %v", function, args, err)
+		}
+	}
+	must("PostUser", userArgs("100", "Auction House", "AH")...)
+	for i := 0; i < loadBuyers; i++ {
+		must("PostUser", userArgs(loadBuyerID(i), "Buyer", "TR")...)
+	}
+	for i := 0; i < auctions; i++ {
+		itemID, auctionID := strconv.Itoa(1000+i), loadAuctionID(i)
+		must("PostItem", itemID, "ARTINV", "Item "+itemID, "Oil on canvas", "Original", "Landscape")
+		must("PostAuctionRequest", auctionID, "AUCREQ", itemID, "100", "2017-03-05", "INIT", "", "")
+		must("OpenAuctionForBids", auctionID, "OPENAUC", "1440")
+	}
+	return g
+}
+
+func loadAuctionID(i int) string { return strconv.Itoa(5000 + i) }
+func loadBuyerID(i int) string   { return strconv.Itoa(200 + i) }
+
+func (g *loadGenerator) context(store TableStore) *TxContext {
+	n := atomic.AddInt64(&g.nTx, 1)
+	return NewTxContext(store, &MemoryTx{ID: "load" + strconv.FormatInt(n, 10), Time: g.now, Attributes: map[string]string{"role": "AH"}})
+}
+
+// Runs one call and records it under the function's name
+func (g *loadGenerator) call(function string, args ...string) ([]byte, error) {
+
+	store := &countingStore{TableStore: g.store}
+	ctx := g.context(store)
+	start := time.Now()
+	var buff []byte
+	var err error
+	if _, ok := InvokeFunc[function]; ok {
+		buff, err = g.cc.InvokeContext(ctx, function, args)
+	} else {
+		buff, err = g.cc.QueryContext(ctx, function, args)
+	}
+	elapsed := time.Since(start)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	st := g.stats[function]
+	if st == nil {
+		st = &callStats{}
+		g.stats[function] = st
+	}
+	st.latencies = append(st.latencies, elapsed)
+	st.gets += store.gets
+	st.scanned += store.scanned
+	if store.scanned > st.maxScan {
+		st.maxScan = store.scanned
+	}
+	if err != nil {
+		st.errors++
+	}
+	return buff, err
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Posts bids rising by 10 on every auction, each followed by loadQueries
+// Auction i goes to worker i % workers. Returns the time taken
+//////////////////////////////////////////////////////////////////////////////////
+func (g *loadGenerator) run(auctions int, bids int, workers int) time.Duration {
+
+	restore := quietStdout()
+	defer restore()
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := w; i < auctions; i += workers {
+				auctionID, itemID := loadAuctionID(i), strconv.Itoa(1000+i)
+				for n := 1; n <= bids; n++ {
+					g.call("PostBid", auctionID, "BID", strconv.Itoa(n), itemID, loadBuyerID(n%loadBuyers), strconv.Itoa(100+10*n))
+					for _, q := range loadQueries {
+						g.call(q, auctionID)
+					}
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	return time.Since(start)
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// One line per function: calls, errors, latency percentiles and reads per call
+//////////////////////////////////////////////////////////////////////////////////
+func (g *loadGenerator) report() string {
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var names []string
+	for name := range g.stats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-20s %7s %6s %10s %10s %10s %10s %10s %10s %9s\n", "function", "calls", "errors", "p50", "p90", "p99", "max", "gets/call", "rows/call", "max rows")
+	for _, name := range names {
+		st := g.stats[name]
+		n := len(st.latencies)
+		lat := append([]time.Duration(nil), st.latencies...)
+		sort.Slice(lat, func(i, j int) bool { return lat[i] < lat[j] })
+		fmt.Fprintf(&b, "%-20s %7d %6d %10s %10s %10s %10s %10.1f %10.1f %9d\n", name, n, st.errors,
+			percentile(lat, 0.50), percentile(lat, 0.90), percentile(lat, 0.99), lat[n-1],
+			float64(st.gets)/float64(n), float64(st.scanned)/float64(n), st.maxScan)
+	}
+	return b.String()
+}
+
+// p of sorted, nearest rank
+func percentile(sorted []time.Duration, p float64) time.Duration {
+
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(p*float64(len(sorted))+0.5) - 1
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(sorted) {
+		i = len(sorted) - 1
+	}
+	return sorted[i]
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// The chaincode logs every step with fmt.Println. Timing those writes would
+// measure the terminal, so they go to /dev/null while load runs
+//////////////////////////////////////////////////////////////////////////////////
+func quietStdout() (restore func()) {
+
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		return func() {}
+	}
+	stdout := os.Stdout
+	os.Stdout = devNull
+	return func() {
+		os.Stdout = stdout
+		devNull.Close()
+	}
+}
+
+func TestLoad(t *testing.T) {
+
+	auctions, bids, workers := *loadAuctions, *loadBids, *loadWorkers
+	if testing.Short() {
+		bids = 20
+	}
+	g := newLoadGenerator(t, auctions)
+	elapsed := g.run(auctions, bids, workers)
+
+	total := auctions * bids
+	t.Logf("%d auctions, %d bids each, %d workers : %s, %.0f bids/s\n%s", auctions, bids, workers, elapsed, float64(total)/elapsed.Seconds(), g.report())
+
+	for name, st := range g.stats {
+		if st.errors > 0 {
+			t.Errorf("%s failed %d times", name, st.errors)
+		}
+	}
+	if got := g.store.TableSizes()["BidTable"]; got != total {
+		t.Fatalf("BidTable has %d rows, expected %d", got, total)
+	}
+
+	// The bid path and the queries read the auction's BidSummary and the bid
+	// it points to, however many bids there are (see bid_summary.go). PostBid
+	// also reads the buyer and the auction, and the summary again to update it;
+	// the first bid of an auction has no high bid to read
+	reads := map[string]int{"PostBid": 5*total - auctions, "GetHighestBid": 2 * total, "GetLastBid": 2 * total, "GetNoOfBidsReceived": total}
+	for name, n := range reads {
+		if st := g.stats[name]; st.scanned != 0 || st.gets != n {
+			t.Errorf("%s scanned %d rows and read %d, expected none and %d", name, st.scanned, st.gets, n)
+		}
+	}
+	for i := 0; i < auctions; i++ {
+		var bid Bid
+		buff, _ := g.call("GetHighestBid", loadAuctionID(i))
+		decodeJSON(t, buff, &bid)
+		if bid.BidNo != strconv.Itoa(bids) || bid.BidPrice != strconv.Itoa(100+10*bids) {
+			t.Fatalf("auction %s : highest bid %+v", loadAuctionID(i), bid)
+		}
+	}
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Benchmarks on a single auction holding a fixed number of bids
+//   go test -run XXX -bench . -benchmem
+//////////////////////////////////////////////////////////////////////////////////
+
+var benchBidCounts = []int{10, 100, 1000}
+
+// Auction 5000 with n bids
+func newBenchAuction(b *testing.B, n int) *loadGenerator {
+
+	b.Helper()
+	restore := quietStdout()
+	defer restore()
+
+	g := newLoadGenerator(b, 1)
+	for i := 1; i <= n; i++ {
+		if _, err := g.call("PostBid", "5000", "BID", strconv.Itoa(i), "1000", loadBuyerID(i%loadBuyers), strconv.Itoa(100+10*i)); err != nil {
+			b.Fatal(err)
+		}
+	}
+	g.stats = map[string]*callStats{}
+	return g
+}
+
+func reportScans(b *testing.B, g *loadGenerator, function string) {
+
+	st := g.stats[function]
+	n := len(st.latencies)
+	b.ReportMetric(float64(st.scanned)/float64(n), "rows/op")
+	sort.Slice(st.latencies, func(i, j int) bool { return st.latencies[i] < st.latencies[j] })
+	b.ReportMetric(float64(percentile(st.latencies, 0.99).Nanoseconds()), "p99-ns")
+}
+
+func BenchmarkGetHighestBid(b *testing.B) {
+
+	for _, n := range benchBidCounts {
+		b.Run("bids="+strconv.Itoa(n), func(b *testing.B) {
+			g := newBenchAuction(b, n)
+			restore := quietStdout()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				g.call("GetHighestBid", "5000")
+			}
+			b.StopTimer()
+			restore()
+			reportScans(b, g, "GetHighestBid")
+		})
+	}
+}
+
+// Each bid is removed again, so that the auction keeps n bids
+func BenchmarkPostBid(b *testing.B) {
+
+	for _, n := range benchBidCounts {
+		b.Run("bids="+strconv.Itoa(n), func(b *testing.B) {
+			g := newBenchAuction(b, n)
+			bidNo, price := strconv.Itoa(n+1), strconv.Itoa(100+10*(n+1))
+			restore := quietStdout()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := g.call("PostBid", "5000", "BID", bidNo, "1000", "200", price); err != nil {
+					b.Fatal(err)
+				}
+				b.StopTimer()
+				g.store.DeleteRow("BidTable", []string{"5000", bidNo})
+				b.StartTimer()
+			}
+			b.StopTimer()
+			restore()
+			reportScans(b, g, "PostBid")
+		})
+	}
+}
@@ -0,0 +1,276 @@
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+//////////////////////////////////////////////////////////////////////////////////
+// Named arguments
+// The create and update invokes take a single JSON object with the fields of
+// the record, instead of the positional Args. RecType is implied by the function
+// ./peer chaincode invoke -l golang -n mycc -c '{"Function": "PostBid", "Args":["{\"AuctionID\":\"1111\",\"BidNo\":\"1\",\"ItemID\":\"1000\",\"BuyerID\":\"300\",\"BidPrice\":\"1200\"}"]}'
+//
+// Unknown fields, fields of the wrong JSON type and trailing data are rejected,
+// then the object is checked against its schema (the Validate methods below)
+// The object is turned into the positional Args before the request is
+// dispatched, so both forms go through the same checks in the handlers.
+// Positional Args are deprecated and are accepted until every client has moved
+//
+// The contract build takes the same structs as typed parameters (see contract.go)
+// The metadata tags mark the fields its generated schema leaves optional
+//////////////////////////////////////////////////////////////////////////////////
+type NamedArgs interface {
+	Validate(fname string) error
+	Positional() []string
+}
+
+var namedArgs = map[string]func() NamedArgs{
+	"PostUser":           func() NamedArgs { return &UserArgs{} },
+	"UpdateUser":         func() NamedArgs { return &UserArgs{} },
+	"PostItem":           func() NamedArgs { return &ItemArgs{} },
+	"UpdateItem":         func() NamedArgs { return &ItemArgs{} },
+	"PostItemDocument":   func() NamedArgs { return &ItemDocumentArgs{} },
+	"PostAuctionRequest": func() NamedArgs { return &AuctionRequestArgs{} },
+	"OpenAuctionForBids": func() NamedArgs { return &OpenAuctionArgs{} },
+	"PostBid":            func() NamedArgs { return &BidArgs{} },
+	"BuyItNow":           func() NamedArgs { return &BidArgs{} },
+	"PostTransaction":    func() NamedArgs { return &TransactionArgs{} },
+}
+
+// PostUser, UpdateUser
+type UserArgs struct {
+	UserID    string
+	Name      string
+	UserType  string
+	Address   string `metadata:",optional"`
+	Phone     string `metadata:",optional"`
+	Email     string `metadata:",optional"`
+	Bank      string `metadata:",optional"`
+	AccountNo string `metadata:",optional"`
+	RoutingNo string `metadata:",optional"`
+}
+
+// PostItem, UpdateItem. UpdateItem keeps the registered documents and takes none
+type ItemArgs struct {
+	ItemID      string
+	ItemDesc    string
+	ItemDetail  string         `metadata:",optional"`
+	ItemType    string         `metadata:",optional"`
+	ItemSubject string         `metadata:",optional"`
+	ItemDocs    []ItemDocument `metadata:",optional"`
+}
+
+type ItemDocumentArgs struct {
+	ItemID    string
+	DocHash   string
+	MediaType string
+	DocURI    string `metadata:",optional"`
+}
+
+type AuctionRequestArgs struct {
+	AuctionID      string
+	ItemID         string
+	AuctionHouseID string
+	RequestDate    string
+}
+
+type OpenAuctionArgs struct {
+	AuctionID string
+	Duration  int // Minutes
+}
+
+// PostBid, BuyItNow
+type BidArgs struct {
+	AuctionID string
+	BidNo     string
+	ItemID    string
+	BuyerID   string
+	BidPrice  string
+}
+
+type TransactionArgs struct {
+	AuctionID   string
+	ItemID      string
+	TransType   string
+	UserId      string
+	TransDate   string `metadata:",optional"`
+	HammerTime  string `metadata:",optional"`
+	HammerPrice string
+	Details     string `metadata:",optional"`
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Turn named arguments into positional ones
+// Args that are not a single JSON object are returned as they are
+//////////////////////////////////////////////////////////////////////////////////
+func ParseNamedArgs(fname string, args []string) ([]string, error) {
+
+	newArgs, ok := namedArgs[fname]
+	if len(args) != 1 || !strings.HasPrefix(strings.TrimSpace(args[0]), "{") {
+		if ok {
+			fmt.Println("ParseNamedArgs() : Positional arguments to " + fname + " are deprecated, pass a JSON object")
+		}
+		return args, nil
+	}
+	if !ok {
+		return nil, NewError(ErrInvalidArgument, fname+"(): Does not take named arguments")
+	}
+
+	named := newArgs()
+	dec := json.NewDecoder(bytes.NewReader([]byte(args[0])))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(named); err != nil {
+		fmt.Println("ParseNamedArgs() : Invalid arguments to ", fname, " : ", err)
+		return nil, NewError(ErrInvalidArgument, fname+"(): Invalid arguments : "+err.Error())
+	}
+	// A second value, or stray closing brackets, must not follow the object
+	if err := dec.Decode(&struct{}{}); err != io.EOF {
+		return nil, NewError(ErrInvalidArgument, fname+"(): Invalid arguments : Unexpected data after the JSON object")
+	}
+
+	if err := named.Validate(fname); err != nil {
+		return nil, err
+	}
+	return named.Positional(), nil
+}
+
+func (a *UserArgs) Validate(fname string) error {
+	if err := requireFields(fname, "UserID", a.UserID, "Name", a.Name, "UserType", a.UserType); err != nil {
+		return err
+	}
+	return integerField(fname, "UserID", a.UserID)
+}
+
+func (a *UserArgs) Positional() []string {
+	return []string{a.UserID, "USER", a.Name, a.UserType, a.Address, a.Phone, a.Email, a.Bank, a.AccountNo, a.RoutingNo}
+}
+
+func (a *ItemArgs) Validate(fname string) error {
+	if err := requireFields(fname, "ItemID", a.ItemID, "ItemDesc", a.ItemDesc); err != nil {
+		return err
+	}
+	if fname == "UpdateItem" && len(a.ItemDocs) > 0 {
+		return NewError(ErrInvalidArgument, "UpdateItem(): Invalid arguments : ItemDocs cannot be updated, use PostItemDocument")
+	}
+	for _, doc := range a.ItemDocs {
+		if err := requireFields(fname, "ItemDocs.DocHash", doc.DocHash, "ItemDocs.MediaType", doc.MediaType); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *ItemArgs) Positional() []string {
+	args := []string{a.ItemID, "ARTINV", a.ItemDesc, a.ItemDetail, a.ItemType, a.ItemSubject}
+	for _, doc := range a.ItemDocs {
+		args = append(args, doc.DocHash, doc.MediaType, doc.DocURI)
+	}
+	return args
+}
+
+func (a *ItemDocumentArgs) Validate(fname string) error {
+	return requireFields(fname, "ItemID", a.ItemID, "DocHash", a.DocHash, "MediaType", a.MediaType)
+}
+
+func (a *ItemDocumentArgs) Positional() []string {
+	return []string{a.ItemID, "ITEMDOC", a.DocHash, a.MediaType, a.DocURI}
+}
+
+func (a *AuctionRequestArgs) Validate(fname string) error {
+	if err := requireFields(fname, "AuctionID", a.AuctionID, "ItemID", a.ItemID, "AuctionHouseID", a.AuctionHouseID, "RequestDate", a.RequestDate); err != nil {
+		return err
+	}
+	if _, err := ParseRecordDate(a.RequestDate); err != nil {
+		return NewError(ErrInvalidArgument, fname+"(): Invalid arguments : RequestDate is not a valid date : "+a.RequestDate)
+	}
+	return nil
+}
+
+// Status and the dates are set by the chaincode as the auction progresses
+func (a *AuctionRequestArgs) Positional() []string {
+	return []string{a.AuctionID, "AUCREQ", a.ItemID, a.AuctionHouseID, a.RequestDate, "INIT", "", ""}
+}
+
+func (a *OpenAuctionArgs) Validate(fname string) error {
+	if err := requireFields(fname, "AuctionID", a.AuctionID); err != nil {
+		return err
+	}
+	if a.Duration <= 0 {
+		return NewError(ErrInvalidArgument, fname+"(): Invalid arguments : Duration should be a positive number of minutes")
+	}
+	return nil
+}
+
+func (a *OpenAuctionArgs) Positional() []string {
+	return []string{a.AuctionID, "OPENAUC", strconv.Itoa(a.Duration)}
+}
+
+func (a *BidArgs) Validate(fname string) error {
+	if err := requireFields(fname, "AuctionID", a.AuctionID, "BidNo", a.BidNo, "ItemID", a.ItemID, "BuyerID", a.BuyerID, "BidPrice", a.BidPrice); err != nil {
+		return err
+	}
+	for _, f := range []struct{ name, value string }{{"AuctionID", a.AuctionID}, {"BidNo", a.BidNo}, {"BidPrice", a.BidPrice}} {
+		if err := integerField(fname, f.name, f.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *BidArgs) Positional() []string {
+	return []string{a.AuctionID, "BID", a.BidNo, a.ItemID, a.BuyerID, a.BidPrice}
+}
+
+func (a *TransactionArgs) Validate(fname string) error {
+	return requireFields(fname, "AuctionID", a.AuctionID, "ItemID", a.ItemID, "TransType", a.TransType, "UserId", a.UserId, "HammerPrice", a.HammerPrice)
+}
+
+func (a *TransactionArgs) Positional() []string {
+	return []string{a.AuctionID, "POSTTRAN", a.ItemID, a.TransType, a.UserId, a.TransDate, a.HammerTime, a.HammerPrice, a.Details}
+}
+
+// Fields that must not be empty, as name, value pairs
+func requireFields(fname string, fields ...string) error {
+
+	var missing []string
+	for i := 0; i+1 < len(fields); i += 2 {
+		if strings.TrimSpace(fields[i+1]) == "" {
+			missing = append(missing, fields[i])
+		}
+	}
+	if len(missing) > 0 {
+		return NewError(ErrInvalidArgument, fname+"(): Invalid arguments : Missing "+strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func integerField(fname string, name string, value string) error {
+	if _, err := strconv.Atoi(value); err != nil {
+		return NewError(ErrInvalidArgument, fname+"(): Invalid arguments : "+name+" should be an integer : "+value)
+	}
+	return nil
+}
@@ -0,0 +1,571 @@
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//////////////////////////////////////////////////////////////////////////////////
+// Paging, sorting and filtering for the list queries
+//
+// Every list query takes its partial key as before, optionally followed by
+// name=value options:
+//   pageSize=N         number of records per page (default 50, at most 500)
+//   token=...          the NextToken returned with the previous page
+//   sort=F1[:desc],F2  order by record fields; prices and the like as numbers
+//   status=S           only records whose Status is S
+//   minPrice=, maxPrice=  price range, inclusive
+//   from=, to=         date range, inclusive ("2006-01-02 15:04:05")
+//
+// The partition key of the category lists may be a range such as "2016..2017".
+// Without options the query returns a plain JSON array, as it always has.
+// With options it returns a ListPage; NextToken is empty on the last page.
+// A token only goes with the keys, sort and filters of the query that made it.
+// Lists in row key order resume from the token's key and stop once the page is
+// full; sorted lists look at every row, the next page being anywhere among them.
+// ./peer chaincode query -l golang -n mycc -c '{"Function": "GetListOfBids", "Args": ["1111", "pageSize=20", "sort=BidPrice:desc"]}'
+// ./peer chaincode query -l golang -n mycc -c '{"Function": "GetListOfOpenAucs", "Args": ["2016", "pageSize=20", "token=eyJUIjoiQXVjT3Blb..."]}'
+//////////////////////////////////////////////////////////////////////////////////
+
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 500
+)
+
+type RecordDecoder func(data []byte) (interface{}, error)
+
+//////////////////////////////////////////////////////////////////////////////////
+// Describes one list query: where the rows live, how to decode them and
+// which record fields the sort and filter options apply to
+//////////////////////////////////////////////////////////////////////////////////
+type ListSpec struct {
+	TableName     string
+	Record        interface{} // Zero value of the record type, used to validate field names
+	Decode        RecordDecoder
+	DefaultSort   []SortField // Order used when no sort option is given; row key order after that
+	PriceField    string      // Field for minPrice/maxPrice, empty if the records carry no price
+	NumericFields []string    // Fields other than PriceField that sort as numbers; the rest sort as strings
+	DateField     string      // Field for from/to, empty if the records carry no date
+	Partitioned   bool        // The first key is a time partition and may be given as a range (see partition.go)
+}
+
+type SortField struct {
+	Field   string
+	Desc    bool
+	numeric bool // Set by QueryList from the ListSpec
+}
+
+type ListOptions struct {
+	Paged    bool // Set when any option was given; the result is then a ListPage
+	PageSize int
+	Token    string
+	Sort     []SortField
+	Status   string
+	MinPrice *float64
+	MaxPrice *float64
+	From     string
+	To       string
+}
+
+type ListPage struct {
+	Items     []interface{}
+	Count     int
+	NextToken string
+}
+
+// Stops the scan of a list in row key order once the page is full
+var errListPageFull = errors.New("QueryList(): Page full")
+
+var listOptionNames = map[string]bool{"pageSize": true, "token": true, "sort": true, "status": true, "minPrice": true, "maxPrice": true, "from": true, "to": true}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Split the arguments of a list query into the partial key and the options
+// Options are the trailing name=value arguments
+//////////////////////////////////////////////////////////////////////////////////
+func ParseListOptions(args []string) ([]string, ListOptions, error) {
+
+	opts := ListOptions{PageSize: DefaultPageSize}
+
+	n := len(args)
+	for n > 0 && isListOption(args[n-1]) {
+		n--
+	}
+
+	for _, arg := range args[n:] {
+		opts.Paged = true
+		i := strings.Index(arg, "=")
+		name, value := arg[:i], arg[i+1:]
+
+		switch name {
+		case "pageSize":
+			size, err := strconv.Atoi(value)
+			if err != nil || size < 1 || size > MaxPageSize {
+				return nil, opts, NewError(ErrInvalidArgument, fmt.Sprintf("ParseListOptions(): pageSize should be between 1 and %d : %s", MaxPageSize, value))
+			}
+			opts.PageSize = size
+		case "token":
+			opts.Token = value
+		case "sort":
+			order, err := parseSortOption(value)
+			if err != nil {
+				return nil, opts, err
+			}
+			opts.Sort = order
+		case "status":
+			opts.Status = value
+		case "minPrice", "maxPrice":
+			price, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, opts, NewError(ErrInvalidArgument, "ParseListOptions(): "+name+" should be a number : "+value)
+			}
+			if name == "minPrice" {
+				opts.MinPrice = &price
+			} else {
+				opts.MaxPrice = &price
+			}
+		case "from":
+			opts.From = value
+		case "to":
+			opts.To = value
+		}
+	}
+
+	return args[:n], opts, nil
+}
+
+func isListOption(arg string) bool {
+	i := strings.Index(arg, "=")
+	return i > 0 && listOptionNames[arg[:i]]
+}
+
+func parseSortOption(value string) ([]SortField, error) {
+
+	var order []SortField
+	for _, part := range strings.Split(value, ",") {
+		f := SortField{Field: part}
+		if i := strings.Index(part, ":"); i >= 0 {
+			f.Field = part[:i]
+			switch strings.ToLower(part[i+1:]) {
+			case "asc":
+			case "desc":
+				f.Desc = true
+			default:
+				return nil, NewError(ErrInvalidArgument, "ParseListOptions(): sort direction should be asc or desc : "+part)
+			}
+		}
+		if f.Field == "" {
+			return nil, NewError(ErrInvalidArgument, "ParseListOptions(): Empty sort field : "+value)
+		}
+		order = append(order, f)
+	}
+	return order, nil
+}
+
+func sortOptionString(order []SortField) string {
+
+	parts := make([]string, len(order))
+	for i, f := range order {
+		parts[i] = f.Field
+		if f.Desc {
+			parts[i] += ":desc"
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Run a list query
+// Rows are read one at a time. Without paging every matching record is kept;
+// with paging only the best pageSize+1 records after the token are kept,
+// the extra one telling us whether there is a next page
+//////////////////////////////////////////////////////////////////////////////////
+func QueryList(store TableStore, spec ListSpec, args []string) ([]byte, error) {
+
+	keys, opts, err := ParseListOptions(args)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(keys) < 1 {
+		return nil, NewError(ErrInvalidArgument, "QueryList(): At least 1 key must be provided for "+spec.TableName)
+	}
+
+	order := opts.Sort
+	if len(order) == 0 {
+		order = spec.DefaultSort
+	}
+	order = append([]SortField(nil), order...)
+	for i, f := range order {
+		if _, ok := recordField(spec.Record, f.Field); !ok {
+			return nil, NewError(ErrInvalidArgument, "QueryList(): Cannot sort "+spec.TableName+" on "+f.Field)
+		}
+		order[i].numeric = spec.isNumeric(f.Field)
+	}
+	keyOrder := len(order) == 0
+
+	err = checkListFilters(spec, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var cursor *listPosition
+	if opts.Token != "" {
+		cursor, err = decodeListToken(opts.Token, spec.TableName, keys, opts, order)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	buf := listBuffer{order: order}
+	if opts.Paged {
+		buf.limit = opts.PageSize + 1
+	}
+
+	partitions := []string{keys[0]}
+	if spec.Partitioned {
+		partitions, err = ExpandPartitions(store, keys[0])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	scan := func(row LedgerRow) error {
+		// In row key order the rows come in the order of the page
+		if keyOrder && buf.limit > 0 && len(buf.entries) >= buf.limit {
+			return errListPageFull
+		}
+
+		rec, err := spec.Decode(row.Value)
+		if err != nil {
+			fmt.Println("QueryList() Failed : Ummarshall error on ", spec.TableName)
+			return err
+		}
+
+		if !matchListFilters(spec, opts, rec) {
+			return nil
+		}
+
+		e := listEntry{rec: rec, pos: newListPosition(row.Keys, rec, order)}
+		if cursor != nil && comparePositions(e.pos, *cursor, order) <= 0 {
+			return nil
+		}
+		buf.add(e)
+		return nil
+	}
+
+	for _, p := range partitions {
+		prefix := append([]string{p}, keys[1:]...)
+		if !keyOrder {
+			err = store.ScanRows(spec.TableName, prefix, scan)
+		} else if cursor == nil || p > cursor.Keys[0] {
+			err = store.ScanRowsAfter(spec.TableName, prefix, nil, scan)
+		} else if p == cursor.Keys[0] {
+			err = store.ScanRowsAfter(spec.TableName, prefix, cursor.Keys, scan)
+		} else {
+			// Partitions before the token's were done on earlier pages
+			continue
+		}
+		if err == errListPageFull {
+			break
+		}
+		if err != nil {
+			return nil, WrapError(err, "QueryList() operation failed on "+spec.TableName)
+		}
+	}
+
+	entries := buf.sorted()
+
+	if !opts.Paged {
+		return json.Marshal(listRecords(entries))
+	}
+
+	page := ListPage{}
+	if len(entries) > opts.PageSize {
+		entries = entries[:opts.PageSize]
+		page.NextToken = encodeListToken(spec.TableName, keys, opts, order, entries[len(entries)-1].pos)
+	}
+	page.Items = listRecords(entries)
+	page.Count = len(page.Items)
+
+	return json.Marshal(page)
+}
+
+func (spec ListSpec) isNumeric(field string) bool {
+
+	if field == spec.PriceField {
+		return true
+	}
+	for _, f := range spec.NumericFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Filters
+//////////////////////////////////////////////////////////////////////////////////
+func checkListFilters(spec ListSpec, opts ListOptions) error {
+
+	if opts.Status != "" {
+		if _, ok := recordField(spec.Record, "Status"); !ok {
+			return NewError(ErrInvalidArgument, "QueryList(): "+spec.TableName+" cannot be filtered by status")
+		}
+	}
+	if (opts.MinPrice != nil || opts.MaxPrice != nil) && spec.PriceField == "" {
+		return NewError(ErrInvalidArgument, "QueryList(): "+spec.TableName+" cannot be filtered by price")
+	}
+	if (opts.From != "" || opts.To != "") && spec.DateField == "" {
+		return NewError(ErrInvalidArgument, "QueryList(): "+spec.TableName+" cannot be filtered by date")
+	}
+	return nil
+}
+
+func matchListFilters(spec ListSpec, opts ListOptions, rec interface{}) bool {
+
+	if opts.Status != "" {
+		if status, _ := recordField(rec, "Status"); status != opts.Status {
+			return false
+		}
+	}
+
+	if opts.MinPrice != nil || opts.MaxPrice != nil {
+		value, _ := recordField(rec, spec.PriceField)
+		price, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return false
+		}
+		if opts.MinPrice != nil && price < *opts.MinPrice {
+			return false
+		}
+		if opts.MaxPrice != nil && price > *opts.MaxPrice {
+			return false
+		}
+	}
+
+	if opts.From != "" || opts.To != "" {
+		date, _ := recordField(rec, spec.DateField)
+		if date == "" {
+			return false
+		}
+		if opts.From != "" && date < opts.From {
+			return false
+		}
+		if opts.To != "" && date > opts.To {
+			return false
+		}
+	}
+
+	return true
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Returns a string field of a record by name
+//////////////////////////////////////////////////////////////////////////////////
+func recordField(rec interface{}, name string) (string, bool) {
+
+	v := reflect.ValueOf(rec)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return "", false
+	}
+	f := v.FieldByName(name)
+	if !f.IsValid() || f.Kind() != reflect.String {
+		return "", false
+	}
+	return f.String(), true
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Ordering
+// A record's position is its sort field values followed by its row key, so
+// two records never compare equal and a page boundary is always well defined
+//////////////////////////////////////////////////////////////////////////////////
+type listPosition struct {
+	Values []string
+	Keys   []string
+}
+
+type listEntry struct {
+	rec interface{}
+	pos listPosition
+}
+
+func newListPosition(keys []string, rec interface{}, order []SortField) listPosition {
+
+	pos := listPosition{Values: make([]string, len(order)), Keys: keys}
+	for i, f := range order {
+		pos.Values[i], _ = recordField(rec, f.Field)
+	}
+	return pos
+}
+
+func comparePositions(a listPosition, b listPosition, order []SortField) int {
+
+	for i, f := range order {
+		c := compareValues(a.Values[i], b.Values[i], f.numeric)
+		if f.Desc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	// As the stores order rows
+	return compareKeys(a.Keys, b.Keys)
+}
+
+// Numeric columns compare as numbers, with values that are not numbers first;
+// the others compare as strings, which suits dates in the "2006-01-02 15:04:05" layout
+func compareValues(a string, b string, numeric bool) int {
+
+	if numeric {
+		an, aerr := strconv.ParseFloat(a, 64)
+		bn, berr := strconv.ParseFloat(b, 64)
+		switch {
+		case aerr == nil && berr == nil:
+			switch {
+			case an < bn:
+				return -1
+			case an > bn:
+				return 1
+			}
+			return 0
+		case aerr == nil:
+			return 1
+		case berr == nil:
+			return -1
+		}
+	}
+	return strings.Compare(a, b)
+}
+
+type listBuffer struct {
+	order   []SortField
+	limit   int // 0 keeps every entry
+	entries []listEntry
+}
+
+func (b *listBuffer) add(e listEntry) {
+
+	if b.limit == 0 {
+		b.entries = append(b.entries, e)
+		return
+	}
+
+	i := sort.Search(len(b.entries), func(i int) bool {
+		return comparePositions(b.entries[i].pos, e.pos, b.order) > 0
+	})
+	if i >= b.limit {
+		return
+	}
+	b.entries = append(b.entries, listEntry{})
+	copy(b.entries[i+1:], b.entries[i:])
+	b.entries[i] = e
+	if len(b.entries) > b.limit {
+		b.entries = b.entries[:b.limit]
+	}
+}
+
+func (b *listBuffer) sorted() []listEntry {
+	if b.limit == 0 {
+		sort.Sort(b)
+	}
+	return b.entries
+}
+
+func (b *listBuffer) Len() int      { return len(b.entries) }
+func (b *listBuffer) Swap(i, j int) { b.entries[i], b.entries[j] = b.entries[j], b.entries[i] }
+func (b *listBuffer) Less(i, j int) bool {
+	return comparePositions(b.entries[i].pos, b.entries[j].pos, b.order) < 0
+}
+
+func listRecords(entries []listEntry) []interface{} {
+
+	recs := make([]interface{}, len(entries))
+	for i, e := range entries {
+		recs[i] = e.rec
+	}
+	return recs
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Continuation tokens
+// The token is opaque to clients. It carries the position of the last record
+// returned, along with the table, keys, sort order and filters it is valid for
+//////////////////////////////////////////////////////////////////////////////////
+type listToken struct {
+	T string   // Table name
+	K []string // Partial key, as given
+	S string   // Sort order
+	F string   // Hash of the filters
+	P listPosition
+}
+
+func encodeListToken(tableName string, keys []string, opts ListOptions, order []SortField, pos listPosition) string {
+
+	buff, _ := json.Marshal(listToken{T: tableName, K: keys, S: sortOptionString(order), F: listFilterHash(opts), P: pos})
+	return base64.RawURLEncoding.EncodeToString(buff)
+}
+
+func decodeListToken(token string, tableName string, keys []string, opts ListOptions, order []SortField) (*listPosition, error) {
+
+	var t listToken
+
+	buff, err := base64.RawURLEncoding.DecodeString(token)
+	if err == nil {
+		err = json.Unmarshal(buff, &t)
+	}
+	if err != nil {
+		return nil, NewError(ErrInvalidArgument, "QueryList(): Invalid continuation token")
+	}
+
+	if t.T != tableName || strings.Join(t.K, "|") != strings.Join(keys, "|") || t.S != sortOptionString(order) ||
+		t.F != listFilterHash(opts) || len(t.P.Values) != len(order) || len(t.P.Keys) == 0 {
+		return nil, NewError(ErrInvalidArgument, "QueryList(): Continuation token does not belong to this query")
+	}
+	return &t.P, nil
+}
+
+func listFilterHash(opts ListOptions) string {
+
+	price := func(p *float64) string {
+		if p == nil {
+			return ""
+		}
+		return strconv.FormatFloat(*p, 'g', -1, 64)
+	}
+	filters := []string{opts.Status, price(opts.MinPrice), price(opts.MaxPrice), opts.From, opts.To}
+	sum := sha256.Sum256([]byte(strings.Join(filters, "\x00")))
+	return hex.EncodeToString(sum[:8])
+}
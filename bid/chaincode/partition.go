@@ -0,0 +1,320 @@
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+//////////////////////////////////////////////////////////////////////////////////
+// Time partitions
+// UserCatTable, ItemCatTable, ItemTypeTable, AucInitTable and AucOpenTable are
+// keyed first by a time partition taken from the record's own date:
+//   Users and Items  - RegisteredDate
+//   AucInitTable     - RequestDate
+//   AucOpenTable     - OpenDate
+// The bucket is chosen at deploy time and is either YEAR ("2017") or MONTH ("2017-03")
+// ./peer chaincode deploy -l golang -n mycc -c '{"Function": "init", "Args": ["MONTH"]}'
+//
+// Rows written before partitions existed all sit in the "2016" partition.
+// ReindexPartitions moves them to where they belong
+//////////////////////////////////////////////////////////////////////////////////
+
+const (
+	PartitionYear    = "YEAR"
+	PartitionMonth   = "MONTH"
+	LegacyPartition  = "2016"
+	MaxPartitionSpan = 1200 // Most partitions a single query may span
+)
+
+// Layouts accepted for record dates. The last one is the MMDDYYYY form used in
+// the original auction request examples
+var recordDateLayouts = []string{"2006-01-02 15:04:05", "2006-01-02 15:04:05.999999999 -0700 MST", "2006-01-02", "01022006"}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Record the partition bucket. Called from Init
+//////////////////////////////////////////////////////////////////////////////////
+func SetPartitionBucket(store TableStore, bucket string) error {
+
+	bucket = strings.ToUpper(bucket)
+	if bucket != PartitionYear && bucket != PartitionMonth {
+		return NewError(ErrInvalidArgument, "SetPartitionBucket(): Partition bucket should be YEAR or MONTH : "+bucket)
+	}
+	return store.PutState("partition", []byte(bucket))
+}
+
+func GetPartitionBucket(store TableStore) string {
+
+	bucket, err := store.GetState("partition")
+	if err != nil || string(bucket) != PartitionMonth {
+		return PartitionYear
+	}
+	return PartitionMonth
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Partition key for a point in time
+//////////////////////////////////////////////////////////////////////////////////
+func PartitionFor(store TableStore, t time.Time) string {
+
+	if GetPartitionBucket(store) == PartitionMonth {
+		return t.Format("2006-01")
+	}
+	return t.Format("2006")
+}
+
+func ParseRecordDate(date string) (time.Time, error) {
+
+	for _, layout := range recordDateLayouts {
+		t, err := time.Parse(layout, date)
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, NewError(ErrInvalidArgument, "ParseRecordDate(): Date not in a recognised format : "+date)
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Partition key for a record date
+// Records that predate partitioning carry no usable date and are found in the
+// legacy partition until ReindexPartitions has moved them
+//////////////////////////////////////////////////////////////////////////////////
+func PartitionForDate(store TableStore, date string) string {
+
+	t, err := ParseRecordDate(date)
+	if err != nil {
+		return LegacyPartition
+	}
+	return PartitionFor(store, t)
+}
+
+func UserPartition(store TableStore, user UserObject) string {
+	return PartitionForDate(store, user.RegisteredDate)
+}
+
+func ItemPartition(store TableStore, item ItemObject) string {
+	return PartitionForDate(store, item.RegisteredDate)
+}
+
+func AucInitPartition(store TableStore, aucR AuctionRequest) string {
+	return PartitionForDate(store, aucR.RequestDate)
+}
+
+func AucOpenPartition(store TableStore, aucR AuctionRequest) string {
+	return PartitionForDate(store, aucR.OpenDate)
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Expand the partition argument of a list query
+// Either a single partition or an inclusive range "from..to", e.g.
+//   "2017"  "2016..2017"  "2017-01..2017-06"
+// With MONTH buckets a year stands for all of its months
+//////////////////////////////////////////////////////////////////////////////////
+func ExpandPartitions(store TableStore, spec string) ([]string, error) {
+
+	lo, hi := spec, spec
+	if i := strings.Index(spec, ".."); i >= 0 {
+		lo, hi = spec[:i], spec[i+2:]
+	}
+
+	start, err := parsePartition(lo, false)
+	if err != nil {
+		return nil, err
+	}
+	end, err := parsePartition(hi, true)
+	if err != nil {
+		return nil, err
+	}
+	if end.Before(start) {
+		return nil, NewError(ErrInvalidArgument, "ExpandPartitions(): Partition range is reversed : "+spec)
+	}
+
+	var partitions []string
+	for t := start; !t.After(end); {
+		p := PartitionFor(store, t)
+		if len(partitions) == 0 || partitions[len(partitions)-1] != p {
+			partitions = append(partitions, p)
+		}
+		if len(partitions) > MaxPartitionSpan {
+			return nil, NewError(ErrInvalidArgument, fmt.Sprintf("ExpandPartitions(): Range spans more than %d partitions : %s", MaxPartitionSpan, spec))
+		}
+		t = t.AddDate(0, 1, 0)
+	}
+	return partitions, nil
+}
+
+// A year is taken as its first month, or its last when it ends a range
+func parsePartition(p string, last bool) (time.Time, error) {
+
+	if t, err := time.Parse("2006-01", p); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006", p)
+	if err != nil {
+		return t, NewError(ErrInvalidArgument, "ExpandPartitions(): Partition should be YYYY or YYYY-MM : "+p)
+	}
+	if last {
+		t = t.AddDate(0, 11, 0)
+	}
+	return t, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Result of ReindexPartitions for one table
+//////////////////////////////////////////////////////////////////////////////////
+type ReindexResult struct {
+	TableName string
+	Moved     int
+	Skipped   int // Auctions whose date could not be read; left where they were
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Move rows out of the given partitions (default "2016") into the partition
+// their date calls for. Meant to be run once by an Auction House after upgrading
+// Users and Items registered before RegisteredDate existed have no date on
+// record; they belong to the legacy partition and are moved there
+// Works on the tables directly rather than through the repositories, being a
+// one-off migration of the table layout
+// ./peer chaincode invoke -l golang -n mycc -c '{"Function": "ReindexPartitions", "Args": ["REINDEX"]}'
+// ./peer chaincode invoke -l golang -n mycc -c '{"Function": "ReindexPartitions", "Args": ["REINDEX", "2016", "2017"]}'
+//////////////////////////////////////////////////////////////////////////////////
+func ReindexPartitions(ctx *TxContext, function string, args []string) ([]byte, error) {
+
+	if len(args) < 1 {
+		fmt.Println("ReindexPartitions(): Incorrect number of arguments. Expecting at least 1 ")
+		return nil, NewError(ErrInvalidArgument, "ReindexPartitions(): Incorrect number of arguments. Expecting at least 1 ")
+	}
+
+	if GetCallerAttribute(ctx, "role") != "AH" {
+		return nil, NewError(ErrUnauthorized, "ReindexPartitions(): Only an Auction House may re-index partitions")
+	}
+
+	sources := args[1:]
+	if len(sources) == 0 {
+		sources = []string{LegacyPartition}
+	}
+
+	userPartition := func(data []byte) (string, []byte, error) {
+		user, err := JSONtoUser(data)
+		if err != nil {
+			return "", nil, err
+		}
+		user, err = GetUserObject(ctx, user.UserID)
+		if err != nil {
+			return "", nil, err
+		}
+		buff, err := UsertoJSON(user)
+		return UserPartition(ctx.Store, user), buff, err
+	}
+
+	itemPartition := func(data []byte) (string, []byte, error) {
+		item, err := JSONtoAR(data)
+		if err != nil {
+			return "", nil, err
+		}
+		item, err = GetItemObject(ctx, item.ItemID)
+		if err != nil {
+			return "", nil, err
+		}
+		buff, err := ARtoJSON(item)
+		return ItemPartition(ctx.Store, item), buff, err
+	}
+
+	aucDate := func(open bool) func(data []byte) (string, []byte, error) {
+		return func(data []byte) (string, []byte, error) {
+			aucR, err := JSONtoAucReq(data)
+			if err != nil {
+				return "", nil, err
+			}
+			date := aucR.RequestDate
+			if open {
+				date = aucR.OpenDate
+			}
+			if _, err := ParseRecordDate(date); err != nil {
+				return "", nil, nil
+			}
+			return PartitionForDate(ctx.Store, date), data, nil
+		}
+	}
+
+	tables := []struct {
+		name      string
+		partition func(data []byte) (string, []byte, error)
+	}{
+		{"UserCatTable", userPartition},
+		{"ItemCatTable", itemPartition},
+		{"ItemTypeTable", itemPartition},
+		{"AucInitTable", aucDate(false)},
+		{"AucOpenTable", aucDate(true)},
+	}
+
+	var results []ReindexResult
+	for _, table := range tables {
+		result := ReindexResult{TableName: table.name}
+		for _, source := range sources {
+			err := reindexPartition(ctx.Store, table.name, source, table.partition, &result)
+			if err != nil {
+				fmt.Println("ReindexPartitions() : Failed on ", table.name, " partition ", source)
+				return nil, err
+			}
+		}
+		fmt.Println("ReindexPartitions() : ", result)
+		results = append(results, result)
+	}
+
+	return json.Marshal(results)
+}
+
+func reindexPartition(store TableStore, tableName string, source string, partition func(data []byte) (string, []byte, error), result *ReindexResult) error {
+
+	// Collect first, the table is rewritten below
+	var entries []LedgerRow
+	err := ScanRows(store, tableName, []string{source}, func(row LedgerRow) error {
+		entries = append(entries, row)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		p, buff, err := partition(e.Value)
+		if err != nil {
+			return err
+		}
+		if p == "" {
+			result.Skipped++
+			continue
+		}
+
+		newKeys := append([]string{p}, e.Keys[1:]...)
+		err = ReplaceIndexEntry(store, tableName, e.Keys, newKeys, buff)
+		if err != nil {
+			return err
+		}
+		if p != source {
+			result.Moved++
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+//go:build !fabric1 && !fabric2
+// +build !fabric1,!fabric2
+
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package main
+
+import (
+	"time"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+//////////////////////////////////////////////////////////////////////////////////
+// Fabric v0.6 peer - the default build
+// The peer calls Init, Invoke and Query with the function name and its
+// arguments. Rows are kept with the table API (store_shim.go)
+// For Fabric 1.x and 2.x build with -tags fabric1 or -tags fabric2 instead
+//////////////////////////////////////////////////////////////////////////////////
+
+func (t *SimpleChaincode) Init(stub shim.ChaincodeStubInterface, function string, args []string) ([]byte, error) {
+	return t.InitContext(NewShimContext(stub), function, args)
+}
+
+func (t *SimpleChaincode) Invoke(stub shim.ChaincodeStubInterface, function string, args []string) ([]byte, error) {
+	return t.InvokeContext(NewShimContext(stub), function, args)
+}
+
+func (t *SimpleChaincode) Query(stub shim.ChaincodeStubInterface, function string, args []string) ([]byte, error) {
+	return t.QueryContext(NewShimContext(stub), function, args)
+}
+
+func startShim() {
+
+	/*err := shim.Start(new(SimpleChaincode))
+	if err != nil {
+		fmt.Println("Error starting Item Fun Application chaincode: %s", err)
+	}*/
+}
+
+func NewShimContext(stub shim.ChaincodeStubInterface) *TxContext {
+	return NewTxContext(NewShimStore(stub), shimTx{stub})
+}
+
+type shimTx struct {
+	stub shim.ChaincodeStubInterface
+}
+
+func (t shimTx) TxID() string {
+	return t.stub.GetTxID()
+}
+
+func (t shimTx) TxTime() (time.Time, error) {
+
+	ts, err := t.stub.GetTxTimestamp()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(ts.Seconds, int64(ts.Nanos)).UTC(), nil
+}
+
+func (t shimTx) CallerAttribute(name string) ([]byte, error) {
+	return t.stub.ReadCertAttribute(name)
+}
+
+func (t shimTx) CallerTransient(name string) ([]byte, error) {
+
+	md, err := t.stub.GetCallerMetadata()
+	if err != nil {
+		return nil, err
+	}
+	return TransientFromMetadata(md, name), nil
+}
+
+func (t shimTx) SetEvent(name string, payload []byte) error {
+	return t.stub.SetEvent(name, payload)
+}
@@ -0,0 +1,172 @@
+//go:build fabric1
+// +build fabric1
+
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric/core/chaincode/lib/cid"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+//////////////////////////////////////////////////////////////////////////////////
+// Fabric 1.x peer - build with -tags fabric1
+// The peer calls Init and Invoke with the stub alone, the function name and its
+// arguments come from GetFunctionAndParameters. Queries come through Invoke
+// too (see CallContext). Rows are kept under composite keys (store_kv.go)
+// Caller attributes are read from the enrollment certificate with the cid
+// library. Keys go in the transient data, not in the arguments (see context.go)
+// ./peer chaincode instantiate -n mycc -v 1.0 -C mychannel -c '{"Args": ["init", "MONTH"]}'
+// ./peer chaincode invoke -n mycc -C mychannel -c '{"Args": ["PostItem", "1000", "ARTINV", "Shadows by Asppen", "Asppen Messer", "Original", "Landscape"]}'
+// ./peer chaincode query -n mycc -C mychannel -c '{"Args": ["GetItem", "1000"]}'
+//////////////////////////////////////////////////////////////////////////////////
+
+func (t *SimpleChaincode) Init(stub shim.ChaincodeStubInterface) pb.Response {
+	function, args := stub.GetFunctionAndParameters()
+	return peerResponse(t.InstantiateContext(NewPeerContext(stub), function, args))
+}
+
+func (t *SimpleChaincode) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
+	function, args := stub.GetFunctionAndParameters()
+	return peerResponse(t.CallContext(NewPeerContext(stub), function, args))
+}
+
+// The chaincode's errors are JSON (see errors.go) and reach the client as the message
+func peerResponse(buff []byte, err error) pb.Response {
+
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	return shim.Success(buff)
+}
+
+func startShim() {
+
+	err := shim.Start(new(SimpleChaincode))
+	if err != nil {
+		fmt.Println("Error starting Item Fun Application chaincode: ", err)
+	}
+}
+
+func NewPeerContext(stub shim.ChaincodeStubInterface) *TxContext {
+	return NewTxContext(NewKVStore(peerState{stub}), peerTx{stub})
+}
+
+// The stub as the KVStore needs it
+type peerState struct {
+	shim.ChaincodeStubInterface
+}
+
+func (s peerState) ScanPartialCompositeKey(objectType string, keys []string, fn func(key string, value []byte) error) error {
+
+	iter, err := s.GetStateByPartialCompositeKey(objectType, keys)
+	if err != nil {
+		return err
+	}
+	defer iter.Close()
+
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return err
+		}
+		err = fn(kv.Key, kv.Value)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Paged reads of the state, resuming at start. Only allowed in a transaction
+// that writes nothing, which holds for the list queries that use it
+func (s peerState) ScanPartialCompositeKeyFrom(objectType string, keys []string, start string, fn func(key string, value []byte) error) error {
+
+	bookmark := start
+	for {
+		iter, meta, err := s.GetStateByPartialCompositeKeyWithPagination(objectType, keys, kvScanPageSize, bookmark)
+		if err != nil {
+			return err
+		}
+		for iter.HasNext() {
+			kv, err := iter.Next()
+			if err == nil {
+				err = fn(kv.Key, kv.Value)
+			}
+			if err != nil {
+				iter.Close()
+				return err
+			}
+		}
+		iter.Close()
+
+		if meta == nil || meta.Bookmark == "" || meta.FetchedRecordsCount < kvScanPageSize {
+			return nil
+		}
+		bookmark = meta.Bookmark
+	}
+}
+
+type peerTx struct {
+	stub shim.ChaincodeStubInterface
+}
+
+func (t peerTx) TxID() string {
+	return t.stub.GetTxID()
+}
+
+func (t peerTx) TxTime() (time.Time, error) {
+
+	ts, err := t.stub.GetTxTimestamp()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(ts.Seconds, int64(ts.Nanos)).UTC(), nil
+}
+
+func (t peerTx) CallerAttribute(name string) ([]byte, error) {
+
+	value, found, err := cid.GetAttributeValue(t.stub, name)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("peerTx: No attribute %s", name)
+	}
+	return []byte(value), nil
+}
+
+func (t peerTx) CallerTransient(name string) ([]byte, error) {
+
+	transient, err := t.stub.GetTransient()
+	if err != nil {
+		return nil, err
+	}
+	return transient[name], nil
+}
+
+func (t peerTx) SetEvent(name string, payload []byte) error {
+	return t.stub.SetEvent(name, payload)
+}
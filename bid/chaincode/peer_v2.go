@@ -0,0 +1,167 @@
+//go:build fabric2
+// +build fabric2
+
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+//////////////////////////////////////////////////////////////////////////////////
+// Fabric 2.x peer - build with -tags fabric2
+// The chaincode API is that of 1.x (see peer_v1.go); the shim and the protos
+// moved to the fabric-chaincode-go and fabric-protos-go modules
+//////////////////////////////////////////////////////////////////////////////////
+
+func (t *SimpleChaincode) Init(stub shim.ChaincodeStubInterface) pb.Response {
+	function, args := stub.GetFunctionAndParameters()
+	return peerResponse(t.InstantiateContext(NewPeerContext(stub), function, args))
+}
+
+func (t *SimpleChaincode) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
+	function, args := stub.GetFunctionAndParameters()
+	return peerResponse(t.CallContext(NewPeerContext(stub), function, args))
+}
+
+func peerResponse(buff []byte, err error) pb.Response {
+
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	return shim.Success(buff)
+}
+
+// The contract build (-tags "fabric2 contract") starts its contracts instead, see contract.go
+var peerChaincode shim.Chaincode = new(SimpleChaincode)
+
+func startShim() {
+
+	err := shim.Start(peerChaincode)
+	if err != nil {
+		fmt.Println("Error starting Item Fun Application chaincode: ", err)
+	}
+}
+
+func NewPeerContext(stub shim.ChaincodeStubInterface) *TxContext {
+	return NewTxContext(NewKVStore(peerState{stub}), peerTx{stub})
+}
+
+type peerState struct {
+	shim.ChaincodeStubInterface
+}
+
+func (s peerState) ScanPartialCompositeKey(objectType string, keys []string, fn func(key string, value []byte) error) error {
+
+	iter, err := s.GetStateByPartialCompositeKey(objectType, keys)
+	if err != nil {
+		return err
+	}
+	defer iter.Close()
+
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return err
+		}
+		err = fn(kv.Key, kv.Value)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Paged reads of the state, resuming at start. Only allowed in a transaction
+// that writes nothing, which holds for the list queries that use it
+func (s peerState) ScanPartialCompositeKeyFrom(objectType string, keys []string, start string, fn func(key string, value []byte) error) error {
+
+	bookmark := start
+	for {
+		iter, meta, err := s.GetStateByPartialCompositeKeyWithPagination(objectType, keys, kvScanPageSize, bookmark)
+		if err != nil {
+			return err
+		}
+		for iter.HasNext() {
+			kv, err := iter.Next()
+			if err == nil {
+				err = fn(kv.Key, kv.Value)
+			}
+			if err != nil {
+				iter.Close()
+				return err
+			}
+		}
+		iter.Close()
+
+		if meta == nil || meta.Bookmark == "" || meta.FetchedRecordsCount < kvScanPageSize {
+			return nil
+		}
+		bookmark = meta.Bookmark
+	}
+}
+
+type peerTx struct {
+	stub shim.ChaincodeStubInterface
+}
+
+func (t peerTx) TxID() string {
+	return t.stub.GetTxID()
+}
+
+func (t peerTx) TxTime() (time.Time, error) {
+
+	ts, err := t.stub.GetTxTimestamp()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(ts.Seconds, int64(ts.Nanos)).UTC(), nil
+}
+
+func (t peerTx) CallerAttribute(name string) ([]byte, error) {
+
+	value, found, err := cid.GetAttributeValue(t.stub, name)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("peerTx: No attribute %s", name)
+	}
+	return []byte(value), nil
+}
+
+func (t peerTx) CallerTransient(name string) ([]byte, error) {
+
+	transient, err := t.stub.GetTransient()
+	if err != nil {
+		return nil, err
+	}
+	return transient[name], nil
+}
+
+func (t peerTx) SetEvent(name string, payload []byte) error {
+	return t.stub.SetEvent(name, payload)
+}
@@ -0,0 +1,372 @@
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////
+// Personal data on the UserObject is never written to the ledger in the clear
+//
+//   AccountNo, RoutingNo     - always stored as an HMAC-SHA256 ("HASH:...")
+//                              A bank can confirm a number with VerifyUserPII
+//   Address, Phone, Email    - AES-GCM encrypted ("ENC:...") when the client passes a
+//                              PII key in the transaction metadata, otherwise HMAC
+//
+// The HMAC key is not on the ledger: each endorsing peer runs the chaincode with the
+// same key in the AUCTION_PII_HMAC_KEY environment variable, so a copy of the ledger
+// is not enough to guess the hashed values. Clients always send plain values; stored
+// forms (HASH:, ENC:) and REDACTED are refused. EraseUser wipes the protected fields
+///////////////////////////////////////////////////////////////////////////////////////
+
+const (
+	piiHashPrefix = "HASH:"
+	piiEncPrefix  = "ENC:"
+	RedactedValue = "REDACTED"
+	PIIHMACKeyEnv = "AUCTION_PII_HMAC_KEY" // base64, at least 16 bytes
+)
+
+// The HMAC key of the hashed fields, nil when the peer has none
+var piiHMACKey = loadPIIHMACKey(os.Getenv(PIIHMACKeyEnv))
+
+// Fields that are always hashed
+var piiHashedFields = []string{"AccountNo", "RoutingNo"}
+
+// Fields that are encrypted when a PII key is available
+var piiContactFields = []string{"Address", "Phone", "Email"}
+
+// Roles that may see protected fields of other users
+var piiPrivilegedRoles = []string{"AH", "BK"}
+
+// Response returned by VerifyUserPII
+type PIIVerification struct {
+	UserID   string
+	Field    string
+	Verified bool
+}
+
+//////////////////////////////////////////////////////////
+// Pointer to a protected field of the User Object
+//////////////////////////////////////////////////////////
+func userPIIField(user *UserObject, name string) *string {
+	switch name {
+	case "Address":
+		return &user.Address
+	case "Phone":
+		return &user.Phone
+	case "Email":
+		return &user.Email
+	case "AccountNo":
+		return &user.AccountNo
+	case "RoutingNo":
+		return &user.RoutingNo
+	}
+	return nil
+}
+
+func loadPIIHMACKey(encoded string) []byte {
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil || len(key) < 16 {
+		if encoded != "" {
+			fmt.Println("loadPIIHMACKey(): " + PIIHMACKeyEnv + " should be base64, at least 16 bytes")
+		}
+		return nil
+	}
+	return key
+}
+
+//////////////////////////////////////////////////////////
+// Keyed hash of a protected field of a user
+//////////////////////////////////////////////////////////
+func HashPII(userID string, field string, value string) (string, error) {
+
+	if piiHMACKey == nil {
+		return "", NewError(ErrInternal, "HashPII(): "+PIIHMACKeyEnv+" is not set on this peer")
+	}
+	mac := hmac.New(sha256.New, piiHMACKey)
+	mac.Write([]byte(userID + "|" + field + "|" + value))
+	return piiHashPrefix + hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+//////////////////////////////////////////////////////////////////////////
+// The PII key is supplied by the client in the transaction metadata
+// so that it never lands in world state. Either the base64 encoding of
+// the key or the raw key bytes are accepted. No metadata - no key
+//////////////////////////////////////////////////////////////////////////
+func GetPIIKey(ctx *TxContext) ([]byte, error) {
+
+	md, err := ctx.Tx.CallerTransient(TransientMetadataKey)
+	if err != nil || len(md) == 0 {
+		return nil, nil
+	}
+	key, err := ParseTransientKey(md)
+	if err != nil {
+		return nil, NewError(ErrInvalidArgument, "GetPIIKey(): Transaction metadata does not hold a valid PII key")
+	}
+	return key, nil
+}
+
+func encryptPII(key []byte, txID string, userID string, field string, value string) (string, error) {
+
+	aead, err := artefactAEAD(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := ArtefactNonce(txID, userID, field)
+	sealed := aead.Seal(nonce, nonce, []byte(value), []byte(userID+"|"+field))
+	return piiEncPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func decryptPII(key []byte, userID string, field string, value string) (string, error) {
+
+	if !strings.HasPrefix(value, piiEncPrefix) {
+		return value, nil
+	}
+	aead, err := artefactAEAD(key)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, piiEncPrefix))
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", NewError(ErrCorruptRecord, "decryptPII(): Corrupt "+field+" for user "+userID, "RecType", "USER", "Field", field)
+	}
+	plain, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(userID+"|"+field))
+	if err != nil {
+		return "", NewError(ErrCorruptRecord, "decryptPII(): "+field+" for user "+userID+" failed authentication", "RecType", "USER", "Field", field)
+	}
+	return string(plain), nil
+}
+
+/////////////////////////////////////////////////////////////////////////////
+// Hash or encrypt the personal data on a User Object before it is written
+// The client sends plain values: a stored form or REDACTED is refused.
+// Empty fields stay empty
+/////////////////////////////////////////////////////////////////////////////
+func ProtectUser(ctx *TxContext, user UserObject) (UserObject, error) {
+
+	for _, name := range append(piiHashedFields, piiContactFields...) {
+		f := userPIIField(&user, name)
+		if *f == RedactedValue || strings.HasPrefix(*f, piiHashPrefix) || strings.HasPrefix(*f, piiEncPrefix) {
+			return user, NewError(ErrInvalidArgument, "ProtectUser(): "+name+" should be a plain value", "Field", name)
+		}
+	}
+
+	key, err := GetPIIKey(ctx)
+	if err != nil {
+		return user, err
+	}
+
+	for _, name := range append(piiHashedFields, piiContactFields...) {
+		f := userPIIField(&user, name)
+		if *f == "" {
+			continue
+		}
+		if key == nil || isHashedField(name) {
+			*f, err = HashPII(user.UserID, name, *f)
+		} else {
+			*f, err = encryptPII(key, ctx.Tx.TxID(), user.UserID, name, *f)
+		}
+		if err != nil {
+			return user, WrapError(err, "ProtectUser(): Cannot protect "+name+" for user "+user.UserID)
+		}
+	}
+
+	return user, nil
+}
+
+func isHashedField(name string) bool {
+	for _, f := range piiHashedFields {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
+
+//////////////////////////////////////////////////////////
+// Caller identity from the enrollment certificate
+// Empty when the attribute is not present
+//////////////////////////////////////////////////////////
+func GetCallerAttribute(ctx *TxContext, name string) string {
+	val, err := ctx.Tx.CallerAttribute(name)
+	if err != nil {
+		return ""
+	}
+	return string(val)
+}
+
+func hasPrivilegedRole(role string) bool {
+	for _, r := range piiPrivilegedRoles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+/////////////////////////////////////////////////////////////////////////////
+// Check whether the caller may see the protected fields of a user
+// The user themselves and the privileged roles (Auction House, Bank) may
+/////////////////////////////////////////////////////////////////////////////
+func CanViewPII(ctx *TxContext, userID string) bool {
+	if caller := GetCallerAttribute(ctx, "userid"); caller != "" && caller == userID {
+		return true
+	}
+	return hasPrivilegedRole(GetCallerAttribute(ctx, "role"))
+}
+
+/////////////////////////////////////////////////////////////////////////////
+// Check whether the caller may change or erase a user's record
+// Only the user themselves or an Auction House may
+/////////////////////////////////////////////////////////////////////////////
+func CanManageUser(ctx *TxContext, userID string) bool {
+	if caller := GetCallerAttribute(ctx, "userid"); caller != "" && caller == userID {
+		return true
+	}
+	return GetCallerAttribute(ctx, "role") == "AH"
+}
+
+/////////////////////////////////////////////////////////////////////////////
+// Prepare a User Object for a query response
+// Callers without access get the protected fields REDACTED. Callers with
+// access who supply the PII key in the metadata get contact fields decrypted
+/////////////////////////////////////////////////////////////////////////////
+func RedactUser(ctx *TxContext, user UserObject) (UserObject, error) {
+
+	if !CanViewPII(ctx, user.UserID) {
+		for _, name := range append(piiContactFields, piiHashedFields...) {
+			if f := userPIIField(&user, name); *f != "" {
+				*f = RedactedValue
+			}
+		}
+		return user, nil
+	}
+
+	key, err := GetPIIKey(ctx)
+	if err != nil || key == nil {
+		return user, err
+	}
+
+	for _, name := range piiContactFields {
+		f := userPIIField(&user, name)
+		*f, err = decryptPII(key, user.UserID, name, *f)
+		if err != nil {
+			return user, err
+		}
+	}
+	return user, nil
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// Check a plaintext value against a protected field without revealing it
+// Used for instance by a bank to confirm the account number a user presents
+// Only callers who may view the user's PII may check it (see CanViewPII)
+// Structure of args UserID, Field, Value
+// ./peer chaincode query -l golang -n mycc -c '{"Function": "VerifyUserPII", "Args": ["100", "AccountNo", "00017102345"]}'
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+func VerifyUserPII(ctx *TxContext, function string, args []string) ([]byte, error) {
+
+	if len(args) != 3 {
+		fmt.Println("VerifyUserPII(): Incorrect number of arguments. Expecting 3 ")
+		return nil, NewError(ErrInvalidArgument, "VerifyUserPII(): Incorrect number of arguments. Expecting 3 ")
+	}
+
+	if !CanViewPII(ctx, args[0]) {
+		return nil, NewError(ErrUnauthorized, "VerifyUserPII(): Caller is not permitted to verify the PII of user "+args[0])
+	}
+
+	user, err := ctx.Users.Get(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	f := userPIIField(&user, args[1])
+	if f == nil {
+		return nil, NewError(ErrInvalidArgument, "VerifyUserPII(): Not a protected field : "+args[1])
+	}
+
+	var match bool
+	switch {
+	case strings.HasPrefix(*f, piiHashPrefix):
+		hash, err := HashPII(user.UserID, args[1], args[2])
+		if err != nil {
+			return nil, err
+		}
+		match = hmac.Equal([]byte(hash), []byte(*f))
+	case strings.HasPrefix(*f, piiEncPrefix):
+		key, err := GetPIIKey(ctx)
+		if err != nil || key == nil {
+			return nil, NewError(ErrUnauthorized, "VerifyUserPII(): PII key required to verify "+args[1])
+		}
+		plain, err := decryptPII(key, user.UserID, args[1], *f)
+		if err != nil {
+			return nil, err
+		}
+		match = plain == args[2]
+	}
+
+	return json.Marshal(PIIVerification{UserID: user.UserID, Field: args[1], Verified: match})
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// Right to erasure
+// Wipes the protected fields on both UserTable and UserCatTable.
+// The user record itself remains so that bids and transactions still resolve
+// Only the user themselves or an Auction House may erase
+// ./peer chaincode invoke -l golang -n mycc -c '{"Function": "EraseUser", "Args":["100", "USER"]}'
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+func EraseUser(ctx *TxContext, function string, args []string) ([]byte, error) {
+
+	if len(args) != 2 {
+		fmt.Println("EraseUser(): Incorrect number of arguments. Expecting 2 ")
+		return nil, NewError(ErrInvalidArgument, "EraseUser(): Incorrect number of arguments. Expecting 2 ")
+	}
+
+	if !CanManageUser(ctx, args[0]) {
+		return nil, NewError(ErrUnauthorized, "EraseUser(): Caller is not permitted to erase user "+args[0])
+	}
+
+	current, err := GetUserObject(ctx, args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	user := current
+	for _, name := range append(piiContactFields, piiHashedFields...) {
+		*userPIIField(&user, name) = ""
+	}
+
+	txTime, err := GetTxTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	user.ErasedDate = txTime.Format("2006-01-02 15:04:05")
+
+	return ctx.Users.Replace(current, user)
+}
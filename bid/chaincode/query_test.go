@@ -0,0 +1,612 @@
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+//////////////////////////////////////////////////////////////////////////////////
+// Every function of QueryFunction, run through QueryContext on a seeded
+// ledger (see ledger_test.go)
+//////////////////////////////////////////////////////////////////////////////////
+
+// Bids of 300 (200) at 10:00, 400 (300) at 10:01 and 350 (400) rejected, on auction 1111
+func bidsOneMinuteApart(l *testLedger) {
+	l.openAuction("1111", 3)
+	l.mustBid("1111", "1", "200", "300")
+	l.advance(time.Minute)
+	l.mustBid("1111", "2", "300", "400")
+}
+
+// The page after page, queried with its token and the same arguments
+func nextUserPage(t *testing.T, l *testLedger, page string, args ...string) (ListPage, error) {
+
+	t.Helper()
+	var first ListPage
+	decodeJSON(t, []byte(page), &first)
+	if first.NextToken == "" {
+		t.Fatalf("no next page after %s", page)
+	}
+	var next ListPage
+	buff, err := l.query("GetUserListByCat", append(args, "token="+first.NextToken)...)
+	if err == nil {
+		decodeJSON(t, buff, &next)
+	}
+	return next, err
+}
+
+var queryCases = []functionCase{
+	{function: "GetVersion", name: "version set by Init",
+		args: []string{"version"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			if string(buff) != "23" {
+				t.Fatalf("version %s", buff)
+			}
+		}},
+	{function: "GetVersion", name: "unknown key", code: ErrNotFound,
+		args: []string{"release"}},
+
+	// Users
+	{function: "GetUser", name: "Auction House sees the protected fields",
+		args: []string{"200"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			var user UserObject
+			decodeJSON(t, buff, &user)
+			if user.UserID != "200" || !strings.HasPrefix(user.AccountNo, piiHashPrefix) {
+				t.Fatalf("unexpected user %+v", user)
+			}
+		}},
+	{function: "GetUser", name: "other traders see them redacted", role: "TR",
+		args: []string{"200"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			var user UserObject
+			decodeJSON(t, buff, &user)
+			if user.AccountNo != RedactedValue || user.Email != RedactedValue {
+				t.Fatalf("not redacted %+v", user)
+			}
+		}},
+	{function: "GetUser", name: "PII key decrypts the contact fields", setup: withEncryptedUser,
+		transient: transient(TransientMetadataKey, testArtefactKey3),
+		args:      []string{"500"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			var user UserObject
+			decodeJSON(t, buff, &user)
+			if user.Email != "ashley@example.com" || !strings.HasPrefix(user.AccountNo, piiHashPrefix) {
+				t.Fatalf("unexpected user %+v", user)
+			}
+		}},
+	{function: "GetUser", name: "raw PII key", setup: withEncryptedUser,
+		transient: transient(TransientMetadataKey, "\x00\x01\x02\x03\x04\x05\x06\x07\x08\x09\x0a\x0b\x0c\x0d\x0e\x0f"),
+		args:      []string{"500"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			var user UserObject
+			decodeJSON(t, buff, &user)
+			if user.Email != "ashley@example.com" {
+				t.Fatalf("unexpected user %+v", user)
+			}
+		}},
+	{function: "GetUser", name: "unknown user", code: ErrNotFound,
+		args: []string{"999"}},
+
+	{function: "VerifyUserPII", name: "matching account number",
+		args: []string{"200", "AccountNo", "00017102345"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			var result PIIVerification
+			decodeJSON(t, buff, &result)
+			if !result.Verified {
+				t.Fatal("account number not verified")
+			}
+		}},
+	{function: "VerifyUserPII", name: "wrong account number",
+		args: []string{"200", "AccountNo", "99999999999"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			var result PIIVerification
+			decodeJSON(t, buff, &result)
+			if result.Verified {
+				t.Fatal("wrong account number verified")
+			}
+		}},
+	{function: "VerifyUserPII", name: "kept by an update", setup: func(l *testLedger) {
+		l.mustInvoke("UpdateUser", "200", "USER", "New Name", "TR", "", "", "", "", "", "")
+	},
+		args: []string{"200", "AccountNo", "00017102345"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			var result PIIVerification
+			decodeJSON(t, buff, &result)
+			if !result.Verified {
+				t.Fatal("account number lost by the update")
+			}
+		}},
+	{function: "VerifyUserPII", name: "the user themselves", setup: asTrader("200"), role: "TR",
+		args: []string{"200", "AccountNo", "00017102345"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			var result PIIVerification
+			decodeJSON(t, buff, &result)
+			if !result.Verified {
+				t.Fatal("account number not verified")
+			}
+		}},
+	{function: "VerifyUserPII", name: "another user's field", setup: asTrader("300"), role: "TR", code: ErrUnauthorized,
+		args: []string{"200", "AccountNo", "00017102345"}},
+	{function: "VerifyUserPII", name: "not a protected field", code: ErrInvalidArgument,
+		args: []string{"200", "Name", "User 200"}},
+	{function: "VerifyUserPII", name: "too few arguments", code: ErrInvalidArgument,
+		args: []string{"200", "AccountNo"}},
+
+	{function: "GetUserListByCat", name: "users of a type",
+		args: []string{"2017", "TR"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			var users []UserObject
+			decodeJSON(t, buff, &users)
+			if len(users) != 3 {
+				t.Fatalf("%d traders", len(users))
+			}
+		}},
+	{function: "GetUserListByCat", name: "all users of the partition",
+		args: []string{"2017"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			var users []UserObject
+			decodeJSON(t, buff, &users)
+			if len(users) != 4 {
+				t.Fatalf("%d users", len(users))
+			}
+		}},
+	{function: "GetUserListByCat", name: "empty partition",
+		args: []string{"2016"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			var users []UserObject
+			decodeJSON(t, buff, &users)
+			if len(users) != 0 {
+				t.Fatalf("%d users", len(users))
+			}
+		}},
+	{function: "GetUserListByCat", name: "next page starts after the token",
+		args: []string{"2016..2017", "TR", "pageSize=2"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			var page struct{ Items []UserObject }
+			decodeJSON(t, buff, &page)
+			if len(page.Items) != 2 || page.Items[0].UserID != "200" || page.Items[1].UserID != "300" {
+				t.Fatalf("first page %+v", page.Items)
+			}
+			next, err := nextUserPage(t, l, string(buff), "2016..2017", "TR", "pageSize=2")
+			if err != nil || next.Count != 1 || next.NextToken != "" {
+				t.Fatalf("next page %+v %v", next, err)
+			}
+			if user := next.Items[0].(map[string]interface{}); user["UserID"] != "400" {
+				t.Fatalf("next page %+v", next.Items)
+			}
+		}},
+	{function: "GetUserListByCat", name: "page size may change between pages",
+		args: []string{"2017", "TR", "pageSize=1"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			next, err := nextUserPage(t, l, string(buff), "2017", "TR", "pageSize=5")
+			if err != nil || next.Count != 2 {
+				t.Fatalf("next page %+v %v", next, err)
+			}
+		}},
+	{function: "GetUserListByCat", name: "token of another query",
+		args: []string{"2017", "TR", "pageSize=1"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			_, err := nextUserPage(t, l, string(buff), "2017", "AH", "pageSize=1")
+			assertErrorCode(t, err, ErrInvalidArgument)
+			_, err = nextUserPage(t, l, string(buff), "2016..2017", "TR", "pageSize=1")
+			assertErrorCode(t, err, ErrInvalidArgument)
+			_, err = nextUserPage(t, l, string(buff), "2017", "TR", "pageSize=1", "status=ACTIVE")
+			assertErrorCode(t, err, ErrInvalidArgument)
+		}},
+	{function: "GetUserListByCat", name: "bad page size", code: ErrInvalidArgument,
+		args: []string{"2017", "pageSize=0"}},
+
+	// Items
+	{function: "GetItem", name: "registered item",
+		args: []string{"1000"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			var item ItemObject
+			decodeJSON(t, buff, &item)
+			if item.ItemID != "1000" || item.RecType != "ARTINV" {
+				t.Fatalf("unexpected item %+v", item)
+			}
+		}},
+	{function: "GetItem", name: "unknown item", code: ErrNotFound,
+		args: []string{"9999"}},
+
+	{function: "GetItemListByCat", name: "items of a type",
+		setup: func(l *testLedger) { l.addItem("1001") },
+		args:  []string{"2017", "Original"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			var items []ItemObject
+			decodeJSON(t, buff, &items)
+			if len(items) != 2 || items[0].ItemID != "1000" || items[1].ItemID != "1001" {
+				t.Fatalf("items %+v", items)
+			}
+		}},
+	{function: "GetItemListByCat", name: "other type",
+		args: []string{"2017", "Reprint"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			var items []ItemObject
+			decodeJSON(t, buff, &items)
+			if len(items) != 0 {
+				t.Fatalf("items %+v", items)
+			}
+		}},
+	{function: "GetItemListByCat", name: "unknown option", code: ErrInvalidArgument,
+		args: []string{"2017", "Original", "sort=Colour"}},
+
+	{function: "GetItemListBySubject", name: "items of a subject",
+		args: []string{"2017", "Landscape"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			var items []ItemObject
+			decodeJSON(t, buff, &items)
+			if len(items) != 1 || items[0].ItemID != "1000" {
+				t.Fatalf("items %+v", items)
+			}
+		}},
+	{function: "GetItemListBySubject", name: "paged",
+		setup: func(l *testLedger) { l.addItem("1001") },
+		args:  []string{"2017", "Landscape", "pageSize=1"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			var page ListPage
+			decodeJSON(t, buff, &page)
+			if page.Count != 1 || page.NextToken == "" {
+				t.Fatalf("page %+v", page)
+			}
+		}},
+
+	{function: "GetItemListBySubject", name: "page size not a number", code: ErrInvalidArgument,
+		args: []string{"2017", "Landscape", "pageSize=ten"}},
+
+	{function: "VerifyItemDocument", name: "registered document",
+		setup: func(l *testLedger) {
+			l.mustInvoke("PostItemDocument", "1000", "ITEMDOC", testDocHash, "image/png", "https://example.com/1000.png")
+		},
+		args: []string{"1000", "BASE64", "dGVzdA=="},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			var result ItemDocVerification
+			decodeJSON(t, buff, &result)
+			if !result.Verified || result.DocURI != "https://example.com/1000.png" {
+				t.Fatalf("result %+v", result)
+			}
+		}},
+	{function: "VerifyItemDocument", name: "unregistered document",
+		args: []string{"1000", "SHA256", testDocHash},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			var result ItemDocVerification
+			decodeJSON(t, buff, &result)
+			if result.Verified {
+				t.Fatalf("result %+v", result)
+			}
+		}},
+	{function: "VerifyItemDocument", name: "unknown mode", code: ErrInvalidArgument,
+		args: []string{"1000", "MD5", testDocHash}},
+	{function: "VerifyItemDocument", name: "too few arguments", code: ErrInvalidArgument,
+		args: []string{"1000", "SHA256"}},
+
+	{function: "GetItemArtefact", name: "encrypted envelope", setup: withArtefact,
+		args: []string{"1000"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			var art ItemArtefact
+			decodeJSON(t, buff, &art)
+			if art.OwnerID != "200" || art.Payload == "" || art.Payload == "iVBORw0KGgo=" {
+				t.Fatalf("artefact %+v", art)
+			}
+		}},
+	{function: "GetItemArtefact", name: "no artefact", code: ErrNotFound,
+		args: []string{"1000"}},
+
+	{function: "OpenItemArtefact", name: "owner's key", setup: withArtefact,
+		transient: transient(TransientArtefactKey, testArtefactKey),
+		args:      []string{"1000"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			if string(buff) != "iVBORw0KGgo=" {
+				t.Fatalf("content %s", buff)
+			}
+		}},
+	{function: "OpenItemArtefact", name: "handover key", setup: withHandover,
+		transient: transient(TransientArtefactKey, testArtefactKey2),
+		args:      []string{"1000"}},
+	{function: "OpenItemArtefact", name: "wrong key", setup: withArtefact, code: ErrUnauthorized,
+		transient: transient(TransientArtefactKey, testArtefactKey2),
+		args:      []string{"1000"}},
+	{function: "OpenItemArtefact", name: "no key", setup: withArtefact, code: ErrInvalidArgument,
+		args: []string{"1000"}},
+	{function: "OpenItemArtefact", name: "too many arguments", setup: withArtefact, code: ErrInvalidArgument,
+		transient: transient(TransientArtefactKey, testArtefactKey),
+		args:      []string{"1000", testArtefactKey}},
+
+	// Auctions
+	{function: "GetAuctionRequest", name: "requested auction",
+		args: []string{"1111"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			var aucR AuctionRequest
+			decodeJSON(t, buff, &aucR)
+			if aucR.ItemID != "1000" || aucR.Status != "INIT" {
+				t.Fatalf("auction %+v", aucR)
+			}
+		}},
+	{function: "GetAuctionRequest", name: "unknown auction", code: ErrNotFound,
+		args: []string{"2222"}},
+
+	{function: "GetListOfInitAucs", name: "auctions not opened yet",
+		args: []string{"2017"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			var aucs []AuctionRequest
+			decodeJSON(t, buff, &aucs)
+			if len(aucs) != 1 || aucs[0].AuctionID != "1111" {
+				t.Fatalf("auctions %+v", aucs)
+			}
+		}},
+	{function: "GetListOfInitAucs", name: "page size too large", code: ErrInvalidArgument,
+		args: []string{"2017", "pageSize=100000"}},
+	{function: "GetListOfInitAucs", name: "opened auctions leave the list",
+		setup: func(l *testLedger) { l.openAuction("1111", 3) },
+		args:  []string{"2017"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			var aucs []AuctionRequest
+			decodeJSON(t, buff, &aucs)
+			if len(aucs) != 0 {
+				t.Fatalf("auctions %+v", aucs)
+			}
+		}},
+
+	{function: "GetListOfOpenAucs", name: "closing first comes first",
+		setup: func(l *testLedger) {
+			l.addItem("1001")
+			l.addAuction("2222", "1001")
+			l.openAuction("1111", 30)
+			l.openAuction("2222", 5)
+		},
+		args: []string{"2017"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			var aucs []AuctionRequest
+			decodeJSON(t, buff, &aucs)
+			if len(aucs) != 2 || aucs[0].AuctionID != "2222" || aucs[1].AuctionID != "1111" {
+				t.Fatalf("auctions %+v", aucs)
+			}
+		}},
+	{function: "GetListOfOpenAucs", name: "none open",
+		args: []string{"2017"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			var aucs []AuctionRequest
+			decodeJSON(t, buff, &aucs)
+			if len(aucs) != 0 {
+				t.Fatalf("auctions %+v", aucs)
+			}
+		}},
+	{function: "GetListOfOpenAucs", name: "bad partition range", code: ErrInvalidArgument,
+		args: []string{"2017..2016"}},
+
+	// Bids
+	{function: "GetBid", name: "bid by number", setup: bidsOneMinuteApart,
+		args: []string{"1111", "2"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			var bid Bid
+			decodeJSON(t, buff, &bid)
+			if bid.BuyerID != "300" || bid.BidPrice != "400" {
+				t.Fatalf("bid %+v", bid)
+			}
+		}},
+	{function: "GetBid", name: "unknown bid", setup: bidsOneMinuteApart, code: ErrNotFound,
+		args: []string{"1111", "9"}},
+	{function: "GetBid", name: "too few arguments", setup: bidsOneMinuteApart, code: ErrInvalidArgument,
+		args: []string{"1111"}},
+
+	{function: "GetLastBid", name: "latest bid", setup: bidsOneMinuteApart,
+		args: []string{"1111"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			var bid Bid
+			decodeJSON(t, buff, &bid)
+			if bid.BidNo != "2" || bid.BidTime != "2017-03-05 10:01:00" {
+				t.Fatalf("bid %+v", bid)
+			}
+		}},
+	{function: "GetLastBid", name: "no bids",
+		args: []string{"1111"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			if buff != nil {
+				t.Fatalf("result %s", buff)
+			}
+		}},
+
+	{function: "GetHighestBid", name: "highest price", setup: bidsOneMinuteApart,
+		args: []string{"1111"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			var bid Bid
+			decodeJSON(t, buff, &bid)
+			if bid.BidPrice != "400" {
+				t.Fatalf("bid %+v", bid)
+			}
+		}},
+	{function: "GetHighestBid", name: "no bids",
+		args: []string{"1111"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			if buff != nil {
+				t.Fatalf("result %s", buff)
+			}
+		}},
+	{function: "GetHighestBid", name: "summary points to a missing bid", code: ErrCorruptRecord,
+		setup: func(l *testLedger) {
+			bidsOneMinuteApart(l)
+			l.store.DeleteRow("BidTable", []string{"1111", "2"})
+		},
+		args: []string{"1111"}},
+
+	{function: "GetNoOfBidsReceived", name: "counts the accepted bids", setup: bidsOneMinuteApart,
+		args: []string{"1111"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			if string(buff) != "2" {
+				t.Fatalf("count %s", buff)
+			}
+		}},
+	{function: "GetNoOfBidsReceived", name: "no bids",
+		args: []string{"1111"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			if string(buff) != "0" {
+				t.Fatalf("count %s", buff)
+			}
+		}},
+
+	{function: "GetListOfBids", name: "in bid number order", setup: bidsOneMinuteApart,
+		args: []string{"1111"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			var bids []Bid
+			decodeJSON(t, buff, &bids)
+			if len(bids) != 2 || bids[0].BidNo != "1" || bids[1].BidNo != "2" {
+				t.Fatalf("bids %+v", bids)
+			}
+		}},
+	{function: "GetListOfBids", name: "highest price first", setup: bidsOneMinuteApart,
+		args: []string{"1111", "sort=BidPrice:desc"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			var page struct{ Items []Bid }
+			decodeJSON(t, buff, &page)
+			if len(page.Items) != 2 || page.Items[0].BidPrice != "400" {
+				t.Fatalf("bids %+v", page.Items)
+			}
+		}},
+	{function: "GetListOfBids", name: "bid numbers compare as numbers",
+		setup: func(l *testLedger) {
+			l.openAuction("1111", 3)
+			l.mustBid("1111", "9", "200", "300")
+			l.mustBid("1111", "10", "300", "400")
+		},
+		args: []string{"1111"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			var bids []Bid
+			decodeJSON(t, buff, &bids)
+			if len(bids) != 2 || bids[0].BidNo != "9" || bids[1].BidNo != "10" {
+				t.Fatalf("bids %+v", bids)
+			}
+		}},
+	{function: "GetListOfBids", name: "unknown sort field", setup: bidsOneMinuteApart, code: ErrInvalidArgument,
+		args: []string{"1111", "sort=Colour"}},
+
+	// Transactions
+	{function: "GetTransactionsByAuction", name: "settlement of the auction", setup: closedAuction,
+		args: []string{"1111"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			var trans []ItemTransaction
+			decodeJSON(t, buff, &trans)
+			if len(trans) == 0 || trans[0].AuctionID != "1111" {
+				t.Fatalf("transactions %+v", trans)
+			}
+		}},
+	{function: "GetTransactionsByAuction", name: "invalid continuation token", setup: closedAuction, code: ErrInvalidArgument,
+		args: []string{"1111", "token=garbage"}},
+	{function: "GetTransactionsByAuction", name: "auction not settled",
+		args: []string{"1111"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			var trans []ItemTransaction
+			decodeJSON(t, buff, &trans)
+			if len(trans) != 0 {
+				t.Fatalf("transactions %+v", trans)
+			}
+		}},
+
+	{function: "GetTransactionsByItem", name: "sale of the item", setup: closedAuction,
+		args: []string{"1000"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			var trans []ItemTransaction
+			decodeJSON(t, buff, &trans)
+			if len(trans) == 0 || trans[0].ItemID != "1000" || trans[0].HammerPrice != "400" {
+				t.Fatalf("transactions %+v", trans)
+			}
+		}},
+	{function: "GetTransactionsByItem", name: "price filter not a number", setup: closedAuction, code: ErrInvalidArgument,
+		args: []string{"1000", "minPrice=cheap"}},
+
+	{function: "GetTransactionsByUser", name: "purchase of the buyer", setup: closedAuction,
+		args: []string{"300"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			var trans []ItemTransaction
+			decodeJSON(t, buff, &trans)
+			if len(trans) != 1 || trans[0].TransType != "BUY" {
+				t.Fatalf("transactions %+v", trans)
+			}
+		}},
+	{function: "GetTransactionsByUser", name: "unknown sort field", setup: closedAuction, code: ErrInvalidArgument,
+		args: []string{"300", "sort=Colour"}},
+	{function: "GetTransactionsByUser", name: "outbid user has none", setup: closedAuction,
+		args: []string{"200"},
+		check: func(t *testing.T, l *testLedger, buff []byte) {
+			var trans []ItemTransaction
+			decodeJSON(t, buff, &trans)
+			if len(trans) != 0 {
+				t.Fatalf("transactions %+v", trans)
+			}
+		}},
+}
+
+func TestQueryFunctions(t *testing.T) {
+	runFunctionCases(t, queryCases, (*testLedger).query)
+}
+
+func TestQueryUnknownFunction(t *testing.T) {
+
+	l := newSeededLedger(t)
+	_, err := l.query("GetNothing", "1")
+	assertErrorCode(t, err, ErrInvalidArgument)
+}
+
+func TestQueryWithoutArguments(t *testing.T) {
+
+	l := newSeededLedger(t)
+	_, err := l.query("GetUser")
+	assertErrorCode(t, err, ErrInvalidArgument)
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Every function in InvokeFunction and QueryFunction has a case that succeeds,
+// and every invoke one that fails. The bid queries have no failure short of a
+// broken ledger
+//////////////////////////////////////////////////////////////////////////////////
+func TestEveryFunctionIsCovered(t *testing.T) {
+
+	check := func(kind string, functions []string, cases []functionCase, needFailure bool) {
+		ok, failed := map[string]bool{}, map[string]bool{}
+		for _, tc := range cases {
+			if tc.code == "" {
+				ok[tc.function] = true
+			} else {
+				failed[tc.function] = true
+			}
+		}
+		for _, function := range functions {
+			if !ok[function] {
+				t.Errorf("%s %s needs a case that succeeds", kind, function)
+			}
+			if needFailure && !failed[function] {
+				t.Errorf("%s %s needs a case that fails", kind, function)
+			}
+		}
+	}
+
+	var invokes, queries []string
+	for function := range InvokeFunc {
+		invokes = append(invokes, function)
+	}
+	for function := range QueryFunc {
+		queries = append(queries, function)
+	}
+	check("Invoke", invokes, invokeCases, true)
+	check("Query", queries, queryCases, false)
+}
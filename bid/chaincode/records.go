@@ -0,0 +1,178 @@
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+//////////////////////////////////////////////////////////////////////////////////
+// Record types
+// Every row holds a JSON record whose RecType says what it is. The registry
+// maps each RecType to the struct it decodes into and to a validator for the
+// fields the chaincode relies on. A row that is not JSON, has no RecType or
+// fails its validator is CORRUPT_RECORD; a RecType that is not registered is
+// UNKNOWN_RECTYPE. Either way the query fails, the container keeps running
+//
+// Decode is nil for the request types that older clients stored as RecType
+// (XFER, VERIFY, DEFAULT): their rows are passed through unchecked, as before
+//////////////////////////////////////////////////////////////////////////////////
+type RecordType struct {
+	Name     string // The Go struct, for messages
+	Decode   func(data []byte) (interface{}, error)
+	Validate func(record interface{}) error
+}
+
+var recordTypes = map[string]RecordType{
+	"ARTINV":   {Name: "ItemObject", Decode: decodeItem, Validate: validateItem},
+	"USER":     {Name: "UserObject", Decode: decodeUserRecord, Validate: validateUser},
+	"AUCREQ":   {Name: "AuctionRequest", Decode: decodeAucReq, Validate: validateAucReq},
+	"OPENAUC":  {Name: "AuctionRequest", Decode: decodeAucReq, Validate: validateAucReq},
+	"CLAUC":    {Name: "AuctionRequest", Decode: decodeAucReq, Validate: validateAucReq},
+	"BID":      {Name: "Bid", Decode: decodeBid, Validate: validateBid},
+	"BIDSUM":   {Name: "BidSummary", Decode: decodeBidSummary, Validate: validateBidSummary},
+	"POSTTRAN": {Name: "ItemTransaction", Decode: decodeTrans, Validate: validateTrans},
+	"ARTEFACT": {Name: "ItemArtefact", Decode: decodeArtefact, Validate: validateArtefact},
+	"ITEMHIS":  {Name: "ItemLog", Decode: decodeItemLog, Validate: validateItemLog},
+	"XFER":     {},
+	"VERIFY":   {},
+	"DEFAULT":  {},
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Decode a row into the struct registered for its RecType and validate it
+// Returns the RecType and the record, nil for the pass-through types
+//////////////////////////////////////////////////////////////////////////////////
+func DecodeRecord(data []byte) (string, interface{}, error) {
+
+	var head struct {
+		RecType string
+	}
+	if err := json.Unmarshal(data, &head); err != nil {
+		fmt.Println("DecodeRecord() : Row is not a JSON record : ", err)
+		return "", nil, NewError(ErrCorruptRecord, "DecodeRecord() : Row is not a JSON record : "+err.Error())
+	}
+	if head.RecType == "" {
+		return "", nil, NewError(ErrCorruptRecord, "DecodeRecord() : Row has no RecType")
+	}
+
+	rt, ok := recordTypes[head.RecType]
+	if !ok {
+		fmt.Println("DecodeRecord() : Unknown RecType : ", head.RecType)
+		return head.RecType, nil, NewError(ErrUnknownRecType, "DecodeRecord() : Unknown RecType : "+head.RecType, "RecType", head.RecType)
+	}
+	if rt.Decode == nil {
+		return head.RecType, nil, nil
+	}
+
+	record, err := rt.Decode(data)
+	if err != nil {
+		return head.RecType, nil, NewError(ErrCorruptRecord, "DecodeRecord() : Cannot decode "+rt.Name+" : "+err.Error(), "RecType", head.RecType)
+	}
+	if err := rt.Validate(record); err != nil {
+		e := AsChaincodeError(err)
+		fmt.Println("DecodeRecord() : Invalid ", rt.Name, " : ", e.Message)
+		return head.RecType, nil, NewError(e.Code, "DecodeRecord() : Invalid "+rt.Name+" : "+e.Message, "RecType", head.RecType, "Field", e.Details["Field"])
+	}
+	return head.RecType, record, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Decode a row that must be a Bid, e.g. one read from the BidTable
+//////////////////////////////////////////////////////////////////////////////////
+func DecodeBidRecord(data []byte) (Bid, error) {
+
+	recType, record, err := DecodeRecord(data)
+	if err != nil {
+		return Bid{}, err
+	}
+	bid, ok := record.(Bid)
+	if !ok {
+		return Bid{}, NewError(ErrCorruptRecord, "DecodeBidRecord() : Expected a bid, found RecType "+recType, "RecType", recType)
+	}
+	return bid, nil
+}
+
+func decodeUserRecord(data []byte) (interface{}, error) { return JSONtoUser(data) }
+func decodeArtefact(data []byte) (interface{}, error)   { return JSONtoArtefact(data) }
+func decodeItemLog(data []byte) (interface{}, error)    { return JSONtoItemLog(data) }
+
+//////////////////////////////////////////////////////////////////////////////////
+// Validators: the keys of each record, and the values the chaincode computes
+// with (bid prices, bid times)
+//////////////////////////////////////////////////////////////////////////////////
+func validateItem(record interface{}) error {
+	item := record.(ItemObject)
+	return requireRecordFields("ItemID", item.ItemID)
+}
+
+func validateUser(record interface{}) error {
+	user := record.(UserObject)
+	return requireRecordFields("UserID", user.UserID)
+}
+
+func validateAucReq(record interface{}) error {
+	ar := record.(AuctionRequest)
+	return requireRecordFields("AuctionID", ar.AuctionID, "ItemID", ar.ItemID)
+}
+
+func validateBid(record interface{}) error {
+
+	bid := record.(Bid)
+	if err := requireRecordFields("AuctionID", bid.AuctionID, "BidNo", bid.BidNo, "BidPrice", bid.BidPrice); err != nil {
+		return err
+	}
+	if _, err := strconv.Atoi(bid.BidPrice); err != nil {
+		return NewError(ErrCorruptRecord, "BidPrice is not a number : "+bid.BidPrice, "Field", "BidPrice")
+	}
+	if _, err := time.Parse("2006-01-02 15:04:05", bid.BidTime); bid.BidTime != "" && err != nil {
+		return NewError(ErrCorruptRecord, "BidTime is not a date : "+bid.BidTime, "Field", "BidTime")
+	}
+	return nil
+}
+
+func validateTrans(record interface{}) error {
+	tran := record.(ItemTransaction)
+	return requireRecordFields("AuctionID", tran.AuctionID, "ItemID", tran.ItemID)
+}
+
+func validateArtefact(record interface{}) error {
+	art := record.(ItemArtefact)
+	return requireRecordFields("ItemID", art.ItemID, "OwnerID", art.OwnerID)
+}
+
+func validateItemLog(record interface{}) error {
+	itemLog := record.(ItemLog)
+	return requireRecordFields("ItemID", itemLog.ItemID)
+}
+
+// Fields that must not be empty, as name, value pairs
+func requireRecordFields(fields ...string) error {
+
+	for i := 0; i+1 < len(fields); i += 2 {
+		if fields[i+1] == "" {
+			return NewError(ErrCorruptRecord, "No "+fields[i], "Field", fields[i])
+		}
+	}
+	return nil
+}
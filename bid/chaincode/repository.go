@@ -0,0 +1,588 @@
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package main
+
+import (
+	"fmt"
+)
+
+//////////////////////////////////////////////////////////////////////////////////
+// Repositories
+// One per entity. Handlers read and write records through these, never through
+// the tables, so the index tables (UserCatTable, AucInitTable, ItemTransTable
+// ...) are kept in step with the master tables in one place.
+// The implementations below keep the table layout of GetNumberOfKeys and work
+// on any TableStore, the peer's or the in-memory one. Writes return the record
+// as written
+//
+// List queries with paging and filters go through QueryList (paging.go), which
+// reads the index tables directly
+//////////////////////////////////////////////////////////////////////////////////
+
+type UserRepository interface {
+	Get(userID string) (UserObject, error)
+	// UserTable and UserCatTable
+	Add(user UserObject) ([]byte, error)
+	// Moves the UserCatTable entry when the partition or the UserType changed
+	Replace(current UserObject, user UserObject) ([]byte, error)
+}
+
+type ItemRepository interface {
+	Get(itemID string) (ItemObject, error)
+	// ItemTable, ItemCatTable and ItemTypeTable
+	Add(item ItemObject) ([]byte, error)
+	// Moves the catalog entries when the partition, subject or type changed
+	Replace(current ItemObject, item ItemObject) ([]byte, error)
+
+	GetArtefact(itemID string) (ItemArtefact, error)
+	AddArtefact(art ItemArtefact) ([]byte, error)
+	ReplaceArtefact(art ItemArtefact) ([]byte, error)
+}
+
+type AuctionRepository interface {
+	Get(auctionID string) (AuctionRequest, error)
+	// AuctionTable and AucInitTable
+	Add(aucR AuctionRequest) ([]byte, error)
+	// Moves the auction from AucInitTable to AucOpenTable
+	Open(aucR AuctionRequest) ([]byte, error)
+	// AuctionTable and the copy in AucInitTable or AucOpenTable, per Status
+	Update(aucR AuctionRequest) ([]byte, error)
+	// Removes the auction from AucOpenTable
+	Close(aucR AuctionRequest) ([]byte, error)
+	// The open auctions of a partition, or of every partition when it is empty
+	ScanOpen(partition string, fn func(aucR AuctionRequest) error) error
+}
+
+type BidRepository interface {
+	Get(auctionID string, bidNo string) (Bid, error)
+	// BidTable, and the auction's BidSummary
+	Add(bid Bid) ([]byte, error)
+	// The bids of an auction in BidNo key order
+	Scan(auctionID string, fn func(bid Bid) error) error
+	Count(auctionID string) (int, error)
+	// False if the auction has no bids. An auction bid on before the upgrade has no
+	// summary yet: it is built from BidTable, and kept unless the repository is read-only
+	Summary(auctionID string) (BidSummary, bool, error)
+	// Recomputes the summary from BidTable. False, and no summary, if there are no bids
+	RebuildSummary(auctionID string) (BidSummary, bool, error)
+}
+
+type TransactionRepository interface {
+	// TransTable, ItemTransTable and UserTransTable
+	Add(tran ItemTransaction) ([]byte, error)
+}
+
+type HistoryRepository interface {
+	Add(itemLog ItemLog) ([]byte, error)
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Users
+//////////////////////////////////////////////////////////////////////////////////
+type userTableRepository struct {
+	store TableStore
+}
+
+func (r userTableRepository) Get(userID string) (UserObject, error) {
+
+	Avalbytes, err := QueryLedger(r.store, "UserTable", []string{userID})
+	if err != nil {
+		return UserObject{}, err
+	}
+	return JSONtoUser(Avalbytes)
+}
+
+func (r userTableRepository) Add(user UserObject) ([]byte, error) {
+
+	buff, err := UsertoJSON(user)
+	if err != nil {
+		return nil, NewError(ErrInternal, "Users.Add(): Failed Cannot create object buffer for write : "+user.UserID+" : "+err.Error())
+	}
+
+	err = UpdateLedger(r.store, "UserTable", []string{user.UserID}, buff)
+	if err != nil {
+		fmt.Println("Users.Add() : write error while inserting record")
+		return nil, err
+	}
+
+	// Post Entry into UserCatTable - i.e. User Category Table
+	err = UpdateLedger(r.store, "UserCatTable", []string{UserPartition(r.store, user), user.UserType, user.UserID}, buff)
+	if err != nil {
+		fmt.Println("Users.Add() : write error while inserting record into UserCatTable")
+		return nil, err
+	}
+	return buff, nil
+}
+
+func (r userTableRepository) Replace(current UserObject, user UserObject) ([]byte, error) {
+
+	buff, err := UsertoJSON(user)
+	if err != nil {
+		return nil, NewError(ErrInternal, "Users.Replace(): Failed Cannot create object buffer for write : "+user.UserID+" : "+err.Error())
+	}
+
+	err = ReplaceLedgerEntry(r.store, "UserTable", []string{user.UserID}, buff)
+	if err != nil {
+		fmt.Println("Users.Replace() : write error while replacing record in UserTable")
+		return nil, err
+	}
+
+	oldKeys := []string{UserPartition(r.store, current), current.UserType, current.UserID}
+	newKeys := []string{UserPartition(r.store, user), user.UserType, user.UserID}
+	err = ReplaceIndexEntry(r.store, "UserCatTable", oldKeys, newKeys, buff)
+	if err != nil {
+		return nil, err
+	}
+	return buff, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Items and their artefacts
+//////////////////////////////////////////////////////////////////////////////////
+type itemTableRepository struct {
+	store TableStore
+}
+
+func (r itemTableRepository) Get(itemID string) (ItemObject, error) {
+
+	Avalbytes, err := QueryLedger(r.store, "ItemTable", []string{itemID})
+	if err != nil {
+		return ItemObject{}, err
+	}
+	return JSONtoAR(Avalbytes)
+}
+
+func (r itemTableRepository) Add(item ItemObject) ([]byte, error) {
+
+	buff, err := ARtoJSON(item)
+	if err != nil {
+		return nil, NewError(ErrInternal, "Items.Add(): Failed Cannot create object buffer for write : "+item.ItemID+" : "+err.Error())
+	}
+
+	err = UpdateLedger(r.store, "ItemTable", []string{item.ItemID}, buff)
+	if err != nil {
+		fmt.Println("Items.Add() : write error while inserting record")
+		return nil, err
+	}
+
+	// Index the item by subject and by type so that the UI can browse the catalog
+	err = UpdateLedger(r.store, "ItemCatTable", []string{ItemPartition(r.store, item), item.ItemSubject, item.ItemID}, buff)
+	if err != nil {
+		fmt.Println("Items.Add() : write error while inserting record into ItemCatTable")
+		return nil, err
+	}
+
+	err = UpdateLedger(r.store, "ItemTypeTable", []string{ItemPartition(r.store, item), item.ItemType, item.ItemID}, buff)
+	if err != nil {
+		fmt.Println("Items.Add() : write error while inserting record into ItemTypeTable")
+		return nil, err
+	}
+	return buff, nil
+}
+
+func (r itemTableRepository) Replace(current ItemObject, item ItemObject) ([]byte, error) {
+
+	buff, err := ARtoJSON(item)
+	if err != nil {
+		return nil, NewError(ErrInternal, "Items.Replace(): Failed Cannot create object buffer for write : "+item.ItemID+" : "+err.Error())
+	}
+
+	err = ReplaceLedgerEntry(r.store, "ItemTable", []string{item.ItemID}, buff)
+	if err != nil {
+		fmt.Println("Items.Replace() : write error while replacing record in ItemTable")
+		return nil, err
+	}
+
+	oldPart, newPart := ItemPartition(r.store, current), ItemPartition(r.store, item)
+
+	err = ReplaceIndexEntry(r.store, "ItemCatTable", []string{oldPart, current.ItemSubject, current.ItemID}, []string{newPart, item.ItemSubject, item.ItemID}, buff)
+	if err != nil {
+		return nil, err
+	}
+
+	err = ReplaceIndexEntry(r.store, "ItemTypeTable", []string{oldPart, current.ItemType, current.ItemID}, []string{newPart, item.ItemType, item.ItemID}, buff)
+	if err != nil {
+		return nil, err
+	}
+	return buff, nil
+}
+
+func (r itemTableRepository) GetArtefact(itemID string) (ItemArtefact, error) {
+
+	Avalbytes, err := QueryLedger(r.store, "ItemArtefactTable", []string{itemID})
+	if err != nil {
+		return ItemArtefact{}, err
+	}
+	return JSONtoArtefact(Avalbytes)
+}
+
+func (r itemTableRepository) AddArtefact(art ItemArtefact) ([]byte, error) {
+
+	buff, err := ArtefacttoJSON(art)
+	if err != nil {
+		return nil, NewError(ErrInternal, "Items.AddArtefact(): Failed Cannot create object buffer for write : "+art.ItemID+" : "+err.Error())
+	}
+
+	err = UpdateLedger(r.store, "ItemArtefactTable", []string{art.ItemID}, buff)
+	if err != nil {
+		fmt.Println("Items.AddArtefact() : write error while inserting record")
+		return nil, err
+	}
+	return buff, nil
+}
+
+func (r itemTableRepository) ReplaceArtefact(art ItemArtefact) ([]byte, error) {
+
+	buff, err := ArtefacttoJSON(art)
+	if err != nil {
+		return nil, NewError(ErrInternal, "Items.ReplaceArtefact(): Failed Cannot create object buffer for write : "+art.ItemID+" : "+err.Error())
+	}
+
+	err = ReplaceLedgerEntry(r.store, "ItemArtefactTable", []string{art.ItemID}, buff)
+	if err != nil {
+		fmt.Println("Items.ReplaceArtefact() : write error while replacing artefact")
+		return nil, err
+	}
+	return buff, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Auctions
+// AucInitTable and AucOpenTable hold a copy of the auction, keyed by the
+// partition of its RequestDate and OpenDate respectively (see partition.go)
+//////////////////////////////////////////////////////////////////////////////////
+type auctionTableRepository struct {
+	store TableStore
+}
+
+func (r auctionTableRepository) Get(auctionID string) (AuctionRequest, error) {
+
+	Avalbytes, err := QueryLedger(r.store, "AuctionTable", []string{auctionID})
+	if err != nil {
+		return AuctionRequest{}, err
+	}
+	return JSONtoAucReq(Avalbytes)
+}
+
+func (r auctionTableRepository) Add(aucR AuctionRequest) ([]byte, error) {
+
+	buff, err := AucReqtoJSON(aucR)
+	if err != nil {
+		return nil, NewError(ErrInternal, "Auctions.Add(): Failed Cannot create object buffer for write : "+aucR.AuctionID+" : "+err.Error())
+	}
+
+	err = UpdateLedger(r.store, "AuctionTable", []string{aucR.AuctionID}, buff)
+	if err != nil {
+		fmt.Println("Auctions.Add() : write error while inserting record")
+		return nil, err
+	}
+
+	// The UI can pull all items available for auction from the AucInitTable
+	err = UpdateLedger(r.store, "AucInitTable", []string{AucInitPartition(r.store, aucR), aucR.AuctionID}, buff)
+	if err != nil {
+		fmt.Println("Auctions.Add() : write error while inserting record into AucInitTable")
+		return nil, err
+	}
+	return buff, nil
+}
+
+func (r auctionTableRepository) Open(aucR AuctionRequest) ([]byte, error) {
+
+	buff, err := r.replace(aucR)
+	if err != nil {
+		return nil, err
+	}
+
+	// Remove the Auction from INIT Bucket and move to OPEN bucket
+	err = DeleteFromLedger(r.store, "AucInitTable", []string{AucInitPartition(r.store, aucR), aucR.AuctionID})
+	if err != nil {
+		fmt.Println("Auctions.Open() : DeleteFromLedger(AucInitTable) Failed ")
+		return nil, err
+	}
+
+	err = UpdateLedger(r.store, "AucOpenTable", []string{AucOpenPartition(r.store, aucR), aucR.AuctionID}, buff)
+	if err != nil {
+		fmt.Println("Auctions.Open() : write error while inserting record into AucOpenTable")
+		return nil, err
+	}
+	return buff, nil
+}
+
+func (r auctionTableRepository) Update(aucR AuctionRequest) ([]byte, error) {
+
+	buff, err := r.replace(aucR)
+	if err != nil {
+		return nil, err
+	}
+
+	switch aucR.Status {
+	case "INIT":
+		err = ReplaceLedgerEntry(r.store, "AucInitTable", []string{AucInitPartition(r.store, aucR), aucR.AuctionID}, buff)
+	case "OPEN":
+		err = ReplaceLedgerEntry(r.store, "AucOpenTable", []string{AucOpenPartition(r.store, aucR), aucR.AuctionID}, buff)
+	}
+	if err != nil {
+		fmt.Println("Auctions.Update() : write error while replacing the copy of ", aucR.AuctionID)
+		return nil, err
+	}
+	return buff, nil
+}
+
+func (r auctionTableRepository) Close(aucR AuctionRequest) ([]byte, error) {
+
+	buff, err := r.replace(aucR)
+	if err != nil {
+		return nil, err
+	}
+
+	// Remove the Auction from Open Bucket
+	err = DeleteFromLedger(r.store, "AucOpenTable", []string{AucOpenPartition(r.store, aucR), aucR.AuctionID})
+	if err != nil {
+		fmt.Println("Auctions.Close() : DeleteFromLedger(AucOpenTable) Failed ")
+		return nil, err
+	}
+	return buff, nil
+}
+
+func (r auctionTableRepository) ScanOpen(partition string, fn func(aucR AuctionRequest) error) error {
+
+	var keys []string
+	if partition != "" {
+		keys = []string{partition}
+	}
+
+	return r.store.ScanRows("AucOpenTable", keys, func(row LedgerRow) error {
+		aucR, err := JSONtoAucReq(row.Value)
+		if err != nil {
+			fmt.Println("Auctions.ScanOpen() Failed : Ummarshall error")
+			return err
+		}
+		return fn(aucR)
+	})
+}
+
+// Replace the AuctionTable row
+func (r auctionTableRepository) replace(aucR AuctionRequest) ([]byte, error) {
+
+	buff, err := AucReqtoJSON(aucR)
+	if err != nil {
+		return nil, NewError(ErrInternal, "Auctions: Failed Cannot create object buffer for write : "+aucR.AuctionID+" : "+err.Error())
+	}
+
+	err = ReplaceLedgerEntry(r.store, "AuctionTable", []string{aucR.AuctionID}, buff)
+	if err != nil {
+		fmt.Println("Auctions : write error while replacing record ", aucR.AuctionID)
+		return nil, err
+	}
+	return buff, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Bids
+//////////////////////////////////////////////////////////////////////////////////
+type bidTableRepository struct {
+	store    TableStore
+	readOnly bool // Queries - the Fabric 0.6 peer refuses their writes
+}
+
+func (r bidTableRepository) Get(auctionID string, bidNo string) (Bid, error) {
+
+	Avalbytes, err := QueryLedger(r.store, "BidTable", []string{auctionID, bidNo})
+	if err != nil {
+		return Bid{}, err
+	}
+	return DecodeBidRecord(Avalbytes)
+}
+
+func (r bidTableRepository) Add(bid Bid) ([]byte, error) {
+
+	buff, err := BidtoJSON(bid)
+	if err != nil {
+		return nil, NewError(ErrInternal, "Bids.Add(): Failed Cannot create object buffer for write : "+bid.AuctionID+" : "+err.Error())
+	}
+
+	summary, _, err := r.readSummary(bid.AuctionID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = UpdateLedger(r.store, "BidTable", []string{bid.AuctionID, bid.BidNo}, buff)
+	if err != nil {
+		fmt.Println("Bids.Add() : write error while inserting record")
+		return nil, err
+	}
+
+	summary.Add(bid)
+	err = r.putSummary(summary)
+	if err != nil {
+		fmt.Println("Bids.Add() : write error while updating BidSummaryTable")
+		return nil, err
+	}
+	return buff, nil
+}
+
+func (r bidTableRepository) Scan(auctionID string, fn func(bid Bid) error) error {
+
+	return ScanRows(r.store, "BidTable", []string{auctionID}, func(row LedgerRow) error {
+		bid, err := DecodeBidRecord(row.Value)
+		if err != nil {
+			fmt.Println("Bids.Scan() Failed : Cannot decode bid")
+			return err
+		}
+		return fn(bid)
+	})
+}
+
+func (r bidTableRepository) Count(auctionID string) (int, error) {
+
+	n := 0
+	err := ScanRows(r.store, "BidTable", []string{auctionID}, func(row LedgerRow) error {
+		n++
+		return nil
+	})
+	return n, err
+}
+
+func (r bidTableRepository) Summary(auctionID string) (BidSummary, bool, error) {
+
+	summary, stored, err := r.readSummary(auctionID)
+	if err != nil {
+		return BidSummary{}, false, err
+	}
+	if !stored && !r.readOnly && summary.BidCount > 0 {
+		fmt.Println("Bids.Summary() : No summary for auction ", auctionID, " - keeping the one built from BidTable")
+		err = r.putSummary(summary)
+		if err != nil {
+			return BidSummary{}, false, err
+		}
+	}
+	return summary, summary.BidCount > 0, nil
+}
+
+func (r bidTableRepository) RebuildSummary(auctionID string) (BidSummary, bool, error) {
+
+	summary, err := r.scanSummary(auctionID)
+	if err != nil {
+		return summary, false, err
+	}
+
+	if summary.BidCount == 0 {
+		return summary, false, DeleteFromLedger(r.store, "BidSummaryTable", []string{auctionID})
+	}
+	return summary, true, r.putSummary(summary)
+}
+
+// The summary in BidSummaryTable, true, or else one built from the auction's bids
+func (r bidTableRepository) readSummary(auctionID string) (BidSummary, bool, error) {
+
+	Avalbytes, found, err := r.store.GetRow("BidSummaryTable", []string{auctionID})
+	if err != nil {
+		return BidSummary{}, false, WrapError(err, "Bids.Summary() : Cannot read BidSummaryTable")
+	}
+	if found {
+		summary, err := DecodeBidSummaryRecord(Avalbytes)
+		return summary, err == nil, err
+	}
+	summary, err := r.scanSummary(auctionID)
+	return summary, false, err
+}
+
+func (r bidTableRepository) scanSummary(auctionID string) (BidSummary, error) {
+
+	var summary BidSummary
+	err := r.Scan(auctionID, func(bid Bid) error {
+		summary.Add(bid)
+		return nil
+	})
+	return summary, err
+}
+
+// Insert or replace
+func (r bidTableRepository) putSummary(summary BidSummary) error {
+
+	buff, err := BidSummarytoJSON(summary)
+	if err != nil {
+		return NewError(ErrInternal, "Bids.putSummary(): Failed Cannot create summary buffer for write : "+summary.AuctionID+" : "+err.Error())
+	}
+
+	keys := []string{summary.AuctionID}
+	err = ReplaceLedgerEntry(r.store, "BidSummaryTable", keys, buff)
+	if err != nil && ErrorCodeOf(err) == ErrNotFound {
+		err = UpdateLedger(r.store, "BidSummaryTable", keys, buff)
+	}
+	return err
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Settlement transactions
+//////////////////////////////////////////////////////////////////////////////////
+type transactionTableRepository struct {
+	store TableStore
+}
+
+func (r transactionTableRepository) Add(tran ItemTransaction) ([]byte, error) {
+
+	buff, err := TranstoJSON(tran)
+	if err != nil {
+		return nil, NewError(ErrInternal, "Transactions.Add(): Failed Cannot create object buffer for write : "+tran.AuctionID+" : "+err.Error())
+	}
+
+	err = UpdateLedger(r.store, "TransTable", []string{tran.AuctionID, tran.ItemID, tran.TransType, tran.TxID}, buff)
+	if err != nil {
+		fmt.Println("Transactions.Add() : write error while inserting record into TransTable")
+		return nil, err
+	}
+
+	err = UpdateLedger(r.store, "ItemTransTable", []string{tran.ItemID, tran.AuctionID, tran.TransType, tran.TxID}, buff)
+	if err != nil {
+		fmt.Println("Transactions.Add() : write error while inserting record into ItemTransTable")
+		return nil, err
+	}
+
+	err = UpdateLedger(r.store, "UserTransTable", []string{tran.UserId, tran.AuctionID, tran.TransType, tran.TxID}, buff)
+	if err != nil {
+		fmt.Println("Transactions.Add() : write error while inserting record into UserTransTable")
+		return nil, err
+	}
+	return buff, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Item history - one row each time an item changes hands
+//////////////////////////////////////////////////////////////////////////////////
+type historyTableRepository struct {
+	store TableStore
+}
+
+func (r historyTableRepository) Add(itemLog ItemLog) ([]byte, error) {
+
+	buff, err := ItemLogtoJSON(itemLog)
+	if err != nil {
+		return nil, NewError(ErrInternal, "History.Add(): Failed Cannot create history buffer for write : "+itemLog.ItemID+" : "+err.Error())
+	}
+
+	keys := []string{itemLog.ItemID, itemLog.Status, itemLog.AuctionedBy, itemLog.Date}
+	err = UpdateLedger(r.store, "ItemHistoryTable", keys, buff)
+	if err != nil {
+		fmt.Println("History.Add() : write error while inserting record into ItemHistoryTable")
+		return nil, err
+	}
+	return buff, nil
+}
@@ -0,0 +1,197 @@
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+//////////////////////////////////////////////////////////////////////////////////
+// Scripted auctions, replayed by the simulator (see simulator_test.go)
+// go test -run TestScenarios -v prints the timeline of each
+//////////////////////////////////////////////////////////////////////////////////
+
+// Registration of a user of every UserType and of three items. Every scenario
+// starts with these
+var castSteps = []scenarioStep{
+	{function: "PostUser", args: userArgs("100", "Auction House", "AH"), note: "Auction house registers"},
+	{function: "PostUser", args: userArgs("500", "Bank", "BK"), note: "Bank registers"},
+	{function: "PostUser", args: userArgs("200", "Seller", "TR"), note: "Seller registers"},
+	{function: "PostUser", args: userArgs("300", "Bidder", "TR"), note: "Bidder registers"},
+	{function: "PostUser", args: userArgs("400", "Bidder", "TR"), note: "Bidder registers"},
+	{function: "PostUser", args: userArgs("600", "Shipper", "SH"), note: "Shipper registers"},
+	{function: "PostUser", args: userArgs("700", "Appraiser", "AP"), note: "Appraiser registers"},
+	{function: "PostItem", args: []string{"1000", "ARTINV", "Harbour at dawn", "Oil on canvas", "Original", "Landscape"}, note: "Item catalogued"},
+	{function: "PostItem", args: []string{"2000", "ARTINV", "Portrait of a lady", "Lithograph", "Print", "Portrait"}, note: "Item catalogued"},
+	{function: "PostItem", args: []string{"3000", "ARTINV", "Blue study", "Acrylic on board", "Original", "Abstract"}, note: "Item catalogued"},
+}
+
+func userArgs(id string, name string, userType string) []string {
+	return []string{id, "USER", name + " " + id, userType, "1 Main Street", "9198063535", id + "@example.com", "SUNTRUST", "00017102345", "0234678"}
+}
+
+func withCast(steps ...scenarioStep) []scenarioStep {
+	return append(append([]scenarioStep{}, castSteps...), steps...)
+}
+
+var scenarios = []scenario{
+	{
+		name: "competing bids, close and settlement",
+		steps: withCast(
+			scenarioStep{role: "TR", userID: "200", function: "PostItemArtefact", args: []string{"1000", "ARTEFACT", "200", "image/png"}, transient: artefactTransient(testArtefactKey), code: ErrUnauthorized, note: "Seller cannot claim a catalogued item"},
+			scenarioStep{function: "PostItemArtefact", args: []string{"1000", "ARTEFACT", "200", "image/png"}, transient: artefactTransient(testArtefactKey), note: "Auction house registers the seller's artwork"},
+			scenarioStep{function: "PostAuctionRequest", args: []string{"1111", "AUCREQ", "1000", "100", "2017-03-05", "INIT", "", ""}, note: "Auction requested"},
+			scenarioStep{role: "AP", userID: "700", function: "GetItem", args: []string{"1000"}, note: "Appraiser inspects the item"},
+			scenarioStep{function: "OpenAuctionForBids", args: []string{"1111", "OPENAUC", "10"}, note: "Open for 10 minutes"},
+			scenarioStep{after: time.Minute, role: "TR", userID: "300", function: "PostBid", args: []string{"1111", "BID", "1", "1000", "300", "500"}, note: "First bid"},
+			scenarioStep{after: time.Minute, role: "TR", userID: "400", function: "PostBid", args: []string{"1111", "BID", "2", "1000", "400", "600"}, note: "Outbids 300"},
+			scenarioStep{after: time.Minute, role: "TR", userID: "300", function: "PostBid", args: []string{"1111", "BID", "3", "1000", "300", "550"}, code: ErrBidTooLow, note: "Does not beat 600"},
+			scenarioStep{after: time.Minute, role: "TR", userID: "800", function: "PostUser", args: userArgs("800", "Late bidder", "TR"), note: "Bidder registers mid-auction"},
+			scenarioStep{role: "TR", userID: "800", function: "PostBid", args: []string{"1111", "BID", "4", "1000", "800", "700"}, note: "Outbids 400"},
+			scenarioStep{role: "TR", userID: "900", function: "PostBid", args: []string{"1111", "BID", "5", "1000", "900", "800"}, code: ErrNotFound, note: "Buyer is not registered"},
+			scenarioStep{after: time.Minute, role: "TR", userID: "400", function: "PostBid", args: []string{"1111", "BID", "6", "1000", "400", "750"}, note: "Outbids 800"},
+			scenarioStep{function: "GetHighestBid", args: []string{"1111"}, note: "Highest bid so far",
+				check: func(t *testing.T, l *testLedger, buff []byte) {
+					var bid Bid
+					decodeJSON(t, buff, &bid)
+					if bid.BuyerID != "400" || bid.BidPrice != "750" {
+						t.Fatalf("highest bid %+v", bid)
+					}
+				}},
+			scenarioStep{after: 10 * time.Minute, role: "TR", userID: "300", function: "PostBid", args: []string{"1111", "BID", "7", "1000", "300", "900"}, code: ErrAuctionNotOpen, note: "Too late"},
+			scenarioStep{function: "CloseOpenAuctions", args: []string{"CLAUC"}, note: "Expired auctions closed"},
+			scenarioStep{role: "TR", userID: "300", function: "PostTransaction", args: []string{"1111", "POSTTRAN", "1000", "COMMISSION", "200", "", "2017-03-05 10:16:00", "75", "10% commission"}, code: ErrUnauthorized, note: "Only the auction house posts"},
+			scenarioStep{userID: "100", function: "PostTransaction", args: []string{"1111", "POSTTRAN", "1000", "COMMISSION", "200", "", "2017-03-05 10:16:00", "75", "10% commission"}, note: "Seller's commission"},
+			scenarioStep{role: "BK", userID: "500", function: "VerifyUserPII", args: []string{"400", "AccountNo", "00017102345"}, note: "Bank checks the buyer's account",
+				check: func(t *testing.T, l *testLedger, buff []byte) {
+					var v PIIVerification
+					decodeJSON(t, buff, &v)
+					if !v.Verified {
+						t.Fatalf("verification %+v", v)
+					}
+				}},
+			scenarioStep{role: "TR", userID: "200", function: "TransferItem", args: []string{"1000", "XFER", "200", "400"}, transient: handoverTransient(testArtefactKey, testArtefactKey2), note: "Seller releases the artwork to the buyer"},
+			scenarioStep{role: "TR", userID: "400", function: "AcceptItemArtefact", args: []string{"1000", "ARTEFACT", "400"}, transient: handoverTransient(testArtefactKey3, testArtefactKey2), note: "Buyer re-keys the artwork"},
+			scenarioStep{role: "SH", userID: "600", function: "GetUser", args: []string{"400"}, note: "Shipper looks up the buyer",
+				check: func(t *testing.T, l *testLedger, buff []byte) {
+					var user UserObject
+					decodeJSON(t, buff, &user)
+					if user.Address != RedactedValue {
+						t.Fatalf("shipper sees address %q", user.Address)
+					}
+				}},
+		),
+		tables: map[string]int{
+			"UserTable": 8, "UserCatTable": 8,
+			"ItemTable": 3, "ItemCatTable": 3, "ItemTypeTable": 3, "ItemHistoryTable": 1, "ItemArtefactTable": 1,
+			"AuctionTable": 1, "AucInitTable": 0, "AucOpenTable": 0, "BidTable": 4, "BidSummaryTable": 1,
+			"TransTable": 3, "ItemTransTable": 3, "UserTransTable": 3,
+		},
+		check: func(t *testing.T, l *testLedger) {
+			if aucR := l.auction("1111"); aucR.Status != "CLOSED" {
+				t.Fatalf("auction %+v", aucR)
+			}
+			var trans []ItemTransaction
+			decodeJSON(t, l.mustQuery("GetTransactionsByAuction", "1111"), &trans)
+			got := map[string]string{}
+			for _, tran := range trans {
+				got[tran.TransType] = tran.UserId + "@" + tran.HammerPrice
+			}
+			if got["BUY"] != "400@750" || got["SALE"] != "200@750" || got["COMMISSION"] != "200@75" {
+				t.Fatalf("transactions %v", got)
+			}
+			if owner := GetItemOwner(l.context(), "1000"); owner != "400" {
+				t.Fatalf("item 1000 owned by %q", owner)
+			}
+			if art := l.artefact("1000"); art.OwnerID != "400" || art.Handover != "" {
+				t.Fatalf("artefact %+v", art)
+			}
+			assertArtefactKey(t, l, testArtefactKey3)
+		},
+	},
+	{
+		name: "buy it now",
+		steps: withCast(
+			scenarioStep{function: "PostAuctionRequest", args: []string{"2222", "AUCREQ", "2000", "100", "2017-03-05", "INIT", "", ""}, note: "Auction requested"},
+			scenarioStep{function: "PostAuctionRequest", args: []string{"2223", "AUCREQ", "1000", "100", "2017-03-05", "INIT", "", ""}, note: "Another auction, left waiting"},
+			scenarioStep{function: "OpenAuctionForBids", args: []string{"2222", "OPENAUC", "30"}, note: "Open for 30 minutes"},
+			scenarioStep{after: 2 * time.Minute, role: "TR", userID: "300", function: "PostBid", args: []string{"2222", "BID", "1", "2000", "300", "400"}, note: "First bid"},
+			scenarioStep{after: time.Minute, role: "TR", userID: "400", function: "BuyItNow", args: []string{"2222", "BID", "2", "2000", "400", "350"}, code: ErrBidTooLow, note: "Offer below the highest bid"},
+			scenarioStep{role: "TR", userID: "400", function: "BuyItNow", args: []string{"2222", "BID", "2", "2000", "400", "1500"}, note: "Buys it now"},
+			scenarioStep{after: time.Minute, role: "TR", userID: "300", function: "PostBid", args: []string{"2222", "BID", "3", "2000", "300", "1600"}, code: ErrAuctionNotOpen, note: "Auction is over"},
+			scenarioStep{after: 30 * time.Minute, function: "CloseOpenAuctions", args: []string{"CLAUC"}, note: "Nothing left to close",
+				check: func(t *testing.T, l *testLedger, buff []byte) {
+					var closed []AuctionRequest
+					decodeJSON(t, buff, &closed)
+					if len(closed) != 0 {
+						t.Fatalf("closed %+v", closed)
+					}
+				}},
+		),
+		tables: map[string]int{
+			"UserTable": 7, "UserCatTable": 7,
+			"ItemTable": 3, "ItemCatTable": 3, "ItemTypeTable": 3, "ItemHistoryTable": 1, "ItemArtefactTable": 0,
+			"AuctionTable": 2, "AucInitTable": 1, "AucOpenTable": 0, "BidTable": 1, "BidSummaryTable": 1,
+			"TransTable": 1, "ItemTransTable": 1, "UserTransTable": 1,
+		},
+		check: func(t *testing.T, l *testLedger) {
+			var trans []ItemTransaction
+			decodeJSON(t, l.mustQuery("GetTransactionsByItem", "2000"), &trans)
+			if len(trans) != 1 || trans[0].TransType != "BUY" || trans[0].UserId != "400" || trans[0].HammerPrice != "1500" || trans[0].Details != "Buy It Now" {
+				t.Fatalf("transactions %+v", trans)
+			}
+			if aucR := l.auction("2223"); aucR.Status != "INIT" {
+				t.Fatalf("auction %+v", aucR)
+			}
+		},
+	},
+	{
+		name: "no sale, then sold at a second auction",
+		steps: withCast(
+			scenarioStep{function: "PostAuctionRequest", args: []string{"3333", "AUCREQ", "3000", "100", "2017-03-05", "INIT", "", ""}, note: "Auction requested"},
+			scenarioStep{function: "OpenAuctionForBids", args: []string{"3333", "OPENAUC", "5"}, note: "Open for 5 minutes"},
+			scenarioStep{after: 6 * time.Minute, function: "CloseOpenAuctions", args: []string{"CLAUC"}, note: "Closed without bids"},
+			scenarioStep{function: "PostAuctionRequest", args: []string{"3334", "AUCREQ", "3000", "100", "2017-03-05", "INIT", "", ""}, note: "Item goes back to auction"},
+			scenarioStep{function: "OpenAuctionForBids", args: []string{"3334", "OPENAUC", "5"}, note: "Open for 5 minutes"},
+			scenarioStep{after: 4 * time.Minute, userID: "100", function: "ExtendAuction", args: []string{"3334", "EXTAUC", "5"}, note: "Extended by 5 minutes"},
+			scenarioStep{after: 4 * time.Minute, role: "TR", userID: "300", function: "PostBid", args: []string{"3334", "BID", "1", "3000", "300", "250"}, note: "Late bid"},
+			scenarioStep{role: "TR", userID: "300", function: "PostBid", args: []string{"3333", "BID", "1", "3000", "300", "250"}, code: ErrAuctionNotOpen, note: "First auction is closed"},
+			scenarioStep{after: 3 * time.Minute, function: "CloseOpenAuctions", args: []string{"CLAUC"}, note: "Sold"},
+		),
+		tables: map[string]int{
+			"UserTable": 7, "UserCatTable": 7,
+			"ItemTable": 3, "ItemCatTable": 3, "ItemTypeTable": 3, "ItemHistoryTable": 1, "ItemArtefactTable": 0,
+			"AuctionTable": 2, "AucInitTable": 0, "AucOpenTable": 0, "BidTable": 1, "BidSummaryTable": 1,
+			"TransTable": 1, "ItemTransTable": 1, "UserTransTable": 1,
+		},
+		check: func(t *testing.T, l *testLedger) {
+			var trans []ItemTransaction
+			decodeJSON(t, l.mustQuery("GetTransactionsByItem", "3000"), &trans)
+			if len(trans) != 1 || trans[0].AuctionID != "3334" || trans[0].UserId != "300" {
+				t.Fatalf("transactions %+v", trans)
+			}
+		},
+	},
+}
+
+func TestScenarios(t *testing.T) {
+	runScenarios(t, scenarios)
+}
@@ -0,0 +1,226 @@
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+//////////////////////////////////////////////////////////////////////////////////
+// Scenario simulator
+// Replays a scripted auction on the test ledger (see ledger_test.go). Each step
+// moves the clock, calls one function in a caller's role and checks the outcome.
+// The run is kept as a timeline, logged by go test -v and on failure, and a
+// scenario ends by checking the row count of every table in aucTables
+//////////////////////////////////////////////////////////////////////////////////
+
+type scenarioStep struct {
+	after     time.Duration // Clock advance before the call
+	role      string        // Caller's role, AH when empty
+	userID    string        // Caller's userid attribute, if any
+	transient map[string][]byte
+	function  string // An invoke or a query function
+	args      []string
+	code      ErrorCode // Expected error code, empty for success
+	note      string    // What the step stands for, shown in the timeline
+	check     func(t *testing.T, l *testLedger, buff []byte)
+}
+
+type scenario struct {
+	name   string
+	steps  []scenarioStep
+	tables map[string]int // Rows expected in each table of aucTables at the end
+	check  func(t *testing.T, l *testLedger)
+}
+
+// One call, as shown in the timeline
+type timelineEntry struct {
+	time     time.Time
+	caller   string
+	function string
+	args     []string
+	outcome  string // ok, or the error code returned
+	note     string
+	events   []AuctionEvent
+}
+
+type simulator struct {
+	l        *testLedger
+	timeline []timelineEntry
+}
+
+func newSimulator(t *testing.T) *simulator {
+	return &simulator{l: newTestLedger(t)}
+}
+
+func runScenarios(t *testing.T, scenarios []scenario) {
+
+	for _, sc := range scenarios {
+		sc := sc
+		t.Run(sc.name, func(t *testing.T) {
+			s := newSimulator(t)
+			defer func() { t.Log("\n" + s.formatTimeline()) }()
+
+			for i, step := range sc.steps {
+				s.run(t, i+1, step)
+			}
+			s.assertTables(t, sc.tables)
+			if sc.check != nil {
+				sc.check(t, s.l)
+			}
+		})
+	}
+}
+
+func (s *simulator) run(t *testing.T, n int, step scenarioStep) {
+
+	t.Helper()
+	l := s.l
+	l.advance(step.after)
+	l.role, l.userID, l.transient = step.role, step.userID, step.transient
+	if l.role == "" {
+		l.role = "AH"
+	}
+	defer func() { l.role, l.userID, l.transient = "AH", "", nil }()
+
+	var buff []byte
+	var err error
+	_, isInvoke := InvokeFunc[step.function]
+	if isInvoke {
+		buff, err = l.invoke(step.function, step.args...)
+	} else {
+		buff, err = l.query(step.function, step.args...)
+	}
+
+	entry := timelineEntry{time: l.now, caller: l.role, function: step.function, args: step.args, outcome: "ok", note: step.note}
+	if l.userID != "" {
+		entry.caller += "/" + l.userID
+	}
+	if err != nil {
+		entry.outcome = string(ErrorCodeOf(err))
+	}
+	if isInvoke && err == nil {
+		entry.events = l.events()
+	}
+	s.timeline = append(s.timeline, entry)
+
+	if step.code == "" && err != nil {
+		t.Fatalf("step %d, %s%v : unexpected error : %v", n, step.function, step.args, err)
+	}
+	if step.code != "" && (err == nil || ErrorCodeOf(err) != step.code) {
+		t.Fatalf("step %d, %s%v : expected %s, got %s (%v)", n, step.function, step.args, step.code, entry.outcome, err)
+	}
+	if err == nil && step.check != nil {
+		step.check(t, l, buff)
+	}
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Every table of aucTables must be listed, so that a scenario states what it
+// leaves behind everywhere, not only in the tables it is about
+//////////////////////////////////////////////////////////////////////////////////
+func (s *simulator) assertTables(t *testing.T, expected map[string]int) {
+
+	t.Helper()
+	sizes := s.l.store.TableSizes()
+	var diffs []string
+	for _, name := range aucTables {
+		want, ok := expected[name]
+		if !ok {
+			diffs = append(diffs, fmt.Sprintf("%s: %d rows, not in the scenario", name, sizes[name]))
+			continue
+		}
+		if sizes[name] != want {
+			diffs = append(diffs, fmt.Sprintf("%s: %d rows, expected %d", name, sizes[name], want))
+		}
+	}
+	for name := range expected {
+		if _, ok := sizes[name]; !ok {
+			diffs = append(diffs, fmt.Sprintf("%s: no such table", name))
+		}
+	}
+	if len(diffs) > 0 {
+		sort.Strings(diffs)
+		t.Fatalf("final table state :\n  %s", strings.Join(diffs, "\n  "))
+	}
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// The timeline, one line per call followed by the events it raised, e.g.
+//   10:02:00 TR/300  PostBid 1111 BID 2 1000 300 600        ok           Bid from 300
+//                    > NewHighBid 1111 item 1000 user 300 600
+//////////////////////////////////////////////////////////////////////////////////
+func (s *simulator) formatTimeline() string {
+
+	var b strings.Builder
+	for _, e := range s.timeline {
+		call := e.function + " " + strings.Join(shortArgs(e.args), " ")
+		fmt.Fprintf(&b, "%s %-8s %-56s %-18s %s\n", e.time.Format("15:04:05"), e.caller, call, e.outcome, e.note)
+		for _, ev := range e.events {
+			fmt.Fprintf(&b, "%17s> %s\n", "", formatEvent(ev))
+		}
+	}
+	return b.String()
+}
+
+// The first six arguments, long ones (keys, named-argument JSON) cut short
+func shortArgs(args []string) []string {
+
+	var short []string
+	for i, a := range args {
+		if i == 6 {
+			short = append(short, "...")
+			break
+		}
+		if len(a) > 12 {
+			a = a[:9] + "..."
+		}
+		short = append(short, a)
+	}
+	return short
+}
+
+func formatEvent(ev AuctionEvent) string {
+
+	parts := []string{ev.Type}
+	if ev.AuctionID != "" {
+		parts = append(parts, ev.AuctionID)
+	}
+	if ev.ItemID != "" {
+		parts = append(parts, "item "+ev.ItemID)
+	}
+	if ev.UserID != "" {
+		parts = append(parts, "user "+ev.UserID)
+	}
+	if ev.PrevUserID != "" {
+		parts = append(parts, "from "+ev.PrevUserID)
+	}
+	if ev.Price != "" {
+		parts = append(parts, ev.Price)
+	}
+	if ev.CloseDate != "" {
+		parts = append(parts, "closes "+ev.CloseDate)
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,64 @@
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package main
+
+//////////////////////////////////////////////////////////////////////////////////
+// Ledger storage
+// The chaincode keeps its records in keyed tables: every table has a fixed
+// number of string key columns (see GetNumberOfKeys) and one column holding
+// the record. TableStore is that model and nothing more, so that the handlers
+// and repositories (see repository.go) do not depend on where the rows live:
+//   ShimStore    - the v0.6 table API of the peer (store_shim.go)
+//   KVStore      - composite keys on Fabric 1.x and later (store_kv.go)
+//   MemoryStore  - plain maps, for tests and tools (store_memory.go)
+// Plain key/value state (the version, the partition bucket) sits alongside
+//////////////////////////////////////////////////////////////////////////////////
+
+//////////////////////////////////////////////////////////////////////////////////
+// A table row: its key columns and the record
+//////////////////////////////////////////////////////////////////////////////////
+type LedgerRow struct {
+	Keys  []string
+	Value []byte
+}
+
+type TableStore interface {
+	// Creates the table unless it exists
+	CreateTable(tableName string, nKeys int) error
+	DeleteTable(tableName string) error
+
+	// False if a row with the same keys exists
+	InsertRow(tableName string, row LedgerRow) (bool, error)
+	// False if there is no row with the same keys
+	ReplaceRow(tableName string, row LedgerRow) (bool, error)
+	// Deleting a row that does not exist is not an error
+	DeleteRow(tableName string, keys []string) error
+
+	// The record under the full key. False if there is none
+	GetRow(tableName string, keys []string) ([]byte, bool, error)
+	// The rows whose leading keys match, one at a time in key order
+	// If fn fails the scan stops and the error is returned
+	ScanRows(tableName string, keys []string, fn func(row LedgerRow) error) error
+	// As ScanRows, but only the rows that come after the full key after, if given
+	ScanRowsAfter(tableName string, keys []string, after []string, fn func(row LedgerRow) error) error
+
+	GetState(key string) ([]byte, error)
+	PutState(key string, value []byte) error
+}
@@ -0,0 +1,243 @@
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+)
+
+//////////////////////////////////////////////////////////////////////////////////
+// TableStore over the key-value state of Fabric 1.x and later
+// Those releases have no tables. A row is stored under the composite key
+// (tableName, key1, key2 ...) and a partial-key range query over the leading
+// keys stands in for GetRows, e.g. the bids of auction 1111 are the keys
+// starting (BidTable, 1111). CreateTable records the number of keys under
+// (~table, tableName) so that rows with the wrong number of keys are refused,
+// as the table API did
+//
+// The peer does not let a transaction read its own writes: GetRow returns the
+// committed row even after an InsertRow or ReplaceRow in the same transaction.
+// No handler reads back a row it has written. The tests run on a snapshot
+// MemoryStore (see store_memory.go), which reads the same way
+//
+// The stub is reached through KVState so that this file builds without the
+// shim; peer_v1.go and peer_v2.go adapt the stubs of the two releases
+//////////////////////////////////////////////////////////////////////////////////
+
+const kvTableObjectType = "~table"
+
+// Rows fetched per page when a scan resumes part way (ScanRowsAfter)
+const kvScanPageSize int32 = 100
+
+type KVState interface {
+	GetState(key string) ([]byte, error)
+	PutState(key string, value []byte) error
+	DelState(key string) error
+	CreateCompositeKey(objectType string, attributes []string) (string, error)
+	SplitCompositeKey(compositeKey string) (string, []string, error)
+	// GetStateByPartialCompositeKey, one key at a time in key order
+	ScanPartialCompositeKey(objectType string, keys []string, fn func(key string, value []byte) error) error
+	// As ScanPartialCompositeKey, from the composite key start on
+	ScanPartialCompositeKeyFrom(objectType string, keys []string, start string, fn func(key string, value []byte) error) error
+}
+
+type KVStore struct {
+	state KVState
+	nKeys map[string]int // Read from (~table, tableName) once per transaction
+}
+
+func NewKVStore(state KVState) *KVStore {
+	return &KVStore{state: state, nKeys: map[string]int{}}
+}
+
+func (s *KVStore) CreateTable(tableName string, nKeys int) error {
+
+	if nKeys < 1 {
+		return fmt.Errorf("KVStore: Table %s needs at least 1 key", tableName)
+	}
+
+	key, err := s.state.CreateCompositeKey(kvTableObjectType, []string{tableName})
+	if err != nil {
+		return err
+	}
+	s.nKeys[tableName] = nKeys
+	return s.state.PutState(key, []byte(strconv.Itoa(nKeys)))
+}
+
+func (s *KVStore) DeleteTable(tableName string) error {
+
+	// Collect first, the rows are deleted below
+	var keys []string
+	err := s.state.ScanPartialCompositeKey(tableName, []string{}, func(key string, value []byte) error {
+		keys = append(keys, key)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		err = s.state.DelState(key)
+		if err != nil {
+			return err
+		}
+	}
+
+	key, err := s.state.CreateCompositeKey(kvTableObjectType, []string{tableName})
+	if err != nil {
+		return err
+	}
+	delete(s.nKeys, tableName)
+	return s.state.DelState(key)
+}
+
+func (s *KVStore) InsertRow(tableName string, row LedgerRow) (bool, error) {
+
+	key, err := s.rowKey(tableName, row.Keys, true)
+	if err != nil {
+		return false, err
+	}
+	current, err := s.state.GetState(key)
+	if err != nil {
+		return false, err
+	}
+	if current != nil {
+		return false, nil
+	}
+	return true, s.state.PutState(key, row.Value)
+}
+
+func (s *KVStore) ReplaceRow(tableName string, row LedgerRow) (bool, error) {
+
+	key, err := s.rowKey(tableName, row.Keys, true)
+	if err != nil {
+		return false, err
+	}
+	current, err := s.state.GetState(key)
+	if err != nil {
+		return false, err
+	}
+	if current == nil {
+		return false, nil
+	}
+	return true, s.state.PutState(key, row.Value)
+}
+
+func (s *KVStore) DeleteRow(tableName string, keys []string) error {
+
+	key, err := s.rowKey(tableName, keys, true)
+	if err != nil {
+		return err
+	}
+	return s.state.DelState(key)
+}
+
+func (s *KVStore) GetRow(tableName string, keys []string) ([]byte, bool, error) {
+
+	key, err := s.rowKey(tableName, keys, true)
+	if err != nil {
+		return nil, false, err
+	}
+	value, err := s.state.GetState(key)
+	if err != nil {
+		return nil, false, err
+	}
+	return value, value != nil, nil
+}
+
+func (s *KVStore) ScanRows(tableName string, keys []string, fn func(row LedgerRow) error) error {
+
+	if _, err := s.rowKey(tableName, keys, false); err != nil {
+		return err
+	}
+
+	return s.state.ScanPartialCompositeKey(tableName, keys, func(key string, value []byte) error {
+		_, rowKeys, err := s.state.SplitCompositeKey(key)
+		if err != nil {
+			return err
+		}
+		return fn(LedgerRow{Keys: rowKeys, Value: value})
+	})
+}
+
+func (s *KVStore) ScanRowsAfter(tableName string, keys []string, after []string, fn func(row LedgerRow) error) error {
+
+	if len(after) == 0 {
+		return s.ScanRows(tableName, keys, fn)
+	}
+	if _, err := s.rowKey(tableName, keys, false); err != nil {
+		return err
+	}
+	start, err := s.rowKey(tableName, after, true)
+	if err != nil {
+		return err
+	}
+
+	return s.state.ScanPartialCompositeKeyFrom(tableName, keys, start, func(key string, value []byte) error {
+		if key == start {
+			return nil
+		}
+		_, rowKeys, err := s.state.SplitCompositeKey(key)
+		if err != nil {
+			return err
+		}
+		return fn(LedgerRow{Keys: rowKeys, Value: value})
+	})
+}
+
+func (s *KVStore) GetState(key string) ([]byte, error) {
+	return s.state.GetState(key)
+}
+
+func (s *KVStore) PutState(key string, value []byte) error {
+	return s.state.PutState(key, value)
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Composite key of a row, after checking the table exists and takes that many
+// keys. A partial key may have fewer
+//////////////////////////////////////////////////////////////////////////////////
+func (s *KVStore) rowKey(tableName string, keys []string, full bool) (string, error) {
+
+	nKeys, ok := s.nKeys[tableName]
+	if !ok {
+		key, err := s.state.CreateCompositeKey(kvTableObjectType, []string{tableName})
+		if err != nil {
+			return "", err
+		}
+		value, err := s.state.GetState(key)
+		if err != nil {
+			return "", err
+		}
+		if value == nil {
+			return "", fmt.Errorf("KVStore: No table %s", tableName)
+		}
+		nKeys, err = strconv.Atoi(string(value))
+		if err != nil {
+			return "", fmt.Errorf("KVStore: Bad key count for table %s : %s", tableName, value)
+		}
+		s.nKeys[tableName] = nKeys
+	}
+
+	if len(keys) > nKeys || (full && len(keys) != nKeys) {
+		return "", fmt.Errorf("KVStore: %s has %d keys, %d given", tableName, nKeys, len(keys))
+	}
+	return s.state.CreateCompositeKey(tableName, keys)
+}
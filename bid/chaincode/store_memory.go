@@ -0,0 +1,317 @@
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//////////////////////////////////////////////////////////////////////////////////
+// TableStore held in memory
+// Behaves as the peer's tables do: inserting over an existing row or replacing
+// a missing one reports false, scans return rows in key order. Safe for use
+// by several goroutines. Nothing is persisted
+//
+// A store made by NewSnapshotMemoryStore behaves as Fabric 1.x does within a
+// transaction: rows and state written are held back until Commit, and reads,
+// including the checks of InsertRow and ReplaceRow, see the store as it was
+// at the last Commit. A handler that reads back its own writes fails on it as
+// it would on the peer. Rollback drops the held writes. Tables are created
+// and deleted at once
+//////////////////////////////////////////////////////////////////////////////////
+type MemoryStore struct {
+	mu     sync.Mutex
+	tables map[string]*memoryTable
+	state  map[string][]byte
+
+	snapshot bool
+	pending  []memoryWrite // Held until Commit, in the order written
+}
+
+type memoryWrite struct {
+	tableName string // Empty for state
+	row       LedgerRow
+	delete    bool
+}
+
+type memoryTable struct {
+	nKeys int
+	rows  map[string]LedgerRow
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{tables: map[string]*memoryTable{}, state: map[string][]byte{}}
+}
+
+func NewSnapshotMemoryStore() *MemoryStore {
+
+	s := NewMemoryStore()
+	s.snapshot = true
+	return s
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// End of a transaction on a snapshot store: Commit applies the writes held
+// back, Rollback drops them. Neither does anything on other stores
+//////////////////////////////////////////////////////////////////////////////////
+func (s *MemoryStore) Commit() {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, w := range s.pending {
+		if w.tableName == "" {
+			s.state[w.row.Keys[0]] = w.row.Value
+			continue
+		}
+		t, ok := s.tables[w.tableName]
+		if !ok {
+			continue
+		}
+		if w.delete {
+			delete(t.rows, rowKey(w.row.Keys))
+		} else {
+			t.rows[rowKey(w.row.Keys)] = w.row
+		}
+	}
+	s.pending = nil
+}
+
+func (s *MemoryStore) Rollback() {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pending = nil
+}
+
+func (s *MemoryStore) CreateTable(tableName string, nKeys int) error {
+
+	if nKeys < 1 {
+		return errors.New("MemoryStore: Table " + tableName + " needs at least 1 key")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.tables[tableName]; !ok {
+		s.tables[tableName] = &memoryTable{nKeys: nKeys, rows: map[string]LedgerRow{}}
+	}
+	return nil
+}
+
+func (s *MemoryStore) DeleteTable(tableName string) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.tables, tableName)
+	return nil
+}
+
+func (s *MemoryStore) InsertRow(tableName string, row LedgerRow) (bool, error) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, err := s.table(tableName, len(row.Keys))
+	if err != nil {
+		return false, err
+	}
+	k := rowKey(row.Keys)
+	if _, dup := t.rows[k]; dup {
+		return false, nil
+	}
+	s.write(t, tableName, copyRow(row), false)
+	return true, nil
+}
+
+func (s *MemoryStore) ReplaceRow(tableName string, row LedgerRow) (bool, error) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, err := s.table(tableName, len(row.Keys))
+	if err != nil {
+		return false, err
+	}
+	k := rowKey(row.Keys)
+	if _, ok := t.rows[k]; !ok {
+		return false, nil
+	}
+	s.write(t, tableName, copyRow(row), false)
+	return true, nil
+}
+
+func (s *MemoryStore) DeleteRow(tableName string, keys []string) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, err := s.table(tableName, len(keys))
+	if err != nil {
+		return err
+	}
+	s.write(t, tableName, LedgerRow{Keys: append([]string(nil), keys...)}, true)
+	return nil
+}
+
+func (s *MemoryStore) GetRow(tableName string, keys []string) ([]byte, bool, error) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, err := s.table(tableName, len(keys))
+	if err != nil {
+		return nil, false, err
+	}
+	row, ok := t.rows[rowKey(keys)]
+	if !ok {
+		return nil, false, nil
+	}
+	return append([]byte(nil), row.Value...), true, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// The matching rows are copied out before fn is called, so fn may write to the
+// store. Rows written during the scan are not seen by it
+//////////////////////////////////////////////////////////////////////////////////
+func (s *MemoryStore) ScanRows(tableName string, keys []string, fn func(row LedgerRow) error) error {
+	return s.ScanRowsAfter(tableName, keys, nil, fn)
+}
+
+func (s *MemoryStore) ScanRowsAfter(tableName string, keys []string, after []string, fn func(row LedgerRow) error) error {
+
+	s.mu.Lock()
+	t, ok := s.tables[tableName]
+	if !ok {
+		s.mu.Unlock()
+		return fmt.Errorf("MemoryStore: No table %s", tableName)
+	}
+	if len(keys) > t.nKeys {
+		s.mu.Unlock()
+		return fmt.Errorf("MemoryStore: %s has %d keys, %d given", tableName, t.nKeys, len(keys))
+	}
+	var rows []LedgerRow
+	for _, row := range t.rows {
+		if hasKeyPrefix(row.Keys, keys) && (len(after) == 0 || compareKeys(row.Keys, after) > 0) {
+			rows = append(rows, copyRow(row))
+		}
+	}
+	s.mu.Unlock()
+
+	sort.Slice(rows, func(i, j int) bool { return compareKeys(rows[i].Keys, rows[j].Keys) < 0 })
+	for _, row := range rows {
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) GetState(key string) ([]byte, error) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	value, ok := s.state[key]
+	if !ok {
+		return nil, nil
+	}
+	return append([]byte(nil), value...), nil
+}
+
+func (s *MemoryStore) PutState(key string, value []byte) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	value = append([]byte(nil), value...)
+	if s.snapshot {
+		s.pending = append(s.pending, memoryWrite{row: LedgerRow{Keys: []string{key}, Value: value}})
+		return nil
+	}
+	s.state[key] = value
+	return nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Names of the tables and the number of rows in each, for tests and tools
+// that check what a scenario left behind
+//////////////////////////////////////////////////////////////////////////////////
+func (s *MemoryStore) TableSizes() map[string]int {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sizes := map[string]int{}
+	for name, t := range s.tables {
+		sizes[name] = len(t.rows)
+	}
+	return sizes
+}
+
+// Caller holds the lock
+func (s *MemoryStore) write(t *memoryTable, tableName string, row LedgerRow, del bool) {
+
+	if s.snapshot {
+		s.pending = append(s.pending, memoryWrite{tableName: tableName, row: row, delete: del})
+		return
+	}
+	if del {
+		delete(t.rows, rowKey(row.Keys))
+	} else {
+		t.rows[rowKey(row.Keys)] = row
+	}
+}
+
+// Caller holds the lock
+func (s *MemoryStore) table(tableName string, nKeys int) (*memoryTable, error) {
+
+	t, ok := s.tables[tableName]
+	if !ok {
+		return nil, fmt.Errorf("MemoryStore: No table %s", tableName)
+	}
+	if nKeys != t.nKeys {
+		return nil, fmt.Errorf("MemoryStore: %s has %d keys, %d given", tableName, t.nKeys, nKeys)
+	}
+	return t, nil
+}
+
+func rowKey(keys []string) string {
+	return strings.Join(keys, "\x00")
+}
+
+func copyRow(row LedgerRow) LedgerRow {
+	return LedgerRow{Keys: append([]string(nil), row.Keys...), Value: append([]byte(nil), row.Value...)}
+}
+
+func hasKeyPrefix(keys []string, prefix []string) bool {
+
+	for i, k := range prefix {
+		if keys[i] != k {
+			return false
+		}
+	}
+	return true
+}
+
+func compareKeys(a []string, b []string) int {
+
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if c := strings.Compare(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+	return len(a) - len(b)
+}
@@ -0,0 +1,128 @@
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+//////////////////////////////////////////////////////////////////////////////////
+// A snapshot store reads as the peer does within a transaction: the rows as of
+// the last Commit, not the transaction's own writes
+//////////////////////////////////////////////////////////////////////////////////
+func TestSnapshotMemoryStore(t *testing.T) {
+
+	s := NewSnapshotMemoryStore()
+	if err := s.CreateTable("T", 2); err != nil {
+		t.Fatal(err)
+	}
+	row := func(k2 string, v string) LedgerRow { return LedgerRow{Keys: []string{"a", k2}, Value: []byte(v)} }
+	rows := func() int {
+		n := 0
+		s.ScanRows("T", []string{"a"}, func(LedgerRow) error { n++; return nil })
+		return n
+	}
+
+	if ok, err := s.InsertRow("T", row("1", "one")); !ok || err != nil {
+		t.Fatalf("insert : %v %v", ok, err)
+	}
+	s.PutState("version", []byte("2"))
+
+	// Not seen until committed, so a replace of the new row fails as on the peer
+	if _, found, _ := s.GetRow("T", []string{"a", "1"}); found || rows() != 0 {
+		t.Fatal("own write read back before Commit")
+	}
+	if ok, _ := s.ReplaceRow("T", row("1", "uno")); ok {
+		t.Fatal("replaced a row written in the same transaction")
+	}
+	if v, _ := s.GetState("version"); v != nil {
+		t.Fatalf("state %s before Commit", v)
+	}
+
+	s.Commit()
+	if v, found, _ := s.GetRow("T", []string{"a", "1"}); !found || string(v) != "one" || rows() != 1 {
+		t.Fatalf("after Commit : %s %v", v, found)
+	}
+	if v, _ := s.GetState("version"); string(v) != "2" {
+		t.Fatalf("state %s after Commit", v)
+	}
+
+	// Dropped writes, a delete among them
+	s.InsertRow("T", row("2", "two"))
+	s.DeleteRow("T", []string{"a", "1"})
+	s.Rollback()
+	if rows() != 1 {
+		t.Fatalf("%d rows after Rollback", rows())
+	}
+
+	// The committed row still stands in the way of an insert after a delete
+	s.DeleteRow("T", []string{"a", "1"})
+	if ok, _ := s.InsertRow("T", row("1", "again")); ok {
+		t.Fatal("inserted over a row deleted in the same transaction")
+	}
+
+	// Writes are applied in order
+	s.InsertRow("T", row("3", "three"))
+	s.DeleteRow("T", []string{"a", "3"})
+	s.Commit()
+	if rows() != 0 {
+		t.Fatalf("%d rows after delete, insert and delete", rows())
+	}
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// ScanRowsAfter picks up after a row, in key order, whether or not the row is
+// still there
+//////////////////////////////////////////////////////////////////////////////////
+func TestMemoryStoreScanRowsAfter(t *testing.T) {
+
+	s := NewMemoryStore()
+	if err := s.CreateTable("T", 2); err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range []string{"1", "10", "2", "3"} {
+		s.InsertRow("T", LedgerRow{Keys: []string{"a", k}})
+	}
+	s.InsertRow("T", LedgerRow{Keys: []string{"b", "0"}})
+
+	after := func(keys ...string) string {
+		var got []string
+		s.ScanRowsAfter("T", []string{"a"}, keys, func(row LedgerRow) error {
+			got = append(got, row.Keys[1])
+			return nil
+		})
+		return strings.Join(got, " ")
+	}
+
+	for _, tc := range []struct {
+		after []string
+		want  string
+	}{
+		{nil, "1 10 2 3"},
+		{[]string{"a", "1"}, "10 2 3"},
+		{[]string{"a", "15"}, "2 3"},
+		{[]string{"a", "3"}, ""},
+	} {
+		if got := after(tc.after...); got != tc.want {
+			t.Errorf("after %v : %q, want %q", tc.after, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+//go:build !fabric1 && !fabric2
+// +build !fabric1,!fabric2
+
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package main
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+//////////////////////////////////////////////////////////////////////////////////
+// TableStore over the v0.6 table API of the peer
+// Key columns are STRING, the record column is BYTES
+//////////////////////////////////////////////////////////////////////////////////
+type ShimStore struct {
+	stub shim.ChaincodeStubInterface
+}
+
+func NewShimStore(stub shim.ChaincodeStubInterface) *ShimStore {
+	return &ShimStore{stub: stub}
+}
+
+func (s *ShimStore) CreateTable(tableName string, nKeys int) error {
+
+	var columnDefsForTbl []*shim.ColumnDefinition
+
+	for i := 0; i < nKeys; i++ {
+		columnDef := shim.ColumnDefinition{Name: "keyName" + strconv.Itoa(i), Type: shim.ColumnDefinition_STRING, Key: true}
+		columnDefsForTbl = append(columnDefsForTbl, &columnDef)
+	}
+
+	columnLastTblDef := shim.ColumnDefinition{Name: "Details", Type: shim.ColumnDefinition_BYTES, Key: false}
+	columnDefsForTbl = append(columnDefsForTbl, &columnLastTblDef)
+
+	// Nil is returned if the Table exists or if the table is created successfully
+	return s.stub.CreateTable(tableName, columnDefsForTbl)
+}
+
+func (s *ShimStore) DeleteTable(tableName string) error {
+	return s.stub.DeleteTable(tableName)
+}
+
+func (s *ShimStore) InsertRow(tableName string, row LedgerRow) (bool, error) {
+	return s.stub.InsertRow(tableName, shimRow(row))
+}
+
+func (s *ShimStore) ReplaceRow(tableName string, row LedgerRow) (bool, error) {
+	return s.stub.ReplaceRow(tableName, shimRow(row))
+}
+
+func (s *ShimStore) DeleteRow(tableName string, keys []string) error {
+	return s.stub.DeleteRow(tableName, keyColumns(keys))
+}
+
+func (s *ShimStore) GetRow(tableName string, keys []string) ([]byte, bool, error) {
+
+	row, err := s.stub.GetRow(tableName, keyColumns(keys))
+	if err != nil {
+		return nil, false, err
+	}
+	if len(row.Columns) <= len(keys) {
+		return nil, false, nil
+	}
+	return row.Columns[len(keys)].GetBytes(), true, nil
+}
+
+func (s *ShimStore) ScanRows(tableName string, keys []string, fn func(row LedgerRow) error) error {
+
+	rowChannel, err := s.stub.GetRows(tableName, keyColumns(keys))
+	if err != nil {
+		return err
+	}
+
+	for row := range rowChannel {
+		err = fn(ledgerRow(row))
+		if err != nil {
+			// Drain the channel so that the producer can finish
+			for range rowChannel {
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// The table API cannot start part way through a table, and orders rows by its
+// own encoding of the keys rather than key by key. The rows after the given key
+// are gathered and put in key order before fn sees them
+//////////////////////////////////////////////////////////////////////////////////
+func (s *ShimStore) ScanRowsAfter(tableName string, keys []string, after []string, fn func(row LedgerRow) error) error {
+
+	var rows []LedgerRow
+	err := s.ScanRows(tableName, keys, func(row LedgerRow) error {
+		if compareKeys(row.Keys, after) > 0 {
+			rows = append(rows, row)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(rows, func(i, j int) bool { return compareKeys(rows[i].Keys, rows[j].Keys) < 0 })
+	for _, row := range rows {
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *ShimStore) GetState(key string) ([]byte, error) {
+	return s.stub.GetState(key)
+}
+
+func (s *ShimStore) PutState(key string, value []byte) error {
+	return s.stub.PutState(key, value)
+}
+
+func keyColumns(keys []string) []shim.Column {
+
+	var columns []shim.Column
+	for _, key := range keys {
+		columns = append(columns, shim.Column{Value: &shim.Column_String_{String_: key}})
+	}
+	return columns
+}
+
+func shimRow(row LedgerRow) shim.Row {
+
+	var columns []*shim.Column
+	for _, key := range row.Keys {
+		columns = append(columns, &shim.Column{Value: &shim.Column_String_{String_: key}})
+	}
+	columns = append(columns, &shim.Column{Value: &shim.Column_Bytes{Bytes: row.Value}})
+	return shim.Row{Columns: columns}
+}
+
+// The last column is the record, the ones before it the keys
+func ledgerRow(row shim.Row) LedgerRow {
+
+	n := len(row.Columns) - 1
+	if n < 0 {
+		return LedgerRow{}
+	}
+	out := LedgerRow{Keys: make([]string, n), Value: row.Columns[n].GetBytes()}
+	for i := 0; i < n; i++ {
+		out.Keys[i] = row.Columns[i].GetString_()
+	}
+	return out
+}
@@ -0,0 +1,289 @@
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+//////////////////////////////////////////////////////////////////////////////////
+// Settlement transactions
+// A closed auction settles as one ItemTransaction per party:
+//   BUY        - the buyer, for the hammer price
+//   SALE       - the seller (current owner of the item's artefact), when known
+//   COMMISSION - posted by the Auction House with PostTransaction
+// TransTable holds the transaction itself; ItemTransTable and UserTransTable
+// index it so that it can be found by item and by user. The Fabric transaction
+// ID ends each key, so an auction may carry any number of COMMISSION rows
+//////////////////////////////////////////////////////////////////////////////////
+
+var transTypes = []string{"BUY", "SALE", "COMMISSION"}
+
+func CreateTransObject(args []string) (ItemTransaction, error) {
+
+	var tran ItemTransaction
+
+	// Check there are 9 Arguments
+	if len(args) != 9 {
+		fmt.Println("CreateTransObject(): Incorrect number of arguments. Expecting 9 ")
+		return tran, NewError(ErrInvalidArgument, "CreateTransObject(): Incorrect number of arguments. Expecting 9 ")
+	}
+
+	// RecType is set here, whatever the caller passed, so the row decodes as a transaction
+	tran = ItemTransaction{
+		AuctionID:   args[0],
+		RecType:     "POSTTRAN",
+		ItemID:      args[2],
+		TransType:   strings.ToUpper(args[3]),
+		UserId:      args[4],
+		TransDate:   args[5],
+		HammerTime:  args[6],
+		HammerPrice: args[7],
+		Details:     args[8],
+	}
+
+	err := validateTransaction(tran)
+	if err != nil {
+		return tran, err
+	}
+
+	fmt.Println("CreateTransObject() : Transaction : ", tran)
+	return tran, nil
+}
+
+func validateTransaction(tran ItemTransaction) error {
+
+	if tran.AuctionID == "" || tran.ItemID == "" || tran.UserId == "" {
+		return NewError(ErrInvalidArgument, "validateTransaction(): AuctionID, ItemID and UserId are required")
+	}
+
+	valid := false
+	for _, t := range transTypes {
+		if t == tran.TransType {
+			valid = true
+		}
+	}
+	if !valid {
+		return NewError(ErrInvalidArgument, "validateTransaction(): TransType should be one of "+strings.Join(transTypes, ", ")+" : "+tran.TransType)
+	}
+
+	if _, err := strconv.ParseFloat(tran.HammerPrice, 64); err != nil {
+		return NewError(ErrInvalidArgument, "validateTransaction(): Hammer Price should be a number : "+tran.HammerPrice)
+	}
+	return nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Convert the winning Bid into the buyer's transaction
+// TransDate is left for the caller to set to the settlement date
+//////////////////////////////////////////////////////////////////////////////////
+func BidtoTransaction(bid Bid) ItemTransaction {
+
+	return ItemTransaction{
+		AuctionID:   bid.AuctionID,
+		RecType:     "POSTTRAN",
+		ItemID:      bid.ItemID,
+		TransType:   "BUY",
+		UserId:      bid.BuyerID,
+		HammerTime:  bid.BidTime,
+		HammerPrice: bid.BidPrice,
+		Details:     "Winning bid " + bid.BidNo,
+	}
+}
+
+func TranstoJSON(tran ItemTransaction) ([]byte, error) {
+
+	ajson, err := json.Marshal(tran)
+	if err != nil {
+		fmt.Println("TranstoJSON error: ", err)
+		return nil, err
+	}
+	return ajson, nil
+}
+
+func JSONtoTrans(data []byte) (ItemTransaction, error) {
+
+	tran := ItemTransaction{}
+	err := json.Unmarshal(data, &tran)
+	if err != nil {
+		fmt.Println("JSONtoTrans error: ", err)
+		return tran, err
+	}
+	return tran, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Post a settlement transaction against a closed auction
+// Only the Auction House running the auction may post directly; CloseAuction and BuyItNow post theirs
+// through RecordTransaction. TransDate defaults to the date of the transaction
+// Structure of args AuctionID, RecType, ItemID, TransType, UserId, TransDate, HammerTime, HammerPrice, Details
+// ./peer chaincode invoke -l golang -n mycc -c '{"Function": "PostTransaction", "Args":["1111", "POSTTRAN", "1000", "COMMISSION", "200", "", "2016-05-24 11:00:00", "120", "10% commission"]}'
+//////////////////////////////////////////////////////////////////////////////////
+func PostTransaction(ctx *TxContext, function string, args []string) ([]byte, error) {
+
+	if GetCallerAttribute(ctx, "role") != "AH" {
+		return nil, NewError(ErrUnauthorized, "PostTransaction(): Only an Auction House may post transactions")
+	}
+
+	tran, err := CreateTransObject(args[0:])
+	if err != nil {
+		return nil, err
+	}
+
+	// Reject the transaction if the user is not registered on the Block Chain
+	_, err = ValidateMember(ctx, tran.UserId)
+	if err != nil {
+		fmt.Println("PostTransaction() : Failed User not registered on the block-chain ", tran.UserId)
+		return nil, err
+	}
+
+	// Transactions settle auctions that have closed
+	aucR, err := ctx.Auctions.Get(tran.AuctionID)
+	if err != nil {
+		return nil, NewError(ErrNotFound, "PostTransaction(): Cannot find Auction record : "+tran.AuctionID)
+	}
+	if !CanManageAuction(ctx, aucR) {
+		return nil, NewError(ErrUnauthorized, "PostTransaction(): Only the Auction House running auction "+aucR.AuctionID+" may post transactions against it")
+	}
+	if aucR.Status != "CLOSED" {
+		return nil, NewError(ErrConflict, "PostTransaction(): Auction is not CLOSED : "+tran.AuctionID)
+	}
+	if aucR.ItemID != tran.ItemID {
+		return nil, NewError(ErrInvalidArgument, "PostTransaction(): Item ID mismatch on transaction : "+tran.ItemID)
+	}
+
+	return RecordTransaction(ctx, tran)
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Write a transaction to TransTable and its item and user indexes
+// (see TransactionRepository)
+//////////////////////////////////////////////////////////////////////////////////
+func RecordTransaction(ctx *TxContext, tran ItemTransaction) ([]byte, error) {
+
+	err := validateTransaction(tran)
+	if err != nil {
+		return nil, err
+	}
+
+	if tran.TransDate == "" {
+		txTime, err := GetTxTime(ctx)
+		if err != nil {
+			return nil, err
+		}
+		tran.TransDate = txTime.Format("2006-01-02 15:04:05")
+	}
+	tran.TxID = ctx.Tx.TxID()
+
+	buff, err := ctx.Transactions.Add(tran)
+	if err != nil {
+		fmt.Println("RecordTransaction() : write error while recording transaction for ", tran.AuctionID)
+		return nil, err
+	}
+
+	return buff, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Settle a sale: the buyer's transaction and, when the item has a registered
+// owner, the seller's. Returns the buyer's transaction
+//////////////////////////////////////////////////////////////////////////////////
+func SettleSale(ctx *TxContext, bid Bid, details string) ([]byte, error) {
+
+	tran := BidtoTransaction(bid)
+	if details != "" {
+		tran.Details = details
+	}
+	fmt.Println("SettleSale(): Converting Bid to tran ", tran)
+
+	buff, err := RecordTransaction(ctx, tran)
+	if err != nil {
+		return nil, err
+	}
+
+	owner := GetItemOwner(ctx, bid.ItemID)
+	if owner != "" {
+		sale := tran
+		sale.TransType = "SALE"
+		sale.UserId = owner
+		_, err = RecordTransaction(ctx, sale)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = HandOverSoldItem(ctx, bid)
+	if err != nil {
+		return nil, err
+	}
+
+	AddEvent(ctx, AuctionEvent{Type: EventItemSold, AuctionID: bid.AuctionID, ItemID: bid.ItemID, UserID: bid.BuyerID, PrevUserID: owner, Price: bid.BidPrice})
+	return buff, nil
+}
+
+func decodeTrans(data []byte) (interface{}, error) { return JSONtoTrans(data) }
+
+var transListSpec = ListSpec{Record: ItemTransaction{}, Decode: decodeTrans, DefaultSort: []SortField{{Field: "TransDate"}}, PriceField: "HammerPrice", DateField: "TransDate"}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Transactions for an Auction
+// Accepts the list options in paging.go, e.g. a date range for reconciliation
+// ./peer chaincode query -l golang -n mycc -c '{"Function": "GetTransactionsByAuction", "Args": ["1111"]}'
+//////////////////////////////////////////////////////////////////////////////////
+func GetTransactionsByAuction(ctx *TxContext, function string, args []string) ([]byte, error) {
+	return getTransactions(ctx, "GetTransactionsByAuction", "TransTable", args)
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Transactions for an Item, across all the auctions it has been through
+// ./peer chaincode query -l golang -n mycc -c '{"Function": "GetTransactionsByItem", "Args": ["1000"]}'
+//////////////////////////////////////////////////////////////////////////////////
+func GetTransactionsByItem(ctx *TxContext, function string, args []string) ([]byte, error) {
+	return getTransactions(ctx, "GetTransactionsByItem", "ItemTransTable", args)
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Transactions for a User, whether buyer or seller
+// ./peer chaincode query -l golang -n mycc -c '{"Function": "GetTransactionsByUser", "Args": ["200", "from=2016-05-01 00:00:00", "to=2016-05-31 23:59:59"]}'
+//////////////////////////////////////////////////////////////////////////////////
+func GetTransactionsByUser(ctx *TxContext, function string, args []string) ([]byte, error) {
+	return getTransactions(ctx, "GetTransactionsByUser", "UserTransTable", args)
+}
+
+func getTransactions(ctx *TxContext, fname string, tableName string, args []string) ([]byte, error) {
+
+	if len(args) < 1 {
+		fmt.Println(fname + "(): Incorrect number of arguments. Expecting 1 ")
+		return nil, NewError(ErrInvalidArgument, fname+"(): Incorrect number of arguments. Expecting 1 ")
+	}
+
+	spec := transListSpec
+	spec.TableName = tableName
+
+	buff, err := QueryList(ctx.Store, spec, args)
+	if err != nil {
+		return nil, WrapError(err, fname+"() operation failed")
+	}
+
+	return buff, nil
+}
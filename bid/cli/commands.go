@@ -0,0 +1,357 @@
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ITPeople-Blockchain/auction/bid/client"
+)
+
+//////////////////////////////////////////////////////////////////////////////////
+// What a command runs against
+//////////////////////////////////////////////////////////////////////////////////
+type Env struct {
+	Ctx    context.Context
+	Client *client.Client
+	Out    *Output
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// A subcommand, e.g. "bid place"
+// Positional names the arguments that come before the flags
+//////////////////////////////////////////////////////////////////////////////////
+type Command struct {
+	Group      string
+	Name       string
+	Positional []string
+	Summary    string
+	Setup      func(fs *flag.FlagSet) func(env *Env, pos []string) error
+}
+
+var userTypes = []string{"AH", "TR", "TRD", "AP", "IN", "BK", "SH"}
+
+var commands = []Command{
+
+	{Group: "user", Name: "add", Summary: "Register a user",
+		Setup: func(fs *flag.FlagSet) func(env *Env, pos []string) error {
+			id := fs.String("id", "", "user id (required)")
+			name := fs.String("name", "", "name (required)")
+			utype := fs.String("type", "", "user type: "+strings.Join(userTypes, ", ")+" (required)")
+			address := fs.String("address", "", "postal address")
+			phone := fs.String("phone", "", "phone number")
+			email := fs.String("email", "", "e-mail address")
+			bank := fs.String("bank", "", "bank name")
+			account := fs.String("account", "", "bank account number")
+			routing := fs.String("routing", "", "bank routing number")
+			return func(env *Env, pos []string) error {
+				if err := required(fs, "id", "name", "type"); err != nil {
+					return err
+				}
+				if err := oneOf("type", *utype, userTypes); err != nil {
+					return err
+				}
+				return env.submitted("PostUser")(env.Client.PostUser(env.Ctx, client.UserObject{UserID: *id, Name: *name, UserType: *utype,
+					Address: *address, Phone: *phone, Email: *email, Bank: *bank, AccountNo: *account, RoutingNo: *routing}))
+			}
+		}},
+	{Group: "user", Name: "get", Positional: []string{"USER"}, Summary: "Show a user",
+		Setup: func(fs *flag.FlagSet) func(env *Env, pos []string) error {
+			return func(env *Env, pos []string) error {
+				return env.record(env.Client.GetUser(env.Ctx, pos[0]))
+			}
+		}},
+
+	{Group: "item", Name: "add", Summary: "Register an item",
+		Setup: func(fs *flag.FlagSet) func(env *Env, pos []string) error {
+			id := fs.String("id", "", "item id (required)")
+			desc := fs.String("desc", "", "description (required)")
+			detail := fs.String("detail", "", "details, e.g. the artist")
+			itype := fs.String("type", "", "item type, e.g. Original (required)")
+			subject := fs.String("subject", "", "subject, e.g. Landscape (required)")
+			var docs docFlag
+			fs.Var(&docs, "doc", "document as SHA256,MEDIATYPE,URI; may be repeated")
+			return func(env *Env, pos []string) error {
+				if err := required(fs, "id", "desc", "type", "subject"); err != nil {
+					return err
+				}
+				item := client.ItemObject{ItemID: *id, ItemDesc: *desc, ItemDetail: *detail, ItemType: *itype, ItemSubject: *subject, ItemDocs: []client.ItemDocument(docs)}
+				return env.submitted("PostItem")(env.Client.PostItem(env.Ctx, item))
+			}
+		}},
+	{Group: "item", Name: "get", Positional: []string{"ITEM"}, Summary: "Show an item",
+		Setup: func(fs *flag.FlagSet) func(env *Env, pos []string) error {
+			return func(env *Env, pos []string) error {
+				return env.record(env.Client.GetItem(env.Ctx, pos[0]))
+			}
+		}},
+
+	{Group: "auction", Name: "request", Summary: "Request an auction for an item",
+		Setup: func(fs *flag.FlagSet) func(env *Env, pos []string) error {
+			id := fs.String("id", "", "auction id (required)")
+			item := fs.String("item", "", "item to auction (required)")
+			house := fs.String("house", "", "auction house user id (required)")
+			date := fs.String("date", time.Now().Format("2006-01-02"), "request date, YYYY-MM-DD")
+			return func(env *Env, pos []string) error {
+				if err := required(fs, "id", "item", "house"); err != nil {
+					return err
+				}
+				if _, err := time.Parse("2006-01-02", *date); err != nil {
+					return usageErr("-date should be YYYY-MM-DD")
+				}
+				ar := client.AuctionRequest{AuctionID: *id, ItemID: *item, AuctionHouseID: *house, RequestDate: *date}
+				return env.submitted("PostAuctionRequest")(env.Client.PostAuctionRequest(env.Ctx, ar))
+			}
+		}},
+	{Group: "auction", Name: "open", Positional: []string{"AUCTION"}, Summary: "Open an auction for bids",
+		Setup: func(fs *flag.FlagSet) func(env *Env, pos []string) error {
+			minutes := fs.Int("minutes", 0, "how long the auction stays open (required)")
+			return func(env *Env, pos []string) error {
+				if *minutes <= 0 {
+					return usageErr("-minutes should be a positive number of minutes")
+				}
+				return env.submitted("OpenAuctionForBids")(env.Client.OpenAuctionForBids(env.Ctx, pos[0], *minutes))
+			}
+		}},
+	{Group: "auction", Name: "close", Positional: []string{"AUCTION"}, Summary: "Close an auction and settle the highest bid",
+		Setup: func(fs *flag.FlagSet) func(env *Env, pos []string) error {
+			return func(env *Env, pos []string) error {
+				return env.submitted("CloseAuction")(env.Client.CloseAuction(env.Ctx, pos[0]))
+			}
+		}},
+	{Group: "auction", Name: "buy-now", Positional: []string{"AUCTION"}, Summary: "Buy the item at its buy it now price",
+		Setup: bidFlags("BuyItNow", (*client.Client).BuyItNow)},
+	{Group: "auction", Name: "get", Positional: []string{"AUCTION"}, Summary: "Show an auction",
+		Setup: func(fs *flag.FlagSet) func(env *Env, pos []string) error {
+			return func(env *Env, pos []string) error {
+				return env.record(env.Client.GetAuctionRequest(env.Ctx, pos[0]))
+			}
+		}},
+	{Group: "auction", Name: "list", Summary: "List requested or open auctions",
+		Setup: func(fs *flag.FlagSet) func(env *Env, pos []string) error {
+			status := fs.String("status", "OPEN", "INIT or OPEN")
+			period := fs.String("period", client.DefaultPeriod(), "partitions to list, e.g. 2017 or 2016..2017")
+			paging := pagingFlags(fs)
+			return func(env *Env, pos []string) error {
+				if err := oneOf("status", *status, []string{"INIT", "OPEN"}); err != nil {
+					return err
+				}
+				return env.list(env.Client.ListAuctions(env.Ctx, *status, *period, paging()))
+			}
+		}},
+
+	{Group: "bid", Name: "place", Positional: []string{"AUCTION"}, Summary: "Place a bid",
+		Setup: bidFlags("PostBid", (*client.Client).PostBid)},
+	{Group: "bid", Name: "list", Positional: []string{"AUCTION"}, Summary: "List the bids on an auction",
+		Setup: func(fs *flag.FlagSet) func(env *Env, pos []string) error {
+			paging := pagingFlags(fs)
+			return func(env *Env, pos []string) error {
+				return env.list(env.Client.ListBids(env.Ctx, pos[0], paging()))
+			}
+		}},
+	{Group: "bid", Name: "highest", Positional: []string{"AUCTION"}, Summary: "Show the highest bid",
+		Setup: func(fs *flag.FlagSet) func(env *Env, pos []string) error {
+			return func(env *Env, pos []string) error {
+				return env.record(env.Client.GetHighestBid(env.Ctx, pos[0]))
+			}
+		}},
+}
+
+// PostBid and BuyItNow take the same arguments
+func bidFlags(function string, call func(*client.Client, context.Context, client.Bid) (string, error)) func(fs *flag.FlagSet) func(env *Env, pos []string) error {
+	return func(fs *flag.FlagSet) func(env *Env, pos []string) error {
+		bidNo := fs.String("bid-no", "", "bid number (required)")
+		item := fs.String("item", "", "item on auction (required)")
+		buyer := fs.String("buyer", "", "buyer user id (required)")
+		price := fs.Int("price", 0, "price, a whole amount (required)")
+		return func(env *Env, pos []string) error {
+			if err := required(fs, "bid-no", "item", "buyer"); err != nil {
+				return err
+			}
+			if *price <= 0 {
+				return usageErr("-price should be a positive whole amount")
+			}
+			bid := client.Bid{AuctionID: pos[0], BidNo: *bidNo, ItemID: *item, BuyerID: *buyer, BidPrice: strconv.Itoa(*price)}
+			return env.submitted(function)(call(env.Client, env.Ctx, bid))
+		}
+	}
+}
+
+// The paging options of the list queries (see paging.go in the chaincode)
+func pagingFlags(fs *flag.FlagSet) func() client.ListOptions {
+	size := fs.Int("page-size", 50, "records per page, at most 500")
+	token := fs.String("token", "", "NextToken of the previous page")
+	sortBy := fs.String("sort", "", "fields to order by, e.g. BidPrice:desc")
+	return func() client.ListOptions {
+		return client.ListOptions{PageSize: *size, Token: *token, Sort: *sortBy}
+	}
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Flag checks
+//////////////////////////////////////////////////////////////////////////////////
+func required(fs *flag.FlagSet, names ...string) error {
+
+	var missing []string
+	for _, name := range names {
+		if strings.TrimSpace(fs.Lookup(name).Value.String()) == "" {
+			missing = append(missing, "-"+name)
+		}
+	}
+	if len(missing) > 0 {
+		return usageErr("missing " + strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func oneOf(name string, value string, allowed []string) error {
+	for _, a := range allowed {
+		if value == a {
+			return nil
+		}
+	}
+	return usageErr(fmt.Sprintf("-%s should be one of %s", name, strings.Join(allowed, ", ")))
+}
+
+// A command line that is wrong, as opposed to a call that failed
+type usageError string
+
+func (e usageError) Error() string {
+	return string(e)
+}
+
+func usageErr(msg string) error {
+	return usageError(msg)
+}
+
+// -doc SHA256,MEDIATYPE,URI
+type docFlag []client.ItemDocument
+
+func (d *docFlag) String() string {
+	return fmt.Sprint(len(*d), " documents")
+}
+
+func (d *docFlag) Set(value string) error {
+
+	parts := strings.SplitN(value, ",", 3)
+	if len(parts) != 3 {
+		return errors.New("expecting SHA256,MEDIATYPE,URI")
+	}
+	if b, err := hex.DecodeString(parts[0]); err != nil || len(b) != 32 {
+		return errors.New("document hash should be a hex encoded SHA-256")
+	}
+	*d = append(*d, client.ItemDocument{DocHash: parts[0], MediaType: parts[1], DocURI: parts[2]})
+	return nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Parse a command line: positional arguments first, then flags
+//////////////////////////////////////////////////////////////////////////////////
+func (c *Command) Parse(args []string) (func(env *Env, pos []string) error, []string, error) {
+
+	fs := flag.NewFlagSet(c.Group+" "+c.Name, flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	run := c.Setup(fs)
+
+	if len(args) < len(c.Positional) {
+		return nil, nil, fmt.Errorf("usage: %s", c.Usage(fs))
+	}
+	pos := args[:len(c.Positional)]
+	for i, p := range pos {
+		if p == "" || strings.HasPrefix(p, "-") {
+			return nil, nil, fmt.Errorf("expecting %s before the flags; usage: %s", c.Positional[i], c.Usage(fs))
+		}
+	}
+
+	if err := fs.Parse(args[len(c.Positional):]); err != nil {
+		return nil, nil, fmt.Errorf("%v; usage: %s", err, c.Usage(fs))
+	}
+	if fs.NArg() > 0 {
+		return nil, nil, fmt.Errorf("unexpected argument %q; usage: %s", fs.Arg(0), c.Usage(fs))
+	}
+	return run, pos, nil
+}
+
+func (c *Command) Usage(fs *flag.FlagSet) string {
+
+	usage := c.Group + " " + c.Name
+	for _, p := range c.Positional {
+		usage += " " + p
+	}
+	fs.VisitAll(func(f *flag.Flag) {
+		usage += " [-" + f.Name + "]"
+	})
+	return usage
+}
+
+func (c *Command) Help() string {
+
+	fs := flag.NewFlagSet(c.Group+" "+c.Name, flag.ContinueOnError)
+	c.Setup(fs)
+	help := c.Summary + "\n\nusage: " + c.Usage(fs) + "\n"
+	fs.VisitAll(func(f *flag.Flag) {
+		help += fmt.Sprintf("  -%s\t%s\n", f.Name, f.Usage)
+	})
+	return help
+}
+
+func findCommand(group string, name string) *Command {
+	for i := range commands {
+		if commands[i].Group == group && commands[i].Name == name {
+			return &commands[i]
+		}
+	}
+	return nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Print what a client call returned
+//////////////////////////////////////////////////////////////////////////////////
+func (env *Env) submitted(function string) func(txID string, err error) error {
+	return func(txID string, err error) error {
+		if err != nil {
+			return err
+		}
+		return env.Out.Submitted(function, txID)
+	}
+}
+
+func (env *Env) record(record interface{}, err error) error {
+	if err != nil {
+		return err
+	}
+	return env.Out.Record(record)
+}
+
+// page is one of the client's page types
+func (env *Env) list(page interface{}, err error) error {
+	if err != nil {
+		return err
+	}
+	return env.Out.List(page)
+}
@@ -0,0 +1,152 @@
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+//////////////////////////////////////////////////////////////////////////////////
+// auction - command line client for the auction chaincode
+//
+//   auction -peer http://localhost:7050 -chaincode mycc user add -id 100 -name "Ashley Hart" -type AH
+//   auction -transport mock -mock-state ledger.json bid place 1111 -bid-no 1 -item 1000 -buyer 300 -price 1200
+//   auction -o json bid list 1111 -sort BidPrice:desc
+//////////////////////////////////////////////////////////////////////////////////
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ITPeople-Blockchain/auction/bid/client"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout io.Writer, stderr io.Writer) int {
+
+	fs := flag.NewFlagSet("auction", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	transport := fs.String("transport", "peer", "peer, or mock for the in-process mock ledger")
+	peerURL := fs.String("peer", envOr("AUCTION_PEER", "http://localhost:7050"), "peer REST address ($AUCTION_PEER)")
+	chaincode := fs.String("chaincode", os.Getenv("AUCTION_CHAINCODE"), "name of the deployed chaincode ($AUCTION_CHAINCODE)")
+	user := fs.String("user", os.Getenv("AUCTION_USER"), "enrolled user to transact as ($AUCTION_USER)")
+	attributes := fs.String("attributes", "", "comma separated certificate attributes to pass, e.g. role")
+	mockState := fs.String("mock-state", "", "file the mock ledger keeps its state in between runs")
+	format := fs.String("o", "table", "output format: table or json")
+	timeout := fs.Duration("timeout", 30*time.Second, "how long to wait for the peer")
+	fs.Usage = func() { usage(stderr, fs) }
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *format != "table" && *format != "json" {
+		fmt.Fprintln(stderr, "auction: -o should be table or json")
+		return 2
+	}
+
+	rest := fs.Args()
+	if len(rest) == 0 || rest[0] == "help" {
+		if len(rest) == 3 {
+			if c := findCommand(rest[1], rest[2]); c != nil {
+				fmt.Fprint(stdout, c.Help())
+				return 0
+			}
+		}
+		usage(stderr, fs)
+		return 2
+	}
+	if len(rest) < 2 {
+		fmt.Fprintf(stderr, "auction: %s needs a command; see auction help\n", rest[0])
+		return 2
+	}
+	cmd := findCommand(rest[0], rest[1])
+	if cmd == nil {
+		fmt.Fprintf(stderr, "auction: unknown command %q; see auction help\n", rest[0]+" "+rest[1])
+		return 2
+	}
+	runCmd, pos, err := cmd.Parse(rest[2:])
+	if err != nil {
+		fmt.Fprintf(stderr, "auction: %v\n", err)
+		return 2
+	}
+
+	var t client.Transport
+	switch *transport {
+	case "peer":
+		if *chaincode == "" {
+			fmt.Fprintln(stderr, "auction: -chaincode is required with the peer transport")
+			return 2
+		}
+		p := &client.PeerTransport{URL: *peerURL, ChaincodeID: *chaincode, SecureContext: *user}
+		if *attributes != "" {
+			p.Attributes = strings.Split(*attributes, ",")
+		}
+		t = p
+	case "mock":
+		m, err := client.NewMockLedger(*mockState)
+		if err != nil {
+			fmt.Fprintf(stderr, "auction: %v\n", err)
+			return 1
+		}
+		t = m
+	default:
+		fmt.Fprintln(stderr, "auction: -transport should be peer or mock")
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	env := &Env{Ctx: ctx, Client: client.New(t), Out: &Output{W: stdout, JSON: *format == "json"}}
+	if err := runCmd(env, pos); err != nil {
+		fmt.Fprintf(stderr, "auction: %s %s: %v\n", cmd.Group, cmd.Name, err)
+		if _, ok := err.(usageError); ok || client.CodeOf(err) == client.InvalidArgument {
+			return 2
+		}
+		return 1
+	}
+	return 0
+}
+
+func usage(w io.Writer, fs *flag.FlagSet) {
+
+	fmt.Fprintln(w, "usage: auction [flags] <group> <command> [arguments] [command flags]")
+	fmt.Fprintln(w, "\ncommands:")
+	for _, c := range commands {
+		name := c.Group + " " + c.Name
+		for _, p := range c.Positional {
+			name += " " + p
+		}
+		fmt.Fprintf(w, "  %-26s %s\n", name, c.Summary)
+	}
+	fmt.Fprintln(w, "\nauction help <group> <command> shows a command's flags\n\nflags:")
+	fs.SetOutput(w)
+	fs.PrintDefaults()
+}
+
+func envOr(name string, def string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,119 @@
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+//////////////////////////////////////////////////////////////////////////////////
+// A session against the mock ledger, kept in a file between the runs as it
+// would be between invocations of the command
+//////////////////////////////////////////////////////////////////////////////////
+func TestSession(t *testing.T) {
+
+	t.Setenv("AUCTION_CHAINCODE", "")
+	state := filepath.Join(t.TempDir(), "ledger.json")
+	docHash := strings.Repeat("ab", 32)
+
+	steps := []struct {
+		args   string
+		code   int
+		stdout string // Part of the output expected
+		stderr string
+	}{
+		{"user add -id 100 -name House -type AH", 0, "PostUser submitted as transaction mock-1", ""},
+		{"user add -id 300 -name Bob -type TR", 0, "submitted", ""},
+		{"user add -id 300 -name Bob -type TR", 1, "", "auction: user add: PostUser: user 300 already exists"},
+		{"user add -id 400 -name Eve -type ZZ", 2, "", "-type should be one of AH, TR"},
+		{"user add -name Eve", 2, "", "missing -id, -type"},
+		{"user get 999", 1, "", "Object not found : 999"},
+		{"user get 300", 0, "UserType        TR", ""},
+
+		{"item add -id 1000 -desc Painting -type Original -subject Landscape -doc " + docHash + ",image/png,s3://a", 0, "PostItem submitted", ""},
+		{"item add -id 1001 -desc Print -type Print -subject Landscape -doc abc", 2, "", "expecting SHA256,MEDIATYPE,URI"},
+		{"item get 1000", 0, "ItemDocs[0]     " + docHash + "  image/png  s3://a", ""},
+
+		{"auction request -id 1111 -item 1000 -house 100 -date 2017-03-05", 0, "PostAuctionRequest submitted", ""},
+		{"auction request -id 2222 -item 1000 -house 100 -date 05/03/2017", 2, "", "-date should be YYYY-MM-DD"},
+		{"auction request -id 2222 -item 9 -house 100", 1, "", "item 9 is not registered"},
+		{"auction list -status INIT", 0, "1111       1000", ""},
+		{"auction list -status CLOSED", 2, "", "-status should be one of INIT, OPEN"},
+		{"bid place 1111 -bid-no 1 -item 1000 -buyer 300 -price 1000", 1, "", "auction 1111 is not open for bids"},
+		{"auction open 1111 -minutes 0", 2, "", "-minutes should be a positive number of minutes"},
+		{"auction open 1111 -minutes 60", 0, "OpenAuctionForBids submitted", ""},
+		{"auction list", 0, "OPEN", ""},
+
+		{"bid place 1111 -bid-no 1 -item 1000 -buyer 300 -price 1000", 0, "PostBid submitted", ""},
+		{"bid place 1111 -bid-no 2 -item 1000 -buyer 300 -price 900", 1, "", "does not beat the highest bid of 1000"},
+		{"bid place 1111 -bid-no 2 -item 1000 -buyer 300 -price -5", 2, "", "-price should be a positive whole amount"},
+		{"bid place -bid-no 2 -item 1000 -buyer 300 -price 1100", 2, "", "expecting AUCTION before the flags"},
+		{"bid place 1111 -bid-no 2 -item 1000 -buyer 300 -price 1100 extra", 2, "", `unexpected argument "extra"`},
+		{"bid place 1111 -bid-no 2 -item 1000 -buyer 300 -price 1100", 0, "PostBid submitted", ""},
+		{"-o json bid highest 1111", 0, `"BidPrice": "1100"`, ""},
+		{"bid list 1111 -page-size 1 -sort BidPrice:desc", 0, "more: -token 1", ""},
+		{"bid list 1111 -page-size 1 -sort BidPrice:desc -token 1", 0, "1      300      1000", ""},
+		{"auction close 1111", 0, "CloseAuction submitted", ""},
+		{"auction list", 0, "(none)", ""},
+	}
+
+	for i, s := range steps {
+		var stdout, stderr bytes.Buffer
+		args := append([]string{"-transport", "mock", "-mock-state", state}, strings.Fields(s.args)...)
+		code := run(args, &stdout, &stderr)
+		if code != s.code || !strings.Contains(stdout.String(), s.stdout) || !strings.Contains(stderr.String(), s.stderr) {
+			t.Fatalf("step %d, auction %s : exit %d\nstdout:\n%s\nstderr:\n%s", i, s.args, code, stdout.String(), stderr.String())
+		}
+	}
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Command lines refused before any command runs
+//////////////////////////////////////////////////////////////////////////////////
+func TestCommandLine(t *testing.T) {
+
+	t.Setenv("AUCTION_CHAINCODE", "")
+	cases := []struct {
+		args   string
+		code   int
+		stdout string
+		stderr string
+	}{
+		{"", 2, "", "usage: auction"},
+		{"help bid place", 0, "Place a bid\n\nusage: bid place AUCTION [-bid-no] [-buyer] [-item] [-price]", ""},
+		{"bid", 2, "", "auction: bid needs a command"},
+		{"bid withdraw 1111", 2, "", `auction: unknown command "bid withdraw"`},
+		{"-o xml user get 100", 2, "", "-o should be table or json"},
+		{"-transport carrier user get 100", 2, "", "-transport should be peer or mock"},
+		{"user get 100", 2, "", "-chaincode is required with the peer transport"},
+		{"-nosuchflag user get 100", 2, "", "flag provided but not defined: -nosuchflag"},
+	}
+
+	for _, c := range cases {
+		var stdout, stderr bytes.Buffer
+		code := run(strings.Fields(c.args), &stdout, &stderr)
+		if code != c.code || !strings.Contains(stdout.String(), c.stdout) || !strings.Contains(stderr.String(), c.stderr) {
+			t.Errorf("auction %s : exit %d\nstdout:\n%s\nstderr:\n%s", c.args, code, stdout.String(), stderr.String())
+		}
+	}
+}
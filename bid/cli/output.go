@@ -0,0 +1,124 @@
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+)
+
+//////////////////////////////////////////////////////////////////////////////////
+// Prints results as tables for people or as JSON for scripts
+//////////////////////////////////////////////////////////////////////////////////
+type Output struct {
+	W    io.Writer
+	JSON bool
+}
+
+// Columns shown when listing records; a single record shows all its fields
+var listColumns = map[string][]string{
+	"AuctionRequest": {"AuctionID", "ItemID", "AuctionHouseID", "Status", "OpenDate", "CloseDate"},
+	"Bid":            {"BidNo", "BuyerID", "BidPrice", "BidTime"},
+}
+
+func (o *Output) Submitted(function string, txID string) error {
+
+	if o.JSON {
+		return o.writeJSON(map[string]string{"Function": function, "TxID": txID})
+	}
+	_, err := fmt.Fprintf(o.W, "%s submitted as transaction %s\n", function, txID)
+	return err
+}
+
+func (o *Output) Record(record interface{}) error {
+
+	if o.JSON {
+		return o.writeJSON(record)
+	}
+
+	tw := tabwriter.NewWriter(o.W, 0, 4, 2, ' ', 0)
+	v := reflect.Indirect(reflect.ValueOf(record))
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Field(i)
+		if f.Kind() == reflect.Slice {
+			for j := 0; j < f.Len(); j++ {
+				fmt.Fprintf(tw, "%s[%d]\t%s\n", v.Type().Field(i).Name, j, values(f.Index(j)))
+			}
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%v\n", v.Type().Field(i).Name, f.Interface())
+	}
+	return tw.Flush()
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// A page of a list query, one of the client's page types: Items, Count and
+// NextToken
+//////////////////////////////////////////////////////////////////////////////////
+func (o *Output) List(page interface{}) error {
+
+	if o.JSON {
+		return o.writeJSON(page)
+	}
+
+	v := reflect.Indirect(reflect.ValueOf(page))
+	items := v.FieldByName("Items")
+	if items.Len() == 0 {
+		_, err := fmt.Fprintln(o.W, "(none)")
+		return err
+	}
+
+	columns := listColumns[items.Type().Elem().Name()]
+	tw := tabwriter.NewWriter(o.W, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(columns, "\t"))
+	for i := 0; i < items.Len(); i++ {
+		row := make([]string, len(columns))
+		for j, c := range columns {
+			row[j] = fmt.Sprint(items.Index(i).FieldByName(c).Interface())
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if token := v.FieldByName("NextToken").String(); token != "" {
+		fmt.Fprintf(o.W, "more: -token %s\n", token)
+	}
+	return nil
+}
+
+func values(v reflect.Value) string {
+
+	var parts []string
+	for i := 0; i < v.NumField(); i++ {
+		parts = append(parts, fmt.Sprint(v.Field(i).Interface()))
+	}
+	return strings.Join(parts, "  ")
+}
+
+func (o *Output) writeJSON(v interface{}) error {
+	enc := json.NewEncoder(o.W)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
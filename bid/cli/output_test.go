@@ -0,0 +1,79 @@
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/ITPeople-Blockchain/auction/bid/client"
+)
+
+func TestOutputTable(t *testing.T) {
+
+	var buf bytes.Buffer
+	out := &Output{W: &buf}
+
+	out.Submitted("PostBid", "tx-1")
+	if buf.String() != "PostBid submitted as transaction tx-1\n" {
+		t.Fatalf("submitted %q", buf.String())
+	}
+
+	buf.Reset()
+	out.Record(client.Bid{AuctionID: "1111", BidNo: "1", BidPrice: "1200"})
+	expected := "AuctionID  1111\nRecType    \nBidNo      1\nItemID     \nBuyerID    \nBidPrice   1200\nBidTime    \n"
+	if buf.String() != expected {
+		t.Fatalf("record %q", buf.String())
+	}
+
+	buf.Reset()
+	out.List(client.BidPage{Items: []client.Bid{{BidNo: "1", BuyerID: "300", BidPrice: "1200", BidTime: "2017-03-05 12:00:00"}}, Count: 1, NextToken: "abc"})
+	expected = "BidNo  BuyerID  BidPrice  BidTime\n1      300      1200      2017-03-05 12:00:00\nmore: -token abc\n"
+	if buf.String() != expected {
+		t.Fatalf("list %q", buf.String())
+	}
+
+	buf.Reset()
+	out.List(client.AuctionPage{})
+	if buf.String() != "(none)\n" {
+		t.Fatalf("empty list %q", buf.String())
+	}
+}
+
+func TestOutputJSON(t *testing.T) {
+
+	var buf bytes.Buffer
+	out := &Output{W: &buf, JSON: true}
+
+	out.Submitted("PostBid", "tx-1")
+	var submitted map[string]string
+	if err := json.Unmarshal(buf.Bytes(), &submitted); err != nil || submitted["Function"] != "PostBid" || submitted["TxID"] != "tx-1" {
+		t.Fatalf("submitted %s : %v", buf.String(), err)
+	}
+
+	buf.Reset()
+	page := client.BidPage{Items: []client.Bid{{BidNo: "1"}}, Count: 1}
+	out.List(page)
+	var decoded client.BidPage
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || decoded.Count != 1 || decoded.Items[0].BidNo != "1" {
+		t.Fatalf("list %s : %v", buf.String(), err)
+	}
+}
@@ -0,0 +1,237 @@
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package client
+
+import (
+	"context"
+	"strconv"
+)
+
+//////////////////////////////////////////////////////////////////////////////////
+// Auctions
+//////////////////////////////////////////////////////////////////////////////////
+
+// Request an auction for an item. Status is set to INIT
+func (c *Client) PostAuctionRequest(ctx context.Context, a AuctionRequest) (string, error) {
+	if err := require("PostAuctionRequest", "AuctionID", a.AuctionID, "ItemID", a.ItemID,
+		"AuctionHouseID", a.AuctionHouseID, "RequestDate", a.RequestDate); err != nil {
+		return "", err
+	}
+	return c.invoke(ctx, "PostAuctionRequest", a.AuctionID, "AUCREQ", a.ItemID, a.AuctionHouseID, a.RequestDate, "INIT", "", "")
+}
+
+func (c *Client) GetAuctionRequest(ctx context.Context, auctionID string) (AuctionRequest, error) {
+	var a AuctionRequest
+	if err := require("GetAuctionRequest", "AuctionID", auctionID); err != nil {
+		return a, err
+	}
+	err := c.query(ctx, &a, "auction "+auctionID, "GetAuctionRequest", auctionID)
+	return a, err
+}
+
+// Open a requested auction for bids, for the given number of minutes
+func (c *Client) OpenAuctionForBids(ctx context.Context, auctionID string, minutes int) (string, error) {
+	if err := require("OpenAuctionForBids", "AuctionID", auctionID); err != nil {
+		return "", err
+	}
+	if err := positive("OpenAuctionForBids", "minutes", minutes); err != nil {
+		return "", err
+	}
+	return c.invoke(ctx, "OpenAuctionForBids", auctionID, "OPENAUC", strconv.Itoa(minutes))
+}
+
+// Push an open auction's close date out by minutes
+func (c *Client) ExtendAuction(ctx context.Context, auctionID string, minutes int) (string, error) {
+	if err := require("ExtendAuction", "AuctionID", auctionID); err != nil {
+		return "", err
+	}
+	if err := positive("ExtendAuction", "minutes", minutes); err != nil {
+		return "", err
+	}
+	return c.invoke(ctx, "ExtendAuction", auctionID, "EXTAUC", strconv.Itoa(minutes))
+}
+
+// Close an auction and settle it with the highest bid
+func (c *Client) CloseAuction(ctx context.Context, auctionID string) (string, error) {
+	if err := require("CloseAuction", "AuctionID", auctionID); err != nil {
+		return "", err
+	}
+	return c.invoke(ctx, "CloseAuction", auctionID, "AUCREQ")
+}
+
+// Close the open auctions past their close date. An empty period leaves it
+// to the chaincode, which looks in every partition
+func (c *Client) CloseOpenAuctions(ctx context.Context, period string) (string, error) {
+	if period == "" {
+		return c.invoke(ctx, "CloseOpenAuctions", "CLAUC")
+	}
+	return c.invoke(ctx, "CloseOpenAuctions", "CLAUC", period)
+}
+
+// Auctions in period with status INIT (requested) or OPEN (taking bids)
+func (c *Client) ListAuctions(ctx context.Context, status string, period string, opts ListOptions) (AuctionPage, error) {
+	var page AuctionPage
+	var function string
+	switch status {
+	case "INIT":
+		function = "GetListOfInitAucs"
+	case "OPEN":
+		function = "GetListOfOpenAucs"
+	default:
+		return page, invalid("ListAuctions", "status should be INIT or OPEN, not %q", status)
+	}
+	err := c.query(ctx, &page, "auctions", function, append([]string{periodOrDefault(period)}, opts.args()...)...)
+	return page, err
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Bids
+// BidTime is set by the chaincode; whatever the caller puts there is ignored
+//////////////////////////////////////////////////////////////////////////////////
+
+func bidArgs(function string, b Bid) ([]string, error) {
+	if err := require(function, "AuctionID", b.AuctionID, "BidNo", b.BidNo, "ItemID", b.ItemID,
+		"BuyerID", b.BuyerID, "BidPrice", b.BidPrice); err != nil {
+		return nil, err
+	}
+	return []string{b.AuctionID, "BID", b.BidNo, b.ItemID, b.BuyerID, b.BidPrice}, nil
+}
+
+func (c *Client) PostBid(ctx context.Context, b Bid) (string, error) {
+	args, err := bidArgs("PostBid", b)
+	if err != nil {
+		return "", err
+	}
+	return c.invoke(ctx, "PostBid", args...)
+}
+
+// Buy the item at its buy it now price, closing the auction
+func (c *Client) BuyItNow(ctx context.Context, b Bid) (string, error) {
+	args, err := bidArgs("BuyItNow", b)
+	if err != nil {
+		return "", err
+	}
+	return c.invoke(ctx, "BuyItNow", args...)
+}
+
+func (c *Client) GetBid(ctx context.Context, auctionID string, bidNo string) (Bid, error) {
+	var b Bid
+	if err := require("GetBid", "AuctionID", auctionID, "BidNo", bidNo); err != nil {
+		return b, err
+	}
+	err := c.query(ctx, &b, "bid "+bidNo+" of auction "+auctionID, "GetBid", auctionID, bidNo)
+	return b, err
+}
+
+// The latest bid; NotFound if there are none
+func (c *Client) GetLastBid(ctx context.Context, auctionID string) (Bid, error) {
+	var b Bid
+	if err := require("GetLastBid", "AuctionID", auctionID); err != nil {
+		return b, err
+	}
+	err := c.query(ctx, &b, "bids on auction "+auctionID, "GetLastBid", auctionID)
+	return b, err
+}
+
+// The highest bid; NotFound if there are none
+func (c *Client) GetHighestBid(ctx context.Context, auctionID string) (Bid, error) {
+	var b Bid
+	if err := require("GetHighestBid", "AuctionID", auctionID); err != nil {
+		return b, err
+	}
+	err := c.query(ctx, &b, "bids on auction "+auctionID, "GetHighestBid", auctionID)
+	return b, err
+}
+
+func (c *Client) GetNoOfBidsReceived(ctx context.Context, auctionID string) (int, error) {
+	var n int
+	if err := require("GetNoOfBidsReceived", "AuctionID", auctionID); err != nil {
+		return 0, err
+	}
+	err := c.query(ctx, &n, "auction "+auctionID, "GetNoOfBidsReceived", auctionID)
+	return n, err
+}
+
+func (c *Client) ListBids(ctx context.Context, auctionID string, opts ListOptions) (BidPage, error) {
+	var page BidPage
+	if err := require("GetListOfBids", "AuctionID", auctionID); err != nil {
+		return page, err
+	}
+	err := c.query(ctx, &page, "bids", "GetListOfBids", append([]string{auctionID}, opts.args()...)...)
+	return page, err
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Settlement transactions of closed auctions
+//////////////////////////////////////////////////////////////////////////////////
+
+func (c *Client) PostTransaction(ctx context.Context, t ItemTransaction) (string, error) {
+	if err := require("PostTransaction", "AuctionID", t.AuctionID, "ItemID", t.ItemID, "TransType", t.TransType,
+		"UserId", t.UserId, "HammerPrice", t.HammerPrice); err != nil {
+		return "", err
+	}
+	return c.invoke(ctx, "PostTransaction", t.AuctionID, "POSTTRAN", t.ItemID, t.TransType, t.UserId,
+		t.TransDate, t.HammerTime, t.HammerPrice, t.Details)
+}
+
+func (c *Client) GetTransactionsByAuction(ctx context.Context, auctionID string, opts ListOptions) (TransactionPage, error) {
+	return c.listTransactions(ctx, "GetTransactionsByAuction", "AuctionID", auctionID, opts)
+}
+
+func (c *Client) GetTransactionsByItem(ctx context.Context, itemID string, opts ListOptions) (TransactionPage, error) {
+	return c.listTransactions(ctx, "GetTransactionsByItem", "ItemID", itemID, opts)
+}
+
+func (c *Client) GetTransactionsByUser(ctx context.Context, userID string, opts ListOptions) (TransactionPage, error) {
+	return c.listTransactions(ctx, "GetTransactionsByUser", "UserID", userID, opts)
+}
+
+func (c *Client) listTransactions(ctx context.Context, function string, name string, id string, opts ListOptions) (TransactionPage, error) {
+	var page TransactionPage
+	if err := require(function, name, id); err != nil {
+		return page, err
+	}
+	err := c.query(ctx, &page, "transactions", function, append([]string{id}, opts.args()...)...)
+	return page, err
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Administration
+//////////////////////////////////////////////////////////////////////////////////
+
+// Move records from the legacy partition into their own (partition.go).
+// Only an Auction House may do this
+func (c *Client) ReindexPartitions(ctx context.Context, partitions ...string) (string, error) {
+	return c.invoke(ctx, "ReindexPartitions", append([]string{"REINDEX"}, partitions...)...)
+}
+
+// Recompute the bid summaries of the given auctions, or of every auction with
+// bids when none is given (bid_summary.go). Only an Auction House may do this
+func (c *Client) RebuildBidSummaries(ctx context.Context, auctionIDs ...string) (string, error) {
+	return c.invoke(ctx, "RebuildBidSummaries", append([]string{"BIDSUM"}, auctionIDs...)...)
+}
+
+func (c *Client) GetVersion(ctx context.Context) (string, error) {
+	var v struct {
+		Version string `json:"version"`
+	}
+	err := c.query(ctx, &v, "version", "GetVersion", "version")
+	return v.Version, err
+}
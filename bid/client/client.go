@@ -0,0 +1,181 @@
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+//////////////////////////////////////////////////////////////////////////////////
+// Package client is a typed Go client for the auction chaincode
+//
+//   import "github.com/ITPeople-Blockchain/auction/bid/client"
+//
+//   c := client.New(&client.PeerTransport{URL: "http://localhost:7050", ChaincodeID: "mycc"})
+//   txID, err := c.PostBid(ctx, client.Bid{AuctionID: "1111", BidNo: "1", ItemID: "1000", BuyerID: "300", BidPrice: "1200"})
+//   bid, err := c.GetHighestBid(ctx, "1111")
+//   if client.IsNotFound(err) { ... }
+//
+// The client lays out the positional args each chaincode function expects, and
+// decodes the results into the chaincode's record types. Every method returns
+// a *client.Error, whose Code says what kind of failure it was.
+// Invokes return the id of the transaction: the peer commits it asynchronously.
+// Tests can run against NewMockLedger instead of a peer
+//////////////////////////////////////////////////////////////////////////////////
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+//////////////////////////////////////////////////////////////////////////////////
+// How calls reach the chaincode
+// Invoke returns the id of the transaction, Query the chaincode's response.
+// A call the peer refused should come back as a *PeerError
+//////////////////////////////////////////////////////////////////////////////////
+type Transport interface {
+	Invoke(ctx context.Context, function string, args []string) (string, error)
+	Query(ctx context.Context, function string, args []string) ([]byte, error)
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// A transport that can also send transient data, which the chaincode reads
+// but the ledger does not keep. The artefact methods need one for their keys
+//////////////////////////////////////////////////////////////////////////////////
+type TransientTransport interface {
+	Transport
+	InvokeTransient(ctx context.Context, function string, args []string, transient map[string][]byte) (string, error)
+	QueryTransient(ctx context.Context, function string, args []string, transient map[string][]byte) ([]byte, error)
+}
+
+type Client struct {
+	transport Transport
+}
+
+func New(transport Transport) *Client {
+	return &Client{transport: transport}
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Paging, sorting and filtering of the list methods (see paging.go in the
+// chaincode). Zero values are left out; PageSize defaults to 50
+//////////////////////////////////////////////////////////////////////////////////
+type ListOptions struct {
+	PageSize int
+	Token    string // NextToken of the previous page
+	Sort     string // e.g. "BidPrice:desc,BidNo"
+	Status   string
+	MinPrice string
+	MaxPrice string
+	From     string // 2006-01-02 15:04:05
+	To       string
+}
+
+func (o ListOptions) args() []string {
+
+	size := o.PageSize
+	if size == 0 {
+		size = 50
+	}
+	args := []string{"pageSize=" + strconv.Itoa(size)}
+	for _, opt := range []struct{ name, value string }{
+		{"token", o.Token}, {"sort", o.Sort}, {"status", o.Status}, {"minPrice", o.MinPrice},
+		{"maxPrice", o.MaxPrice}, {"from", o.From}, {"to", o.To},
+	} {
+		if opt.value != "" {
+			args = append(args, opt.name+"="+opt.value)
+		}
+	}
+	return args
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Partitions listed when a list method is given no period: records from
+// before partitioning are in 2016, so 2016 to the current year covers all
+//////////////////////////////////////////////////////////////////////////////////
+func DefaultPeriod() string {
+	return fmt.Sprintf("2016..%d", time.Now().Year())
+}
+
+func periodOrDefault(p string) string {
+	if p == "" {
+		return DefaultPeriod()
+	}
+	return p
+}
+
+func (c *Client) invoke(ctx context.Context, function string, args ...string) (string, error) {
+
+	txID, err := c.transport.Invoke(ctx, function, args)
+	if err != nil {
+		return "", TransportError(function, err)
+	}
+	return txID, nil
+}
+
+// Invoke with transient data, which needs a TransientTransport
+func (c *Client) invokeTransient(ctx context.Context, transient map[string][]byte, function string, args ...string) (string, error) {
+
+	t, ok := c.transport.(TransientTransport)
+	if !ok {
+		return "", invalid(function, "the transport cannot send transient data")
+	}
+	txID, err := t.InvokeTransient(ctx, function, args, transient)
+	if err != nil {
+		return "", TransportError(function, err)
+	}
+	return txID, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Query and decode the result into v
+// An empty result, which some queries give when there is nothing to return,
+// is reported as what not found
+//////////////////////////////////////////////////////////////////////////////////
+func (c *Client) query(ctx context.Context, v interface{}, what string, function string, args ...string) error {
+
+	data, err := c.transport.Query(ctx, function, args)
+	if err != nil {
+		return TransportError(function, err)
+	}
+	if len(data) == 0 {
+		return notFound(function, what)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return &Error{Function: function, Code: Unknown, Message: "unexpected response: " + err.Error(), Err: err}
+	}
+	return nil
+}
+
+// Fields that must not be empty, as name, value pairs
+func require(function string, fields ...string) error {
+
+	for i := 0; i+1 < len(fields); i += 2 {
+		if fields[i+1] == "" {
+			return invalid(function, "%s is required", fields[i])
+		}
+	}
+	return nil
+}
+
+func positive(function string, name string, n int) error {
+	if n <= 0 {
+		return invalid(function, "%s should be positive", name)
+	}
+	return nil
+}
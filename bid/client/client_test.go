@@ -0,0 +1,430 @@
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"reflect"
+	"testing"
+)
+
+//////////////////////////////////////////////////////////////////////////////////
+// A transport that records the calls it is given and answers every query with
+// response
+//////////////////////////////////////////////////////////////////////////////////
+type recordedCall struct {
+	function  string
+	args      []string
+	transient map[string][]byte
+}
+
+type recordingTransport struct {
+	calls    []recordedCall
+	response string
+}
+
+func (r *recordingTransport) Invoke(ctx context.Context, function string, args []string) (string, error) {
+	r.calls = append(r.calls, recordedCall{function, args, nil})
+	return "tx-1", nil
+}
+
+func (r *recordingTransport) Query(ctx context.Context, function string, args []string) ([]byte, error) {
+	r.calls = append(r.calls, recordedCall{function, args, nil})
+	return []byte(r.response), nil
+}
+
+func (r *recordingTransport) InvokeTransient(ctx context.Context, function string, args []string, transient map[string][]byte) (string, error) {
+	r.calls = append(r.calls, recordedCall{function, args, transient})
+	return "tx-1", nil
+}
+
+func (r *recordingTransport) QueryTransient(ctx context.Context, function string, args []string, transient map[string][]byte) ([]byte, error) {
+	r.calls = append(r.calls, recordedCall{function, args, transient})
+	return []byte(r.response), nil
+}
+
+var (
+	key16 = bytes.Repeat([]byte{1}, 16)
+	key32 = bytes.Repeat([]byte{2}, 32)
+)
+
+//////////////////////////////////////////////////////////////////////////////////
+// Each typed call, and the chaincode function and arguments it sends
+//////////////////////////////////////////////////////////////////////////////////
+func TestCallArguments(t *testing.T) {
+
+	user := UserObject{UserID: "200", Name: "Ann", UserType: "TR", Address: "1 Main St", Phone: "555", Email: "ann@example.com",
+		Bank: "B", AccountNo: "123", RoutingNo: "456"}
+	userArgs := []string{"200", "USER", "Ann", "TR", "1 Main St", "555", "ann@example.com", "B", "123", "456"}
+	item := ItemObject{ItemID: "1000", ItemDesc: "Painting", ItemDetail: "Oil", ItemType: "Modern", ItemSubject: "Landscape",
+		ItemDocs: []ItemDocument{{"aa", "image/png", "s3://a"}, {"bb", "application/pdf", "s3://b"}}}
+	bid := Bid{AuctionID: "1111", BidNo: "1", ItemID: "1000", BuyerID: "300", BidPrice: "1200"}
+	bidArgs := []string{"1111", "BID", "1", "1000", "300", "1200"}
+	opts := ListOptions{PageSize: 10, Token: "t", Sort: "BidPrice:desc", Status: "OPEN", MinPrice: "1", MaxPrice: "9", From: "2017-01-01 00:00:00", To: "2018-01-01 00:00:00"}
+	optArgs := []string{"pageSize=10", "token=t", "sort=BidPrice:desc", "status=OPEN", "minPrice=1", "maxPrice=9",
+		"from=2017-01-01 00:00:00", "to=2018-01-01 00:00:00"}
+	content := []byte("the artefact")
+	sum := sha256.Sum256(content)
+
+	cases := []struct {
+		name      string
+		response  string
+		call      func(ctx context.Context, c *Client) error
+		function  string
+		args      []string
+		transient map[string][]byte
+	}{
+		{"PostUser", "", func(ctx context.Context, c *Client) error { _, err := c.PostUser(ctx, user); return err },
+			"PostUser", userArgs, nil},
+		{"UpdateUser", "", func(ctx context.Context, c *Client) error { _, err := c.UpdateUser(ctx, user); return err },
+			"UpdateUser", userArgs, nil},
+		{"DeactivateUser", "", func(ctx context.Context, c *Client) error { _, err := c.DeactivateUser(ctx, "200"); return err },
+			"DeactivateUser", []string{"200", "USER"}, nil},
+		{"EraseUser", "", func(ctx context.Context, c *Client) error { _, err := c.EraseUser(ctx, "200"); return err },
+			"EraseUser", []string{"200", "USER"}, nil},
+		{"GetUser", "{}", func(ctx context.Context, c *Client) error { _, err := c.GetUser(ctx, "200"); return err },
+			"GetUser", []string{"200"}, nil},
+		{"VerifyUserPII", "{}", func(ctx context.Context, c *Client) error {
+			_, err := c.VerifyUserPII(ctx, "200", "AccountNo", "123")
+			return err
+		},
+			"VerifyUserPII", []string{"200", "AccountNo", "123"}, nil},
+		{"ListUsers of a type", "{}", func(ctx context.Context, c *Client) error { _, err := c.ListUsers(ctx, "2017", "AH", opts); return err },
+			"GetUserListByCat", append([]string{"2017", "AH"}, optArgs...), nil},
+		{"ListUsers by default", "{}", func(ctx context.Context, c *Client) error {
+			_, err := c.ListUsers(ctx, "", "", ListOptions{})
+			return err
+		},
+			"GetUserListByCat", []string{DefaultPeriod(), "pageSize=50"}, nil},
+
+		{"PostItem", "", func(ctx context.Context, c *Client) error { _, err := c.PostItem(ctx, item); return err },
+			"PostItem", []string{"1000", "ARTINV", "Painting", "Oil", "Modern", "Landscape", "aa", "image/png", "s3://a", "bb", "application/pdf", "s3://b"}, nil},
+		{"UpdateItem", "", func(ctx context.Context, c *Client) error { _, err := c.UpdateItem(ctx, item); return err },
+			"UpdateItem", []string{"1000", "ARTINV", "Painting", "Oil", "Modern", "Landscape"}, nil},
+		{"GetItem", "{}", func(ctx context.Context, c *Client) error { _, err := c.GetItem(ctx, "1000"); return err },
+			"GetItem", []string{"1000"}, nil},
+		{"PostItemDocument", "", func(ctx context.Context, c *Client) error {
+			_, err := c.PostItemDocument(ctx, "1000", item.ItemDocs[0])
+			return err
+		},
+			"PostItemDocument", []string{"1000", "ITEMDOC", "aa", "image/png", "s3://a"}, nil},
+		{"VerifyItemDocumentHash", "{}", func(ctx context.Context, c *Client) error {
+			_, err := c.VerifyItemDocumentHash(ctx, "1000", "aa")
+			return err
+		},
+			"VerifyItemDocument", []string{"1000", "SHA256", "aa"}, nil},
+		{"VerifyItemDocument", "{}", func(ctx context.Context, c *Client) error {
+			_, err := c.VerifyItemDocument(ctx, "1000", content)
+			return err
+		},
+			"VerifyItemDocument", []string{"1000", "SHA256", hex.EncodeToString(sum[:])}, nil},
+		{"PostItemArtefact", "", func(ctx context.Context, c *Client) error {
+			_, err := c.PostItemArtefact(ctx, "1000", "200", "image/png", key16, content)
+			return err
+		}, "PostItemArtefact", []string{"1000", "ARTEFACT", "200", "image/png"}, map[string][]byte{"artefactKey": key16, "artefactContent": content}},
+		{"GetItemArtefact", "{}", func(ctx context.Context, c *Client) error { _, err := c.GetItemArtefact(ctx, "1000"); return err },
+			"GetItemArtefact", []string{"1000"}, nil},
+		{"OpenItemArtefact", "dGhlIGFydGVmYWN0", func(ctx context.Context, c *Client) error {
+			_, err := c.OpenItemArtefact(ctx, "1000", key16)
+			return err
+		},
+			"OpenItemArtefact", []string{"1000"}, map[string][]byte{"artefactKey": key16}},
+		{"TransferItem", "", func(ctx context.Context, c *Client) error {
+			_, err := c.TransferItem(ctx, "1000", "200", key16, "300", key32)
+			return err
+		}, "TransferItem", []string{"1000", "XFER", "200", "300"}, map[string][]byte{"artefactKey": key16, "handoverKey": key32}},
+		{"AcceptItemArtefact", "", func(ctx context.Context, c *Client) error {
+			_, err := c.AcceptItemArtefact(ctx, "1000", "300", key32, key16)
+			return err
+		}, "AcceptItemArtefact", []string{"1000", "ARTEFACT", "300"}, map[string][]byte{"handoverKey": key32, "artefactKey": key16}},
+		{"ListItemsByType", "{}", func(ctx context.Context, c *Client) error {
+			_, err := c.ListItemsByType(ctx, "2017", "Modern", ListOptions{})
+			return err
+		},
+			"GetItemListByCat", []string{"2017", "Modern", "pageSize=50"}, nil},
+		{"ListItemsBySubject", "{}", func(ctx context.Context, c *Client) error {
+			_, err := c.ListItemsBySubject(ctx, "", "", ListOptions{})
+			return err
+		},
+			"GetItemListBySubject", []string{DefaultPeriod(), "pageSize=50"}, nil},
+
+		{"PostAuctionRequest", "", func(ctx context.Context, c *Client) error {
+			_, err := c.PostAuctionRequest(ctx, AuctionRequest{AuctionID: "1111", ItemID: "1000", AuctionHouseID: "100", RequestDate: "2017-03-05",
+				Status: "OPEN", OpenDate: "ignored"})
+			return err
+		}, "PostAuctionRequest", []string{"1111", "AUCREQ", "1000", "100", "2017-03-05", "INIT", "", ""}, nil},
+		{"GetAuctionRequest", "{}", func(ctx context.Context, c *Client) error { _, err := c.GetAuctionRequest(ctx, "1111"); return err },
+			"GetAuctionRequest", []string{"1111"}, nil},
+		{"OpenAuctionForBids", "", func(ctx context.Context, c *Client) error {
+			_, err := c.OpenAuctionForBids(ctx, "1111", 30)
+			return err
+		},
+			"OpenAuctionForBids", []string{"1111", "OPENAUC", "30"}, nil},
+		{"ExtendAuction", "", func(ctx context.Context, c *Client) error { _, err := c.ExtendAuction(ctx, "1111", 5); return err },
+			"ExtendAuction", []string{"1111", "EXTAUC", "5"}, nil},
+		{"CloseAuction", "", func(ctx context.Context, c *Client) error { _, err := c.CloseAuction(ctx, "1111"); return err },
+			"CloseAuction", []string{"1111", "AUCREQ"}, nil},
+		{"CloseOpenAuctions", "", func(ctx context.Context, c *Client) error { _, err := c.CloseOpenAuctions(ctx, ""); return err },
+			"CloseOpenAuctions", []string{"CLAUC"}, nil},
+		{"CloseOpenAuctions in a period", "", func(ctx context.Context, c *Client) error {
+			_, err := c.CloseOpenAuctions(ctx, "2017..2018")
+			return err
+		},
+			"CloseOpenAuctions", []string{"CLAUC", "2017..2018"}, nil},
+		{"ListAuctions INIT", "{}", func(ctx context.Context, c *Client) error {
+			_, err := c.ListAuctions(ctx, "INIT", "2017", ListOptions{})
+			return err
+		},
+			"GetListOfInitAucs", []string{"2017", "pageSize=50"}, nil},
+		{"ListAuctions OPEN", "{}", func(ctx context.Context, c *Client) error {
+			_, err := c.ListAuctions(ctx, "OPEN", "", opts)
+			return err
+		},
+			"GetListOfOpenAucs", append([]string{DefaultPeriod()}, optArgs...), nil},
+
+		{"PostBid", "", func(ctx context.Context, c *Client) error { _, err := c.PostBid(ctx, bid); return err },
+			"PostBid", bidArgs, nil},
+		{"BuyItNow", "", func(ctx context.Context, c *Client) error { _, err := c.BuyItNow(ctx, bid); return err },
+			"BuyItNow", bidArgs, nil},
+		{"GetBid", "{}", func(ctx context.Context, c *Client) error { _, err := c.GetBid(ctx, "1111", "1"); return err },
+			"GetBid", []string{"1111", "1"}, nil},
+		{"GetLastBid", "{}", func(ctx context.Context, c *Client) error { _, err := c.GetLastBid(ctx, "1111"); return err },
+			"GetLastBid", []string{"1111"}, nil},
+		{"GetHighestBid", "{}", func(ctx context.Context, c *Client) error { _, err := c.GetHighestBid(ctx, "1111"); return err },
+			"GetHighestBid", []string{"1111"}, nil},
+		{"GetNoOfBidsReceived", "3", func(ctx context.Context, c *Client) error { _, err := c.GetNoOfBidsReceived(ctx, "1111"); return err },
+			"GetNoOfBidsReceived", []string{"1111"}, nil},
+		{"ListBids", "{}", func(ctx context.Context, c *Client) error {
+			_, err := c.ListBids(ctx, "1111", ListOptions{PageSize: 2, Token: "x"})
+			return err
+		},
+			"GetListOfBids", []string{"1111", "pageSize=2", "token=x"}, nil},
+
+		{"PostTransaction", "", func(ctx context.Context, c *Client) error {
+			_, err := c.PostTransaction(ctx, ItemTransaction{AuctionID: "1111", ItemID: "1000", TransType: "SALE", UserId: "200",
+				TransDate: "2017-03-06", HammerTime: "2017-03-05 12:00:00", HammerPrice: "1200", Details: "d", TxID: "ignored"})
+			return err
+		}, "PostTransaction", []string{"1111", "POSTTRAN", "1000", "SALE", "200", "2017-03-06", "2017-03-05 12:00:00", "1200", "d"}, nil},
+		{"GetTransactionsByAuction", "{}", func(ctx context.Context, c *Client) error {
+			_, err := c.GetTransactionsByAuction(ctx, "1111", ListOptions{})
+			return err
+		}, "GetTransactionsByAuction", []string{"1111", "pageSize=50"}, nil},
+		{"GetTransactionsByItem", "{}", func(ctx context.Context, c *Client) error {
+			_, err := c.GetTransactionsByItem(ctx, "1000", ListOptions{})
+			return err
+		},
+			"GetTransactionsByItem", []string{"1000", "pageSize=50"}, nil},
+		{"GetTransactionsByUser", "{}", func(ctx context.Context, c *Client) error {
+			_, err := c.GetTransactionsByUser(ctx, "200", ListOptions{})
+			return err
+		},
+			"GetTransactionsByUser", []string{"200", "pageSize=50"}, nil},
+
+		{"ReindexPartitions", "", func(ctx context.Context, c *Client) error {
+			_, err := c.ReindexPartitions(ctx, "2017", "2018")
+			return err
+		},
+			"ReindexPartitions", []string{"REINDEX", "2017", "2018"}, nil},
+		{"RebuildBidSummaries", "", func(ctx context.Context, c *Client) error { _, err := c.RebuildBidSummaries(ctx); return err },
+			"RebuildBidSummaries", []string{"BIDSUM"}, nil},
+		{"GetVersion", `{"version":"1.2"}`, func(ctx context.Context, c *Client) error { _, err := c.GetVersion(ctx); return err },
+			"GetVersion", []string{"version"}, nil},
+	}
+
+	for _, c := range cases {
+		r := &recordingTransport{response: c.response}
+		if err := c.call(context.Background(), New(r)); err != nil {
+			t.Errorf("%s : %v", c.name, err)
+			continue
+		}
+		if len(r.calls) != 1 {
+			t.Errorf("%s : calls %+v", c.name, r.calls)
+			continue
+		}
+		call := r.calls[0]
+		if call.function != c.function || !reflect.DeepEqual(call.args, c.args) || !reflect.DeepEqual(call.transient, c.transient) {
+			t.Errorf("%s : sent %s %q %q, expected %s %q %q", c.name, call.function, call.args, call.transient, c.function, c.args, c.transient)
+		}
+	}
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// What comes back is decoded into the typed results
+//////////////////////////////////////////////////////////////////////////////////
+func TestCallResults(t *testing.T) {
+
+	ctx := context.Background()
+	r := &recordingTransport{}
+	c := New(r)
+
+	r.response = `{"UserID":"200","UserType":"TR","Status":"ACTIVE"}`
+	if u, err := c.GetUser(ctx, "200"); err != nil || u.UserID != "200" || u.UserType != "TR" || u.Status != "ACTIVE" {
+		t.Errorf("GetUser : %+v : %v", u, err)
+	}
+	r.response = `{"Items":[{"AuctionID":"1111","BidNo":"1"}],"Count":1,"NextToken":"abc"}`
+	if page, err := c.ListBids(ctx, "1111", ListOptions{}); err != nil || page.Count != 1 || page.Items[0].BidNo != "1" || page.NextToken != "abc" {
+		t.Errorf("ListBids : %+v : %v", page, err)
+	}
+	r.response = "3"
+	if n, err := c.GetNoOfBidsReceived(ctx, "1111"); err != nil || n != 3 {
+		t.Errorf("GetNoOfBidsReceived : %d : %v", n, err)
+	}
+	r.response = `{"version":"1.2"}`
+	if v, err := c.GetVersion(ctx); err != nil || v != "1.2" {
+		t.Errorf("GetVersion : %q : %v", v, err)
+	}
+	r.response = "dGhlIGFydGVmYWN0"
+	if content, err := c.OpenItemArtefact(ctx, "1000", key16); err != nil || string(content) != "the artefact" {
+		t.Errorf("OpenItemArtefact : %q : %v", content, err)
+	}
+
+	r.response = ""
+	if _, err := c.GetHighestBid(ctx, "1111"); CodeOf(err) != NotFound {
+		t.Errorf("GetHighestBid of no bids : %v", err)
+	}
+	r.response = "not JSON"
+	if _, err := c.GetItem(ctx, "1000"); CodeOf(err) != Unknown || err.(*Error).Err == nil {
+		t.Errorf("GetItem of a bad response : %v", err)
+	}
+	if _, err := c.OpenItemArtefact(ctx, "1000", key16); CodeOf(err) != Unknown {
+		t.Errorf("OpenItemArtefact of a bad response : %v", err)
+	}
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Calls the client refuses, without reaching the transport
+//////////////////////////////////////////////////////////////////////////////////
+func TestCallValidation(t *testing.T) {
+
+	user := UserObject{UserID: "200", Name: "Ann", UserType: "TR"}
+	bid := Bid{AuctionID: "1111", BidNo: "1", ItemID: "1000", BuyerID: "300", BidPrice: "1200"}
+	noPrice := bid
+	noPrice.BidPrice = ""
+
+	cases := []struct {
+		name string
+		call func(ctx context.Context, c *Client) error
+	}{
+		{"PostUser without a name", func(ctx context.Context, c *Client) error {
+			_, err := c.PostUser(ctx, UserObject{UserID: "200", UserType: "TR"})
+			return err
+		}},
+		{"UpdateUser without a type", func(ctx context.Context, c *Client) error {
+			u := user
+			u.UserType = ""
+			_, err := c.UpdateUser(ctx, u)
+			return err
+		}},
+		{"GetUser without an id", func(ctx context.Context, c *Client) error { _, err := c.GetUser(ctx, ""); return err }},
+		{"VerifyUserPII without a value", func(ctx context.Context, c *Client) error {
+			_, err := c.VerifyUserPII(ctx, "200", "Email", "")
+			return err
+		}},
+		{"PostItem without a description", func(ctx context.Context, c *Client) error {
+			_, err := c.PostItem(ctx, ItemObject{ItemID: "1000"})
+			return err
+		}},
+		{"PostItem with a document without a hash", func(ctx context.Context, c *Client) error {
+			_, err := c.PostItem(ctx, ItemObject{ItemID: "1000", ItemDesc: "Painting", ItemDocs: []ItemDocument{{MediaType: "image/png"}}})
+			return err
+		}},
+		{"PostItemDocument without a hash", func(ctx context.Context, c *Client) error {
+			_, err := c.PostItemDocument(ctx, "1000", ItemDocument{DocURI: "s3://a"})
+			return err
+		}},
+		{"PostItemArtefact with a short key", func(ctx context.Context, c *Client) error {
+			_, err := c.PostItemArtefact(ctx, "1000", "200", "image/png", key16[:15], nil)
+			return err
+		}},
+		{"OpenItemArtefact without a key", func(ctx context.Context, c *Client) error { _, err := c.OpenItemArtefact(ctx, "1000", nil); return err }},
+		{"TransferItem with a short handover key", func(ctx context.Context, c *Client) error {
+			_, err := c.TransferItem(ctx, "1000", "200", key16, "300", key16[:8])
+			return err
+		}},
+		{"TransferItem without a new owner", func(ctx context.Context, c *Client) error {
+			_, err := c.TransferItem(ctx, "1000", "200", key16, "", key32)
+			return err
+		}},
+		{"AcceptItemArtefact with a long key", func(ctx context.Context, c *Client) error {
+			_, err := c.AcceptItemArtefact(ctx, "1000", "300", key32, append(key32, 0))
+			return err
+		}},
+		{"PostAuctionRequest without a request date", func(ctx context.Context, c *Client) error {
+			_, err := c.PostAuctionRequest(ctx, AuctionRequest{AuctionID: "1111", ItemID: "1000", AuctionHouseID: "100"})
+			return err
+		}},
+		{"OpenAuctionForBids for no time", func(ctx context.Context, c *Client) error { _, err := c.OpenAuctionForBids(ctx, "1111", 0); return err }},
+		{"ExtendAuction backwards", func(ctx context.Context, c *Client) error { _, err := c.ExtendAuction(ctx, "1111", -5); return err }},
+		{"ListAuctions CLOSED", func(ctx context.Context, c *Client) error {
+			_, err := c.ListAuctions(ctx, "CLOSED", "", ListOptions{})
+			return err
+		}},
+		{"PostBid without a price", func(ctx context.Context, c *Client) error { _, err := c.PostBid(ctx, noPrice); return err }},
+		{"BuyItNow without a price", func(ctx context.Context, c *Client) error { _, err := c.BuyItNow(ctx, noPrice); return err }},
+		{"GetBid without a number", func(ctx context.Context, c *Client) error { _, err := c.GetBid(ctx, "1111", ""); return err }},
+		{"ListBids without an auction", func(ctx context.Context, c *Client) error { _, err := c.ListBids(ctx, "", ListOptions{}); return err }},
+		{"PostTransaction without a price", func(ctx context.Context, c *Client) error {
+			_, err := c.PostTransaction(ctx, ItemTransaction{AuctionID: "1111", ItemID: "1000", TransType: "SALE", UserId: "200"})
+			return err
+		}},
+		{"GetTransactionsByUser without a user", func(ctx context.Context, c *Client) error {
+			_, err := c.GetTransactionsByUser(ctx, "", ListOptions{})
+			return err
+		}},
+	}
+
+	for _, c := range cases {
+		r := &recordingTransport{response: "{}"}
+		err := c.call(context.Background(), New(r))
+		if CodeOf(err) != InvalidArgument {
+			t.Errorf("%s : %v", c.name, err)
+		}
+		if len(r.calls) != 0 {
+			t.Errorf("%s : calls %+v", c.name, r.calls)
+		}
+	}
+}
+
+// The artefact calls need transient data, which a plain Transport cannot send
+func TestTransientNeedsTransientTransport(t *testing.T) {
+
+	ctx := context.Background()
+	r := &recordingTransport{}
+	c := New(struct{ Transport }{r}) // Hides the transient methods
+
+	if _, err := c.PostItemArtefact(ctx, "1000", "200", "image/png", key16, []byte("x")); CodeOf(err) != InvalidArgument {
+		t.Errorf("PostItemArtefact : %v", err)
+	}
+	if _, err := c.OpenItemArtefact(ctx, "1000", key16); CodeOf(err) != InvalidArgument {
+		t.Errorf("OpenItemArtefact : %v", err)
+	}
+	if _, err := c.TransferItem(ctx, "1000", "200", key16, "300", key32); CodeOf(err) != InvalidArgument {
+		t.Errorf("TransferItem : %v", err)
+	}
+	if _, err := c.AcceptItemArtefact(ctx, "1000", "300", key32, key16); CodeOf(err) != InvalidArgument {
+		t.Errorf("AcceptItemArtefact : %v", err)
+	}
+	if len(r.calls) != 0 {
+		t.Errorf("calls %+v", r.calls)
+	}
+}
@@ -0,0 +1,187 @@
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+//////////////////////////////////////////////////////////////////////////////////
+// What went wrong with a call
+//////////////////////////////////////////////////////////////////////////////////
+type ErrorCode int
+
+const (
+	Unknown         ErrorCode = iota
+	InvalidArgument           // Refused by the client, or by the chaincode, for its arguments
+	NotFound                  // The record does not exist
+	Rejected                  // The peer or the chaincode refused the call
+	Unavailable               // The peer could not be reached
+)
+
+var errorCodeNames = []string{"Unknown", "InvalidArgument", "NotFound", "Rejected", "Unavailable"}
+
+func (c ErrorCode) String() string {
+	if int(c) < len(errorCodeNames) {
+		return errorCodeNames[c]
+	}
+	return fmt.Sprintf("ErrorCode(%d)", int(c))
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// The error every Client method returns
+// Reason and Details are the chaincode's, when the chaincode refused the call
+// (see errors.go in the chaincode). Err is the underlying error from the
+// transport, if there is one
+//////////////////////////////////////////////////////////////////////////////////
+type Error struct {
+	Function string
+	Code     ErrorCode
+	Reason   string
+	Message  string
+	Details  map[string]string
+	Err      error
+}
+
+// The chaincode's error codes
+const (
+	ReasonNotFound        = "NOT_FOUND"
+	ReasonInvalidArgument = "INVALID_ARGUMENT"
+	ReasonAuctionNotOpen  = "AUCTION_NOT_OPEN"
+	ReasonBidTooLow       = "BID_TOO_LOW"
+	ReasonUnauthorized    = "UNAUTHORIZED"
+	ReasonConflict        = "CONFLICT"
+	ReasonCorruptRecord   = "CORRUPT_RECORD"
+	ReasonUnknownRecType  = "UNKNOWN_RECTYPE"
+	ReasonInternal        = "INTERNAL"
+)
+
+func (e *Error) Error() string {
+	return e.Function + ": " + e.Message
+}
+
+// Code of err, Unknown if it did not come from a Client
+func CodeOf(err error) ErrorCode {
+	if e, ok := err.(*Error); ok {
+		return e.Code
+	}
+	return Unknown
+}
+
+func IsNotFound(err error) bool {
+	return CodeOf(err) == NotFound
+}
+
+// The chaincode's error code for err, e.g. ReasonBidTooLow; empty if it did
+// not come from the chaincode
+func ReasonOf(err error) string {
+	if e, ok := err.(*Error); ok {
+		return e.Reason
+	}
+	return ""
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// An error returned by the peer, as opposed to failing to reach it
+// Transports return it for calls the peer or the chaincode refused; Data holds
+// the chaincode's message
+//////////////////////////////////////////////////////////////////////////////////
+type PeerError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    string `json:"data"`
+}
+
+func (e *PeerError) Error() string {
+	if e.Data != "" {
+		return e.Message + ": " + e.Data
+	}
+	return e.Message
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// The chaincode's error, which the peer passes on somewhere in PeerError.Data:
+//   {"Code":"BID_TOO_LOW","Message":"...","Details":{"HighestBid":"1200"}}
+//////////////////////////////////////////////////////////////////////////////////
+type chaincodeError struct {
+	Code    string
+	Message string
+	Details map[string]string `json:",omitempty"`
+}
+
+func (e *chaincodeError) Error() string {
+	buff, _ := json.Marshal(e)
+	return string(buff)
+}
+
+func parseChaincodeError(data string) (*chaincodeError, bool) {
+
+	i := strings.Index(data, `{"Code":`)
+	if i < 0 {
+		return nil, false
+	}
+	var e chaincodeError
+	if err := json.NewDecoder(strings.NewReader(data[i:])).Decode(&e); err != nil || e.Code == "" {
+		return nil, false
+	}
+	return &e, true
+}
+
+// Chaincode from before the error codes reported every failed query this way
+const notFoundMessage = "Object not found"
+
+func invalid(function string, format string, a ...interface{}) error {
+	return &Error{Function: function, Code: InvalidArgument, Message: fmt.Sprintf(format, a...)}
+}
+
+func notFound(function string, what string) error {
+	return &Error{Function: function, Code: NotFound, Message: what + " not found"}
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// The *Error a Client method returns for err, an error from a Transport
+// For callers that use a Transport directly, such as the REST gateway
+//////////////////////////////////////////////////////////////////////////////////
+func TransportError(function string, err error) *Error {
+
+	if e, ok := err.(*Error); ok {
+		return e
+	}
+	if pe, ok := err.(*PeerError); ok {
+		if ce, ok := parseChaincodeError(pe.Data); ok {
+			code := Rejected
+			switch ce.Code {
+			case ReasonNotFound:
+				code = NotFound
+			case ReasonInvalidArgument:
+				code = InvalidArgument
+			}
+			return &Error{Function: function, Code: code, Reason: ce.Code, Message: ce.Message, Details: ce.Details, Err: err}
+		}
+		code := Rejected
+		if strings.Contains(pe.Data, notFoundMessage) {
+			code = NotFound
+		}
+		return &Error{Function: function, Code: code, Message: pe.Error(), Err: err}
+	}
+	return &Error{Function: function, Code: Unavailable, Message: err.Error(), Err: err}
+}
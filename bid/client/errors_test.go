@@ -0,0 +1,95 @@
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package client
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+//////////////////////////////////////////////////////////////////////////////////
+// How TransportError classifies what the transports return
+//////////////////////////////////////////////////////////////////////////////////
+func TestTransportError(t *testing.T) {
+
+	own := &Error{Function: "GetUser", Code: InvalidArgument, Message: "UserID is required"}
+	cases := []struct {
+		name    string
+		err     error
+		code    ErrorCode
+		reason  string
+		message string
+		details map[string]string
+	}{
+		{"a client error is kept", own, InvalidArgument, "", "UserID is required", nil},
+		{"chaincode not found", &PeerError{Code: -32003, Message: "Query failure",
+			Data: `Error when querying chaincode: {"Code":"NOT_FOUND","Message":"Object not found : 200","Details":{"Key":"200"}}`},
+			NotFound, ReasonNotFound, "Object not found : 200", map[string]string{"Key": "200"}},
+		{"chaincode invalid argument", &PeerError{Data: `{"Code":"INVALID_ARGUMENT","Message":"expecting 6 arguments"}`},
+			InvalidArgument, ReasonInvalidArgument, "expecting 6 arguments", nil},
+		{"chaincode refusal", &PeerError{Message: "Invocation failure",
+			Data: `{"Code":"BID_TOO_LOW","Message":"too low","Details":{"HighestBid":"1200"}} trailing text`},
+			Rejected, ReasonBidTooLow, "too low", map[string]string{"HighestBid": "1200"}},
+		{"legacy not found", &PeerError{Message: "Query failure", Data: "Error when querying chaincode: Object not found : 200"},
+			NotFound, "", "Query failure: Error when querying chaincode: Object not found : 200", nil},
+		{"legacy refusal", &PeerError{Message: "Invocation failure", Data: "Auction is not OPEN"},
+			Rejected, "", "Invocation failure: Auction is not OPEN", nil},
+		{"JSON without a code", &PeerError{Message: "Invocation failure", Data: `{"Code":"","Message":"x"}`},
+			Rejected, "", `Invocation failure: {"Code":"","Message":"x"}`, nil},
+		{"no peer", errors.New("dial tcp: connection refused"), Unavailable, "", "dial tcp: connection refused", nil},
+	}
+
+	for _, c := range cases {
+		e := TransportError("GetUser", c.err)
+		if e.Function != "GetUser" || e.Code != c.code || e.Reason != c.reason || e.Message != c.message ||
+			!reflect.DeepEqual(e.Details, c.details) {
+			t.Errorf("%s : %+v", c.name, e)
+		}
+		if c.err != own && e.Err != c.err {
+			t.Errorf("%s : underlying error %v", c.name, e.Err)
+		}
+	}
+}
+
+func TestErrorHelpers(t *testing.T) {
+
+	e := TransportError("PostBid", &PeerError{Data: `{"Code":"NOT_FOUND","Message":"auction 1 not found"}`})
+	if CodeOf(e) != NotFound || !IsNotFound(e) || ReasonOf(e) != ReasonNotFound {
+		t.Errorf("%v : code %v, reason %q", e, CodeOf(e), ReasonOf(e))
+	}
+	if e.Error() != "PostBid: auction 1 not found" {
+		t.Errorf("message %q", e.Error())
+	}
+
+	other := errors.New("not from a client")
+	if CodeOf(other) != Unknown || IsNotFound(other) || ReasonOf(other) != "" {
+		t.Errorf("%v : code %v, reason %q", other, CodeOf(other), ReasonOf(other))
+	}
+	if CodeOf(nil) != Unknown {
+		t.Errorf("nil : code %v", CodeOf(nil))
+	}
+
+	for code, name := range map[ErrorCode]string{Unknown: "Unknown", Rejected: "Rejected", Unavailable: "Unavailable", ErrorCode(9): "ErrorCode(9)"} {
+		if code.String() != name {
+			t.Errorf("%d is %s, not %s", int(code), code, name)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+//////////////////////////////////////////////////////////////////////////////////
+// Items, their documents and their encrypted artefacts
+//////////////////////////////////////////////////////////////////////////////////
+
+func (c *Client) PostItem(ctx context.Context, item ItemObject) (string, error) {
+	if err := require("PostItem", "ItemID", item.ItemID, "ItemDesc", item.ItemDesc); err != nil {
+		return "", err
+	}
+	args := []string{item.ItemID, "ARTINV", item.ItemDesc, item.ItemDetail, item.ItemType, item.ItemSubject}
+	for _, d := range item.ItemDocs {
+		if err := require("PostItem", "DocHash", d.DocHash); err != nil {
+			return "", err
+		}
+		args = append(args, d.DocHash, d.MediaType, d.DocURI)
+	}
+	return c.invoke(ctx, "PostItem", args...)
+}
+
+// Documents are left as they are; add them with PostItemDocument
+func (c *Client) UpdateItem(ctx context.Context, item ItemObject) (string, error) {
+	if err := require("UpdateItem", "ItemID", item.ItemID, "ItemDesc", item.ItemDesc); err != nil {
+		return "", err
+	}
+	return c.invoke(ctx, "UpdateItem", item.ItemID, "ARTINV", item.ItemDesc, item.ItemDetail, item.ItemType, item.ItemSubject)
+}
+
+func (c *Client) GetItem(ctx context.Context, itemID string) (ItemObject, error) {
+	var item ItemObject
+	if err := require("GetItem", "ItemID", itemID); err != nil {
+		return item, err
+	}
+	err := c.query(ctx, &item, "item "+itemID, "GetItem", itemID)
+	return item, err
+}
+
+func (c *Client) PostItemDocument(ctx context.Context, itemID string, doc ItemDocument) (string, error) {
+	if err := require("PostItemDocument", "ItemID", itemID, "DocHash", doc.DocHash); err != nil {
+		return "", err
+	}
+	return c.invoke(ctx, "PostItemDocument", itemID, "ITEMDOC", doc.DocHash, doc.MediaType, doc.DocURI)
+}
+
+// Check a hex SHA-256 hash against the documents registered for an item
+func (c *Client) VerifyItemDocumentHash(ctx context.Context, itemID string, docHash string) (ItemDocVerification, error) {
+	var v ItemDocVerification
+	if err := require("VerifyItemDocument", "ItemID", itemID, "DocHash", docHash); err != nil {
+		return v, err
+	}
+	err := c.query(ctx, &v, "item "+itemID, "VerifyItemDocument", itemID, "SHA256", docHash)
+	return v, err
+}
+
+// Check a document against those registered for an item. The hash is
+// computed here, so only the hash is sent to the peer
+func (c *Client) VerifyItemDocument(ctx context.Context, itemID string, content []byte) (ItemDocVerification, error) {
+	sum := sha256.Sum256(content)
+	return c.VerifyItemDocumentHash(ctx, itemID, hex.EncodeToString(sum[:]))
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Store an item's artefact (e.g. an image), encrypted under the owner's key
+// key is the raw AES key, 16, 24 or 32 bytes. The key and the content are sent
+// as transient data, so the transport must be a TransientTransport
+//////////////////////////////////////////////////////////////////////////////////
+func (c *Client) PostItemArtefact(ctx context.Context, itemID string, ownerID string, mediaType string, key []byte, content []byte) (string, error) {
+	if err := require("PostItemArtefact", "ItemID", itemID, "OwnerID", ownerID); err != nil {
+		return "", err
+	}
+	if err := aesKey("PostItemArtefact", "key", key); err != nil {
+		return "", err
+	}
+	transient := map[string][]byte{"artefactKey": key, "artefactContent": content}
+	return c.invokeTransient(ctx, transient, "PostItemArtefact", itemID, "ARTEFACT", ownerID, mediaType)
+}
+
+// The artefact as stored; Payload is still encrypted
+func (c *Client) GetItemArtefact(ctx context.Context, itemID string) (ItemArtefact, error) {
+	var a ItemArtefact
+	if err := require("GetItemArtefact", "ItemID", itemID); err != nil {
+		return a, err
+	}
+	err := c.query(ctx, &a, "artefact of item "+itemID, "GetItemArtefact", itemID)
+	return a, err
+}
+
+// Decrypt an item's artefact with the key sealing it: the owner's, or the
+// handover key until the new owner accepts it
+func (c *Client) OpenItemArtefact(ctx context.Context, itemID string, key []byte) ([]byte, error) {
+	if err := require("OpenItemArtefact", "ItemID", itemID); err != nil {
+		return nil, err
+	}
+	if err := aesKey("OpenItemArtefact", "key", key); err != nil {
+		return nil, err
+	}
+	t, ok := c.transport.(TransientTransport)
+	if !ok {
+		return nil, invalid("OpenItemArtefact", "the transport cannot send transient data")
+	}
+	data, err := t.QueryTransient(ctx, "OpenItemArtefact", []string{itemID}, map[string][]byte{"artefactKey": key})
+	if err != nil {
+		return nil, TransportError("OpenItemArtefact", err)
+	}
+	content, err := base64.StdEncoding.DecodeString(string(data))
+	if err != nil {
+		return nil, &Error{Function: "OpenItemArtefact", Code: Unknown, Message: "unexpected response: " + err.Error(), Err: err}
+	}
+	return content, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Hand an item to a new owner, or release a sold item's artefact to its buyer.
+// The artefact is re-encrypted under handoverKey, a one-time key to pass to the
+// new owner, who then takes it over with AcceptItemArtefact
+//////////////////////////////////////////////////////////////////////////////////
+func (c *Client) TransferItem(ctx context.Context, itemID string, ownerID string, key []byte, newOwnerID string, handoverKey []byte) (string, error) {
+	if err := require("TransferItem", "ItemID", itemID, "OwnerID", ownerID, "NewOwnerID", newOwnerID); err != nil {
+		return "", err
+	}
+	if err := aesKey("TransferItem", "key", key); err != nil {
+		return "", err
+	}
+	if err := aesKey("TransferItem", "handoverKey", handoverKey); err != nil {
+		return "", err
+	}
+	transient := map[string][]byte{"artefactKey": key, "handoverKey": handoverKey}
+	return c.invokeTransient(ctx, transient, "TransferItem", itemID, "XFER", ownerID, newOwnerID)
+}
+
+// Take over an artefact handed over to newOwnerID, re-encrypting it under their own key
+func (c *Client) AcceptItemArtefact(ctx context.Context, itemID string, newOwnerID string, handoverKey []byte, key []byte) (string, error) {
+	if err := require("AcceptItemArtefact", "ItemID", itemID, "NewOwnerID", newOwnerID); err != nil {
+		return "", err
+	}
+	if err := aesKey("AcceptItemArtefact", "handoverKey", handoverKey); err != nil {
+		return "", err
+	}
+	if err := aesKey("AcceptItemArtefact", "key", key); err != nil {
+		return "", err
+	}
+	transient := map[string][]byte{"handoverKey": handoverKey, "artefactKey": key}
+	return c.invokeTransient(ctx, transient, "AcceptItemArtefact", itemID, "ARTEFACT", newOwnerID)
+}
+
+// Items registered in period, of itemType if given
+func (c *Client) ListItemsByType(ctx context.Context, period string, itemType string, opts ListOptions) (ItemPage, error) {
+	return c.listItems(ctx, "GetItemListByCat", period, itemType, opts)
+}
+
+// Items registered in period, of subject if given
+func (c *Client) ListItemsBySubject(ctx context.Context, period string, subject string, opts ListOptions) (ItemPage, error) {
+	return c.listItems(ctx, "GetItemListBySubject", period, subject, opts)
+}
+
+func (c *Client) listItems(ctx context.Context, function string, period string, value string, opts ListOptions) (ItemPage, error) {
+	var page ItemPage
+	keys := []string{periodOrDefault(period)}
+	if value != "" {
+		keys = append(keys, value)
+	}
+	err := c.query(ctx, &page, "items", function, append(keys, opts.args()...)...)
+	return page, err
+}
+
+func aesKey(function string, name string, key []byte) error {
+	switch len(key) {
+	case 16, 24, 32:
+		return nil
+	}
+	return invalid(function, "%s should be 16, 24 or 32 bytes, not %d", name, len(key))
+}
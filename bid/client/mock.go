@@ -0,0 +1,453 @@
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+//////////////////////////////////////////////////////////////////////////////////
+// In-process stand-in for the auction chaincode, a Transport for tests
+// Covers users, items, auctions and bids, with the chaincode's argument
+// layout and its main rules: registered auction houses and items, bids only on
+// OPEN auctions and above the highest bid so far. Invokes apply at once.
+// Failures come back as *PeerError, carrying the chaincode's error codes as a
+// peer would, so that the Client sees the same errors it does against a peer.
+// With a Path the state is kept in that file, so that successive processes
+// (runs of the CLI, say) see each other's changes
+//////////////////////////////////////////////////////////////////////////////////
+type MockLedger struct {
+	Path string
+	Now  func() time.Time
+
+	mu    sync.Mutex
+	state mockState
+}
+
+type mockState struct {
+	Users    map[string]UserObject
+	Items    map[string]ItemObject
+	Auctions map[string]AuctionRequest
+	Bids     map[string][]Bid // By AuctionID
+	LastTx   int
+}
+
+const mockTimeLayout = "2006-01-02 15:04:05"
+
+func NewMockLedger(path string) (*MockLedger, error) {
+
+	m := &MockLedger{Path: path, Now: time.Now}
+	m.state = mockState{Users: map[string]UserObject{}, Items: map[string]ItemObject{}, Auctions: map[string]AuctionRequest{}, Bids: map[string][]Bid{}}
+	if path == "" {
+		return m, nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &m.state); err != nil {
+		return nil, fmt.Errorf("mock ledger %s: %v", path, err)
+	}
+	return m, nil
+}
+
+func (m *MockLedger) Invoke(ctx context.Context, function string, args []string) (string, error) {
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var err error
+	switch function {
+	case "PostUser":
+		err = m.postUser(args)
+	case "PostItem":
+		err = m.postItem(args)
+	case "PostAuctionRequest":
+		err = m.postAuctionRequest(args)
+	case "OpenAuctionForBids":
+		err = m.openAuction(args)
+	case "PostBid":
+		err = m.postBid(args, false)
+	case "BuyItNow":
+		err = m.postBid(args, true)
+	case "CloseAuction":
+		err = m.closeAuction(args)
+	default:
+		err = failure(ReasonInvalidArgument, "the mock ledger does not support "+function)
+	}
+	if err != nil {
+		return "", &PeerError{Code: -32002, Message: "Invocation failure", Data: err.Error()}
+	}
+
+	m.state.LastTx++
+	return fmt.Sprintf("mock-%d", m.state.LastTx), m.save()
+}
+
+func (m *MockLedger) Query(ctx context.Context, function string, args []string) ([]byte, error) {
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(args) < 1 {
+		return nil, queryFailure(failure(ReasonInvalidArgument, "expecting at least 1 argument"))
+	}
+
+	var found bool
+	var result interface{}
+	switch function {
+	case "GetUser":
+		result, found = m.state.Users[args[0]]
+	case "GetItem":
+		result, found = m.state.Items[args[0]]
+	case "GetAuctionRequest":
+		result, found = m.state.Auctions[args[0]]
+	case "GetBid":
+		if len(args) != 2 {
+			return nil, queryFailure(failure(ReasonInvalidArgument, "expecting 2 arguments"))
+		}
+		for _, b := range m.state.Bids[args[0]] {
+			if b.BidNo == args[1] {
+				result, found = b, true
+			}
+		}
+	case "GetHighestBid", "GetLastBid":
+		// Like the chaincode, an empty response when there are no bids
+		bids := m.state.Bids[args[0]]
+		if len(bids) == 0 {
+			return nil, nil
+		}
+		if function == "GetLastBid" {
+			return json.Marshal(bids[len(bids)-1])
+		}
+		high, _ := highestBid(bids)
+		return json.Marshal(high)
+	case "GetNoOfBidsReceived":
+		return []byte(strconv.Itoa(len(m.state.Bids[args[0]]))), nil
+	case "GetListOfBids":
+		var records []interface{}
+		for _, b := range m.state.Bids[args[0]] {
+			records = append(records, b)
+		}
+		return listResult(records, args[1:], "BidNo")
+	case "GetListOfInitAucs", "GetListOfOpenAucs":
+		status := "INIT"
+		if function == "GetListOfOpenAucs" {
+			status = "OPEN"
+		}
+		var records []interface{}
+		for _, a := range m.state.Auctions {
+			if a.Status == status {
+				records = append(records, a)
+			}
+		}
+		return listResult(records, args[1:], "CloseDate", "AuctionID")
+	default:
+		return nil, queryFailure(failure(ReasonInvalidArgument, "the mock ledger does not support "+function))
+	}
+
+	if !found {
+		return nil, queryFailure(failure(ReasonNotFound, "Object not found : "+args[0], "Key", args[0]))
+	}
+	return json.Marshal(result)
+}
+
+func queryFailure(err error) error {
+	return &PeerError{Code: -32003, Message: "Query failure", Data: "Error when querying chaincode: " + err.Error()}
+}
+
+// An error as the chaincode returns it, with details as name, value pairs
+func failure(reason string, message string, details ...string) error {
+
+	e := &chaincodeError{Code: reason, Message: message}
+	for i := 0; i+1 < len(details); i += 2 {
+		if e.Details == nil {
+			e.Details = map[string]string{}
+		}
+		e.Details[details[i]] = details[i+1]
+	}
+	return e
+}
+
+func (m *MockLedger) save() error {
+
+	if m.Path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(m.state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(m.Path, data, 0644)
+}
+
+func (m *MockLedger) now() string {
+	return m.Now().UTC().Format(mockTimeLayout)
+}
+
+func (m *MockLedger) postUser(args []string) error {
+
+	if len(args) != 10 {
+		return failure(ReasonInvalidArgument, "expecting 10 arguments")
+	}
+	if _, ok := m.state.Users[args[0]]; ok {
+		return failure(ReasonConflict, "user "+args[0]+" already exists", "UserID", args[0])
+	}
+	m.state.Users[args[0]] = UserObject{UserID: args[0], RecType: args[1], Name: args[2], UserType: args[3], Address: args[4], Phone: args[5],
+		Email: args[6], Bank: args[7], AccountNo: args[8], RoutingNo: args[9], Status: "ACTIVE", RegisteredDate: m.now()}
+	return nil
+}
+
+func (m *MockLedger) postItem(args []string) error {
+
+	if len(args) < 6 || (len(args)-6)%3 != 0 {
+		return failure(ReasonInvalidArgument, "expecting 6 arguments plus 3 per document")
+	}
+	item := ItemObject{ItemID: args[0], RecType: args[1], ItemDesc: args[2], ItemDetail: args[3], ItemType: args[4], ItemSubject: args[5], RegisteredDate: m.now()}
+	for i := 6; i < len(args); i += 3 {
+		item.ItemDocs = append(item.ItemDocs, ItemDocument{DocHash: args[i], MediaType: args[i+1], DocURI: args[i+2]})
+	}
+	m.state.Items[args[0]] = item
+	return nil
+}
+
+func (m *MockLedger) postAuctionRequest(args []string) error {
+
+	if len(args) != 8 {
+		return failure(ReasonInvalidArgument, "expecting 8 arguments")
+	}
+	ar := AuctionRequest{AuctionID: args[0], RecType: args[1], ItemID: args[2], AuctionHouseID: args[3], RequestDate: args[4], Status: args[5], OpenDate: args[6], CloseDate: args[7]}
+	if _, ok := m.state.Users[ar.AuctionHouseID]; !ok {
+		return failure(ReasonNotFound, "auction house "+ar.AuctionHouseID+" is not registered", "UserID", ar.AuctionHouseID)
+	}
+	if _, ok := m.state.Items[ar.ItemID]; !ok {
+		return failure(ReasonNotFound, "item "+ar.ItemID+" is not registered", "ItemID", ar.ItemID)
+	}
+	m.state.Auctions[ar.AuctionID] = ar
+	return nil
+}
+
+func (m *MockLedger) openAuction(args []string) error {
+
+	if len(args) != 3 {
+		return failure(ReasonInvalidArgument, "expecting 3 arguments")
+	}
+	ar, ok := m.state.Auctions[args[0]]
+	if !ok {
+		return failure(ReasonNotFound, "auction "+args[0]+" not found", "AuctionID", args[0])
+	}
+	if ar.Status == "CLOSED" {
+		return failure(ReasonAuctionNotOpen, "auction "+args[0]+" is closed", "AuctionID", args[0], "Status", "CLOSED")
+	}
+	minutes, err := strconv.Atoi(args[2])
+	if err != nil {
+		return failure(ReasonInvalidArgument, "duration should be a number of minutes")
+	}
+	now := m.Now().UTC()
+	ar.Status = "OPEN"
+	ar.OpenDate = now.Format(mockTimeLayout)
+	ar.CloseDate = now.Add(time.Duration(minutes) * time.Minute).Format(mockTimeLayout)
+	m.state.Auctions[ar.AuctionID] = ar
+	return nil
+}
+
+func (m *MockLedger) postBid(args []string, buyNow bool) error {
+
+	if len(args) != 6 {
+		return failure(ReasonInvalidArgument, "expecting 6 arguments")
+	}
+	bid := Bid{AuctionID: args[0], RecType: args[1], BidNo: args[2], ItemID: args[3], BuyerID: args[4], BidPrice: args[5], BidTime: m.now()}
+
+	ar, ok := m.state.Auctions[bid.AuctionID]
+	if !ok {
+		return failure(ReasonNotFound, "auction "+bid.AuctionID+" not found", "AuctionID", bid.AuctionID)
+	}
+	if ar.Status != "OPEN" || bid.BidTime > ar.CloseDate {
+		return failure(ReasonAuctionNotOpen, "auction "+bid.AuctionID+" is not open for bids", "AuctionID", bid.AuctionID, "Status", ar.Status,
+			"CloseDate", ar.CloseDate)
+	}
+	if ar.ItemID != bid.ItemID {
+		return failure(ReasonInvalidArgument, "item "+bid.ItemID+" is not on auction "+bid.AuctionID, "ItemID", bid.ItemID, "AuctionID", bid.AuctionID)
+	}
+	if _, ok := m.state.Users[bid.BuyerID]; !ok {
+		return failure(ReasonNotFound, "buyer "+bid.BuyerID+" is not registered", "UserID", bid.BuyerID)
+	}
+	price, err := strconv.Atoi(bid.BidPrice)
+	if err != nil {
+		return failure(ReasonInvalidArgument, "bid price should be a whole number")
+	}
+	for _, b := range m.state.Bids[bid.AuctionID] {
+		if b.BidNo == bid.BidNo {
+			return failure(ReasonConflict, "bid "+bid.BidNo+" already exists", "AuctionID", bid.AuctionID, "BidNo", bid.BidNo)
+		}
+	}
+	if high, ok := highestBid(m.state.Bids[bid.AuctionID]); ok && !buyNow {
+		if p, _ := strconv.Atoi(high.BidPrice); price <= p {
+			return failure(ReasonBidTooLow, "bid of "+bid.BidPrice+" does not beat the highest bid of "+high.BidPrice, "BidPrice", bid.BidPrice, "HighestBid", high.BidPrice)
+		}
+	}
+
+	m.state.Bids[bid.AuctionID] = append(m.state.Bids[bid.AuctionID], bid)
+	if buyNow {
+		ar.Status = "CLOSED"
+		m.state.Auctions[ar.AuctionID] = ar
+	}
+	return nil
+}
+
+func (m *MockLedger) closeAuction(args []string) error {
+
+	if len(args) != 2 {
+		return failure(ReasonInvalidArgument, "expecting 2 arguments")
+	}
+	ar, ok := m.state.Auctions[args[0]]
+	if !ok {
+		return failure(ReasonNotFound, "auction "+args[0]+" not found", "AuctionID", args[0])
+	}
+	if ar.Status != "OPEN" {
+		return failure(ReasonAuctionNotOpen, "auction "+args[0]+" is not OPEN", "AuctionID", args[0], "Status", ar.Status)
+	}
+	ar.Status = "CLOSED"
+	m.state.Auctions[ar.AuctionID] = ar
+	return nil
+}
+
+func highestBid(bids []Bid) (Bid, bool) {
+
+	var high Bid
+	found := false
+	for _, b := range bids {
+		p, _ := strconv.Atoi(b.BidPrice)
+		h, _ := strconv.Atoi(high.BidPrice)
+		if !found || p >= h {
+			high, found = b, true
+		}
+	}
+	return high, found
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// List queries: a plain array without options, a Page with them
+// Supports pageSize, token and a single sort field; records are otherwise in
+// the chaincode's default order, given by defaultSort
+//////////////////////////////////////////////////////////////////////////////////
+type mockRecord struct {
+	record interface{}
+	fields map[string]interface{}
+}
+
+func listResult(records []interface{}, options []string, defaultSort ...string) ([]byte, error) {
+
+	sortFields, desc := defaultSort, false
+	pageSize, offset := 50, 0
+	for _, opt := range options {
+		i := strings.Index(opt, "=")
+		if i < 0 {
+			continue
+		}
+		name, value := opt[:i], opt[i+1:]
+		switch name {
+		case "pageSize":
+			pageSize, _ = strconv.Atoi(value)
+		case "token":
+			offset, _ = strconv.Atoi(value)
+		case "sort":
+			field := strings.Split(value, ",")[0]
+			desc = strings.HasSuffix(field, ":desc")
+			sortFields = append([]string{strings.TrimSuffix(field, ":desc")}, defaultSort...)
+		default:
+			return nil, queryFailure(failure(ReasonInvalidArgument, "the mock ledger does not support the "+name+" option"))
+		}
+	}
+
+	list := make([]mockRecord, len(records))
+	for i, r := range records {
+		data, _ := json.Marshal(r)
+		list[i].record = r
+		json.Unmarshal(data, &list[i].fields)
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		for n, f := range sortFields {
+			c := compareField(list[i].fields[f], list[j].fields[f])
+			if c != 0 {
+				return (c < 0) != (desc && n == 0)
+			}
+		}
+		return false
+	})
+
+	sorted := make([]interface{}, len(list))
+	for i := range list {
+		sorted[i] = list[i].record
+	}
+
+	if len(options) == 0 {
+		return json.Marshal(sorted)
+	}
+
+	page := struct {
+		Items     []interface{}
+		Count     int
+		NextToken string
+	}{Items: []interface{}{}}
+	if pageSize <= 0 {
+		pageSize = 50
+	}
+	if offset < len(sorted) {
+		end := offset + pageSize
+		if end < len(sorted) {
+			page.NextToken = strconv.Itoa(end)
+		} else {
+			end = len(sorted)
+		}
+		page.Items = sorted[offset:end]
+	}
+	page.Count = len(page.Items)
+	return json.Marshal(page)
+}
+
+// Numbers compare as numbers, anything else as text
+func compareField(a, b interface{}) int {
+
+	sa, sb := fmt.Sprint(a), fmt.Sprint(b)
+	fa, errA := strconv.ParseFloat(sa, 64)
+	fb, errB := strconv.ParseFloat(sb, 64)
+	switch {
+	case errA == nil && errB == nil && fa < fb:
+		return -1
+	case errA == nil && errB == nil && fa > fb:
+		return 1
+	case errA == nil && errB == nil:
+		return 0
+	}
+	return strings.Compare(sa, sb)
+}
@@ -0,0 +1,224 @@
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package client
+
+import (
+	"context"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+//////////////////////////////////////////////////////////////////////////////////
+// A Client against the mock ledger: an auction from request to close, and the
+// chaincode's refusals as the Client reports them
+//////////////////////////////////////////////////////////////////////////////////
+
+var mockStart = time.Date(2017, 3, 5, 12, 0, 0, 0, time.UTC)
+
+// A ledger with auction house 100, traders 200 and 300, item 1000 and auction
+// 1111 open for an hour
+func newMockAuction(t *testing.T, path string) (*Client, *MockLedger) {
+
+	m, err := NewMockLedger(path)
+	if err != nil {
+		t.Fatalf("NewMockLedger : %v", err)
+	}
+	now := mockStart
+	m.Now = func() time.Time { return now }
+
+	ctx := context.Background()
+	c := New(m)
+	for _, u := range []UserObject{{UserID: "100", Name: "House", UserType: "AH"}, {UserID: "200", Name: "Ann", UserType: "TR"},
+		{UserID: "300", Name: "Bob", UserType: "TR"}} {
+		if _, err := c.PostUser(ctx, u); err != nil {
+			t.Fatalf("PostUser %s : %v", u.UserID, err)
+		}
+	}
+	if _, err := c.PostItem(ctx, ItemObject{ItemID: "1000", ItemDesc: "Painting", ItemType: "Modern"}); err != nil {
+		t.Fatalf("PostItem : %v", err)
+	}
+	if _, err := c.PostAuctionRequest(ctx, AuctionRequest{AuctionID: "1111", ItemID: "1000", AuctionHouseID: "100", RequestDate: "2017-03-05"}); err != nil {
+		t.Fatalf("PostAuctionRequest : %v", err)
+	}
+	if _, err := c.OpenAuctionForBids(ctx, "1111", 60); err != nil {
+		t.Fatalf("OpenAuctionForBids : %v", err)
+	}
+	return c, m
+}
+
+func bidOf(no int, buyer string, price int) Bid {
+	return Bid{AuctionID: "1111", BidNo: strconv.Itoa(no), ItemID: "1000", BuyerID: buyer, BidPrice: strconv.Itoa(price)}
+}
+
+func TestMockAuction(t *testing.T) {
+
+	ctx := context.Background()
+	c, _ := newMockAuction(t, "")
+
+	if _, err := c.GetHighestBid(ctx, "1111"); !IsNotFound(err) {
+		t.Fatalf("highest bid before any bids : %v", err)
+	}
+	for i, price := range []int{1000, 1100, 1300} {
+		if _, err := c.PostBid(ctx, bidOf(i+1, []string{"200", "300"}[i%2], price)); err != nil {
+			t.Fatalf("bid %d : %v", i+1, err)
+		}
+	}
+
+	_, err := c.PostBid(ctx, bidOf(4, "200", 1200))
+	if CodeOf(err) != Rejected || ReasonOf(err) != ReasonBidTooLow || err.(*Error).Details["HighestBid"] != "1300" {
+		t.Fatalf("low bid : %+v", err)
+	}
+	if _, err := c.PostBid(ctx, bidOf(3, "200", 1400)); ReasonOf(err) != ReasonConflict {
+		t.Fatalf("bid number taken : %v", err)
+	}
+	if _, err := c.PostBid(ctx, bidOf(4, "999", 1400)); !IsNotFound(err) {
+		t.Fatalf("unknown buyer : %v", err)
+	}
+
+	high, err := c.GetHighestBid(ctx, "1111")
+	if err != nil || high.BidNo != "3" || high.BidPrice != "1300" {
+		t.Fatalf("highest bid %+v : %v", high, err)
+	}
+	if n, err := c.GetNoOfBidsReceived(ctx, "1111"); err != nil || n != 3 {
+		t.Fatalf("number of bids %d : %v", n, err)
+	}
+
+	if _, err := c.CloseAuction(ctx, "1111"); err != nil {
+		t.Fatalf("CloseAuction : %v", err)
+	}
+	if a, err := c.GetAuctionRequest(ctx, "1111"); err != nil || a.Status != "CLOSED" {
+		t.Fatalf("auction %+v : %v", a, err)
+	}
+	if _, err := c.PostBid(ctx, bidOf(4, "200", 1400)); CodeOf(err) != Rejected || ReasonOf(err) != ReasonAuctionNotOpen {
+		t.Fatalf("bid on a closed auction : %v", err)
+	}
+	if _, err := c.CloseAuction(ctx, "1111"); ReasonOf(err) != ReasonAuctionNotOpen {
+		t.Fatalf("closed twice : %v", err)
+	}
+}
+
+func TestMockErrors(t *testing.T) {
+
+	ctx := context.Background()
+	c, m := newMockAuction(t, "")
+
+	cases := []struct {
+		name   string
+		call   func() error
+		code   ErrorCode
+		reason string
+	}{
+		{"unknown user", func() error { _, err := c.GetUser(ctx, "999"); return err }, NotFound, ReasonNotFound},
+		{"unknown bid", func() error { _, err := c.GetBid(ctx, "1111", "9"); return err }, NotFound, ReasonNotFound},
+		{"user twice", func() error {
+			_, err := c.PostUser(ctx, UserObject{UserID: "200", Name: "Ann", UserType: "TR"})
+			return err
+		}, Rejected, ReasonConflict},
+		{"auction of an unknown item", func() error {
+			_, err := c.PostAuctionRequest(ctx, AuctionRequest{AuctionID: "2222", ItemID: "9", AuctionHouseID: "100", RequestDate: "2017-03-05"})
+			return err
+		}, NotFound, ReasonNotFound},
+		{"bid on another item", func() error {
+			b := bidOf(1, "200", 1000)
+			b.ItemID = "9"
+			_, err := c.PostBid(ctx, b)
+			return err
+		}, InvalidArgument, ReasonInvalidArgument},
+		{"bid after the close date", func() error {
+			m.Now = func() time.Time { return mockStart.Add(2 * time.Hour) }
+			defer func() { m.Now = func() time.Time { return mockStart } }()
+			_, err := c.PostBid(ctx, bidOf(1, "200", 1000))
+			return err
+		}, Rejected, ReasonAuctionNotOpen},
+		{"unsupported call", func() error { _, err := c.EraseUser(ctx, "200"); return err }, InvalidArgument, ReasonInvalidArgument},
+	}
+
+	for _, tc := range cases {
+		err := tc.call()
+		if CodeOf(err) != tc.code || ReasonOf(err) != tc.reason {
+			t.Errorf("%s : %v (%v, %q)", tc.name, err, CodeOf(err), ReasonOf(err))
+		}
+	}
+}
+
+func TestMockPaging(t *testing.T) {
+
+	ctx := context.Background()
+	c, _ := newMockAuction(t, "")
+	for i := 1; i <= 5; i++ {
+		if _, err := c.PostBid(ctx, bidOf(i, "200", 1000+i*100)); err != nil {
+			t.Fatalf("bid %d : %v", i, err)
+		}
+	}
+
+	var seen []string
+	opts := ListOptions{PageSize: 2, Sort: "BidPrice:desc"}
+	for pages := 0; ; pages++ {
+		if pages == 3 {
+			t.Fatalf("more than 3 pages : %v", seen)
+		}
+		page, err := c.ListBids(ctx, "1111", opts)
+		if err != nil {
+			t.Fatalf("ListBids : %v", err)
+		}
+		if page.Count != len(page.Items) {
+			t.Fatalf("page %+v", page)
+		}
+		for _, b := range page.Items {
+			seen = append(seen, b.BidPrice)
+		}
+		if page.NextToken == "" {
+			break
+		}
+		opts.Token = page.NextToken
+	}
+	if len(seen) != 5 || seen[0] != "1500" || seen[4] != "1100" {
+		t.Fatalf("bids %v", seen)
+	}
+
+	open, err := c.ListAuctions(ctx, "OPEN", "", ListOptions{})
+	if err != nil || open.Count != 1 || open.Items[0].AuctionID != "1111" {
+		t.Fatalf("open auctions %+v : %v", open, err)
+	}
+	if init, err := c.ListAuctions(ctx, "INIT", "", ListOptions{}); err != nil || init.Count != 0 {
+		t.Fatalf("requested auctions %+v : %v", init, err)
+	}
+}
+
+// With a Path, a second ledger on the same file sees the first one's changes
+func TestMockLedgerFile(t *testing.T) {
+
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "ledger.json")
+	c, _ := newMockAuction(t, path)
+	if _, err := c.PostBid(ctx, bidOf(1, "200", 1000)); err != nil {
+		t.Fatalf("PostBid : %v", err)
+	}
+
+	m, err := NewMockLedger(path)
+	if err != nil {
+		t.Fatalf("NewMockLedger : %v", err)
+	}
+	if b, err := New(m).GetLastBid(ctx, "1111"); err != nil || b.BuyerID != "200" || b.BidPrice != "1000" {
+		t.Fatalf("last bid %+v : %v", b, err)
+	}
+}
@@ -0,0 +1,150 @@
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+//////////////////////////////////////////////////////////////////////////////////
+// A peer's JSON-RPC endpoint (fabric v0.6, POST /chaincode on port 7050)
+// SecureContext is the enrolled user the peer signs transactions as, when
+// security is enabled. Attributes are the certificate attributes, such as
+// role, that the chaincode reads with ReadCertAttribute
+//////////////////////////////////////////////////////////////////////////////////
+type PeerTransport struct {
+	URL           string // e.g. http://localhost:7050
+	ChaincodeID   string // Name returned when the chaincode was deployed
+	SecureContext string
+	Attributes    []string
+	Client        *http.Client
+
+	lastID uint64
+}
+
+type rpcRequest struct {
+	JSONRPC string    `json:"jsonrpc"`
+	Method  string    `json:"method"`
+	Params  rpcParams `json:"params"`
+	ID      uint64    `json:"id"`
+}
+
+type rpcParams struct {
+	Type        int `json:"type"` // 1 = GOLANG
+	ChaincodeID struct {
+		Name string `json:"name"`
+	} `json:"chaincodeID"`
+	CtorMsg struct {
+		Function string   `json:"function"`
+		Args     []string `json:"args"`
+	} `json:"ctorMsg"`
+	SecureContext string   `json:"secureContext,omitempty"`
+	Attributes    []string `json:"attributes,omitempty"`
+	Metadata      []byte   `json:"metadata,omitempty"` // Transient data, see InvokeTransient
+}
+
+type rpcResponse struct {
+	Result *struct {
+		Status  string `json:"status"`
+		Message string `json:"message"`
+	} `json:"result"`
+	Error *PeerError `json:"error"`
+}
+
+func (p *PeerTransport) Invoke(ctx context.Context, function string, args []string) (string, error) {
+	return p.call(ctx, "invoke", function, args, nil)
+}
+
+func (p *PeerTransport) Query(ctx context.Context, function string, args []string) ([]byte, error) {
+	return p.QueryTransient(ctx, function, args, nil)
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Fabric v0.6 has no transient data. The chaincode reads it from the caller
+// metadata instead, as a JSON object with base64 values
+//////////////////////////////////////////////////////////////////////////////////
+func (p *PeerTransport) InvokeTransient(ctx context.Context, function string, args []string, transient map[string][]byte) (string, error) {
+	return p.call(ctx, "invoke", function, args, transient)
+}
+
+func (p *PeerTransport) QueryTransient(ctx context.Context, function string, args []string, transient map[string][]byte) ([]byte, error) {
+	result, err := p.call(ctx, "query", function, args, transient)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(result), nil
+}
+
+func (p *PeerTransport) call(ctx context.Context, method string, function string, args []string, transient map[string][]byte) (string, error) {
+
+	req := rpcRequest{JSONRPC: "2.0", Method: method, ID: atomic.AddUint64(&p.lastID, 1)}
+	req.Params.Type = 1
+	req.Params.ChaincodeID.Name = p.ChaincodeID
+	req.Params.CtorMsg.Function = function
+	req.Params.CtorMsg.Args = args
+	req.Params.SecureContext = p.SecureContext
+	req.Params.Attributes = p.Attributes
+	if transient != nil {
+		metadata, err := json.Marshal(transient)
+		if err != nil {
+			return "", err
+		}
+		req.Params.Metadata = metadata
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+	httpReq, err := http.NewRequest("POST", strings.TrimRight(p.URL, "/")+"/chaincode", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(httpReq.WithContext(ctx))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var rpcResp rpcResponse
+	err = json.NewDecoder(resp.Body).Decode(&rpcResp)
+	if err != nil {
+		return "", fmt.Errorf("peer %s: %s: unreadable response: %v", function, resp.Status, err)
+	}
+	if rpcResp.Error != nil {
+		return "", rpcResp.Error
+	}
+	if rpcResp.Result == nil {
+		return "", fmt.Errorf("peer %s: %s: response has neither result nor error", function, resp.Status)
+	}
+	return rpcResp.Result.Message, nil
+}
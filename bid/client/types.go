@@ -0,0 +1,160 @@
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package client
+
+//////////////////////////////////////////////////////////////////////////////////
+// The chaincode's records
+// Field for field the structs of bid_app_1.go and friends, so that they
+// decode what the queries return. RecType is set by the chaincode
+//////////////////////////////////////////////////////////////////////////////////
+
+type UserObject struct {
+	UserID         string
+	RecType        string
+	Name           string
+	UserType       string // AH (Auction House), TR (Buyer or Seller), AP, IN, BK, SH
+	Address        string // Encrypted or hashed on the ledger; see pii.go
+	Phone          string
+	Email          string
+	Bank           string
+	AccountNo      string // Salted hash on the ledger
+	RoutingNo      string // Salted hash on the ledger
+	ErasedDate     string
+	Status         string // ACTIVE, INACTIVE
+	RegisteredDate string
+}
+
+type ItemDocument struct {
+	DocHash   string // Hex encoded SHA-256 of the document
+	MediaType string
+	DocURI    string // Off-chain location of the document
+}
+
+type ItemObject struct {
+	ItemID         string
+	RecType        string
+	ItemDesc       string
+	ItemDetail     string
+	ItemType       string
+	ItemSubject    string
+	ItemDocs       []ItemDocument
+	RegisteredDate string
+	OwnerID        string // Set by the chaincode, empty until known
+}
+
+type ItemDocVerification struct {
+	ItemID    string
+	DocHash   string
+	Verified  bool
+	MediaType string
+	DocURI    string
+}
+
+// An item's artefact as stored: encrypted under the owner's key, or under
+// the handover key while Handover is RELEASED
+type ItemArtefact struct {
+	ItemID         string
+	RecType        string
+	OwnerID        string
+	PendingOwnerID string
+	Handover       string // PENDING, RELEASED or empty
+	MediaType      string
+	DocHash        string
+	KeyCheck       string
+	Nonce          string
+	Payload        string
+}
+
+type PIIVerification struct {
+	UserID   string
+	Field    string
+	Verified bool
+}
+
+type AuctionRequest struct {
+	AuctionID      string
+	RecType        string
+	ItemID         string
+	AuctionHouseID string
+	RequestDate    string
+	Status         string // INIT, OPEN, CLOSED
+	OpenDate       string
+	CloseDate      string
+}
+
+type Bid struct {
+	AuctionID string
+	RecType   string
+	BidNo     string
+	ItemID    string
+	BuyerID   string
+	BidPrice  string
+	BidTime   string // Set by the chaincode
+}
+
+type ItemTransaction struct {
+	AuctionID   string
+	RecType     string
+	ItemID      string
+	TransType   string // BUY, SALE, COMMISSION
+	UserId      string
+	TransDate   string
+	HammerTime  string
+	HammerPrice string
+	Details     string
+	TxID        string // Set by the chaincode
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Pages of the list queries - ListPage in the chaincode's paging.go
+// NextToken is passed as ListOptions.Token to get the next page; it is empty
+// on the last page. The next page must be asked for with the same period, sort
+// and filters, or the chaincode rejects the token
+//////////////////////////////////////////////////////////////////////////////////
+
+type UserPage struct {
+	Items     []UserObject
+	Count     int
+	NextToken string
+}
+
+type ItemPage struct {
+	Items     []ItemObject
+	Count     int
+	NextToken string
+}
+
+type AuctionPage struct {
+	Items     []AuctionRequest
+	Count     int
+	NextToken string
+}
+
+type BidPage struct {
+	Items     []Bid
+	Count     int
+	NextToken string
+}
+
+type TransactionPage struct {
+	Items     []ItemTransaction
+	Count     int
+	NextToken string
+}
@@ -0,0 +1,93 @@
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package client
+
+import (
+	"context"
+)
+
+//////////////////////////////////////////////////////////////////////////////////
+// Users
+// PostUser and UpdateUser send the protected fields in the clear; the
+// chaincode hashes or encrypts them before they are stored (see pii.go)
+//////////////////////////////////////////////////////////////////////////////////
+
+func userArgs(u UserObject) []string {
+	return []string{u.UserID, "USER", u.Name, u.UserType, u.Address, u.Phone, u.Email, u.Bank, u.AccountNo, u.RoutingNo}
+}
+
+func (c *Client) PostUser(ctx context.Context, u UserObject) (string, error) {
+	if err := require("PostUser", "UserID", u.UserID, "Name", u.Name, "UserType", u.UserType); err != nil {
+		return "", err
+	}
+	return c.invoke(ctx, "PostUser", userArgs(u)...)
+}
+
+func (c *Client) UpdateUser(ctx context.Context, u UserObject) (string, error) {
+	if err := require("UpdateUser", "UserID", u.UserID, "Name", u.Name, "UserType", u.UserType); err != nil {
+		return "", err
+	}
+	return c.invoke(ctx, "UpdateUser", userArgs(u)...)
+}
+
+func (c *Client) DeactivateUser(ctx context.Context, userID string) (string, error) {
+	if err := require("DeactivateUser", "UserID", userID); err != nil {
+		return "", err
+	}
+	return c.invoke(ctx, "DeactivateUser", userID, "USER")
+}
+
+// Erase a user's personal data; the record stays, marked erased
+func (c *Client) EraseUser(ctx context.Context, userID string) (string, error) {
+	if err := require("EraseUser", "UserID", userID); err != nil {
+		return "", err
+	}
+	return c.invoke(ctx, "EraseUser", userID, "USER")
+}
+
+func (c *Client) GetUser(ctx context.Context, userID string) (UserObject, error) {
+	var u UserObject
+	if err := require("GetUser", "UserID", userID); err != nil {
+		return u, err
+	}
+	err := c.query(ctx, &u, "user "+userID, "GetUser", userID)
+	return u, err
+}
+
+// Check value against one of a user's protected fields, e.g. AccountNo
+func (c *Client) VerifyUserPII(ctx context.Context, userID string, field string, value string) (PIIVerification, error) {
+	var v PIIVerification
+	if err := require("VerifyUserPII", "UserID", userID, "field", field, "value", value); err != nil {
+		return v, err
+	}
+	err := c.query(ctx, &v, "user "+userID, "VerifyUserPII", userID, field, value)
+	return v, err
+}
+
+// Users registered in period (e.g. "2017" or "2016..2018"), of userType if given
+func (c *Client) ListUsers(ctx context.Context, period string, userType string, opts ListOptions) (UserPage, error) {
+	var page UserPage
+	keys := []string{periodOrDefault(period)}
+	if userType != "" {
+		keys = append(keys, userType)
+	}
+	err := c.query(ctx, &page, "users", "GetUserListByCat", append(keys, opts.args()...)...)
+	return page, err
+}
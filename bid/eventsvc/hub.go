@@ -0,0 +1,313 @@
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+//////////////////////////////////////////////////////////////////////////////////
+// Event payloads - mirror EventEnvelope and AuctionEvent in the chaincode
+//////////////////////////////////////////////////////////////////////////////////
+type Envelope struct {
+	SchemaVersion int
+	TxID          string
+	Events        []Event
+}
+
+type Event struct {
+	Type       string
+	AuctionID  string
+	ItemID     string
+	UserID     string
+	PrevUserID string
+	Price      string
+	CloseDate  string
+	Time       string
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// What a client receives: one message per transaction, holding the events of
+// that transaction the client subscribed to
+//////////////////////////////////////////////////////////////////////////////////
+type Message struct {
+	Block         uint64
+	TxID          string
+	Name          string
+	SchemaVersion int
+	Events        []Event
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// A client's subscription. Empty means everything
+// A user subscription matches events where the user is UserID or PrevUserID
+//////////////////////////////////////////////////////////////////////////////////
+type Subscription struct {
+	Auctions map[string]bool
+	Users    map[string]bool
+}
+
+func (s Subscription) Match(ev Event) bool {
+
+	if len(s.Auctions) == 0 && len(s.Users) == 0 {
+		return true
+	}
+	return s.Auctions[ev.AuctionID] || s.Users[ev.UserID] || (ev.PrevUserID != "" && s.Users[ev.PrevUserID])
+}
+
+func (s Subscription) Filter(ev BlockEvent, env Envelope) *Message {
+
+	var events []Event
+	for _, e := range env.Events {
+		if s.Match(e) {
+			events = append(events, e)
+		}
+	}
+	if len(events) == 0 {
+		return nil
+	}
+	return &Message{Block: ev.Block, TxID: ev.TxID, Name: ev.Name, SchemaVersion: env.SchemaVersion, Events: events}
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Hub - receives events from the source and serves them to WebSocket clients
+//////////////////////////////////////////////////////////////////////////////////
+type Hub struct {
+	log      *eventLog
+	source   EventSource // Used to replay blocks the log no longer holds
+	upgrader websocket.Upgrader
+
+	mu      sync.Mutex
+	clients int
+}
+
+const (
+	readBatch    = 100
+	writeTimeout = 10 * time.Second
+	pingInterval = 30 * time.Second
+)
+
+func NewHub(source EventSource, buffer int, origins []string) *Hub {
+
+	h := &Hub{log: newEventLog(buffer), source: source}
+	if len(origins) > 0 {
+		h.upgrader.CheckOrigin = func(r *http.Request) bool {
+			origin := r.Header.Get("Origin")
+			for _, o := range origins {
+				if o == "*" || o == origin {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	return h
+}
+
+func parseEnvelope(payload []byte) (Envelope, error) {
+	var env Envelope
+	err := json.Unmarshal(payload, &env)
+	return env, err
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Add an event from the source. Payloads that are not auction envelopes are
+// dropped
+//////////////////////////////////////////////////////////////////////////////////
+func (h *Hub) Publish(ev BlockEvent) {
+
+	env, err := parseEnvelope(ev.Payload)
+	if err != nil {
+		log.Printf("eventsvc: block %d tx %s: dropping %s event: %v", ev.Block, ev.TxID, ev.Name, err)
+		return
+	}
+	h.log.Append(ev, env)
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// GET /events?auction=1111&user=200&from=120
+// auction and user may be repeated or comma separated. from replays the events
+// of blocks from that one on before going live
+//////////////////////////////////////////////////////////////////////////////////
+func (h *Hub) ServeEvents(w http.ResponseWriter, r *http.Request) {
+
+	q := r.URL.Query()
+	sub := Subscription{Auctions: queryList(q["auction"]), Users: queryList(q["user"])}
+
+	var from *uint64
+	if v := q.Get("from"); v != "" {
+		block, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			http.Error(w, "from should be a block number", http.StatusBadRequest)
+			return
+		}
+		from = &block
+	}
+
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already answered the request
+		return
+	}
+
+	h.mu.Lock()
+	h.clients++
+	h.mu.Unlock()
+	defer func() {
+		h.mu.Lock()
+		h.clients--
+		h.mu.Unlock()
+	}()
+
+	h.serveClient(conn, sub, from)
+}
+
+func queryList(values []string) map[string]bool {
+
+	set := map[string]bool{}
+	for _, v := range values {
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				set[s] = true
+			}
+		}
+	}
+	return set
+}
+
+func (h *Hub) serveClient(conn *websocket.Conn, sub Subscription, from *uint64) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer conn.Close()
+
+	// Clients do not send anything, but reading is what notices them going away
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	var lastBlock uint64
+	send := func(ev BlockEvent, env Envelope) error {
+		lastBlock = ev.Block
+		msg := sub.Filter(ev, env)
+		if msg == nil {
+			return nil
+		}
+		conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+		return conn.WriteJSON(msg)
+	}
+
+	seq := h.log.Tail()
+	if from != nil {
+		block := *from
+		lastBlock = block
+		for {
+			s, replayTo, ok := h.log.Seek(block)
+			if ok {
+				seq = s
+				break
+			}
+			if h.source == nil {
+				closeWith(conn, websocket.CloseInternalServerErr, "replay is not available")
+				return
+			}
+			err := h.source.Events(ctx, block, replayTo, func(ev BlockEvent) error {
+				env, err := parseEnvelope(ev.Payload)
+				if err != nil {
+					return nil
+				}
+				return send(ev, env)
+			})
+			if err != nil {
+				closeWith(conn, websocket.CloseInternalServerErr, fmt.Sprintf("replay from block %d failed: %v", block, err))
+				return
+			}
+			block = replayTo
+		}
+	}
+
+	ping := time.NewTicker(pingInterval)
+	defer ping.Stop()
+
+	for {
+		entries, next, lagged, wait := h.log.Read(seq, readBatch)
+		if lagged {
+			closeWith(conn, websocket.CloseTryAgainLater, fmt.Sprintf("client fell behind, reconnect with from=%d", lastBlock))
+			return
+		}
+
+		for _, e := range entries {
+			if err := send(e.Event, e.Envelope); err != nil {
+				return
+			}
+		}
+		seq = next
+		if len(entries) > 0 {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-wait:
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeTimeout)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func closeWith(conn *websocket.Conn, code int, text string) {
+	conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, text), time.Now().Add(writeTimeout))
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// GET /status
+//////////////////////////////////////////////////////////////////////////////////
+func (h *Hub) ServeStatus(w http.ResponseWriter, r *http.Request) {
+
+	head, buffered := h.log.Stats()
+	h.mu.Lock()
+	clients := h.clients
+	h.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		Head     uint64
+		Buffered int
+		Clients  int
+	}{head, buffered, clients})
+}
@@ -0,0 +1,178 @@
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestSubscriptionFilter(t *testing.T) {
+
+	env := Envelope{SchemaVersion: 1, Events: []Event{
+		{Type: "NewHighBid", AuctionID: "1111", UserID: "300", PrevUserID: "200"},
+		{Type: "AuctionClosed", AuctionID: "2222"},
+	}}
+	ev := BlockEvent{Block: 5, TxID: "tx-1", Name: "NewHighBid"}
+
+	for _, c := range []struct {
+		name   string
+		sub    Subscription
+		events []string
+	}{
+		{"everything", Subscription{}, []string{"NewHighBid", "AuctionClosed"}},
+		{"an auction", Subscription{Auctions: queryList([]string{"2222"})}, []string{"AuctionClosed"}},
+		{"the bidder", Subscription{Users: queryList([]string{"300"})}, []string{"NewHighBid"}},
+		{"the outbid bidder", Subscription{Users: queryList([]string{"200"})}, []string{"NewHighBid"}},
+		{"either", Subscription{Auctions: queryList([]string{"2222"}), Users: queryList([]string{"200"})}, []string{"NewHighBid", "AuctionClosed"}},
+		{"someone else", Subscription{Users: queryList([]string{"400"})}, nil},
+	} {
+		msg := c.sub.Filter(ev, env)
+		var got []string
+		if msg != nil {
+			if msg.Block != 5 || msg.TxID != "tx-1" || msg.SchemaVersion != 1 {
+				t.Errorf("%s : %+v", c.name, msg)
+			}
+			for _, e := range msg.Events {
+				got = append(got, e.Type)
+			}
+		}
+		if strings.Join(got, ",") != strings.Join(c.events, ",") {
+			t.Errorf("%s : %v", c.name, got)
+		}
+	}
+
+	if set := queryList([]string{"1111, 2222", "", "3333"}); len(set) != 3 || !set["2222"] {
+		t.Errorf("queryList %v", set)
+	}
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// WebSocket clients of a hub fed from testdata/events.jsonl
+//////////////////////////////////////////////////////////////////////////////////
+
+func dial(t *testing.T, server *httptest.Server, query string) *websocket.Conn {
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/events" + query
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("dial %s : %v", url, err)
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	return conn
+}
+
+func readMessage(t *testing.T, conn *websocket.Conn) Message {
+
+	var msg Message
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatalf("read : %v", err)
+	}
+	return msg
+}
+
+func envelope(events ...Event) []byte {
+	data, _ := json.Marshal(Envelope{SchemaVersion: 1, Events: events})
+	return data
+}
+
+// Replays blocks the log no longer holds from the source, then carries on live
+func TestHubReplay(t *testing.T) {
+
+	hub := NewHub(&LocalSource{Path: "testdata/events.jsonl"}, 3, nil)
+	hub.Publish(BlockEvent{Block: 7, TxID: "tx-close-1111", Name: "AuctionClosed",
+		Payload: envelope(Event{Type: "AuctionClosed", AuctionID: "1111"})})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/events", hub.ServeEvents)
+	mux.HandleFunc("/status", hub.ServeStatus)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	conn := dial(t, server, "?auction=1111&from=4")
+	defer conn.Close()
+
+	var got []string
+	for i := 0; i < 3; i++ {
+		msg := readMessage(t, conn)
+		got = append(got, msg.TxID)
+	}
+	if strings.Join(got, ",") != "tx-bid-1,tx-bid-2,tx-close-1111" {
+		t.Fatalf("replayed %v", got)
+	}
+
+	hub.Publish(BlockEvent{Block: 8, TxID: "tx-other", Payload: envelope(Event{Type: "AuctionOpened", AuctionID: "2222"})})
+	hub.Publish(BlockEvent{Block: 9, TxID: "not-an-auction-event", Payload: []byte("not JSON")})
+	hub.Publish(BlockEvent{Block: 9, TxID: "tx-open-1111", Payload: envelope(Event{Type: "AuctionOpened", AuctionID: "1111"})})
+	if msg := readMessage(t, conn); msg.Block != 9 || msg.TxID != "tx-open-1111" || msg.Events[0].Type != "AuctionOpened" {
+		t.Fatalf("live %+v", msg)
+	}
+
+	resp, err := http.Get(server.URL + "/status")
+	if err != nil {
+		t.Fatalf("status : %v", err)
+	}
+	defer resp.Body.Close()
+	var status struct {
+		Head     uint64
+		Buffered int
+		Clients  int
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil || status.Head != 9 || status.Clients != 1 {
+		t.Fatalf("status %+v : %v", status, err)
+	}
+}
+
+func TestHubRefusals(t *testing.T) {
+
+	hub := NewHub(nil, 10, []string{"https://auction.example.com"})
+	hub.Publish(BlockEvent{Block: 7, TxID: "tx-1", Payload: envelope(Event{Type: "AuctionClosed", AuctionID: "1111"})})
+	server := httptest.NewServer(http.HandlerFunc(hub.ServeEvents))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/events?from=abc")
+	if err != nil || resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("from=abc : %v %v", resp, err)
+	}
+	resp.Body.Close()
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/events"
+	if _, resp, err := websocket.DefaultDialer.Dial(url, http.Header{"Origin": {"https://elsewhere.example.com"}}); err == nil || resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("other origin : %v", err)
+	}
+
+	// Block 3 is gone and there is no source to replay it from
+	conn, _, err := websocket.DefaultDialer.Dial(url+"?from=3", http.Header{"Origin": {"https://auction.example.com"}})
+	if err != nil {
+		t.Fatalf("dial : %v", err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, _, err = conn.ReadMessage()
+	if ce, ok := err.(*websocket.CloseError); !ok || ce.Code != websocket.CloseInternalServerErr || ce.Text != "replay is not available" {
+		t.Fatalf("replay without a source : %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+//////////////////////////////////////////////////////////////////////////////////
+// Local stand-in for a peer
+// Reads events from a file with one JSON object per line, in block order:
+//   {"Block": 12, "TxID": "tx-1", "Name": "NewHighBid", "Payload": {"SchemaVersion": 1, ...}}
+// When following, lines appended to the file later are picked up, so a UI can
+// be driven by echoing events into the file
+//////////////////////////////////////////////////////////////////////////////////
+type LocalSource struct {
+	Path string
+	Poll time.Duration
+}
+
+type localLine struct {
+	Block   uint64
+	TxID    string
+	Name    string
+	Payload json.RawMessage
+}
+
+func (s *LocalSource) Events(ctx context.Context, from uint64, to uint64, fn func(BlockEvent) error) error {
+
+	f, err := os.Open(s.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	var partial []byte
+	lineNo := 0
+
+	for {
+		chunk, err := r.ReadBytes('\n')
+		partial = append(partial, chunk...)
+
+		if err == io.EOF {
+			// A line still being written stays in partial until its newline arrives
+			if to != 0 {
+				return nil
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(s.Poll):
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		line := partial
+		partial = nil
+		lineNo++
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		var l localLine
+		if err := json.Unmarshal(line, &l); err != nil {
+			return fmt.Errorf("%s:%d: %v", s.Path, lineNo, err)
+		}
+		if l.Block < from {
+			continue
+		}
+		if to != 0 && l.Block >= to {
+			return nil
+		}
+
+		err = fn(BlockEvent{Block: l.Block, TxID: l.TxID, Name: l.Name, Payload: []byte(l.Payload)})
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,157 @@
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package main
+
+import (
+	"sync"
+)
+
+//////////////////////////////////////////////////////////////////////////////////
+// In-memory log of the most recent events
+// Every client reads the log at its own pace from its own position (Seq), so a
+// slow client never holds up the others. The log keeps at most max entries and
+// always drops whole blocks, so a block is either fully in the log or not at all
+//////////////////////////////////////////////////////////////////////////////////
+type logEntry struct {
+	Seq      uint64
+	Event    BlockEvent
+	Envelope Envelope
+}
+
+type eventLog struct {
+	mu      sync.Mutex
+	entries []logEntry
+	nextSeq uint64
+	head    uint64 // Highest block published so far
+	hasHead bool
+	max     int
+	notify  chan struct{} // Closed and replaced on every append
+}
+
+func newEventLog(max int) *eventLog {
+	return &eventLog{max: max, notify: make(chan struct{})}
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Add an event. Returns false for an event already seen, which happens when
+// the source reconnects and resumes from the last block it delivered
+//////////////////////////////////////////////////////////////////////////////////
+func (l *eventLog) Append(ev BlockEvent, env Envelope) bool {
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.hasHead {
+		if ev.Block < l.head {
+			return false
+		}
+		for i := len(l.entries) - 1; i >= 0 && l.entries[i].Event.Block == ev.Block; i-- {
+			if l.entries[i].Event.TxID == ev.TxID {
+				return false
+			}
+		}
+	}
+
+	l.entries = append(l.entries, logEntry{Seq: l.nextSeq, Event: ev, Envelope: env})
+	l.nextSeq++
+	l.head = ev.Block
+	l.hasHead = true
+
+	for len(l.entries) > l.max {
+		oldest := l.entries[0].Event.Block
+		n := 0
+		for n < len(l.entries) && l.entries[n].Event.Block == oldest {
+			n++
+		}
+		l.entries = append([]logEntry(nil), l.entries[n:]...)
+	}
+
+	close(l.notify)
+	l.notify = make(chan struct{})
+	return true
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Position of the first event at or after block from
+// If the log no longer holds that far back, ok is false and blocks
+// from..replayTo-1 must be replayed from the source before seeking replayTo
+//////////////////////////////////////////////////////////////////////////////////
+func (l *eventLog) Seek(from uint64) (seq uint64, replayTo uint64, ok bool) {
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.entries) > 0 && from >= l.entries[0].Event.Block {
+		for _, e := range l.entries {
+			if e.Event.Block >= from {
+				return e.Seq, 0, true
+			}
+		}
+		return l.nextSeq, 0, true
+	}
+
+	if len(l.entries) == 0 && (!l.hasHead || from > l.head) {
+		return l.nextSeq, 0, true
+	}
+
+	if len(l.entries) > 0 {
+		return 0, l.entries[0].Event.Block, false
+	}
+	return 0, l.head + 1, false
+}
+
+// Position after the last event - where a client without a from block starts
+func (l *eventLog) Tail() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.nextSeq
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Up to max events from position seq
+// lagged is set when the events at seq have already been dropped. When there is
+// nothing to read, wait is closed as soon as there is
+//////////////////////////////////////////////////////////////////////////////////
+func (l *eventLog) Read(seq uint64, max int) (entries []logEntry, next uint64, lagged bool, wait <-chan struct{}) {
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.entries) > 0 && seq < l.entries[0].Seq {
+		return nil, seq, true, nil
+	}
+	if seq >= l.nextSeq {
+		return nil, seq, false, l.notify
+	}
+
+	i := int(seq - l.entries[0].Seq)
+	j := i + max
+	if j > len(l.entries) {
+		j = len(l.entries)
+	}
+	entries = append([]logEntry(nil), l.entries[i:j]...)
+	return entries, entries[len(entries)-1].Seq + 1, false, nil
+}
+
+func (l *eventLog) Stats() (head uint64, buffered int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.head, len(l.entries)
+}
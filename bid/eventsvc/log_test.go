@@ -0,0 +1,139 @@
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func blockEvent(block uint64, txID string) BlockEvent {
+	return BlockEvent{Block: block, TxID: txID, Name: "NewHighBid"}
+}
+
+// Blocks and transactions of the entries from seq on
+func readAll(l *eventLog, seq uint64) []string {
+
+	var got []string
+	entries, _, _, _ := l.Read(seq, 1000)
+	for _, e := range entries {
+		got = append(got, e.Event.TxID)
+	}
+	return got
+}
+
+func TestEventLogAppend(t *testing.T) {
+
+	l := newEventLog(10)
+	for _, c := range []struct {
+		ev    BlockEvent
+		added bool
+	}{
+		{blockEvent(3, "a"), true},
+		{blockEvent(3, "b"), true},
+		{blockEvent(3, "a"), false}, // Seen again after a reconnect
+		{blockEvent(4, "c"), true},
+		{blockEvent(3, "d"), false}, // Behind the head
+		{blockEvent(4, "c"), false},
+		{blockEvent(6, "e"), true},
+	} {
+		if added := l.Append(c.ev, Envelope{}); added != c.added {
+			t.Fatalf("block %d tx %s : added %v", c.ev.Block, c.ev.TxID, added)
+		}
+	}
+	if got := readAll(l, 0); !reflect.DeepEqual(got, []string{"a", "b", "c", "e"}) {
+		t.Fatalf("log %v", got)
+	}
+	if head, buffered := l.Stats(); head != 6 || buffered != 4 {
+		t.Fatalf("head %d, buffered %d", head, buffered)
+	}
+}
+
+// Whole blocks are dropped, oldest first, once the log is over its size
+func TestEventLogDropsWholeBlocks(t *testing.T) {
+
+	l := newEventLog(3)
+	l.Append(blockEvent(3, "a"), Envelope{})
+	l.Append(blockEvent(3, "b"), Envelope{})
+	l.Append(blockEvent(4, "c"), Envelope{})
+	l.Append(blockEvent(5, "d"), Envelope{})
+	l.Append(blockEvent(5, "e"), Envelope{})
+
+	if _, buffered := l.Stats(); buffered != 3 {
+		t.Fatalf("buffered %d", buffered)
+	}
+	if _, next, lagged, _ := l.Read(0, 10); !lagged || next != 0 {
+		t.Fatalf("read from a dropped entry : lagged %v, next %d", lagged, next)
+	}
+	if got := readAll(l, 2); !reflect.DeepEqual(got, []string{"c", "d", "e"}) {
+		t.Fatalf("log %v", got)
+	}
+}
+
+func TestEventLogSeek(t *testing.T) {
+
+	empty := newEventLog(10)
+	if seq, _, ok := empty.Seek(0); !ok || seq != 0 {
+		t.Fatalf("empty log : seq %d, ok %v", seq, ok)
+	}
+
+	l := newEventLog(3)
+	for i, block := range []uint64{3, 4, 5, 5, 7} {
+		l.Append(blockEvent(block, string(rune('a'+i))), Envelope{})
+	}
+	// Holds 5, 5 and 7 (seq 2 to 4)
+
+	for _, c := range []struct {
+		from     uint64
+		seq      uint64
+		replayTo uint64
+		ok       bool
+	}{
+		{5, 2, 0, true},
+		{6, 4, 0, true},
+		{7, 4, 0, true},
+		{8, 5, 0, true}, // Not yet published: from the tail
+		{2, 0, 5, false},
+		{4, 0, 5, false},
+	} {
+		seq, replayTo, ok := l.Seek(c.from)
+		if seq != c.seq || replayTo != c.replayTo || ok != c.ok {
+			t.Errorf("from %d : seq %d, replay to %d, ok %v", c.from, seq, replayTo, ok)
+		}
+	}
+}
+
+func TestEventLogReadWaits(t *testing.T) {
+
+	l := newEventLog(10)
+	entries, next, lagged, wait := l.Read(l.Tail(), 10)
+	if len(entries) != 0 || next != 0 || lagged || wait == nil {
+		t.Fatalf("entries %v, next %d, lagged %v", entries, next, lagged)
+	}
+	l.Append(blockEvent(3, "a"), Envelope{})
+	select {
+	case <-wait:
+	default:
+		t.Fatalf("wait not closed by Append")
+	}
+	if entries, next, _, _ := l.Read(next, 10); len(entries) != 1 || next != 1 {
+		t.Fatalf("entries %v, next %d", entries, next)
+	}
+}
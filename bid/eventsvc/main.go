@@ -0,0 +1,92 @@
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+//////////////////////////////////////////////////////////////////////////////////
+// eventsvc - streams auction events to WebSocket clients
+//
+// Against a local stand-in (see testdata/events.jsonl):
+//   go run . -source local -file testdata/events.jsonl
+// Against a peer:
+//   go run . -source rest -peer http://localhost:7050 -chaincode <chaincode name>
+// Then connect to ws://localhost:8090/events?auction=1111
+//////////////////////////////////////////////////////////////////////////////////
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+)
+
+func main() {
+
+	listen := flag.String("listen", ":8090", "address to serve WebSocket clients on")
+	source := flag.String("source", "local", "event source: local or rest")
+	file := flag.String("file", "events.jsonl", "events file for -source local")
+	peer := flag.String("peer", "http://localhost:7050", "peer REST address for -source rest")
+	chaincode := flag.String("chaincode", "", "only events from this chaincode (-source rest)")
+	from := flag.Uint64("from", 0, "block to start from when there is no checkpoint")
+	poll := flag.Duration("poll", time.Second, "how often to look for new events")
+	buffer := flag.Int("buffer", 10000, "events kept in memory for clients to catch up from")
+	checkpoint := flag.String("checkpoint", "", "file recording the last block read, to resume after a restart")
+	origins := flag.String("origin", "", "comma separated origins allowed to connect, * for any (default same origin)")
+	flag.Parse()
+
+	var src EventSource
+	switch *source {
+	case "local":
+		src = &LocalSource{Path: *file, Poll: *poll}
+	case "rest":
+		src = &RESTSource{PeerURL: *peer, ChaincodeID: *chaincode, Poll: *poll}
+	default:
+		log.Fatalf("eventsvc: unknown source %q, should be local or rest", *source)
+	}
+
+	start := *from
+	if block, ok := loadCheckpoint(*checkpoint); ok {
+		start = block
+	}
+
+	var allowed []string
+	if *origins != "" {
+		allowed = strings.Split(*origins, ",")
+	}
+	hub := NewHub(src, *buffer, allowed)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt)
+		<-sig
+		cancel()
+		os.Exit(0)
+	}()
+	go Follow(ctx, src, hub, start, *checkpoint)
+
+	http.HandleFunc("/events", hub.ServeEvents)
+	http.HandleFunc("/status", hub.ServeStatus)
+
+	log.Printf("eventsvc: reading %s events from block %d, serving on %s", *source, start, *listen)
+	log.Fatal(http.ListenAndServe(*listen, nil))
+}
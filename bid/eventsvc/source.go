@@ -0,0 +1,120 @@
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//////////////////////////////////////////////////////////////////////////////////
+// A chaincode event as it came out of a block
+// Payload is the EventEnvelope raised by the auction chaincode (see docs/events.md)
+//////////////////////////////////////////////////////////////////////////////////
+type BlockEvent struct {
+	Block   uint64
+	TxID    string
+	Name    string
+	Payload []byte
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Where events come from
+// Events calls fn for every chaincode event in blocks from..to-1, in block order.
+// With to == 0 it follows the chain and only returns when ctx is cancelled or
+// the source fails. An error from fn stops the stream and is returned
+//////////////////////////////////////////////////////////////////////////////////
+type EventSource interface {
+	Events(ctx context.Context, from uint64, to uint64, fn func(BlockEvent) error) error
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Feed the hub from the source, reconnecting with back-off when it fails
+// Each reconnect resumes from the last block seen; the hub drops the events of
+// that block it already has. The block is saved to the checkpoint file, if one
+// is given, so that a restarted service carries on where it stopped
+//////////////////////////////////////////////////////////////////////////////////
+func Follow(ctx context.Context, src EventSource, hub *Hub, from uint64, checkpoint string) {
+
+	next := from
+	backoff := time.Second
+
+	for ctx.Err() == nil {
+		err := src.Events(ctx, next, 0, func(ev BlockEvent) error {
+			hub.Publish(ev)
+			backoff = time.Second
+			if ev.Block != next {
+				next = ev.Block
+				saveCheckpoint(checkpoint, next)
+			}
+			return nil
+		})
+		if ctx.Err() != nil {
+			return
+		}
+
+		log.Printf("eventsvc: event source stopped (%v), reconnecting from block %d in %s", err, next, backoff)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > 30*time.Second {
+			backoff = 30 * time.Second
+		}
+	}
+}
+
+func loadCheckpoint(path string) (uint64, bool) {
+
+	if path == "" {
+		return 0, false
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+	block, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		log.Printf("eventsvc: ignoring unreadable checkpoint %s: %v", path, err)
+		return 0, false
+	}
+	return block, true
+}
+
+func saveCheckpoint(path string, block uint64) {
+
+	if path == "" {
+		return
+	}
+	tmp := path + ".tmp"
+	err := ioutil.WriteFile(tmp, []byte(strconv.FormatUint(block, 10)+"\n"), 0644)
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		log.Printf("eventsvc: cannot save checkpoint %s: %v", path, err)
+	}
+}
@@ -0,0 +1,137 @@
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLocalSource(t *testing.T) {
+
+	src := &LocalSource{Path: "testdata/events.jsonl"}
+	var got []string
+	err := src.Events(context.Background(), 4, 7, func(ev BlockEvent) error {
+		got = append(got, ev.TxID)
+		if _, err := parseEnvelope(ev.Payload); err != nil {
+			t.Errorf("block %d : %v", ev.Block, err)
+		}
+		return nil
+	})
+	if err != nil || strings.Join(got, ",") != "tx-bid-1,tx-bid-2" {
+		t.Fatalf("events %v : %v", got, err)
+	}
+
+	stop := errors.New("stop")
+	if err := src.Events(context.Background(), 0, 0, func(ev BlockEvent) error { return stop }); err != stop {
+		t.Fatalf("error from fn : %v", err)
+	}
+
+	bad := filepath.Join(t.TempDir(), "bad.jsonl")
+	os.WriteFile(bad, []byte("\n{\"Block\": 1}\nnot JSON\n"), 0644)
+	err = (&LocalSource{Path: bad}).Events(context.Background(), 0, 10, func(ev BlockEvent) error { return nil })
+	if err == nil || !strings.HasPrefix(err.Error(), bad+":3:") {
+		t.Fatalf("bad line : %v", err)
+	}
+}
+
+// Lines appended to the file are picked up while following
+func TestLocalSourceFollows(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	os.WriteFile(path, []byte(`{"Block": 1, "TxID": "a", "Payload": {}}`+"\n"+`{"Block": 2, "TxID": "b", `), 0644)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	src := &LocalSource{Path: path, Poll: time.Millisecond}
+	var got []string
+	err := src.Events(ctx, 0, 0, func(ev BlockEvent) error {
+		got = append(got, ev.TxID)
+		if len(got) == 1 {
+			// Finish the partial line
+			f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+			f.WriteString(`"Payload": {}}` + "\n")
+			f.Close()
+			return nil
+		}
+		cancel()
+		return nil
+	})
+	if err != context.Canceled || strings.Join(got, ",") != "a,b" {
+		t.Fatalf("events %v : %v", got, err)
+	}
+}
+
+func TestCheckpoint(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "checkpoint")
+	if _, ok := loadCheckpoint(path); ok {
+		t.Fatalf("checkpoint before any was saved")
+	}
+	saveCheckpoint(path, 42)
+	if block, ok := loadCheckpoint(path); !ok || block != 42 {
+		t.Fatalf("checkpoint %d, %v", block, ok)
+	}
+	os.WriteFile(path, []byte("garbage"), 0644)
+	if _, ok := loadCheckpoint(path); ok {
+		t.Fatalf("unreadable checkpoint loaded")
+	}
+	if _, ok := loadCheckpoint(""); ok {
+		t.Fatalf("checkpoint without a file")
+	}
+}
+
+// Follow feeds the hub and records the last block it saw
+func TestFollow(t *testing.T) {
+
+	checkpoint := filepath.Join(t.TempDir(), "checkpoint")
+	hub := NewHub(nil, 100, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		Follow(ctx, &LocalSource{Path: "testdata/events.jsonl", Poll: time.Millisecond}, hub, 4, checkpoint)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if head, _ := hub.log.Stats(); head == 7 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("hub did not reach block 7")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	if _, buffered := hub.log.Stats(); buffered != 3 {
+		t.Fatalf("buffered %d", buffered)
+	}
+	if block, ok := loadCheckpoint(checkpoint); !ok || block != 7 {
+		t.Fatalf("checkpoint %d, %v", block, ok)
+	}
+}
@@ -0,0 +1,74 @@
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+//////////////////////////////////////////////////////////////////////////////////
+// gateway - REST/JSON front end to the auction chaincode
+//
+//   go run . -peer http://localhost:7050 -chaincode <chaincode name> -user jim -attributes role
+//   curl -X POST localhost:8080/auctions/1111/bids -d '{"BidNo": "1", "ItemID": "1000", "BuyerID": "300", "BidPrice": "1200"}'
+//   curl localhost:8080/auctions?status=OPEN
+//   curl localhost:8080/openapi.json
+//   go run . -openapi > openapi.json
+//////////////////////////////////////////////////////////////////////////////////
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ITPeople-Blockchain/auction/bid/client"
+)
+
+func main() {
+
+	listen := flag.String("listen", ":8080", "address to serve on")
+	peerURL := flag.String("peer", "http://localhost:7050", "peer REST address")
+	chaincode := flag.String("chaincode", "", "name of the deployed auction chaincode")
+	user := flag.String("user", "", "enrolled user to transact as, when security is enabled")
+	attributes := flag.String("attributes", "", "comma separated certificate attributes to pass, e.g. role")
+	timeout := flag.Duration("timeout", 30*time.Second, "how long to wait for the peer")
+	openapi := flag.Bool("openapi", false, "print the OpenAPI document and exit")
+	flag.Parse()
+
+	if *openapi {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(OpenAPI())
+		return
+	}
+
+	if *chaincode == "" {
+		log.Fatal("gateway: -chaincode is required")
+	}
+
+	peer := &client.PeerTransport{URL: *peerURL, ChaincodeID: *chaincode, SecureContext: *user}
+	if *attributes != "" {
+		peer.Attributes = strings.Split(*attributes, ",")
+	}
+
+	gw := &Gateway{Transport: peer, Timeout: *timeout}
+
+	log.Printf("gateway: forwarding to %s on %s, serving on %s", *chaincode, *peerURL, *listen)
+	log.Fatal(http.ListenAndServe(*listen, gw))
+}
@@ -0,0 +1,187 @@
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package main
+
+import (
+	"reflect"
+	"strings"
+)
+
+type object map[string]interface{}
+
+//////////////////////////////////////////////////////////////////////////////////
+// OpenAPI 3.0 document of the gateway, generated from the route table
+// Served at GET /openapi.json
+//////////////////////////////////////////////////////////////////////////////////
+func OpenAPI() object {
+
+	schemas := object{}
+	paths := object{}
+
+	for _, r := range routes {
+
+		op := object{
+			"operationId": r.Function,
+			"summary":     r.Summary,
+			"tags":        []string{strings.Split(strings.Trim(r.Path, "/"), "/")[0]},
+		}
+
+		var params []object
+		for _, seg := range strings.Split(r.Path, "/") {
+			if strings.HasPrefix(seg, "{") {
+				params = append(params, object{"name": strings.Trim(seg, "{}"), "in": "path", "required": true, "schema": object{"type": "string"}})
+			}
+		}
+		for _, p := range r.AllParams() {
+			params = append(params, object{"name": p.Name, "in": "query", "required": p.Required, "description": p.Doc, "schema": object{"type": "string"}})
+		}
+		if len(params) > 0 {
+			op["parameters"] = params
+		}
+
+		if r.Body != nil {
+			op["requestBody"] = object{"required": true, "content": jsonContent(schemaOf(reflect.TypeOf(r.Body), schemas))}
+		}
+
+		responses := object{
+			"404": response("Not found", ref("ErrorBody", reflect.TypeOf(ErrorBody{}), schemas)),
+			"400": response("The request is not valid", ref("ErrorBody", reflect.TypeOf(ErrorBody{}), schemas)),
+			"403": response("The chaincode refused the caller (UNAUTHORIZED)", ref("ErrorBody", reflect.TypeOf(ErrorBody{}), schemas)),
+			"409": response("The call conflicts with the ledger's state (CONFLICT, AUCTION_NOT_OPEN, BID_TOO_LOW)", ref("ErrorBody", reflect.TypeOf(ErrorBody{}), schemas)),
+			"502": response("The peer failed or could not be reached", ref("ErrorBody", reflect.TypeOf(ErrorBody{}), schemas)),
+		}
+		if r.Query {
+			result := object{}
+			if r.Result != nil {
+				result = schemaOf(reflect.TypeOf(r.Result), schemas)
+			}
+			if r.List {
+				result = pageOf(reflect.TypeOf(r.Result), schemas)
+			}
+			responses["200"] = response("OK", result)
+		} else {
+			responses["202"] = response("Submitted; the peer commits the transaction asynchronously", ref("Submitted", reflect.TypeOf(Submitted{}), schemas))
+		}
+		op["responses"] = responses
+
+		item, _ := paths[r.Path].(object)
+		if item == nil {
+			item = object{}
+			paths[r.Path] = item
+		}
+		item[strings.ToLower(r.Method)] = op
+	}
+
+	return object{
+		"openapi": "3.0.0",
+		"info": object{
+			"title":       "Auction gateway",
+			"version":     "1.0",
+			"description": "Typed REST access to the auction chaincode. Invokes return the transaction id; follow its outcome through the event service.",
+		},
+		"paths":      paths,
+		"components": object{"schemas": schemas},
+	}
+}
+
+func jsonContent(schema object) object {
+	return object{"application/json": object{"schema": schema}}
+}
+
+func response(desc string, schema object) object {
+	return object{"description": desc, "content": jsonContent(schema)}
+}
+
+func ref(name string, t reflect.Type, schemas object) object {
+	if _, ok := schemas[name]; !ok {
+		schemas[name] = object{} // Placeholder in case the type refers to itself
+		schemas[name] = structSchema(t, schemas)
+	}
+	return object{"$ref": "#/components/schemas/" + name}
+}
+
+func schemaOf(t reflect.Type, schemas object) object {
+
+	switch t.Kind() {
+	case reflect.String:
+		return object{"type": "string"}
+	case reflect.Bool:
+		return object{"type": "boolean"}
+	case reflect.Int, reflect.Int64, reflect.Uint64:
+		return object{"type": "integer"}
+	case reflect.Float64:
+		return object{"type": "number"}
+	case reflect.Slice:
+		return object{"type": "array", "items": schemaOf(t.Elem(), schemas)}
+	case reflect.Map:
+		return object{"type": "object", "additionalProperties": schemaOf(t.Elem(), schemas)}
+	case reflect.Struct:
+		return ref(t.Name(), t, schemas)
+	}
+	return object{}
+}
+
+func structSchema(t reflect.Type, schemas object) object {
+
+	props := object{}
+	var required []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := f.Name
+		if tag := strings.Split(f.Tag.Get("json"), ",")[0]; tag != "" {
+			name = tag
+		}
+		s := schemaOf(f.Type, schemas)
+		if doc := f.Tag.Get("doc"); doc != "" {
+			if _, isRef := s["$ref"]; isRef {
+				s = object{"allOf": []object{s}, "description": doc}
+			} else {
+				s["description"] = doc
+			}
+		}
+		props[name] = s
+		if f.Tag.Get("gw") == "required" {
+			required = append(required, name)
+		}
+	}
+
+	s := object{"type": "object", "properties": props}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+// A page of a list query - ListPage in the chaincode's paging.go
+func pageOf(t reflect.Type, schemas object) object {
+
+	name := t.Name() + "Page"
+	if _, ok := schemas[name]; !ok {
+		schemas[name] = object{
+			"type": "object",
+			"properties": object{
+				"Items":     object{"type": "array", "items": schemaOf(t, schemas)},
+				"Count":     object{"type": "integer"},
+				"NextToken": object{"type": "string", "description": "Pass as token to get the next page; empty on the last page"},
+			},
+		}
+	}
+	return object{"$ref": "#/components/schemas/" + name}
+}
@@ -0,0 +1,407 @@
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/ITPeople-Blockchain/auction/bid/client"
+)
+
+//////////////////////////////////////////////////////////////////////////////////
+// A gateway endpoint and the chaincode function behind it
+// Args turns the request into the function's positional args. The same table
+// serves the requests and produces the OpenAPI document
+//////////////////////////////////////////////////////////////////////////////////
+type Route struct {
+	Method   string
+	Path     string // Path parameters in braces, e.g. /auctions/{id}/bids
+	Function string
+	Query    bool // A query rather than an invoke
+	Summary  string
+	Body     interface{} // Zero value of the request body, nil if there is none
+	Params   []Param     // Query string parameters
+	List     bool        // Takes the paging options and returns a page of Result
+	Result   interface{} // Zero value of the result of a query, for the OpenAPI document
+	Args     func(c *Call) ([]string, error)
+}
+
+type Param struct {
+	Name     string
+	Doc      string
+	Required bool
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Options of the list queries, passed on as name=value args (see paging.go in
+// the chaincode). pageSize is always sent, so lists always come back as a page
+//////////////////////////////////////////////////////////////////////////////////
+var listParams = []Param{
+	{Name: "pageSize", Doc: "Records per page, default 50, at most 500"},
+	{Name: "token", Doc: "NextToken of the previous page"},
+	{Name: "sort", Doc: "Fields to order by, e.g. BidPrice:desc,BidNo"},
+	{Name: "minPrice", Doc: "Lowest price, inclusive"},
+	{Name: "maxPrice", Doc: "Highest price, inclusive"},
+	{Name: "from", Doc: "Earliest date, inclusive (2006-01-02 15:04:05)"},
+	{Name: "to", Doc: "Latest date, inclusive (2006-01-02 15:04:05)"},
+}
+
+var periodParam = Param{Name: "period", Doc: "Partition or range of partitions to list, e.g. 2017 or 2016..2017. Default 2016 to this year"}
+
+func listArgs(c *Call, keys ...string) []string {
+
+	args := keys
+	if c.Query.Get("pageSize") == "" {
+		args = append(args, "pageSize=50")
+	}
+	for _, p := range listParams {
+		if v := c.Query.Get(p.Name); v != "" {
+			args = append(args, p.Name+"="+v)
+		}
+	}
+	return args
+}
+
+// Records from before partitioning are in the 2016 partition
+func period(c *Call) string {
+	if p := c.Query.Get("period"); p != "" {
+		return p
+	}
+	return fmt.Sprintf("2016..%d", time.Now().Year())
+}
+
+func minutes(n int) (string, error) {
+	if n <= 0 {
+		return "", fmt.Errorf("minutes should be positive")
+	}
+	return strconv.Itoa(n), nil
+}
+
+var routes = []Route{
+
+	// Users
+	{Method: "POST", Path: "/users", Function: "PostUser", Summary: "Register a user", Body: UserBody{},
+		Args: func(c *Call) ([]string, error) {
+			var b UserBody
+			if err := c.Decode(&b); err != nil {
+				return nil, err
+			}
+			return []string{b.UserID, "USER", b.Name, b.UserType, b.Address, b.Phone, b.Email, b.Bank, b.AccountNo, b.RoutingNo}, nil
+		}},
+	{Method: "GET", Path: "/users", Function: "GetUserListByCat", Query: true, Summary: "List users, by type", List: true, Result: client.UserObject{},
+		Params: []Param{{Name: "type", Doc: "User type, e.g. AH"}, periodParam},
+		Args: func(c *Call) ([]string, error) {
+			keys := []string{period(c)}
+			if t := c.Query.Get("type"); t != "" {
+				keys = append(keys, t)
+			}
+			return listArgs(c, keys...), nil
+		}},
+	{Method: "GET", Path: "/users/{id}", Function: "GetUser", Query: true, Summary: "Get a user", Result: client.UserObject{},
+		Args: func(c *Call) ([]string, error) {
+			return []string{c.Path["id"]}, nil
+		}},
+	{Method: "PUT", Path: "/users/{id}", Function: "UpdateUser", Summary: "Update a user", Body: UserBody{},
+		Args: func(c *Call) ([]string, error) {
+			b := UserBody{UserID: c.Path["id"]}
+			if err := c.Decode(&b); err != nil {
+				return nil, err
+			}
+			if b.UserID != c.Path["id"] {
+				return nil, fmt.Errorf("UserID does not match the path")
+			}
+			return []string{c.Path["id"], "USER", b.Name, b.UserType, b.Address, b.Phone, b.Email, b.Bank, b.AccountNo, b.RoutingNo}, nil
+		}},
+	{Method: "POST", Path: "/users/{id}/deactivate", Function: "DeactivateUser", Summary: "Deactivate a user",
+		Args: func(c *Call) ([]string, error) {
+			return []string{c.Path["id"], "USER"}, nil
+		}},
+	{Method: "DELETE", Path: "/users/{id}/pii", Function: "EraseUser", Summary: "Erase a user's personal data",
+		Args: func(c *Call) ([]string, error) {
+			return []string{c.Path["id"], "USER"}, nil
+		}},
+	{Method: "POST", Path: "/users/{id}/pii/verify", Function: "VerifyUserPII", Query: true, Summary: "Check a value against a protected field", Body: VerifyPIIBody{}, Result: client.PIIVerification{},
+		Args: func(c *Call) ([]string, error) {
+			var b VerifyPIIBody
+			if err := c.Decode(&b); err != nil {
+				return nil, err
+			}
+			return []string{c.Path["id"], b.Field, b.Value}, nil
+		}},
+	{Method: "GET", Path: "/users/{id}/transactions", Function: "GetTransactionsByUser", Query: true, Summary: "List a user's transactions", List: true, Result: client.ItemTransaction{},
+		Args: func(c *Call) ([]string, error) {
+			return listArgs(c, c.Path["id"]), nil
+		}},
+
+	// Items
+	{Method: "POST", Path: "/items", Function: "PostItem", Summary: "Register an item", Body: ItemBody{},
+		Args: func(c *Call) ([]string, error) {
+			var b ItemBody
+			if err := c.Decode(&b); err != nil {
+				return nil, err
+			}
+			args := []string{b.ItemID, "ARTINV", b.ItemDesc, b.ItemDetail, b.ItemType, b.ItemSubject}
+			for _, d := range b.ItemDocs {
+				args = append(args, d.DocHash, d.MediaType, d.DocURI)
+			}
+			return args, nil
+		}},
+	{Method: "GET", Path: "/items", Function: "GetItemListByCat", Query: true, Summary: "List items, by type or by subject", List: true, Result: client.ItemObject{},
+		Params: []Param{{Name: "type", Doc: "Item type, e.g. Original"}, {Name: "subject", Doc: "Item subject, e.g. Landscape. Lists by subject instead of type"}, periodParam},
+		Args: func(c *Call) ([]string, error) {
+			keys := []string{period(c)}
+			if s := c.Query.Get("subject"); s != "" {
+				if c.Query.Get("type") != "" {
+					return nil, fmt.Errorf("give either type or subject")
+				}
+				c.Function = "GetItemListBySubject"
+				keys = append(keys, s)
+			} else if t := c.Query.Get("type"); t != "" {
+				keys = append(keys, t)
+			}
+			return listArgs(c, keys...), nil
+		}},
+	{Method: "GET", Path: "/items/{id}", Function: "GetItem", Query: true, Summary: "Get an item", Result: client.ItemObject{},
+		Args: func(c *Call) ([]string, error) {
+			return []string{c.Path["id"]}, nil
+		}},
+	{Method: "PUT", Path: "/items/{id}", Function: "UpdateItem", Summary: "Update an item", Body: ItemBody{},
+		Args: func(c *Call) ([]string, error) {
+			b := ItemBody{ItemID: c.Path["id"]}
+			if err := c.Decode(&b); err != nil {
+				return nil, err
+			}
+			if b.ItemID != c.Path["id"] {
+				return nil, fmt.Errorf("ItemID does not match the path")
+			}
+			return []string{c.Path["id"], "ARTINV", b.ItemDesc, b.ItemDetail, b.ItemType, b.ItemSubject}, nil
+		}},
+	{Method: "POST", Path: "/items/{id}/documents", Function: "PostItemDocument", Summary: "Register a document against an item", Body: DocumentBody{},
+		Args: func(c *Call) ([]string, error) {
+			var b DocumentBody
+			if err := c.Decode(&b); err != nil {
+				return nil, err
+			}
+			return []string{c.Path["id"], "ITEMDOC", b.DocHash, b.MediaType, b.DocURI}, nil
+		}},
+	{Method: "POST", Path: "/items/{id}/documents/verify", Function: "VerifyItemDocument", Query: true, Summary: "Check a document against those registered", Body: VerifyDocumentBody{}, Result: client.ItemDocVerification{},
+		Args: func(c *Call) ([]string, error) {
+			var b VerifyDocumentBody
+			if err := c.Decode(&b); err != nil {
+				return nil, err
+			}
+			return []string{c.Path["id"], b.Mode, b.Value}, nil
+		}},
+	{Method: "POST", Path: "/items/{id}/artefact", Function: "PostItemArtefact", Summary: "Store an item's encrypted artefact", Body: ArtefactBody{},
+		Args: func(c *Call) ([]string, error) {
+			var b ArtefactBody
+			if err := c.Decode(&b); err != nil {
+				return nil, err
+			}
+			if err := c.SetTransient("artefactKey", b.Key, "artefactContent", b.Content); err != nil {
+				return nil, err
+			}
+			return []string{c.Path["id"], "ARTEFACT", b.OwnerID, b.MediaType}, nil
+		}},
+	{Method: "GET", Path: "/items/{id}/artefact", Function: "GetItemArtefact", Query: true, Summary: "Get an item's encrypted artefact", Result: client.ItemArtefact{},
+		Args: func(c *Call) ([]string, error) {
+			return []string{c.Path["id"]}, nil
+		}},
+	{Method: "POST", Path: "/items/{id}/artefact/open", Function: "OpenItemArtefact", Query: true, Summary: "Decrypt an item's artefact; returns the base64 content", Body: OpenArtefactBody{}, Result: "",
+		Args: func(c *Call) ([]string, error) {
+			var b OpenArtefactBody
+			if err := c.Decode(&b); err != nil {
+				return nil, err
+			}
+			if err := c.SetTransient("artefactKey", b.Key); err != nil {
+				return nil, err
+			}
+			return []string{c.Path["id"]}, nil
+		}},
+	{Method: "POST", Path: "/items/{id}/transfer", Function: "TransferItem", Summary: "Transfer an item to a new owner", Body: TransferBody{},
+		Args: func(c *Call) ([]string, error) {
+			var b TransferBody
+			if err := c.Decode(&b); err != nil {
+				return nil, err
+			}
+			if err := c.SetTransient("artefactKey", b.Key, "handoverKey", b.HandoverKey); err != nil {
+				return nil, err
+			}
+			return []string{c.Path["id"], "XFER", b.OwnerID, b.NewOwnerID}, nil
+		}},
+	{Method: "POST", Path: "/items/{id}/artefact/accept", Function: "AcceptItemArtefact", Summary: "Take over an artefact handed over to the new owner", Body: AcceptArtefactBody{},
+		Args: func(c *Call) ([]string, error) {
+			var b AcceptArtefactBody
+			if err := c.Decode(&b); err != nil {
+				return nil, err
+			}
+			if err := c.SetTransient("handoverKey", b.HandoverKey, "artefactKey", b.Key); err != nil {
+				return nil, err
+			}
+			return []string{c.Path["id"], "ARTEFACT", b.NewOwnerID}, nil
+		}},
+	{Method: "GET", Path: "/items/{id}/transactions", Function: "GetTransactionsByItem", Query: true, Summary: "List an item's transactions", List: true, Result: client.ItemTransaction{},
+		Args: func(c *Call) ([]string, error) {
+			return listArgs(c, c.Path["id"]), nil
+		}},
+
+	// Auctions
+	{Method: "POST", Path: "/auctions", Function: "PostAuctionRequest", Summary: "Request an auction for an item", Body: AuctionBody{},
+		Args: func(c *Call) ([]string, error) {
+			var b AuctionBody
+			if err := c.Decode(&b); err != nil {
+				return nil, err
+			}
+			return []string{b.AuctionID, "AUCREQ", b.ItemID, b.AuctionHouseID, b.RequestDate, "INIT", "", ""}, nil
+		}},
+	{Method: "GET", Path: "/auctions", Function: "GetListOfOpenAucs", Query: true, Summary: "List requested or open auctions", List: true, Result: client.AuctionRequest{},
+		Params: []Param{{Name: "status", Doc: "INIT for requested auctions, OPEN for those taking bids", Required: true}, periodParam},
+		Args: func(c *Call) ([]string, error) {
+			switch c.Query.Get("status") {
+			case "OPEN":
+			case "INIT":
+				c.Function = "GetListOfInitAucs"
+			default:
+				return nil, fmt.Errorf("status should be INIT or OPEN")
+			}
+			return listArgs(c, period(c)), nil
+		}},
+	{Method: "POST", Path: "/auctions/close-expired", Function: "CloseOpenAuctions", Summary: "Close the open auctions past their close date",
+		Params: []Param{{Name: "period", Doc: "Partitions to look in, default all of them"}},
+		Args: func(c *Call) ([]string, error) {
+			if p := c.Query.Get("period"); p != "" {
+				return []string{"CLAUC", p}, nil
+			}
+			return []string{"CLAUC"}, nil
+		}},
+	{Method: "GET", Path: "/auctions/{id}", Function: "GetAuctionRequest", Query: true, Summary: "Get an auction", Result: client.AuctionRequest{},
+		Args: func(c *Call) ([]string, error) {
+			return []string{c.Path["id"]}, nil
+		}},
+	{Method: "POST", Path: "/auctions/{id}/open", Function: "OpenAuctionForBids", Summary: "Open an auction for bids", Body: OpenAuctionBody{},
+		Args: func(c *Call) ([]string, error) {
+			var b OpenAuctionBody
+			if err := c.Decode(&b); err != nil {
+				return nil, err
+			}
+			d, err := minutes(b.Duration)
+			if err != nil {
+				return nil, err
+			}
+			return []string{c.Path["id"], "OPENAUC", d}, nil
+		}},
+	{Method: "POST", Path: "/auctions/{id}/extend", Function: "ExtendAuction", Summary: "Push an open auction's close date out", Body: ExtendAuctionBody{},
+		Args: func(c *Call) ([]string, error) {
+			var b ExtendAuctionBody
+			if err := c.Decode(&b); err != nil {
+				return nil, err
+			}
+			m, err := minutes(b.Minutes)
+			if err != nil {
+				return nil, err
+			}
+			return []string{c.Path["id"], "EXTAUC", m}, nil
+		}},
+	{Method: "POST", Path: "/auctions/{id}/close", Function: "CloseAuction", Summary: "Close an auction and settle the highest bid",
+		Args: func(c *Call) ([]string, error) {
+			return []string{c.Path["id"], "AUCREQ"}, nil
+		}},
+	{Method: "POST", Path: "/auctions/{id}/buy-now", Function: "BuyItNow", Summary: "Buy the item at its buy it now price", Body: BidBody{},
+		Args: func(c *Call) ([]string, error) {
+			var b BidBody
+			if err := c.Decode(&b); err != nil {
+				return nil, err
+			}
+			return []string{c.Path["id"], "BID", b.BidNo, b.ItemID, b.BuyerID, b.BidPrice}, nil
+		}},
+	{Method: "POST", Path: "/auctions/{id}/bids", Function: "PostBid", Summary: "Place a bid", Body: BidBody{},
+		Args: func(c *Call) ([]string, error) {
+			var b BidBody
+			if err := c.Decode(&b); err != nil {
+				return nil, err
+			}
+			return []string{c.Path["id"], "BID", b.BidNo, b.ItemID, b.BuyerID, b.BidPrice}, nil
+		}},
+	{Method: "GET", Path: "/auctions/{id}/bids", Function: "GetListOfBids", Query: true, Summary: "List the bids on an auction", List: true, Result: client.Bid{},
+		Args: func(c *Call) ([]string, error) {
+			return listArgs(c, c.Path["id"]), nil
+		}},
+	{Method: "GET", Path: "/auctions/{id}/bids/{bidNo}", Function: "GetBid", Query: true, Summary: "Get a bid", Result: client.Bid{},
+		Args: func(c *Call) ([]string, error) {
+			return []string{c.Path["id"], c.Path["bidNo"]}, nil
+		}},
+	{Method: "GET", Path: "/auctions/{id}/highest-bid", Function: "GetHighestBid", Query: true, Summary: "Get the highest bid", Result: client.Bid{},
+		Args: func(c *Call) ([]string, error) {
+			return []string{c.Path["id"]}, nil
+		}},
+	{Method: "GET", Path: "/auctions/{id}/last-bid", Function: "GetLastBid", Query: true, Summary: "Get the latest bid", Result: client.Bid{},
+		Args: func(c *Call) ([]string, error) {
+			return []string{c.Path["id"]}, nil
+		}},
+	{Method: "GET", Path: "/auctions/{id}/bid-count", Function: "GetNoOfBidsReceived", Query: true, Summary: "Count the bids on an auction", Result: 0,
+		Args: func(c *Call) ([]string, error) {
+			return []string{c.Path["id"]}, nil
+		}},
+	{Method: "POST", Path: "/auctions/{id}/transactions", Function: "PostTransaction", Summary: "Post a settlement transaction for a closed auction", Body: TransactionBody{},
+		Args: func(c *Call) ([]string, error) {
+			var b TransactionBody
+			if err := c.Decode(&b); err != nil {
+				return nil, err
+			}
+			return []string{c.Path["id"], "POSTTRAN", b.ItemID, b.TransType, b.UserId, b.TransDate, b.HammerTime, b.HammerPrice, b.Details}, nil
+		}},
+	{Method: "GET", Path: "/auctions/{id}/transactions", Function: "GetTransactionsByAuction", Query: true, Summary: "List an auction's transactions", List: true, Result: client.ItemTransaction{},
+		Args: func(c *Call) ([]string, error) {
+			return listArgs(c, c.Path["id"]), nil
+		}},
+
+	// Administration
+	{Method: "POST", Path: "/admin/reindex", Function: "ReindexPartitions", Summary: "Move records from the legacy partition into their own", Body: ReindexBody{},
+		Args: func(c *Call) ([]string, error) {
+			var b ReindexBody
+			if err := c.Decode(&b); err != nil {
+				return nil, err
+			}
+			return append([]string{"REINDEX"}, b.Partitions...), nil
+		}},
+	{Method: "POST", Path: "/admin/rebuild-bid-summaries", Function: "RebuildBidSummaries", Summary: "Recompute the highest bid, last bid and bid count kept for each auction", Body: RebuildBidSummariesBody{},
+		Args: func(c *Call) ([]string, error) {
+			var b RebuildBidSummariesBody
+			if err := c.Decode(&b); err != nil {
+				return nil, err
+			}
+			return append([]string{"BIDSUM"}, b.AuctionIDs...), nil
+		}},
+	{Method: "GET", Path: "/version", Function: "GetVersion", Query: true, Summary: "Version of the deployed chaincode", Result: Version{},
+		Args: func(c *Call) ([]string, error) {
+			return []string{"version"}, nil
+		}},
+}
+
+// The query string parameters a route accepts
+func (r *Route) AllParams() []Param {
+	params := r.Params
+	if r.List {
+		params = append(append([]Param(nil), params...), listParams...)
+	}
+	return params
+}
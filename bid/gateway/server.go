@@ -0,0 +1,322 @@
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ITPeople-Blockchain/auction/bid/client"
+)
+
+const maxBodySize = 4 << 20 // Artefacts are sent inline, base64 encoded
+
+//////////////////////////////////////////////////////////////////////////////////
+// The gateway: maps HTTP requests onto chaincode functions (see routes.go)
+// Queries answer with the chaincode's response. Invokes answer 202 with the
+// transaction id, as the peer commits them asynchronously; the outcome shows
+// in the events the transaction raises (see docs/events.md)
+//////////////////////////////////////////////////////////////////////////////////
+type Gateway struct {
+	Transport client.Transport
+	Timeout   time.Duration // For each call to the ledger
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// A request as seen by a route's Args
+// Function starts as the route's and may be changed by Args, for routes that
+// pick between chaincode functions
+//////////////////////////////////////////////////////////////////////////////////
+type Call struct {
+	Function  string
+	Path      map[string]string
+	Query     url.Values
+	Transient map[string][]byte // Set by Args for the keys of the artefact routes
+	body      io.Reader
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Decode the JSON body into v
+// Unknown fields are rejected, so that a misspelt field is not silently
+// dropped, and so are missing required fields
+//////////////////////////////////////////////////////////////////////////////////
+func (c *Call) Decode(v interface{}) error {
+
+	dec := json.NewDecoder(c.body)
+	dec.DisallowUnknownFields()
+	err := dec.Decode(v)
+	if err == io.EOF {
+		return errors.New("request body is missing")
+	}
+	if err != nil {
+		return fmt.Errorf("request body: %v", err)
+	}
+	if dec.More() {
+		return errors.New("request body: unexpected data after the JSON object")
+	}
+	return checkRequired(reflect.ValueOf(v).Elem())
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Send base64 body fields to the chaincode as transient data, decoded, as
+// name, value pairs
+//////////////////////////////////////////////////////////////////////////////////
+func (c *Call) SetTransient(fields ...string) error {
+
+	if c.Transient == nil {
+		c.Transient = map[string][]byte{}
+	}
+	for i := 0; i+1 < len(fields); i += 2 {
+		value, err := base64.StdEncoding.DecodeString(fields[i+1])
+		if err != nil {
+			return fmt.Errorf("request body: %s is not base64 encoded", fields[i])
+		}
+		c.Transient[fields[i]] = value
+	}
+	return nil
+}
+
+func checkRequired(v reflect.Value) error {
+
+	switch v.Kind() {
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			if err := checkRequired(v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+			if f.Tag.Get("gw") == "required" && isZero(v.Field(i)) {
+				return fmt.Errorf("%s is required", f.Name)
+			}
+			if err := checkRequired(v.Field(i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func isZero(v reflect.Value) bool {
+	return reflect.DeepEqual(v.Interface(), reflect.Zero(v.Type()).Interface())
+}
+
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	if r.URL.Path == "/openapi.json" && r.Method == "GET" {
+		writeJSON(w, http.StatusOK, OpenAPI())
+		return
+	}
+
+	route, params, allowed := match(r.Method, r.URL.Path)
+	if route == nil {
+		if len(allowed) > 0 {
+			w.Header().Set("Allow", strings.Join(allowed, ", "))
+			writeError(w, http.StatusMethodNotAllowed, r.Method+" is not supported on "+r.URL.Path)
+			return
+		}
+		writeError(w, http.StatusNotFound, "no such resource "+r.URL.Path)
+		return
+	}
+
+	q := r.URL.Query()
+	known := map[string]bool{}
+	for _, p := range route.AllParams() {
+		known[p.Name] = true
+		if p.Required && q.Get(p.Name) == "" {
+			writeError(w, http.StatusBadRequest, p.Name+" is required")
+			return
+		}
+	}
+	for name := range q {
+		if !known[name] {
+			writeError(w, http.StatusBadRequest, "unknown parameter "+name)
+			return
+		}
+	}
+
+	c := &Call{Function: route.Function, Path: params, Query: q, body: http.MaxBytesReader(w, r.Body, maxBodySize)}
+	args, err := route.Args(c)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx := r.Context()
+	if g.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, g.Timeout)
+		defer cancel()
+	}
+
+	if c.Transient != nil {
+		if _, ok := g.Transport.(client.TransientTransport); !ok {
+			writeError(w, http.StatusNotImplemented, c.Function+" needs transient data, which the transport cannot send")
+			return
+		}
+	}
+
+	if !route.Query {
+		txID, err := g.invoke(ctx, c, args)
+		if err != nil {
+			writeLedgerError(ctx, w, c.Function, err)
+			return
+		}
+		writeJSON(w, http.StatusAccepted, Submitted{TxID: txID})
+		return
+	}
+
+	result, err := g.query(ctx, c, args)
+	if err != nil {
+		writeLedgerError(ctx, w, c.Function, err)
+		return
+	}
+	if len(result) == 0 {
+		// e.g. GetHighestBid on an auction without bids
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	if !json.Valid(result) {
+		// Plain text results, such as the content of an artefact
+		result, _ = json.Marshal(string(result))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(result)
+}
+
+func (g *Gateway) invoke(ctx context.Context, c *Call, args []string) (string, error) {
+	if c.Transient != nil {
+		return g.Transport.(client.TransientTransport).InvokeTransient(ctx, c.Function, args, c.Transient)
+	}
+	return g.Transport.Invoke(ctx, c.Function, args)
+}
+
+func (g *Gateway) query(ctx context.Context, c *Call, args []string) ([]byte, error) {
+	if c.Transient != nil {
+		return g.Transport.(client.TransientTransport).QueryTransient(ctx, c.Function, args, c.Transient)
+	}
+	return g.Transport.Query(ctx, c.Function, args)
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Find the route for a request
+// When the path matches but the method does not, the methods the path does
+// support are returned instead
+//////////////////////////////////////////////////////////////////////////////////
+func match(method string, path string) (*Route, map[string]string, []string) {
+
+	segs := strings.Split(strings.Trim(path, "/"), "/")
+	var allowed []string
+
+	for i := range routes {
+		params, ok := matchPath(routes[i].Path, segs)
+		if !ok {
+			continue
+		}
+		if routes[i].Method == method {
+			return &routes[i], params, nil
+		}
+		allowed = append(allowed, routes[i].Method)
+	}
+	sort.Strings(allowed)
+	return nil, nil, allowed
+}
+
+func matchPath(pattern string, segs []string) (map[string]string, bool) {
+
+	parts := strings.Split(strings.Trim(pattern, "/"), "/")
+	if len(parts) != len(segs) {
+		return nil, false
+	}
+	params := map[string]string{}
+	for i, p := range parts {
+		if strings.HasPrefix(p, "{") {
+			if segs[i] == "" {
+				return nil, false
+			}
+			params[strings.Trim(p, "{}")] = segs[i]
+		} else if p != segs[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Map the chaincode's error code to a status (see docs/errors.md); errors
+// without one come from the peer, or from failing to reach it
+//////////////////////////////////////////////////////////////////////////////////
+func writeLedgerError(ctx context.Context, w http.ResponseWriter, function string, err error) {
+
+	log.Printf("gateway: %s: %v", function, err)
+
+	if ctx.Err() == context.DeadlineExceeded {
+		writeError(w, http.StatusGatewayTimeout, "the peer did not answer in time")
+		return
+	}
+	e := client.TransportError(function, err)
+	if e.Code == client.Unavailable {
+		writeError(w, http.StatusBadGateway, "cannot reach the peer")
+		return
+	}
+
+	status := http.StatusBadGateway
+	switch e.Reason {
+	case client.ReasonNotFound:
+		status = http.StatusNotFound
+	case client.ReasonInvalidArgument:
+		status = http.StatusBadRequest
+	case client.ReasonUnauthorized:
+		status = http.StatusForbidden
+	case client.ReasonConflict, client.ReasonAuctionNotOpen, client.ReasonBidTooLow:
+		status = http.StatusConflict
+	case "":
+		// Chaincode from before the error codes
+		if e.Code == client.NotFound {
+			writeError(w, http.StatusNotFound, "not found")
+			return
+		}
+	}
+	writeJSON(w, status, ErrorBody{Error: e.Message, Code: e.Reason, Details: e.Details})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, ErrorBody{Error: msg})
+}
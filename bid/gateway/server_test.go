@@ -0,0 +1,306 @@
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ITPeople-Blockchain/auction/bid/client"
+)
+
+//////////////////////////////////////////////////////////////////////////////////
+// A transport that records what the gateway sends, and answers with response,
+// or fails with err
+//////////////////////////////////////////////////////////////////////////////////
+type recordingTransport struct {
+	function  string
+	args      []string
+	transient map[string][]byte
+	response  string
+	err       error
+}
+
+func (r *recordingTransport) Invoke(ctx context.Context, function string, args []string) (string, error) {
+	r.function, r.args = function, args
+	return "tx-1", r.err
+}
+
+func (r *recordingTransport) Query(ctx context.Context, function string, args []string) ([]byte, error) {
+	r.function, r.args = function, args
+	return []byte(r.response), r.err
+}
+
+func (r *recordingTransport) InvokeTransient(ctx context.Context, function string, args []string, transient map[string][]byte) (string, error) {
+	r.transient = transient
+	return r.Invoke(ctx, function, args)
+}
+
+func (r *recordingTransport) QueryTransient(ctx context.Context, function string, args []string, transient map[string][]byte) ([]byte, error) {
+	r.transient = transient
+	return r.Query(ctx, function, args)
+}
+
+func serve(g *Gateway, method string, target string, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	g.ServeHTTP(w, httptest.NewRequest(method, target, strings.NewReader(body)))
+	return w
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// The chaincode function and args a request turns into
+//////////////////////////////////////////////////////////////////////////////////
+func TestRouteArgs(t *testing.T) {
+
+	allYears := period(&Call{})
+	cases := []struct {
+		method    string
+		target    string
+		body      string
+		function  string
+		args      []string
+		transient map[string][]byte
+	}{
+		{"POST", "/users", `{"UserID":"200","Name":"Ann","UserType":"TR","Email":"ann@example.com"}`,
+			"PostUser", []string{"200", "USER", "Ann", "TR", "", "", "ann@example.com", "", "", ""}, nil},
+		{"PUT", "/users/200", `{"Name":"Ann","UserType":"TR"}`,
+			"UpdateUser", []string{"200", "USER", "Ann", "TR", "", "", "", "", "", ""}, nil},
+		{"GET", "/users?type=AH&period=2017&pageSize=5", "", "GetUserListByCat", []string{"2017", "AH", "pageSize=5"}, nil},
+		{"GET", "/users", "", "GetUserListByCat", []string{allYears, "pageSize=50"}, nil},
+		{"DELETE", "/users/200/pii", "", "EraseUser", []string{"200", "USER"}, nil},
+		{"POST", "/users/200/pii/verify", `{"Field":"Email","Value":"ann@example.com"}`,
+			"VerifyUserPII", []string{"200", "Email", "ann@example.com"}, nil},
+
+		{"POST", "/items", `{"ItemID":"1000","ItemDesc":"Painting","ItemType":"Original","ItemSubject":"Landscape",
+			"ItemDocs":[{"DocHash":"aa","MediaType":"image/png","DocURI":"s3://a"}]}`,
+			"PostItem", []string{"1000", "ARTINV", "Painting", "", "Original", "Landscape", "aa", "image/png", "s3://a"}, nil},
+		{"GET", "/items?subject=Landscape&period=2017&token=abc", "", "GetItemListBySubject", []string{"2017", "Landscape", "pageSize=50", "token=abc"}, nil},
+		{"GET", "/items?type=Original&period=2017", "", "GetItemListByCat", []string{"2017", "Original", "pageSize=50"}, nil},
+		{"POST", "/items/1000/documents/verify", `{"Mode":"SHA256","Value":"aa"}`, "VerifyItemDocument", []string{"1000", "SHA256", "aa"}, nil},
+		{"POST", "/items/1000/artefact", `{"OwnerID":"200","MediaType":"image/png","Key":"a2V5","Content":"Y29udGVudA=="}`,
+			"PostItemArtefact", []string{"1000", "ARTEFACT", "200", "image/png"}, map[string][]byte{"artefactKey": []byte("key"), "artefactContent": []byte("content")}},
+		{"POST", "/items/1000/transfer", `{"OwnerID":"200","Key":"a2V5","NewOwnerID":"300","HandoverKey":"aGFuZA=="}`,
+			"TransferItem", []string{"1000", "XFER", "200", "300"}, map[string][]byte{"artefactKey": []byte("key"), "handoverKey": []byte("hand")}},
+
+		{"POST", "/auctions", `{"AuctionID":"1111","ItemID":"1000","AuctionHouseID":"100","RequestDate":"2017-03-05"}`,
+			"PostAuctionRequest", []string{"1111", "AUCREQ", "1000", "100", "2017-03-05", "INIT", "", ""}, nil},
+		{"GET", "/auctions?status=INIT&period=2017..2018", "", "GetListOfInitAucs", []string{"2017..2018", "pageSize=50"}, nil},
+		{"GET", "/auctions?status=OPEN&sort=CloseDate:desc", "", "GetListOfOpenAucs", []string{allYears, "pageSize=50", "sort=CloseDate:desc"}, nil},
+		{"POST", "/auctions/close-expired", "", "CloseOpenAuctions", []string{"CLAUC"}, nil},
+		{"POST", "/auctions/close-expired?period=2017", "", "CloseOpenAuctions", []string{"CLAUC", "2017"}, nil},
+		{"POST", "/auctions/1111/open", `{"Duration":30}`, "OpenAuctionForBids", []string{"1111", "OPENAUC", "30"}, nil},
+		{"POST", "/auctions/1111/extend", `{"Minutes":5}`, "ExtendAuction", []string{"1111", "EXTAUC", "5"}, nil},
+		{"POST", "/auctions/1111/bids", `{"BidNo":"1","ItemID":"1000","BuyerID":"300","BidPrice":"1200"}`,
+			"PostBid", []string{"1111", "BID", "1", "1000", "300", "1200"}, nil},
+		{"GET", "/auctions/1111/bids?minPrice=1000&maxPrice=2000", "", "GetListOfBids", []string{"1111", "pageSize=50", "minPrice=1000", "maxPrice=2000"}, nil},
+		{"GET", "/auctions/1111/bids/2", "", "GetBid", []string{"1111", "2"}, nil},
+		{"POST", "/auctions/1111/transactions", `{"ItemID":"1000","TransType":"SALE","UserId":"200","HammerPrice":"1200"}`,
+			"PostTransaction", []string{"1111", "POSTTRAN", "1000", "SALE", "200", "", "", "1200", ""}, nil},
+
+		{"POST", "/admin/reindex", `{"Partitions":["2016"]}`, "ReindexPartitions", []string{"REINDEX", "2016"}, nil},
+		{"POST", "/admin/rebuild-bid-summaries", `{}`, "RebuildBidSummaries", []string{"BIDSUM"}, nil},
+		{"GET", "/version", "", "GetVersion", []string{"version"}, nil},
+	}
+
+	for _, c := range cases {
+		r := &recordingTransport{response: "{}"}
+		w := serve(&Gateway{Transport: r}, c.method, c.target, c.body)
+		if w.Code != http.StatusOK && w.Code != http.StatusAccepted {
+			t.Errorf("%s %s : %d %s", c.method, c.target, w.Code, w.Body)
+			continue
+		}
+		if r.function != c.function || !reflect.DeepEqual(r.args, c.args) || !reflect.DeepEqual(r.transient, c.transient) {
+			t.Errorf("%s %s : sent %s %q %q", c.method, c.target, r.function, r.args, r.transient)
+		}
+	}
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Requests refused before they reach the ledger
+//////////////////////////////////////////////////////////////////////////////////
+func TestBadRequests(t *testing.T) {
+
+	cases := []struct {
+		method string
+		target string
+		body   string
+		status int
+		error  string
+	}{
+		{"GET", "/nowhere", "", http.StatusNotFound, "no such resource /nowhere"},
+		{"DELETE", "/auctions/1111", "", http.StatusMethodNotAllowed, "DELETE is not supported on /auctions/1111"},
+		{"GET", "/auctions", "", http.StatusBadRequest, "status is required"},
+		{"GET", "/auctions?status=CLOSED", "", http.StatusBadRequest, "status should be INIT or OPEN"},
+		{"GET", "/users?colour=red", "", http.StatusBadRequest, "unknown parameter colour"},
+		{"GET", "/items?type=Original&subject=Landscape", "", http.StatusBadRequest, "give either type or subject"},
+		{"POST", "/users", "", http.StatusBadRequest, "request body is missing"},
+		{"POST", "/users", `{"UserID":"200","Name":"Ann"}`, http.StatusBadRequest, "UserType is required"},
+		{"POST", "/users", `{"UserID":"200","Name":"Ann","UserType":"TR","Colour":"red"}`, http.StatusBadRequest,
+			`request body: json: unknown field "Colour"`},
+		{"POST", "/users", `{"UserID":"200","Name":"Ann","UserType":"TR"} {}`, http.StatusBadRequest, "request body: unexpected data after the JSON object"},
+		{"PUT", "/users/200", `{"UserID":"300","Name":"Ann","UserType":"TR"}`, http.StatusBadRequest, "UserID does not match the path"},
+		{"POST", "/items", `{"ItemID":"1000","ItemDesc":"P","ItemType":"O","ItemSubject":"L","ItemDocs":[{"DocHash":"aa"}]}`,
+			http.StatusBadRequest, "MediaType is required"},
+		{"POST", "/items/1000/artefact/open", `{"Key":"not base64!"}`, http.StatusBadRequest, "request body: artefactKey is not base64 encoded"},
+		{"POST", "/auctions/1111/open", `{"Duration":-5}`, http.StatusBadRequest, "minutes should be positive"},
+	}
+
+	for _, c := range cases {
+		r := &recordingTransport{}
+		w := serve(&Gateway{Transport: r}, c.method, c.target, c.body)
+		var body ErrorBody
+		json.Unmarshal(w.Body.Bytes(), &body)
+		if w.Code != c.status || body.Error != c.error {
+			t.Errorf("%s %s : %d %s", c.method, c.target, w.Code, w.Body)
+		}
+		if r.function != "" {
+			t.Errorf("%s %s : sent %s", c.method, c.target, r.function)
+		}
+	}
+
+	w := serve(&Gateway{Transport: struct{ client.Transport }{&recordingTransport{}}}, "POST", "/items/1000/artefact/open", `{"Key":"a2V5"}`)
+	if w.Code != http.StatusNotImplemented {
+		t.Errorf("transient data without a TransientTransport : %d %s", w.Code, w.Body)
+	}
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// The status each kind of ledger error is answered with
+//////////////////////////////////////////////////////////////////////////////////
+func TestLedgerErrors(t *testing.T) {
+
+	refusal := func(code string) error {
+		return &client.PeerError{Code: -32002, Message: "Invocation failure", Data: `{"Code":"` + code + `","Message":"refused","Details":{"AuctionID":"1111"}}`}
+	}
+	cases := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+	}{
+		{"not found", refusal(client.ReasonNotFound), http.StatusNotFound, client.ReasonNotFound},
+		{"invalid argument", refusal(client.ReasonInvalidArgument), http.StatusBadRequest, client.ReasonInvalidArgument},
+		{"unauthorized", refusal(client.ReasonUnauthorized), http.StatusForbidden, client.ReasonUnauthorized},
+		{"conflict", refusal(client.ReasonConflict), http.StatusConflict, client.ReasonConflict},
+		{"auction not open", refusal(client.ReasonAuctionNotOpen), http.StatusConflict, client.ReasonAuctionNotOpen},
+		{"bid too low", refusal(client.ReasonBidTooLow), http.StatusConflict, client.ReasonBidTooLow},
+		{"internal", refusal(client.ReasonInternal), http.StatusBadGateway, client.ReasonInternal},
+		{"legacy not found", &client.PeerError{Message: "Query failure", Data: "Object not found : 1111"}, http.StatusNotFound, ""},
+		{"legacy refusal", &client.PeerError{Message: "Invocation failure", Data: "Auction is not OPEN"}, http.StatusBadGateway, ""},
+		{"no peer", errors.New("connection refused"), http.StatusBadGateway, ""},
+	}
+
+	for _, c := range cases {
+		w := serve(&Gateway{Transport: &recordingTransport{err: c.err}}, "POST", "/auctions/1111/close", "")
+		var body ErrorBody
+		json.Unmarshal(w.Body.Bytes(), &body)
+		if w.Code != c.status || body.Code != c.code {
+			t.Errorf("%s : %d %s", c.name, w.Code, w.Body)
+		}
+		if c.code != "" && body.Details["AuctionID"] != "1111" {
+			t.Errorf("%s : details %v", c.name, body.Details)
+		}
+	}
+}
+
+// A peer that does not answer in time
+type slowTransport struct {
+	recordingTransport
+}
+
+func (s *slowTransport) Query(ctx context.Context, function string, args []string) ([]byte, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestTimeout(t *testing.T) {
+
+	w := serve(&Gateway{Transport: &slowTransport{}, Timeout: time.Millisecond}, "GET", "/auctions/1111", "")
+	if w.Code != http.StatusGatewayTimeout {
+		t.Fatalf("%d %s", w.Code, w.Body)
+	}
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// The gateway in front of the mock ledger: an auction from request to bids
+//////////////////////////////////////////////////////////////////////////////////
+func TestGatewayOnMockLedger(t *testing.T) {
+
+	m, err := client.NewMockLedger("")
+	if err != nil {
+		t.Fatalf("NewMockLedger : %v", err)
+	}
+	g := &Gateway{Transport: m}
+
+	steps := []struct {
+		method string
+		target string
+		body   string
+		status int
+		result string // Part of the response expected
+	}{
+		{"POST", "/users", `{"UserID":"100","Name":"House","UserType":"AH"}`, http.StatusAccepted, `"TxID":"mock-1"`},
+		{"POST", "/users", `{"UserID":"300","Name":"Bob","UserType":"TR"}`, http.StatusAccepted, `"TxID"`},
+		{"POST", "/users", `{"UserID":"300","Name":"Bob","UserType":"TR"}`, http.StatusConflict, `"Code":"CONFLICT"`},
+		{"POST", "/items", `{"ItemID":"1000","ItemDesc":"Painting","ItemType":"Original","ItemSubject":"Landscape"}`, http.StatusAccepted, `"TxID"`},
+		{"POST", "/auctions", `{"AuctionID":"1111","ItemID":"1000","AuctionHouseID":"100","RequestDate":"2017-03-05"}`, http.StatusAccepted, `"TxID"`},
+		{"GET", "/auctions?status=INIT", "", http.StatusOK, `"Count":1`},
+		{"POST", "/auctions/1111/bids", `{"BidNo":"1","ItemID":"1000","BuyerID":"300","BidPrice":"1000"}`, http.StatusConflict, `"Code":"AUCTION_NOT_OPEN"`},
+		{"POST", "/auctions/1111/open", `{"Duration":60}`, http.StatusAccepted, `"TxID"`},
+		{"GET", "/auctions/1111/highest-bid", "", http.StatusNotFound, `"Error":"not found"`},
+		{"POST", "/auctions/1111/bids", `{"BidNo":"1","ItemID":"1000","BuyerID":"300","BidPrice":"1000"}`, http.StatusAccepted, `"TxID"`},
+		{"POST", "/auctions/1111/bids", `{"BidNo":"2","ItemID":"1000","BuyerID":"300","BidPrice":"900"}`, http.StatusConflict, `"HighestBid":"1000"`},
+		{"GET", "/auctions/1111/highest-bid", "", http.StatusOK, `"BidPrice":"1000"`},
+		{"GET", "/auctions/1111/bid-count", "", http.StatusOK, `1`},
+		{"GET", "/auctions/1111/bids?pageSize=1", "", http.StatusOK, `"Count":1`},
+		{"GET", "/users/999", "", http.StatusNotFound, `"Code":"NOT_FOUND"`},
+	}
+
+	for i, s := range steps {
+		w := serve(g, s.method, s.target, s.body)
+		if w.Code != s.status || !strings.Contains(w.Body.String(), s.result) {
+			t.Fatalf("step %d, %s %s : %d %s", i, s.method, s.target, w.Code, w.Body)
+		}
+	}
+}
+
+// Every route is in the OpenAPI document the gateway serves
+func TestOpenAPIServed(t *testing.T) {
+
+	w := serve(&Gateway{Transport: &recordingTransport{}}, "GET", "/openapi.json", "")
+	var doc struct {
+		Paths map[string]map[string]interface{} `json:"paths"`
+	}
+	if w.Code != http.StatusOK || json.Unmarshal(w.Body.Bytes(), &doc) != nil {
+		t.Fatalf("%d %s", w.Code, w.Body)
+	}
+	for _, r := range routes {
+		if _, ok := doc.Paths[r.Path][strings.ToLower(r.Method)]; !ok {
+			t.Errorf("%s %s is not documented", r.Method, r.Path)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+/******************************************************************
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+******************************************************************/
+
+package main
+
+//////////////////////////////////////////////////////////////////////////////////
+// Request bodies
+// Field names are those of the chaincode records. The gw:"required" tag marks
+// the fields a request must carry; doc is the description in the OpenAPI document
+//////////////////////////////////////////////////////////////////////////////////
+type UserBody struct {
+	UserID    string `gw:"required" doc:"Id of the user; taken from the path on update"`
+	Name      string `gw:"required"`
+	UserType  string `gw:"required" doc:"AH (Auction House), TR (Buyer or Seller), AP (Appraiser), IN (Insurance), BK (Bank), SH (Shipper)"`
+	Address   string
+	Phone     string
+	Email     string
+	Bank      string
+	AccountNo string
+	RoutingNo string
+}
+
+type ItemBody struct {
+	ItemID      string         `gw:"required" doc:"Id of the item; taken from the path on update"`
+	ItemDesc    string         `gw:"required"`
+	ItemDetail  string         `doc:"Details such as who created the art work"`
+	ItemType    string         `gw:"required" doc:"e.g. Original, Print"`
+	ItemSubject string         `gw:"required" doc:"e.g. Landscape, Portrait"`
+	ItemDocs    []DocumentBody `doc:"Documents to register with the item. Ignored on update"`
+}
+
+type DocumentBody struct {
+	DocHash   string `gw:"required" doc:"Hex encoded SHA-256 of the document"`
+	MediaType string `gw:"required" doc:"e.g. image/png, application/pdf"`
+	DocURI    string `gw:"required" doc:"Off-chain location of the document"`
+}
+
+type VerifyDocumentBody struct {
+	Mode  string `gw:"required" doc:"SHA256 when Value is the hex encoded hash, BASE64 when it is the document content"`
+	Value string `gw:"required"`
+}
+
+// The keys and the content of the artefact bodies reach the chaincode as
+// transient data, which the ledger does not keep
+type ArtefactBody struct {
+	OwnerID   string `gw:"required"`
+	MediaType string `gw:"required"`
+	Key       string `gw:"required" doc:"base64 AES key (16, 24 or 32 bytes) of the owner"`
+	Content   string `gw:"required" doc:"base64 content of the artefact"`
+}
+
+type OpenArtefactBody struct {
+	Key string `gw:"required" doc:"base64 AES key sealing the artefact: the owner's, or the handover key"`
+}
+
+type TransferBody struct {
+	OwnerID     string `gw:"required" doc:"Current owner"`
+	Key         string `gw:"required" doc:"base64 AES key of the current owner"`
+	NewOwnerID  string `gw:"required"`
+	HandoverKey string `gw:"required" doc:"base64 one-time AES key, passed to the new owner"`
+}
+
+type AcceptArtefactBody struct {
+	NewOwnerID  string `gw:"required"`
+	HandoverKey string `gw:"required" doc:"base64 handover key given by the previous owner"`
+	Key         string `gw:"required" doc:"base64 AES key of the new owner"`
+}
+
+type VerifyPIIBody struct {
+	Field string `gw:"required" doc:"Protected field, e.g. AccountNo"`
+	Value string `gw:"required" doc:"Plain text value to check"`
+}
+
+type AuctionBody struct {
+	AuctionID      string `gw:"required"`
+	ItemID         string `gw:"required"`
+	AuctionHouseID string `gw:"required"`
+	RequestDate    string `gw:"required" doc:"Date the request was filed, e.g. 2017-03-01"`
+}
+
+type OpenAuctionBody struct {
+	Duration int `gw:"required" doc:"Minutes the auction stays open for bids"`
+}
+
+type ExtendAuctionBody struct {
+	Minutes int `gw:"required" doc:"Minutes to push the close date out by"`
+}
+
+type BidBody struct {
+	BidNo    string `gw:"required"`
+	ItemID   string `gw:"required"`
+	BuyerID  string `gw:"required"`
+	BidPrice string `gw:"required"`
+}
+
+type TransactionBody struct {
+	ItemID      string `gw:"required"`
+	TransType   string `gw:"required" doc:"BUY, SALE or COMMISSION"`
+	UserId      string `gw:"required" doc:"Buyer or seller"`
+	TransDate   string
+	HammerTime  string
+	HammerPrice string `gw:"required"`
+	Details     string
+}
+
+type ReindexBody struct {
+	Partitions []string `doc:"Partitions to move rows out of; 2016 if none"`
+}
+
+type RebuildBidSummariesBody struct {
+	AuctionIDs []string `doc:"Auctions to rebuild; every auction with bids if none"`
+}
+
+//////////////////////////////////////////////////////////////////////////////////
+// Results the chaincode's records (the client package's types) do not cover
+// Only used to describe the responses in the OpenAPI document
+//////////////////////////////////////////////////////////////////////////////////
+type Version struct {
+	Version string `json:"version"`
+}
+
+// Returned for an invoke; the transaction runs asynchronously on the peer
+type Submitted struct {
+	TxID string
+}
+
+// Code and Details are the chaincode's, when it refused the call
+type ErrorBody struct {
+	Error   string
+	Code    string            `json:",omitempty" doc:"e.g. BID_TOO_LOW"`
+	Details map[string]string `json:",omitempty"`
+}